@@ -0,0 +1,178 @@
+package options
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+)
+
+const ConfigPathFlag = "config_path"
+
+// commandLine is the flag set the config file was loaded into, kept to give precedence to the command line on reload.
+var commandLine *pflag.FlagSet
+
+// ParseConfig parses a config file, a YAML or JSON map of flag names to values, e.g. log_level: debug.
+// The values of the list flags are YAML sequences.
+func ParseConfig(content []byte) (map[string]interface{}, error) {
+	config := map[string]interface{}{}
+	jsonContent, err := yaml.YAMLToJSON(content)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(jsonContent))
+	// Keep the numbers as written, e.g. no 1e+06 for an int flag
+	decoder.UseNumber()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("expected a map of flag names to values: %w", err)
+	}
+	if config == nil {
+		// Empty file
+		return map[string]interface{}{}, nil
+	}
+	return config, nil
+}
+
+// LoadConfigFile sets the flags not set on the command line to the values of the config file at GetConfigPath(),
+// and validates the args, see ValidateArgs. A missing config file is only an error when the path is set on the command line.
+func LoadConfigFile(flags *pflag.FlagSet) error {
+	commandLine = flags
+	path := GetConfigPath()
+	content, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && !flags.Changed(ConfigPathFlag):
+	case err != nil:
+		return fmt.Errorf("error reading config file: %w", err)
+	default:
+		config, err := ParseConfig(content)
+		if err != nil {
+			return fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+		if err := applyConfig(flags, config, true); err != nil {
+			return fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+	return ValidateArgs()
+}
+
+// ValidateArgs validates the args of Params and sets the dynamic args from them.
+// Every command runs it once its flags are parsed, serving runs it once the config file is loaded.
+func ValidateArgs() error {
+	args := dynamicArgsFrom(Params)
+	if err := args.Validate(); err != nil {
+		return err
+	}
+	if err := ValidateTokenBucket(Params.VhostMaxTokens, Params.VhostTokensPerFill, Params.VhostFillInterval); err != nil {
+		return fmt.Errorf("invalid vhost token bucket: %w", err)
+	}
+	if err := ValidateAppQuotaEnforcement(Params.AppQuotaEnforcement); err != nil {
+		return err
+	}
+	if err := ValidateMeshTrustDomain(Params.MeshTrustDomain); err != nil {
+		return err
+	}
+	if err := ValidateQuotaScheduleInterval(Params.QuotaScheduleInterval); err != nil {
+		return err
+	}
+	if err := ValidateFullReconcileQPS(Params.FullReconcileQPS); err != nil {
+		return err
+	}
+	SetDynamicArgs(args)
+	return nil
+}
+
+// ReloadConfig returns the dynamic args from the content of the config file.
+// The flags set on the command line take precedence, the args missing from the config are set to their defaults.
+// The other args of the config are ignored, they require a restart.
+func ReloadConfig(content []byte) (*DynamicArgs, error) {
+	config, err := ParseConfig(content)
+	if err != nil {
+		return nil, err
+	}
+	args := &DynamicArgs{}
+	flags := newDynamicFlagSet(args)
+	if commandLine != nil {
+		var copyErr error
+		commandLine.Visit(func(flag *pflag.Flag) {
+			if dynamicFlag := flags.Lookup(flag.Name); dynamicFlag != nil && copyErr == nil {
+				copyErr = copyFlagValue(dynamicFlag, flag)
+				dynamicFlag.Changed = true
+			}
+		})
+		if copyErr != nil {
+			return nil, copyErr
+		}
+	}
+	if err := applyConfig(flags, config, false); err != nil {
+		return nil, err
+	}
+	if err := args.Validate(); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// applyConfig sets the flags to the values of the config, skipping the flags set on the command line.
+// Keys without flag are errors when strict, ignored otherwise.
+func applyConfig(flags *pflag.FlagSet, config map[string]interface{}, strict bool) error {
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		flag := flags.Lookup(name)
+		if flag == nil {
+			if strict {
+				return fmt.Errorf("unknown flag %q", name)
+			}
+			continue
+		}
+		// The config file cannot point to another config file
+		if flag.Changed || name == ConfigPathFlag {
+			continue
+		}
+		if err := setFlagValue(flag, config[name]); err != nil {
+			return fmt.Errorf("invalid value for %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setFlagValue sets the value of the flag without marking it as changed on the command line.
+func setFlagValue(flag *pflag.Flag, value interface{}) error {
+	sliceValue, isSlice := flag.Value.(pflag.SliceValue)
+	switch v := value.(type) {
+	case nil:
+		return fmt.Errorf("value is required")
+	case map[string]interface{}:
+		return fmt.Errorf("expected a %s, got a map", flag.Value.Type())
+	case []interface{}:
+		if !isSlice {
+			return fmt.Errorf("expected a %s, got a list", flag.Value.Type())
+		}
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return sliceValue.Replace(values)
+	default:
+		if isSlice {
+			return sliceValue.Replace([]string{fmt.Sprint(v)})
+		}
+		return flag.Value.Set(fmt.Sprint(v))
+	}
+}
+
+func copyFlagValue(to *pflag.Flag, from *pflag.Flag) error {
+	if sliceValue, ok := from.Value.(pflag.SliceValue); ok {
+		return to.Value.(pflag.SliceValue).Replace(sliceValue.GetSlice())
+	}
+	return to.Value.Set(from.Value.String())
+}
@@ -0,0 +1,174 @@
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/pflag"
+)
+
+// newCommandLine returns the flags of the args covered by the tests, bound to Params as the root command does, parsed from the args.
+func newCommandLine(args ...string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("naavik", pflag.ContinueOnError)
+	flags.StringVar(&Params.LogLevel, LogLevelFlag, DefaultLogLevel, "")
+	flags.StringVar(&Params.ConfigPath, ConfigPathFlag, DefaultConfigPath, "")
+	flags.IntVar(&Params.WorkerConcurrency, "worker_concurrency", DefaultWorkerConcurrency, "")
+	flags.StringArrayVar(&Params.DisabledFeatures, DisabledFeaturesFlag, DefaultDisabledFeatures, "")
+	flags.StringArrayVar(&Params.IgnoreAssetAliases, IgnoreAssetAliasesFlag, DefaultIgnoreAssetAliases, "")
+	flags.StringArrayVar(&Params.AllowedClusterScope, ClustersScopeFlag, DefaultTrafficConfigClustersScope, "")
+	flags.StringArrayVar(&Params.EnvoyFilterVersions, EnvoyFilterVersionsFlag, DefaultEnvoyFilterVersions, "")
+	Expect(flags.Parse(args)).To(Succeed())
+	return flags
+}
+
+// writeConfigFile writes the config file in a temporary directory and returns its path.
+func writeConfigFile(content string) string {
+	path := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+	Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+	return path
+}
+
+var _ = Describe("Test config file", func() {
+	BeforeEach(func() {
+		InitializeNaavikArgs(nil)
+		commandLine = nil
+	})
+
+	AfterEach(func() {
+		InitializeNaavikArgs(nil)
+		commandLine = nil
+	})
+
+	It("should give precedence to the command line over the config file", func() {
+		path := writeConfigFile(`
+log_level: debug
+worker_concurrency: 7
+disabled_features: [virtualservice]
+`)
+		flags := newCommandLine("--config_path="+path, "--log_level=warn")
+		Expect(LoadConfigFile(flags)).To(Succeed())
+		Expect(Params.LogLevel).To(Equal("warn"))
+		Expect(Params.WorkerConcurrency).To(Equal(7))
+		Expect(GetLogLevel()).To(Equal("warn"))
+		Expect(GetDynamicArgs().DisabledFeatures).To(Equal([]string{"virtualservice"}))
+	})
+
+	It("should reject the unknown keys at startup and ignore them on reload", func() {
+		path := writeConfigFile("log_level: debug\nunknown_flag: 1\n")
+		Expect(LoadConfigFile(newCommandLine("--config_path=" + path))).To(MatchError(ContainSubstring(`unknown flag "unknown_flag"`)))
+
+		Expect(LoadConfigFile(newCommandLine("--config_path=" + writeConfigFile("log_level: info\n")))).To(Succeed())
+		args, err := ReloadConfig([]byte("log_level: debug\nunknown_flag: 1\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(args.LogLevel).To(Equal("debug"))
+	})
+
+	It("should keep the command line values and reset the missing keys to their defaults on reload", func() {
+		path := writeConfigFile(`
+disabled_features: [virtualservice]
+ignore_asset_aliases: [foo]
+`)
+		Expect(LoadConfigFile(newCommandLine("--config_path="+path, "--log_level=warn"))).To(Succeed())
+		Expect(GetDynamicArgs().IgnoreAssetAliases).To(Equal([]string{"foo"}))
+
+		args, err := ReloadConfig([]byte("log_level: debug\nignore_asset_aliases: [bar]\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(args.LogLevel).To(Equal("warn"))
+		Expect(args.IgnoreAssetAliases).To(Equal([]string{"bar"}))
+		Expect(args.DisabledFeatures).To(Equal(DefaultDisabledFeatures))
+		Expect(args.EnvoyFilterVersions).To(Equal(DefaultEnvoyFilterVersions))
+	})
+
+	It("should reject an invalid cluster scope regex", func() {
+		path := writeConfigFile("traffic_config_clusters_scope: ['[']\n")
+		Expect(LoadConfigFile(newCommandLine("--config_path=" + path))).To(MatchError(ContainSubstring("invalid " + ClustersScopeFlag)))
+
+		Expect(LoadConfigFile(newCommandLine("--config_path=" + writeConfigFile("traffic_config_clusters_scope: [west-.*]\n")))).To(Succeed())
+		_, err := ReloadConfig([]byte("traffic_config_clusters_scope: ['[']\n"))
+		Expect(err).To(MatchError(ContainSubstring("invalid " + ClustersScopeFlag)))
+		Expect(GetDynamicArgs().AllowedClusterScope).To(Equal([]string{"west-.*"}))
+	})
+
+	It("should reject a mesh trust domain that is not a host name", func() {
+		Expect(ValidateMeshTrustDomain(DefaultMeshTrustDomain)).To(Succeed())
+		Expect(ValidateMeshTrustDomain("")).To(HaveOccurred())
+		Expect(ValidateMeshTrustDomain("cluster.local/ns")).To(HaveOccurred())
+		Params.MeshTrustDomain = "spiffe://cluster.local"
+		Expect(LoadConfigFile(newCommandLine())).To(MatchError(ContainSubstring("mesh trust domain")))
+	})
+
+	It("should reject a quota schedule interval under a second", func() {
+		Expect(ValidateQuotaScheduleInterval(DefaultQuotaScheduleInterval)).To(Succeed())
+		Params.QuotaScheduleInterval = 100 * time.Millisecond
+		Expect(LoadConfigFile(newCommandLine())).To(MatchError(ContainSubstring("quota schedule interval 100ms must be at least 1s")))
+	})
+
+	It("should reject a full reconcile qps that is not positive", func() {
+		Expect(ValidateFullReconcileQPS(DefaultFullReconcileQPS)).To(Succeed())
+		Params.FullReconcileQPS = 0
+		Expect(LoadConfigFile(newCommandLine())).To(MatchError(ContainSubstring("full reconcile qps 0 must be positive")))
+		Params.FullReconcileQPS = -1
+		Expect(LoadConfigFile(newCommandLine())).To(MatchError(ContainSubstring("full reconcile qps -1 must be positive")))
+	})
+
+	It("should only require the config file when its path is set on the command line", func() {
+		missing := filepath.Join(GinkgoT().TempDir(), "missing.yaml")
+		Expect(LoadConfigFile(newCommandLine("--config_path=" + missing))).To(MatchError(ContainSubstring("error reading config file")))
+
+		Params.ConfigPath = missing
+		Expect(LoadConfigFile(newCommandLine())).To(Succeed())
+	})
+})
+
+var _ = Describe("Test dynamic args", func() {
+	AfterEach(func() {
+		InitializeNaavikArgs(nil)
+	})
+
+	It("should fall back to the params until the dynamic args are set", func() {
+		InitializeNaavikArgs(&NaavikArgs{LogLevel: "debug", IgnoreAssetAliases: []string{"Foo"}})
+		Expect(GetDynamicArgs().LogLevel).To(Equal("debug"))
+		Expect(GetDynamicArgs().IgnoreAssetAliases).To(Equal([]string{"Foo"}))
+
+		SetDynamicArgs(&DynamicArgs{LogLevel: "error"})
+		Expect(GetLogLevel()).To(Equal("error"))
+		Expect(Params.LogLevel).To(Equal("debug"))
+
+		SetDynamicArgs(nil)
+		Expect(GetLogLevel()).To(Equal("debug"))
+	})
+
+	It("should compile the dynamic args of the params once", func() {
+		InitializeNaavikArgs(&NaavikArgs{AllowedClusterScope: []string{"west-.*"}})
+		args := GetDynamicArgs()
+		Expect(args.clusterScope).To(HaveLen(1))
+		Expect(GetDynamicArgs()).To(BeIdenticalTo(args))
+
+		InitializeNaavikArgs(&NaavikArgs{AllowedClusterScope: []string{"east-.*"}})
+		Expect(GetDynamicArgs()).NotTo(BeIdenticalTo(args))
+		Expect(GetDynamicArgs().AllowedClusterScope).To(Equal([]string{"east-.*"}))
+	})
+
+	It("should validate the args without config file and set the dynamic args from them", func() {
+		InitializeNaavikArgs(&NaavikArgs{IgnoreAssetAliases: []string{"Foo"}})
+		Expect(ValidateArgs()).To(Succeed())
+		Expect(GetDynamicArgs().IgnoreAssetAliases).To(Equal([]string{"Foo"}))
+
+		Params.VhostTokensPerFill = Params.VhostMaxTokens + 1
+		Expect(ValidateArgs()).To(MatchError(ContainSubstring("invalid vhost token bucket")))
+		InitializeNaavikArgs(&NaavikArgs{AppQuotaEnforcement: "client"})
+		Expect(ValidateArgs()).To(HaveOccurred())
+		InitializeNaavikArgs(&NaavikArgs{AllowedClusterScope: []string{"["}})
+		Expect(ValidateArgs()).To(MatchError(ContainSubstring("invalid " + ClustersScopeFlag)))
+	})
+
+	It("should compare all the args", func() {
+		args := &DynamicArgs{LogLevel: "info", DisabledFeatures: []string{"virtualservice"}}
+		Expect(args.Equal(&DynamicArgs{LogLevel: "info", DisabledFeatures: []string{"virtualservice"}})).To(BeTrue())
+		Expect(args.Equal(&DynamicArgs{LogLevel: "debug", DisabledFeatures: []string{"virtualservice"}})).To(BeFalse())
+		Expect(args.Equal(&DynamicArgs{LogLevel: "info"})).To(BeFalse())
+	})
+})
@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/pkg/eventhistory"
+	"github.com/intuit/naavik/pkg/tracing"
 )
 
 const (
@@ -18,18 +20,43 @@ const (
 	DefaultMeshInjectionKey           = "sidecar.istio.io/inject"
 	DefaultHostnameSuffix             = "mesh"
 	DefaultConfigPath                 = "/etc/admiral/config.yaml"
+	DefaultConfigReloadInterval       = 30 * time.Second
 	DefaultLogLevel                   = "info"
 	DefaultLogColor                   = false
 	DefaultProfilerEndpoint           = "localhost:4040"
 	DefaultArgoRolloutsEnabled        = true
 	DefaultEnableProfiling            = false
+	DefaultAdmissionWebhookEnabled    = false
 	DefaultConfigResolver             = types.ConfigResolverSecret
 	DefaultStateChecker               = types.StateCheckerNone
+	DefaultLeaseName                  = "naavik-lease"
+	DefaultLeaseDuration              = 15 * time.Second
+	DefaultLeaseRenewDeadline         = 10 * time.Second
+	DefaultLeaseRetryPeriod           = 2 * time.Second
+	DefaultDRConfigMapName            = "naavik-dr"
+	DefaultFeatureGatesConfigMap      = "naavik-feature-gates"
+	DefaultScopeConfigMap             = "naavik-scope"
+	DefaultRateLimitKillSwitch        = false
+	DefaultKillSwitchConfigMap        = "naavik-kill-switch"
+	DefaultVhostMaxTokens             = 1000000
+	DefaultVhostTokensPerFill         = 1000000
+	DefaultVhostFillInterval          = time.Second
+	DefaultAppQuotaEnforcement        = types.EnforceInbound
+	DefaultAppQuotaPeerIdentity       = false
+	DefaultMeshTrustDomain            = "cluster.local"
+	DefaultProxyVersionDetection      = false
+	DefaultProxyDetectionInterval     = 5 * time.Minute
+	DefaultQuotaScheduleInterval      = time.Minute
+	DefaultTracingExporter            = tracing.ExporterNone
+	DefaultTracingSampleRatio         = 1.0
+	DefaultEventHistorySize           = eventhistory.DefaultSize
+	DefaultEventHistoryRetention      = eventhistory.DefaultRetention
 	DefaultTrafficConfigNamespace     = "admiral"
 	DefaultTrafficConfigIdentityKey   = "asset"
 	DefaultRefreshInterval            = time.Minute
 	DefaultAsyncExecutorMaxGoRoutines = 20000
 	DefaultWorkerConcurrency          = 1
+	DefaultFullReconcileQPS           = 10
 )
 
 var (
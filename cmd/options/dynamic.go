@@ -0,0 +1,118 @@
+package options
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/spf13/pflag"
+)
+
+// Flags of the dynamic args.
+const (
+	LogLevelFlag            = "log_level"
+	DisabledFeaturesFlag    = "disabled_features"
+	IgnoreAssetAliasesFlag  = "ignore_asset_aliases"
+	ClustersScopeFlag       = "traffic_config_clusters_scope"
+	EnvoyFilterVersionsFlag = "envoy_filter_versions"
+)
+
+// DynamicArgs are the NaavikArgs reloaded from the config file at runtime.
+// They are replaced as a whole on reload, so a DynamicArgs and its slices must not be modified.
+type DynamicArgs struct {
+	LogLevel            string
+	DisabledFeatures    []string
+	IgnoreAssetAliases  []string
+	AllowedClusterScope []string
+	EnvoyFilterVersions []string
+
+	// clusterScope and ignoredAssets are compiled once from AllowedClusterScope and IgnoreAssetAliases, see compile.
+	clusterScope  []*regexp.Regexp
+	ignoredAssets map[string]bool
+}
+
+// dynamicArgs holds the reloaded args, set from Params on first use until the config file is loaded.
+var dynamicArgs atomic.Pointer[DynamicArgs]
+
+// GetDynamicArgs returns the args that can be reloaded at runtime, safe to call concurrently with a reload.
+func GetDynamicArgs() *DynamicArgs {
+	if args := dynamicArgs.Load(); args != nil {
+		return args
+	}
+	// Compile the args of Params once, they are set again when the args are validated or initialized
+	args := dynamicArgsFrom(Params)
+	args.compile()
+	if dynamicArgs.CompareAndSwap(nil, args) {
+		return args
+	}
+	return dynamicArgs.Load()
+}
+
+// SetDynamicArgs replaces the args that can be reloaded at runtime, the args are expected to be validated.
+func SetDynamicArgs(args *DynamicArgs) {
+	if args != nil {
+		args.compile()
+	}
+	dynamicArgs.Store(args)
+}
+
+// Validate returns an error if a cluster scope is not a valid regex.
+func (d *DynamicArgs) Validate() error {
+	for _, scope := range d.AllowedClusterScope {
+		if _, err := CompileClusterRegex(scope); err != nil {
+			return fmt.Errorf("invalid %s %q: %w", ClustersScopeFlag, scope, err)
+		}
+	}
+	return nil
+}
+
+// compile compiles the cluster scopes and lowercases the ignored assets, invalid scopes are skipped.
+func (d *DynamicArgs) compile() {
+	d.clusterScope = make([]*regexp.Regexp, 0, len(d.AllowedClusterScope))
+	for _, scope := range d.AllowedClusterScope {
+		if regex, err := CompileClusterRegex(scope); err == nil {
+			d.clusterScope = append(d.clusterScope, regex)
+		}
+	}
+	d.ignoredAssets = make(map[string]bool, len(d.IgnoreAssetAliases))
+	for _, asset := range d.IgnoreAssetAliases {
+		d.ignoredAssets[strings.ToLower(asset)] = true
+	}
+}
+
+// CompileClusterRegex compiles a case insensitive cluster regex.
+func CompileClusterRegex(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + strings.TrimSpace(pattern))
+}
+
+func dynamicArgsFrom(args NaavikArgs) *DynamicArgs {
+	return &DynamicArgs{
+		LogLevel:            args.LogLevel,
+		DisabledFeatures:    args.DisabledFeatures,
+		IgnoreAssetAliases:  args.IgnoreAssetAliases,
+		AllowedClusterScope: args.AllowedClusterScope,
+		EnvoyFilterVersions: args.EnvoyFilterVersions,
+	}
+}
+
+// Equal returns true if both args have the same values.
+func (d *DynamicArgs) Equal(other *DynamicArgs) bool {
+	return d.LogLevel == other.LogLevel &&
+		slices.Equal(d.DisabledFeatures, other.DisabledFeatures) &&
+		slices.Equal(d.IgnoreAssetAliases, other.IgnoreAssetAliases) &&
+		slices.Equal(d.AllowedClusterScope, other.AllowedClusterScope) &&
+		slices.Equal(d.EnvoyFilterVersions, other.EnvoyFilterVersions)
+}
+
+// newDynamicFlagSet returns the flags of the dynamic args, set to their defaults.
+func newDynamicFlagSet(args *DynamicArgs) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("dynamic", pflag.ContinueOnError)
+	flags.StringVar(&args.LogLevel, LogLevelFlag, DefaultLogLevel, "")
+	flags.StringArrayVar(&args.DisabledFeatures, DisabledFeaturesFlag, DefaultDisabledFeatures, "")
+	flags.StringArrayVar(&args.IgnoreAssetAliases, IgnoreAssetAliasesFlag, DefaultIgnoreAssetAliases, "")
+	flags.StringArrayVar(&args.AllowedClusterScope, ClustersScopeFlag, DefaultTrafficConfigClustersScope, "")
+	flags.StringArrayVar(&args.EnvoyFilterVersions, EnvoyFilterVersionsFlag, DefaultEnvoyFilterVersions, "")
+	return flags
+}
@@ -2,8 +2,8 @@ package options
 
 import (
 	"fmt"
+	"math"
 	"os"
-	"regexp"
 	"slices"
 	"strings"
 	"time"
@@ -22,6 +22,7 @@ type NaavikArgs struct {
 	LogColor                bool
 	ArgoRolloutsEnabled     bool
 	ConfigPath              string
+	ConfigReloadInterval    time.Duration
 	WorkloadIdentityKey     string
 	MeshInjectionEnabledKey string
 	EnvKey                  string
@@ -34,15 +35,53 @@ type NaavikArgs struct {
 	ConfigResolver string
 	StateChecker   string
 
+	LeaseName          string
+	LeaseIdentity      string
+	LeaseDuration      time.Duration
+	LeaseRenewDeadline time.Duration
+	LeaseRetryPeriod   time.Duration
+
+	Region          string
+	DRConfigMapName string
+
+	FeatureGatesConfigMap string
+	ScopeConfigMap        string
+
+	RateLimitKillSwitch bool
+	KillSwitchConfigMap string
+
+	VhostMaxTokens     int
+	VhostTokensPerFill int
+	VhostFillInterval  time.Duration
+
+	AppQuotaEnforcement  string
+	AppQuotaPeerIdentity bool
+	MeshTrustDomain      string
+
+	TracingExporter    string
+	TracingEndpoint    string
+	TracingSampleRatio float64
+
+	EventHistorySize      int
+	EventHistoryRetention time.Duration
+	EventHistoryFile      string
+
+	APITokenFile            string
+	AdmissionWebhookEnabled bool
+
 	TrafficConfigNamespace        string
 	TrafficConfigIdentityKey      string
 	AllowedClusterScope           []string
 	IgnoreAssetAliases            []string
 	EnvoyFilterVersions           []string
 	DeprecatedEnvoyFilterVersions []string
+	ProxyVersionDetection         bool
+	ProxyVersionDetectionInterval time.Duration
+	QuotaScheduleInterval         time.Duration
 	DisabledFeatures              []string
 	AsyncExecutorMaxGoRoutines    int
 	WorkerConcurrency             int
+	FullReconcileQPS              int
 
 	CacheRefreshInterval time.Duration
 
@@ -52,12 +91,20 @@ type NaavikArgs struct {
 	SyncNamespace              string
 }
 
+// GetNaavikArgs returns the args, with the dynamic args as last reloaded from the config file.
 func GetNaavikArgs() NaavikArgs {
-	return Params
+	args := Params
+	dynamic := GetDynamicArgs()
+	args.LogLevel = dynamic.LogLevel
+	args.DisabledFeatures = dynamic.DisabledFeatures
+	args.IgnoreAssetAliases = dynamic.IgnoreAssetAliases
+	args.AllowedClusterScope = dynamic.AllowedClusterScope
+	args.EnvoyFilterVersions = dynamic.EnvoyFilterVersions
+	return args
 }
 
 func GetLogLevel() string {
-	return Params.LogLevel
+	return GetDynamicArgs().LogLevel
 }
 
 func GetLogColor() bool {
@@ -80,6 +127,10 @@ func GetConfigPath() string {
 	return Params.ConfigPath
 }
 
+func GetConfigReloadInterval() time.Duration {
+	return Params.ConfigReloadInterval
+}
+
 func GetWorkloadIdentityKey() string {
 	return Params.WorkloadIdentityKey
 }
@@ -113,26 +164,168 @@ func GetTrafficConfigIdentityKey() string {
 }
 
 func GetTrafficConfigScope() []string {
-	return Params.AllowedClusterScope
+	return GetDynamicArgs().AllowedClusterScope
 }
 
 func GetStateChecker() string {
 	return Params.StateChecker
 }
 
+func GetLeaseName() string {
+	return Params.LeaseName
+}
+
+// GetLeaseIdentity returns the identity used to acquire the lease, defaults to the hostname (pod name).
+func GetLeaseIdentity() string {
+	if len(Params.LeaseIdentity) > 0 {
+		return Params.LeaseIdentity
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return hostname
+}
+
+func GetLeaseDuration() time.Duration {
+	return Params.LeaseDuration
+}
+
+func GetLeaseRenewDeadline() time.Duration {
+	return Params.LeaseRenewDeadline
+}
+
+func GetLeaseRetryPeriod() time.Duration {
+	return Params.LeaseRetryPeriod
+}
+
+func GetRegion() string {
+	return Params.Region
+}
+
+func GetDRConfigMapName() string {
+	return Params.DRConfigMapName
+}
+
+func GetFeatureGatesConfigMap() string {
+	return Params.FeatureGatesConfigMap
+}
+
+func GetScopeConfigMap() string {
+	return Params.ScopeConfigMap
+}
+
+func IsRateLimitKillSwitchEngaged() bool {
+	return Params.RateLimitKillSwitch
+}
+
+func GetKillSwitchConfigMap() string {
+	return Params.KillSwitchConfigMap
+}
+
+// GetVhostMaxTokens returns the burst capacity of the vhost token bucket of the throttle filters.
+func GetVhostMaxTokens() int {
+	return Params.VhostMaxTokens
+}
+
+func GetVhostTokensPerFill() int {
+	return Params.VhostTokensPerFill
+}
+
+func GetVhostFillInterval() time.Duration {
+	return Params.VhostFillInterval
+}
+
+// MinFillInterval is the shortest token bucket fill interval envoy accepts.
+const MinFillInterval = 50 * time.Millisecond
+
+// ValidateTokenBucket returns an error if envoy would reject the token bucket, or if a fill would overflow it.
+func ValidateTokenBucket(maxTokens int, tokensPerFill int, fillInterval time.Duration) error {
+	switch {
+	case maxTokens <= 0 || maxTokens > math.MaxUint32:
+		return fmt.Errorf("max tokens %d must be between 1 and %d", maxTokens, uint32(math.MaxUint32))
+	case tokensPerFill <= 0:
+		return fmt.Errorf("tokens per fill %d must be positive", tokensPerFill)
+	case tokensPerFill > maxTokens:
+		return fmt.Errorf("tokens per fill %d exceed the max tokens %d of the bucket", tokensPerFill, maxTokens)
+	case fillInterval < MinFillInterval:
+		return fmt.Errorf("fill interval %s must be at least %s", fillInterval, MinFillInterval)
+	}
+	return nil
+}
+
+// GetAppQuotaEnforcement returns where the app quota groups are enforced when the traffic config does not set it.
+func GetAppQuotaEnforcement() string {
+	return Params.AppQuotaEnforcement
+}
+
+// IsAppQuotaPeerIdentityEnabled returns true if the callers of the app quota groups are identified by their mTLS peer principal.
+func IsAppQuotaPeerIdentityEnabled() bool {
+	return Params.AppQuotaPeerIdentity
+}
+
+// GetMeshTrustDomain returns the trust domain of the SPIFFE IDs of the mesh workloads.
+func GetMeshTrustDomain() string {
+	return Params.MeshTrustDomain
+}
+
+// ValidateMeshTrustDomain returns an error if the trust domain is empty or is not a single SPIFFE ID segment.
+func ValidateMeshTrustDomain(trustDomain string) error {
+	if len(trustDomain) == 0 || strings.ContainsAny(trustDomain, "/:") {
+		return fmt.Errorf("mesh trust domain %q must be a non empty host name", trustDomain)
+	}
+	return nil
+}
+
+// ValidateAppQuotaEnforcement returns an error if the app quota group enforcement is unknown.
+func ValidateAppQuotaEnforcement(enforcement string) error {
+	switch enforcement {
+	case types.EnforceInbound, types.EnforceOutbound, types.EnforceBoth:
+		return nil
+	}
+	return fmt.Errorf("app quota enforcement %q must be one of %q, %q or %q", enforcement, types.EnforceInbound, types.EnforceOutbound, types.EnforceBoth)
+}
+
+func GetTracingExporter() string {
+	return Params.TracingExporter
+}
+
+func GetTracingEndpoint() string {
+	return Params.TracingEndpoint
+}
+
+func GetTracingSampleRatio() float64 {
+	return Params.TracingSampleRatio
+}
+
+func GetEventHistorySize() int {
+	return Params.EventHistorySize
+}
+
+func GetEventHistoryRetention() time.Duration {
+	return Params.EventHistoryRetention
+}
+
+func GetEventHistoryFile() string {
+	return Params.EventHistoryFile
+}
+
+func GetAPITokenFile() string {
+	return Params.APITokenFile
+}
+
+func IsAdmissionWebhookEnabled() bool {
+	return Params.AdmissionWebhookEnabled
+}
+
 func GetConfigResolver() string {
 	return Params.ConfigResolver
 }
 
 func IsClusterInAllowedScope(cluster string) bool {
-	for _, scope := range Params.AllowedClusterScope {
-		r, e := regexp.Compile("(?i)" + strings.TrimSpace(scope))
-		if e == nil {
-			if r.MatchString(cluster) {
-				return true
-			}
-		} else {
-			panic(fmt.Errorf("unable to compile regex for scope=%s", scope))
+	for _, regex := range GetDynamicArgs().clusterScope {
+		if regex.MatchString(cluster) {
+			return true
 		}
 	}
 	return false
@@ -140,24 +333,49 @@ func IsClusterInAllowedScope(cluster string) bool {
 
 func GetTrafficConfigIgnoreAssets() []string {
 	assets := []string{}
-	for _, asset := range Params.IgnoreAssetAliases {
+	for _, asset := range GetDynamicArgs().IgnoreAssetAliases {
 		assets = append(assets, strings.ToLower(asset))
 	}
 	return assets
 }
 
 func IsAssetIgnored(asset string) bool {
-	return slices.Contains(GetTrafficConfigIgnoreAssets(), strings.ToLower(asset))
+	return GetDynamicArgs().ignoredAssets[strings.ToLower(asset)]
 }
 
 func GetEnvoyFilterVersions() []string {
-	return Params.EnvoyFilterVersions
+	return GetDynamicArgs().EnvoyFilterVersions
 }
 
 func GetDeprecatedEnvoyFilterVersions() []string {
 	return Params.DeprecatedEnvoyFilterVersions
 }
 
+// IsProxyVersionDetectionEnabled returns true if the throttle filters are generated for the proxy versions detected in each cluster.
+func IsProxyVersionDetectionEnabled() bool {
+	return Params.ProxyVersionDetection
+}
+
+func GetProxyVersionDetectionInterval() time.Duration {
+	return Params.ProxyVersionDetectionInterval
+}
+
+// GetQuotaScheduleInterval returns the max time between two evaluations of the quota schedules.
+func GetQuotaScheduleInterval() time.Duration {
+	return Params.QuotaScheduleInterval
+}
+
+// MinQuotaScheduleInterval is the shortest time between two evaluations of the quota schedules.
+const MinQuotaScheduleInterval = time.Second
+
+// ValidateQuotaScheduleInterval returns an error if the quota schedules would be evaluated too often.
+func ValidateQuotaScheduleInterval(interval time.Duration) error {
+	if interval < MinQuotaScheduleInterval {
+		return fmt.Errorf("quota schedule interval %s must be at least %s", interval, MinQuotaScheduleInterval)
+	}
+	return nil
+}
+
 func GetAsyncExecutorMaxGoRoutines() int32 {
 	return int32(Params.AsyncExecutorMaxGoRoutines)
 }
@@ -166,6 +384,18 @@ func GetWorkerConcurrency() int {
 	return Params.WorkerConcurrency
 }
 
+func GetFullReconcileQPS() int {
+	return Params.FullReconcileQPS
+}
+
+// ValidateFullReconcileQPS returns an error if the rate limiter of the full reconciles would never let an identity through.
+func ValidateFullReconcileQPS(qps int) error {
+	if qps <= 0 {
+		return fmt.Errorf("full reconcile qps %d must be positive", qps)
+	}
+	return nil
+}
+
 func GetCacheRefreshInterval() time.Duration {
 	return Params.CacheRefreshInterval
 }
@@ -190,10 +420,6 @@ func GetStartUpTime() time.Time {
 	return StartUpTime
 }
 
-func IsCacheWarmedUp() bool {
-	return time.Since(StartUpTime) > Params.CacheRefreshInterval
-}
-
 func GetEnvironment() string {
 	env := os.Getenv(types.AppEnvKey)
 	if len(env) == 0 {
@@ -203,7 +429,7 @@ func GetEnvironment() string {
 }
 
 func IsFeatureEnabled(feature types.FeatureName) bool {
-	return !slices.Contains(Params.DisabledFeatures, feature.String())
+	return !slices.Contains(GetDynamicArgs().DisabledFeatures, feature.String())
 }
 
 func InitializeNaavikArgs(args *NaavikArgs) {
@@ -216,7 +442,33 @@ func InitializeNaavikArgs(args *NaavikArgs) {
 		ArgoRolloutsEnabled:           getValueOrDefault[bool](args.ArgoRolloutsEnabled, DefaultArgoRolloutsEnabled),
 		ConfigResolver:                getValueOrDefault[string](args.ConfigResolver, DefaultConfigResolver),
 		StateChecker:                  getValueOrDefault[string](args.StateChecker, DefaultStateChecker),
+		LeaseName:                     getValueOrDefault[string](args.LeaseName, DefaultLeaseName),
+		LeaseIdentity:                 args.LeaseIdentity,
+		LeaseDuration:                 getValueOrDefault[time.Duration](args.LeaseDuration, DefaultLeaseDuration),
+		LeaseRenewDeadline:            getValueOrDefault[time.Duration](args.LeaseRenewDeadline, DefaultLeaseRenewDeadline),
+		LeaseRetryPeriod:              getValueOrDefault[time.Duration](args.LeaseRetryPeriod, DefaultLeaseRetryPeriod),
+		Region:                        args.Region,
+		DRConfigMapName:               getValueOrDefault[string](args.DRConfigMapName, DefaultDRConfigMapName),
+		FeatureGatesConfigMap:         getValueOrDefault[string](args.FeatureGatesConfigMap, DefaultFeatureGatesConfigMap),
+		ScopeConfigMap:                getValueOrDefault[string](args.ScopeConfigMap, DefaultScopeConfigMap),
+		RateLimitKillSwitch:           args.RateLimitKillSwitch,
+		KillSwitchConfigMap:           getValueOrDefault[string](args.KillSwitchConfigMap, DefaultKillSwitchConfigMap),
+		VhostMaxTokens:                getValueOrDefault[int](args.VhostMaxTokens, DefaultVhostMaxTokens),
+		VhostTokensPerFill:            getValueOrDefault[int](args.VhostTokensPerFill, DefaultVhostTokensPerFill),
+		VhostFillInterval:             getValueOrDefault[time.Duration](args.VhostFillInterval, DefaultVhostFillInterval),
+		AppQuotaEnforcement:           getValueOrDefault[string](args.AppQuotaEnforcement, DefaultAppQuotaEnforcement),
+		AppQuotaPeerIdentity:          getValueOrDefault[bool](args.AppQuotaPeerIdentity, DefaultAppQuotaPeerIdentity),
+		MeshTrustDomain:               getValueOrDefault[string](args.MeshTrustDomain, DefaultMeshTrustDomain),
+		TracingExporter:               getValueOrDefault[string](args.TracingExporter, DefaultTracingExporter),
+		TracingEndpoint:               args.TracingEndpoint,
+		TracingSampleRatio:            getValueOrDefault[float64](args.TracingSampleRatio, DefaultTracingSampleRatio),
+		EventHistorySize:              getValueOrDefault[int](args.EventHistorySize, DefaultEventHistorySize),
+		EventHistoryRetention:         getValueOrDefault[time.Duration](args.EventHistoryRetention, DefaultEventHistoryRetention),
+		EventHistoryFile:              args.EventHistoryFile,
+		APITokenFile:                  args.APITokenFile,
+		AdmissionWebhookEnabled:       getValueOrDefault[bool](args.AdmissionWebhookEnabled, DefaultAdmissionWebhookEnabled),
 		ConfigPath:                    getValueOrDefault[string](args.ConfigPath, DefaultConfigPath),
+		ConfigReloadInterval:          getValueOrDefault[time.Duration](args.ConfigReloadInterval, DefaultConfigReloadInterval),
 		WorkloadIdentityKey:           getValueOrDefault[string](args.WorkloadIdentityKey, DefaultWorkloadIdentity),
 		EnvKey:                        getValueOrDefault[string](args.EnvKey, DefaultWorkloadEnvKey),
 		ResourceIgnoreLabel:           getValueOrDefault[string](args.ResourceIgnoreLabel, DefaultResourceIgnoreLabel),
@@ -229,16 +481,21 @@ func InitializeNaavikArgs(args *NaavikArgs) {
 		IgnoreAssetAliases:            getValueOrDefaultSlice(args.IgnoreAssetAliases, DefaultIgnoreAssetAliases),
 		EnvoyFilterVersions:           getValueOrDefaultSlice(args.EnvoyFilterVersions, DefaultEnvoyFilterVersions),
 		DeprecatedEnvoyFilterVersions: getValueOrDefaultSlice(args.DeprecatedEnvoyFilterVersions, DefaultDeprecatedEnvoyFilterVersions),
+		ProxyVersionDetection:         getValueOrDefault[bool](args.ProxyVersionDetection, DefaultProxyVersionDetection),
+		ProxyVersionDetectionInterval: getValueOrDefault[time.Duration](args.ProxyVersionDetectionInterval, DefaultProxyDetectionInterval),
+		QuotaScheduleInterval:         getValueOrDefault[time.Duration](args.QuotaScheduleInterval, DefaultQuotaScheduleInterval),
 		DisabledFeatures:              getValueOrDefaultSlice(args.DisabledFeatures, DefaultDisabledFeatures),
 		AsyncExecutorMaxGoRoutines:    getValueOrDefault[int](args.AsyncExecutorMaxGoRoutines, DefaultAsyncExecutorMaxGoRoutines),
 		MeshInjectionEnabledKey:       getValueOrDefault[string](args.MeshInjectionEnabledKey, DefaultMeshInjectionKey),
 		WorkerConcurrency:             getValueOrDefault[int](args.WorkerConcurrency, DefaultWorkerConcurrency),
+		FullReconcileQPS:              getValueOrDefault[int](args.FullReconcileQPS, DefaultFullReconcileQPS),
 		KubeConfigPath:                getValueOrDefault[string](args.ClusterRegistriesNamespace, ""),
 		ClusterRegistriesNamespace:    getValueOrDefault[string](args.ClusterRegistriesNamespace, DefaultClusterRegistriesNamespace),
 		DependenciesNamespace:         getValueOrDefault[string](args.DependenciesNamespace, DefaultDependencyNamespace),
 		SyncNamespace:                 getValueOrDefault[string](args.SyncNamespace, DefaultSyncNamespace),
 		CacheRefreshInterval:          getValueOrDefault[time.Duration](args.CacheRefreshInterval, DefaultRefreshInterval),
 	}
+	SetDynamicArgs(nil)
 }
 
 func getValueOrDefaultSlice(val, def []string) []string {
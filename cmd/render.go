@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/handler/trafficconfig"
+	"github.com/intuit/naavik/internal/render"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+var (
+	renderTrafficConfigFile string
+	renderFixtureFile       string
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Render the VirtualServices and EnvoyFilters of a TrafficConfig without a cluster",
+	Long: `Render the VirtualServices and EnvoyFilters of a TrafficConfig per cluster, using the same builders as the controller.
+The dependencies, the identity to cluster mapping and the deployments are read from a fixture file instead of the clusters.
+The resources are printed as YAML to stdout, the warnings to stderr.`,
+	Example: "naavik render --traffic_config trafficconfig.yaml --fixture fixture.yaml",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		// Logs are written to stdout, keep them out of the rendered resources unless asked for
+		if !cmd.Flags().Changed("log_level") {
+			logger.Log.SetLogLevel("error")
+		} else {
+			logger.Log.SetLogLevel(options.GetLogLevel())
+		}
+		trafficConfig, err := render.ReadTrafficConfig(renderTrafficConfigFile)
+		if err != nil {
+			return err
+		}
+		fixture, err := render.ReadFixture(renderFixtureFile)
+		if err != nil {
+			return err
+		}
+		if err := fixture.Load(); err != nil {
+			return err
+		}
+		rendered, err := trafficconfig.RenderTrafficConfig(context.NewContextWithLogger(), trafficConfig)
+		if err != nil {
+			return err
+		}
+		for _, warning := range rendered.Warnings {
+			fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s\n", warning)
+		}
+		return render.WriteYAML(cmd.OutOrStdout(), rendered)
+	},
+}
+
+func init() {
+	renderCmd.Flags().StringVar(&renderTrafficConfigFile, "traffic_config", "", "TrafficConfig file to render, as YAML or JSON")
+	renderCmd.Flags().StringVar(&renderFixtureFile, "fixture", "", "Fixture file with the dependencies, the identity to cluster mapping and the deployments, as YAML or JSON")
+	_ = renderCmd.MarkFlagRequired("traffic_config")
+	_ = renderCmd.MarkFlagRequired("fixture")
+	rootCmd.AddCommand(renderCmd)
+}
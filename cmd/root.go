@@ -6,7 +6,9 @@ import (
 
 	"github.com/intuit/naavik/cmd/options"
 	"github.com/intuit/naavik/internal/bootstrap"
+	"github.com/intuit/naavik/internal/types"
 	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/tracing"
 	"github.com/spf13/cobra"
 )
 
@@ -18,6 +20,14 @@ var rootCmd = &cobra.Command{
 		ctx := context.NewContextWithLogger()
 		bootstrap.InitNaavik(ctx)
 	},
+	// Serving loads the config file, the flags set on the command line take precedence over it.
+	// The subcommands only read their flags, the args are validated the same way.
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		if cmd.HasParent() {
+			return options.ValidateArgs()
+		}
+		return options.LoadConfigFile(cmd.Flags())
+	},
 }
 
 // Execute executes the root command.
@@ -27,9 +37,8 @@ func Execute() error {
 }
 
 func init() {
-	// TODO: Add support for config file
 	// Basic options
-	rootCmd.PersistentFlags().StringVar(&options.Params.LogLevel, "log_level", options.DefaultLogLevel,
+	rootCmd.PersistentFlags().StringVar(&options.Params.LogLevel, options.LogLevelFlag, options.DefaultLogLevel,
 		fmt.Sprintf("Set log verbosity, defaults to 'Info'. Must be between %q and %q", "trace", "info"))
 	rootCmd.PersistentFlags().BoolVar(&options.Params.LogColor, "log_color", options.DefaultLogColor,
 		fmt.Sprintf("Enable color for logs. Default is %t", options.DefaultLogColor))
@@ -37,13 +46,51 @@ func init() {
 		fmt.Sprintf("Set the config resolver to run naavik with, defaults to %q", options.DefaultConfigResolver))
 	rootCmd.PersistentFlags().StringVar(&options.Params.StateChecker, "state_checker", options.DefaultStateChecker,
 		fmt.Sprintf("Set the state checker to run naavik with, defaults to %q", options.DefaultStateChecker))
-	rootCmd.PersistentFlags().StringVar(&options.Params.ConfigPath, "config_path", options.DefaultConfigPath,
-		fmt.Sprintf("Path where the configuration file resides. Defaults to %q", options.DefaultConfigPath))
+	rootCmd.PersistentFlags().StringVar(&options.Params.LeaseName, "lease_name", options.DefaultLeaseName,
+		fmt.Sprintf("Name of the lease in the sync namespace used by the %q state checker. Defaults to %q", types.StateCheckerLease, options.DefaultLeaseName))
+	rootCmd.PersistentFlags().StringVar(&options.Params.LeaseIdentity, "lease_identity", "",
+		"Identity of this instance when acquiring the lease. Defaults to empty string, which means the hostname (pod name)")
+	rootCmd.PersistentFlags().DurationVar(&options.Params.LeaseDuration, "lease_duration", options.DefaultLeaseDuration,
+		fmt.Sprintf("Duration that non-leader instances wait before trying to acquire the lease. Defaults to %s", options.DefaultLeaseDuration))
+	rootCmd.PersistentFlags().DurationVar(&options.Params.LeaseRenewDeadline, "lease_renew_deadline", options.DefaultLeaseRenewDeadline,
+		fmt.Sprintf("Duration that the leader retries renewing the lease before switching to read only. Defaults to %s", options.DefaultLeaseRenewDeadline))
+	rootCmd.PersistentFlags().DurationVar(&options.Params.LeaseRetryPeriod, "lease_retry_period", options.DefaultLeaseRetryPeriod,
+		fmt.Sprintf("Duration between lease acquire and renew attempts. Defaults to %s", options.DefaultLeaseRetryPeriod))
+	rootCmd.PersistentFlags().StringVar(&options.Params.Region, "region", "",
+		fmt.Sprintf("Region this instance runs in, required by the %q state checker", types.StateCheckerDR))
+	rootCmd.PersistentFlags().StringVar(&options.Params.DRConfigMapName, "dr_config_map", options.DefaultDRConfigMapName,
+		fmt.Sprintf("Name of the config map in the sync namespace naming the active region for the %q state checker. Defaults to %q", types.StateCheckerDR, options.DefaultDRConfigMapName))
+	rootCmd.PersistentFlags().StringVar(&options.Params.ConfigPath, options.ConfigPathFlag, options.DefaultConfigPath,
+		fmt.Sprintf("Path of the YAML config file mapping flag names to values, the flags set on the command line take precedence. Defaults to %q", options.DefaultConfigPath))
+	rootCmd.PersistentFlags().DurationVar(&options.Params.ConfigReloadInterval, "config_reload_interval", options.DefaultConfigReloadInterval,
+		fmt.Sprintf("Interval for checking the config file for changes, to reload the log level, disabled features, ignored asset aliases, clusters scope and envoy filter versions. 0 disables the reload. Defaults to %s", options.DefaultConfigReloadInterval))
 	rootCmd.PersistentFlags().BoolVar(&options.Params.EnableProfiling, "enable_profiling", options.DefaultEnableProfiling,
 		fmt.Sprintf("Enable go profiling for cpu, memory, goroutines, etc. Defaults to %t", options.DefaultEnableProfiling))
 	rootCmd.PersistentFlags().StringVar(&options.Params.ProfilerEndpoint, "profiler_endpoint", options.DefaultProfilerEndpoint,
 		fmt.Sprintf("Set the continuous profiler endpoint. Defaults to %q", options.DefaultProfilerEndpoint))
 
+	// Tracing options
+	rootCmd.PersistentFlags().StringVar(&options.Params.TracingExporter, "tracing_exporter", options.DefaultTracingExporter,
+		fmt.Sprintf("Exporter for the event traces, one of %q, %q or %q. Defaults to %q", tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout, options.DefaultTracingExporter))
+	rootCmd.PersistentFlags().StringVar(&options.Params.TracingEndpoint, "tracing_endpoint", "",
+		"OTLP gRPC endpoint to export the traces to. Defaults to empty string, which means the OTEL_EXPORTER_OTLP_* environment variables are used")
+	rootCmd.PersistentFlags().Float64Var(&options.Params.TracingSampleRatio, "tracing_sample_ratio", options.DefaultTracingSampleRatio,
+		fmt.Sprintf("Ratio of the events traced. Defaults to %.1f", options.DefaultTracingSampleRatio))
+
+	// Event history options
+	rootCmd.PersistentFlags().IntVar(&options.Params.EventHistorySize, "event_history_size", options.DefaultEventHistorySize,
+		fmt.Sprintf("Max number of processed events kept in the event history. Defaults to %d", options.DefaultEventHistorySize))
+	rootCmd.PersistentFlags().DurationVar(&options.Params.EventHistoryRetention, "event_history_retention", options.DefaultEventHistoryRetention,
+		fmt.Sprintf("Max age of the processed events kept in the event history. Defaults to %s", options.DefaultEventHistoryRetention))
+	rootCmd.PersistentFlags().StringVar(&options.Params.EventHistoryFile, "event_history_file", "",
+		"File the event history is persisted to. Defaults to empty string, which means the event history is only kept in memory")
+
+	// API options
+	rootCmd.PersistentFlags().StringVar(&options.Params.APITokenFile, "api_token_file", "",
+		"File holding the bearer tokens, one per line, allowed to call the write APIs, e.g. reconcile. Defaults to empty string, which means the write APIs are disabled")
+	rootCmd.PersistentFlags().BoolVar(&options.Params.AdmissionWebhookEnabled, "admission_webhook", options.DefaultAdmissionWebhookEnabled,
+		fmt.Sprintf("Serve the validating admission webhook for traffic configs on the TLS server. Defaults to %t", options.DefaultAdmissionWebhookEnabled))
+
 	// Controller options
 	rootCmd.PersistentFlags().BoolVar(&options.Params.ArgoRolloutsEnabled, "argo_rollouts", options.DefaultArgoRolloutsEnabled,
 		fmt.Sprintf("Use argo rollout configurations. Defaults to %t", options.DefaultArgoRolloutsEnabled))
@@ -59,6 +106,8 @@ func init() {
 		fmt.Sprintf("Namespace to monitor for service dependency data. Defaults to %q", options.DefaultDependencyNamespace))
 	rootCmd.PersistentFlags().DurationVar(&options.Params.CacheRefreshInterval, "sync_period", options.DefaultRefreshInterval,
 		fmt.Sprintf("Interval for syncing Kubernetes resources. Defaults to %d", options.DefaultRefreshInterval))
+	rootCmd.PersistentFlags().IntVar(&options.Params.FullReconcileQPS, "full_reconcile_qps", options.DefaultFullReconcileQPS,
+		fmt.Sprintf("Maximum number of identities reconciled per second when all traffic configs are reconciled, e.g. after cache warm up or failover, a new full reconcile cancels the one in flight. Must be positive. Defaults to %d", options.DefaultFullReconcileQPS))
 	rootCmd.PersistentFlags().IntVar(&options.Params.AsyncExecutorMaxGoRoutines, "async_executor_max_goroutines", options.DefaultAsyncExecutorMaxGoRoutines,
 		fmt.Sprintf("Maximum number of go routines to be used by async executor. Defaults to %d", options.DefaultAsyncExecutorMaxGoRoutines))
 
@@ -85,14 +134,41 @@ func init() {
 			fmt.Sprintf("Namespace to monitor for service traffic config data. Defaults to %q", options.DefaultTrafficConfigNamespace))
 	rootCmd.PersistentFlags().StringVar(&options.Params.TrafficConfigIdentityKey, "traffic_config_identity_key", options.DefaultTrafficConfigIdentityKey,
 		fmt.Sprintf("The traffic config identity key holds identity value of a service. Default label key will be %q.", options.DefaultTrafficConfigIdentityKey))
-	rootCmd.PersistentFlags().StringArrayVar(&options.Params.AllowedClusterScope, "traffic_config_clusters_scope", options.DefaultTrafficConfigClustersScope,
+	rootCmd.PersistentFlags().StringArrayVar(&options.Params.AllowedClusterScope, options.ClustersScopeFlag, options.DefaultTrafficConfigClustersScope,
 		fmt.Sprintf("List of clusters that should be processed for traffic config. Defaults to %q", options.DefaultTrafficConfigClustersScope))
-	rootCmd.PersistentFlags().StringArrayVar(&options.Params.IgnoreAssetAliases, "ignore_asset_aliases", options.DefaultIgnoreAssetAliases,
+	rootCmd.PersistentFlags().StringArrayVar(&options.Params.IgnoreAssetAliases, options.IgnoreAssetAliasesFlag, options.DefaultIgnoreAssetAliases,
 		fmt.Sprintf("List of asset aliases that should be ignored for traffic config processing. Defaults to %q", options.DefaultIgnoreAssetAliases))
-	rootCmd.PersistentFlags().StringArrayVar(&options.Params.EnvoyFilterVersions, "envoy_filter_versions", options.DefaultEnvoyFilterVersions,
+	rootCmd.PersistentFlags().StringArrayVar(&options.Params.EnvoyFilterVersions, options.EnvoyFilterVersionsFlag, options.DefaultEnvoyFilterVersions,
 		fmt.Sprintf("List of envoy filter versions that should be processed for traffic config. Defaults to %q", options.DefaultEnvoyFilterVersions))
 	rootCmd.PersistentFlags().StringArrayVar(&options.Params.DeprecatedEnvoyFilterVersions, "deprecated_envoy_filter_versions", options.DefaultDeprecatedEnvoyFilterVersions,
 		fmt.Sprintf("List of envoy filter versions that are deprecated and should be removed while traffic config processing. Defaults to %q", options.DefaultDeprecatedEnvoyFilterVersions))
-	rootCmd.PersistentFlags().StringArrayVar(&options.Params.DisabledFeatures, "disabled_features", options.DefaultDisabledFeatures,
+	rootCmd.PersistentFlags().BoolVar(&options.Params.ProxyVersionDetection, "proxy_version_detection", options.DefaultProxyVersionDetection,
+		fmt.Sprintf("Generate the throttle filters for the proxy versions of the injected pods of each cluster instead of the %s. Defaults to %t", options.EnvoyFilterVersionsFlag, options.DefaultProxyVersionDetection))
+	rootCmd.PersistentFlags().DurationVar(&options.Params.ProxyVersionDetectionInterval, "proxy_version_detection_interval", options.DefaultProxyDetectionInterval,
+		fmt.Sprintf("Interval between two detections of the proxy versions of the clusters. Defaults to %s", options.DefaultProxyDetectionInterval))
+	rootCmd.PersistentFlags().DurationVar(&options.Params.QuotaScheduleInterval, "quota_schedule_interval", options.DefaultQuotaScheduleInterval,
+		fmt.Sprintf("Max time between two evaluations of the quota schedules, so the schedules of new traffic configs are picked up between the window boundaries. Must be at least %s. Defaults to %s", options.MinQuotaScheduleInterval, options.DefaultQuotaScheduleInterval))
+	rootCmd.PersistentFlags().StringArrayVar(&options.Params.DisabledFeatures, options.DisabledFeaturesFlag, options.DefaultDisabledFeatures,
 		fmt.Sprintf("Comma separated list of features to be disabled. Available features %v", options.AvailableFeatures))
+	rootCmd.PersistentFlags().StringVar(&options.Params.FeatureGatesConfigMap, "feature_gates_config_map", options.DefaultFeatureGatesConfigMap,
+		fmt.Sprintf("Name of the config map in the sync namespace enabling or disabling features per identity, env and cluster. Empty disables the feature gates. Defaults to %q", options.DefaultFeatureGatesConfigMap))
+	rootCmd.PersistentFlags().StringVar(&options.Params.ScopeConfigMap, "scope_config_map", options.DefaultScopeConfigMap,
+		fmt.Sprintf("Name of the config map in the sync namespace with the allow and deny lists of clusters and assets, narrowing the traffic config clusters scope and ignored asset aliases. Empty disables the scope config map. Defaults to %q", options.DefaultScopeConfigMap))
+	rootCmd.PersistentFlags().BoolVar(&options.Params.RateLimitKillSwitch, "rate_limit_kill_switch", options.DefaultRateLimitKillSwitch,
+		fmt.Sprintf("Engage the rate limit kill switch, the generated throttle filters are not enforced whatever the state of the kill switch config map. Defaults to %t", options.DefaultRateLimitKillSwitch))
+	rootCmd.PersistentFlags().StringVar(&options.Params.KillSwitchConfigMap, "kill_switch_config_map", options.DefaultKillSwitchConfigMap,
+		fmt.Sprintf("Name of the config map in the sync namespace persisting the rate limit kill switch and its audit trail. Empty disables the kill switch API. Defaults to %q", options.DefaultKillSwitchConfigMap))
+	rootCmd.PersistentFlags().IntVar(&options.Params.VhostMaxTokens, "vhost_max_tokens", options.DefaultVhostMaxTokens,
+		fmt.Sprintf("Burst capacity of the vhost token bucket of the throttle filters, used by the requests matching no quota. Defaults to %d", options.DefaultVhostMaxTokens))
+	rootCmd.PersistentFlags().IntVar(&options.Params.VhostTokensPerFill, "vhost_tokens_per_fill", options.DefaultVhostTokensPerFill,
+		fmt.Sprintf("Tokens added to the vhost token bucket of the throttle filters at each fill interval. Defaults to %d", options.DefaultVhostTokensPerFill))
+	rootCmd.PersistentFlags().DurationVar(&options.Params.VhostFillInterval, "vhost_fill_interval", options.DefaultVhostFillInterval,
+		fmt.Sprintf("Fill interval of the vhost token bucket of the throttle filters. Defaults to %s", options.DefaultVhostFillInterval))
+	rootCmd.PersistentFlags().StringVar(&options.Params.AppQuotaEnforcement, "app_quota_enforcement", options.DefaultAppQuotaEnforcement,
+		fmt.Sprintf("Where the app quota groups are enforced when the traffic config does not set it: %q on the sidecars of the identity, %q on the outbound sidecars of the associated apps, or %q. Defaults to %q",
+			types.EnforceInbound, types.EnforceOutbound, types.EnforceBoth, options.DefaultAppQuotaEnforcement))
+	rootCmd.PersistentFlags().BoolVar(&options.Params.AppQuotaPeerIdentity, "app_quota_peer_identity", options.DefaultAppQuotaPeerIdentity,
+		fmt.Sprintf("Identify the associated apps of the app quota groups by the SPIFFE principal of their mTLS peer certificate instead of the %q header. Defaults to %t", options.DefaultTrafficConfigIdentityKey, options.DefaultAppQuotaPeerIdentity))
+	rootCmd.PersistentFlags().StringVar(&options.Params.MeshTrustDomain, "mesh_trust_domain", options.DefaultMeshTrustDomain,
+		fmt.Sprintf("Trust domain of the SPIFFE IDs of the mesh workloads, the peer certificates of the associated apps must be issued in it. Defaults to %q", options.DefaultMeshTrustDomain))
 }
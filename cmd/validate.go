@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/intuit/naavik/internal/validation"
+	"github.com/spf13/cobra"
+)
+
+const (
+	validateOutputText = "text"
+	validateOutputJSON = "json"
+)
+
+var validateOutput string
+
+// validateFileResult is the validation of the manifests of a file.
+type validateFileResult struct {
+	File      string                       `json:"file"`
+	Manifests []*validation.ManifestResult `json:"manifests"`
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate FILE...",
+	Short: "Validate TrafficConfig and Dependency manifests",
+	Long: `Validate TrafficConfig and Dependency manifests, as YAML or JSON. A YAML file can hold several manifests separated by ---.
+The errors and the warnings are reported with the path of the field. The command fails when any manifest has an error.`,
+	Example: "naavik validate trafficconfig.yaml dependency.yaml",
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, files []string) error {
+		if validateOutput != validateOutputText && validateOutput != validateOutputJSON {
+			return fmt.Errorf("invalid output %q, expected %q or %q", validateOutput, validateOutputText, validateOutputJSON)
+		}
+		cmd.SilenceUsage = true
+		fileResults := make([]*validateFileResult, 0, len(files))
+		errorCount := 0
+		for _, file := range files {
+			manifests, err := validateFile(file)
+			if err != nil {
+				return err
+			}
+			for _, manifest := range manifests {
+				errorCount += len(manifest.Errors)
+			}
+			fileResults = append(fileResults, &validateFileResult{File: file, Manifests: manifests})
+		}
+
+		if validateOutput == validateOutputJSON {
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(fileResults); err != nil {
+				return err
+			}
+		} else {
+			printValidateResults(cmd, fileResults)
+		}
+		if errorCount > 0 {
+			return fmt.Errorf("%d validation errors found", errorCount)
+		}
+		return nil
+	},
+}
+
+func validateFile(file string) ([]*validation.ManifestResult, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	manifests, err := validation.ValidateManifests(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return manifests, nil
+}
+
+func printValidateResults(cmd *cobra.Command, fileResults []*validateFileResult) {
+	for _, fileResult := range fileResults {
+		for _, manifest := range fileResult.Manifests {
+			prefix := fmt.Sprintf("%s[%d] %s/%s", fileResult.File, manifest.Document, manifest.Kind, manifest.Name)
+			for _, issue := range manifest.Errors {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: error: %s\n", prefix, issue)
+			}
+			for _, issue := range manifest.Warnings {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: warning: %s\n", prefix, issue)
+			}
+			if len(manifest.Errors) == 0 && len(manifest.Warnings) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: valid\n", prefix)
+			}
+		}
+	}
+}
+
+func init() {
+	validateCmd.Flags().StringVarP(&validateOutput, "output", "o", validateOutputText,
+		fmt.Sprintf("Output format, %q or %q. Defaults to %q", validateOutputText, validateOutputJSON, validateOutputText))
+	rootCmd.AddCommand(validateCmd)
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/render"
+	"github.com/intuit/naavik/internal/webhook"
+	"github.com/spf13/cobra"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+)
+
+var (
+	webhookServiceName      string
+	webhookServiceNamespace string
+	webhookServicePort      int32
+	webhookCABundleFile     string
+	webhookFailurePolicy    string
+	webhookTimeoutSeconds   int32
+)
+
+var webhookConfigCmd = &cobra.Command{
+	Use:   "webhook-config",
+	Short: "Print the ValidatingWebhookConfiguration of the TrafficConfig admission webhook",
+	Long: `Print the ValidatingWebhookConfiguration calling the naavik TrafficConfig admission webhook on creates and updates, as YAML.
+The webhook is served on the TLS server when naavik runs with --admission_webhook, the CA bundle must be the CA of the TLS server certificate.
+Only the traffic config namespace, --traffic_config_namespace, is validated.`,
+	Example: "naavik webhook-config --service_name naavik --service_namespace admiral --ca_bundle_file ca.crt | kubectl apply -f -",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		var caBundle []byte
+		if len(webhookCABundleFile) > 0 {
+			content, err := os.ReadFile(webhookCABundleFile)
+			if err != nil {
+				return err
+			}
+			caBundle = content
+		}
+		configuration, err := webhook.BuildValidatingWebhookConfiguration(webhook.ConfigurationOptions{
+			ServiceName:            webhookServiceName,
+			ServiceNamespace:       webhookServiceNamespace,
+			ServicePort:            webhookServicePort,
+			CABundle:               caBundle,
+			FailurePolicy:          admissionregistrationv1.FailurePolicyType(webhookFailurePolicy),
+			TrafficConfigNamespace: options.GetTrafficConfigNamespace(),
+			TimeoutSeconds:         webhookTimeoutSeconds,
+		})
+		if err != nil {
+			return err
+		}
+		content, err := render.ToManifest(configuration)
+		if err != nil {
+			return err
+		}
+		_, err = cmd.OutOrStdout().Write(content)
+		return err
+	},
+}
+
+func init() {
+	webhookConfigCmd.Flags().StringVar(&webhookServiceName, "service_name", "naavik", "Name of the service in front of naavik. Defaults to naavik")
+	webhookConfigCmd.Flags().StringVar(&webhookServiceNamespace, "service_namespace", "", "Namespace of the service in front of naavik")
+	webhookConfigCmd.Flags().Int32Var(&webhookServicePort, "service_port", webhook.DefaultServicePort,
+		fmt.Sprintf("Port of the service routing to the naavik TLS server. Defaults to %d", webhook.DefaultServicePort))
+	webhookConfigCmd.Flags().StringVar(&webhookCABundleFile, "ca_bundle_file", "", "PEM file of the CA of the TLS server certificate. Defaults to empty, e.g. when injected by cert-manager")
+	webhookConfigCmd.Flags().StringVar(&webhookFailurePolicy, "failure_policy", string(admissionregistrationv1.Ignore),
+		fmt.Sprintf("What the API server does when naavik is not reachable, %q or %q. Defaults to %q", admissionregistrationv1.Ignore, admissionregistrationv1.Fail, admissionregistrationv1.Ignore))
+	webhookConfigCmd.Flags().Int32Var(&webhookTimeoutSeconds, "timeout_seconds", 5, "Timeout of the webhook calls in seconds. Defaults to 5")
+	_ = webhookConfigCmd.MarkFlagRequired("service_namespace")
+	rootCmd.AddCommand(webhookConfigCmd)
+}
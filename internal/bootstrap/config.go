@@ -0,0 +1,74 @@
+package bootstrap
+
+import (
+	"bytes"
+	gocontext "context"
+	"errors"
+	"io/fs"
+	"os"
+
+	"github.com/intuit/naavik/cmd/options"
+	trafficconfig_handler "github.com/intuit/naavik/internal/handler/trafficconfig"
+	"github.com/intuit/naavik/internal/scope"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/logger"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// watchConfigFile polls the config file and reloads the dynamic args when its content changes.
+// Polling follows the symlinks swapped by kubernetes when a mounted config map is updated.
+func watchConfigFile(ctx context.Context) {
+	interval := options.GetConfigReloadInterval()
+	if interval <= 0 {
+		return
+	}
+	path := options.GetConfigPath()
+	lastContent, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		ctx.Log.Str(logger.ErrorKey, err.Error()).Warn("error reading config file")
+	}
+	ctx.Log.Str("path", path).Infof("Watching config file for changes every %s", interval)
+	wait.UntilWithContext(ctx.Context, func(_ gocontext.Context) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				ctx.Log.Str(logger.ErrorKey, err.Error()).Warn("error reading config file")
+			}
+			return
+		}
+		if bytes.Equal(content, lastContent) {
+			return
+		}
+		lastContent = content
+		reloadConfig(ctx, content)
+	}, interval)
+}
+
+// reloadConfig replaces the dynamic args, and reconciles all the traffic configs if the resources they render changed.
+// The resources of the clusters and assets that left the scope are deleted first, or once this instance can write.
+func reloadConfig(ctx context.Context, content []byte) {
+	args, err := options.ReloadConfig(content)
+	if err != nil {
+		// Keep the last valid config
+		ctx.Log.Str(logger.ErrorKey, err.Error()).Error("invalid config file, config not reloaded")
+		return
+	}
+	previous := options.GetDynamicArgs()
+	if args.Equal(previous) {
+		return
+	}
+	before := scope.TakeSnapshot()
+	options.SetDynamicArgs(args)
+	log.SetLogLevel(args.LogLevel)
+	ctx.Log.Str(options.LogLevelFlag, args.LogLevel).Any(options.DisabledFeaturesFlag, args.DisabledFeatures).
+		Any(options.IgnoreAssetAliasesFlag, args.IgnoreAssetAliases).Any(options.ClustersScopeFlag, args.AllowedClusterScope).
+		Any(options.EnvoyFilterVersionsFlag, args.EnvoyFilterVersions).Info("Config reloaded")
+
+	onlyLogLevel := *args
+	onlyLogLevel.LogLevel = previous.LogLevel
+	if onlyLogLevel.Equal(previous) || !recordScopeCleanup(ctx, scope.Diff(before, scope.TakeSnapshot())) {
+		return
+	}
+	runPendingScopeCleanup(ctx)
+	trafficconfig_handler.NewTrafficConfigHandler().ReconcileAllTrafficConfigs(ctx)
+}
@@ -0,0 +1,71 @@
+package bootstrap
+
+import (
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/scope"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/pflag"
+)
+
+var _ = Describe("Test config reload", func() {
+	var (
+		ctx         context.Context
+		logMessages []string
+	)
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		// No config file, the reloads start from the defaults
+		Expect(options.LoadConfigFile(pflag.NewFlagSet("naavik", pflag.ContinueOnError))).To(Succeed())
+		cache.ResetAllCaches()
+		scope.Reset()
+		cache.InformerSync.SetWarmedUp()
+		ctx = context.NewContextWithLogger()
+		leasechecker.RunStateCheck(ctx, leasechecker.GetStateChecker(ctx, types.StateCheckerNone))
+		logMessages = []string{}
+		ctx.Log = ctx.Log.Hook(func(_ string, msg string) {
+			logMessages = append(logMessages, msg)
+		})
+	})
+
+	AfterEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		scope.Reset()
+		leasechecker.ResetState()
+	})
+
+	It("should only reconcile all the traffic configs when more than the log level changed", func() {
+		reloadConfig(ctx, []byte("log_level: debug\n"))
+		Expect(options.GetLogLevel()).To(Equal("debug"))
+		Expect(logMessages).To(ContainElement("Config reloaded"))
+		Expect(logMessages).NotTo(ContainElement("Reconciling all traffic configs started"))
+
+		logMessages = []string{}
+		reloadConfig(ctx, []byte("log_level: debug\ndisabled_features: [virtualservice]\n"))
+		Expect(options.IsFeatureEnabled(types.FeatureVirtualService)).To(BeFalse())
+		Expect(logMessages).To(ContainElement("Reconciling all traffic configs started"))
+	})
+
+	It("should not reconcile when the args are unchanged", func() {
+		reloadConfig(ctx, []byte("log_level: "+options.DefaultLogLevel+"\n"))
+		Expect(logMessages).NotTo(ContainElement("Config reloaded"))
+	})
+
+	It("should keep the last valid args on an invalid cluster scope regex", func() {
+		reloadConfig(ctx, []byte("traffic_config_clusters_scope: [west-.*]\n"))
+		Expect(options.GetDynamicArgs().AllowedClusterScope).To(Equal([]string{"west-.*"}))
+
+		logMessages = []string{}
+		reloadConfig(ctx, []byte("traffic_config_clusters_scope: ['[', east-.*]\n"))
+		Expect(logMessages).To(ContainElement("invalid config file, config not reloaded"))
+		Expect(options.GetDynamicArgs().AllowedClusterScope).To(Equal([]string{"west-.*"}))
+		Expect(scope.IsClusterInScope("west-1")).To(BeTrue())
+		Expect(scope.IsClusterInScope("east-1")).To(BeFalse())
+	})
+})
@@ -13,10 +13,12 @@ import (
 	"github.com/intuit/naavik/internal/handler/remotecluster"
 	"github.com/intuit/naavik/internal/handler/remotecluster/resolver"
 	trafficconfig_handler "github.com/intuit/naavik/internal/handler/trafficconfig"
+	"github.com/intuit/naavik/internal/override"
 	"github.com/intuit/naavik/internal/types/context"
 	k8s_utils "github.com/intuit/naavik/internal/utils/k8s"
 	"github.com/intuit/naavik/pkg/logger"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 )
@@ -72,6 +74,14 @@ func startTrafficConfigController(ctx context.Context, name string, k8sConfig *r
 	admiral_controller.NewTrafficConfigController(k8sConfig.Host, k8sConfig, configLoader, namespace, listOpts, resyncPeriod,
 		trafficconfig_handler.NewTrafficConfigHandler(),
 	)
+
+	// The tracked overrides are saved in the traffic config statuses, their typed client has no field for them
+	client, err := dynamic.NewForConfig(k8sConfig)
+	if err != nil {
+		ctx.Log.Str(logger.ErrorKey, err.Error()).Error("error creating dynamic client, the override statuses are not saved")
+		return
+	}
+	override.SetStatusClient(client)
 }
 
 // Wrapper around klog.LogFilter to prevent klog from logging errors too frequently in different format.
@@ -0,0 +1,44 @@
+package bootstrap
+
+import (
+	"github.com/google/uuid"
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/featuregate"
+	trafficconfig_handler "github.com/intuit/naavik/internal/handler/trafficconfig"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/types/context"
+)
+
+const featureGatesReconcileReason = "feature gates changed"
+
+// startFeatureGatesWatcher watches the feature gates config map, the traffic configs the changed gates apply to are reconciled.
+func startFeatureGatesWatcher(ctx context.Context) {
+	configMapName := options.GetFeatureGatesConfigMap()
+	if len(configMapName) == 0 {
+		ctx.Log.Info("Feature gates are disabled")
+		return
+	}
+	client, err := configLoader.ClientFromPath(options.GetKubeConfigPath())
+	if err != nil {
+		ctx.Log.Fatalf("error creating k8s client for feature gates: %v", err)
+	}
+	go featuregate.Watch(ctx, client, featuregate.WatcherOpts{
+		Namespace:     options.GetSyncNamespace(),
+		ConfigMapName: configMapName,
+		ResyncPeriod:  options.GetCacheRefreshInterval(),
+		OnChange:      reconcileFeatureGates,
+	})
+}
+
+// reconcileFeatureGates reconciles the identities as a reconcile operation, so its progress can be polled through the API.
+// Gates changed before this instance can write need no reconcile of their own, the reconcile of all the traffic configs evaluates the current gates.
+func reconcileFeatureGates(ctx context.Context, identities []string) {
+	if !cache.InformerSync.IsWarmedUp() || leasechecker.IsReadOnly() {
+		return
+	}
+	operationID := uuid.New().String()
+	cache.ReconcileOperations.Start(operationID, cache.ReconcileScope{Reason: featureGatesReconcileReason}, identities)
+	ctx.Log.Str("operationId", operationID).Any("identities", identities).Info("Reconciling the identities of the changed feature gates")
+	go trafficconfig_handler.RunReconcileOperation(ctx, operationID, identities, "")
+}
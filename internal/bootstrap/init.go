@@ -1,6 +1,7 @@
 package bootstrap
 
 import (
+	gocontext "context"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,36 +11,99 @@ import (
 	"github.com/intuit/naavik/cmd/options"
 	"github.com/intuit/naavik/internal/controller"
 	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/types"
 	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/eventhistory"
 	"github.com/intuit/naavik/pkg/logger"
+	"github.com/intuit/naavik/pkg/tracing"
 )
 
-var log = logger.NewLogger()
+const (
+	// stateCheckerStopTimeout is the time shutdown waits for the state checker to release the lease.
+	stateCheckerStopTimeout = 5 * time.Second
+	// tracingShutdownTimeout is the time shutdown waits for the pending spans to be exported.
+	tracingShutdownTimeout = 5 * time.Second
+)
+
+var (
+	log = logger.NewLogger()
+
+	// cancelStateChecker cancels the context of the state checker, set once it is started
+	cancelStateChecker gocontext.CancelFunc = func() {}
+)
 
 func InitNaavik(ctx context.Context) {
 	// Initialize logger
 	log.SetLogLevel(options.GetLogLevel())
 	ctx.Log.Info("Starting Naavik")
 
+	// Initialize tracing, spans are no-op if the exporter is not set
+	err := tracing.Init(ctx.Context, tracing.Opts{
+		ServiceName: types.NaavikName,
+		Exporter:    options.GetTracingExporter(),
+		Endpoint:    options.GetTracingEndpoint(),
+		SampleRatio: options.GetTracingSampleRatio(),
+	})
+	if err != nil {
+		ctx.Log.Fatalf("error initializing tracing: %v", err)
+	}
+
+	err = eventhistory.Init(eventhistory.Opts{
+		Size:      options.GetEventHistorySize(),
+		Retention: options.GetEventHistoryRetention(),
+		FilePath:  options.GetEventHistoryFile(),
+		OnError: func(err error) {
+			ctx.Log.Str(logger.ErrorKey, err.Error()).Error("error persisting event history")
+		},
+	})
+	if err != nil {
+		ctx.Log.Fatalf("error initializing event history: %v", err)
+	}
+
 	// Start profiling if enabled
 	if options.IsProfilingEnabled() {
 		StartProfiler(ctx)
 	}
 
+	registerMetrics()
 	httpServer, tlsServer := StartServer()
 
 	// Initialize state checker
 	startStateChecker(ctx)
 
+	startFeatureGatesWatcher(ctx)
+	startScopeWatcher(ctx)
+	startKillSwitchWatcher(ctx)
+	startQuotaScheduler(ctx)
+	startOverrideExpirer(ctx)
+	startProxyVersionDetector(ctx)
+
 	StartControllers(ctx)
 
+	go waitForCacheWarmUp(ctx)
+	go watchConfigFile(ctx)
+
 	shutdown(ctx, httpServer, tlsServer)
 }
 
 func startStateChecker(ctx context.Context) {
+	subscribeLeaseStateTransitions(ctx)
+
+	// Run the state checker on a cancellable context, so the lease is released on shutdown
+	stateCheckerCtx := ctx
+	stateCheckerCtx.Context, cancelStateChecker = gocontext.WithCancel(ctx.Context)
+
 	// Initialize State Checker
-	leaseChecker := leasechecker.GetStateChecker(ctx, options.GetStateChecker())
-	leasechecker.RunStateCheck(ctx, leaseChecker)
+	leaseChecker := leasechecker.GetStateChecker(stateCheckerCtx, options.GetStateChecker())
+	leasechecker.RunStateCheck(stateCheckerCtx, leaseChecker)
+}
+
+// stopStateChecker stops the state checker and waits for it to release the lease.
+func stopStateChecker(ctx context.Context) {
+	cancelStateChecker()
+	if !leasechecker.WaitForStateCheckers(stateCheckerStopTimeout) {
+		ctx.Log.Warn("Timed out waiting for the state checker to stop")
+	}
 }
 
 func shutdown(ctx context.Context, httpServer *http.Server, tlsServer *http.Server) {
@@ -58,7 +122,20 @@ func shutdown(ctx context.Context, httpServer *http.Server, tlsServer *http.Serv
 		go tlsServer.Shutdown(ctx.Context)
 	}
 
+	// Release the lease before stopping the controllers, so the standby takes over without waiting for the lease to expire
+	stopStateChecker(ctx)
+
 	// close the remote controllers stop channel
 	controller.StopAllControllers()
+
+	// Flush the pending spans, an unreachable collector must not block the shutdown
+	tracingCtx, cancel := gocontext.WithTimeout(ctx.Context, tracingShutdownTimeout)
+	defer cancel()
+	if err := tracing.Shutdown(tracingCtx); err != nil {
+		ctx.Log.Str(logger.ErrorKey, err.Error()).Error("error shutting down tracing")
+	}
+	if err := eventhistory.History.Close(); err != nil {
+		ctx.Log.Str(logger.ErrorKey, err.Error()).Error("error closing event history")
+	}
 	ctx.Log.Int(logger.TimeTakenMSKey, int(time.Since(startTime).Milliseconds())).Info("Graceful shutdown completed")
 }
@@ -0,0 +1,70 @@
+package bootstrap
+
+import (
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	trafficconfig_handler "github.com/intuit/naavik/internal/handler/trafficconfig"
+	"github.com/intuit/naavik/internal/killswitch"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/logger"
+)
+
+// startKillSwitchWatcher watches the kill switch config map, the throttle filters are updated in place when it is engaged or restored.
+func startKillSwitchWatcher(ctx context.Context) {
+	if options.IsRateLimitKillSwitchEngaged() {
+		ctx.Log.Warn("Rate limit kill switch is engaged by flag, the throttle filters are not enforced")
+	}
+	configMapName := options.GetKillSwitchConfigMap()
+	if len(configMapName) == 0 {
+		ctx.Log.Info("Kill switch config map is disabled")
+		return
+	}
+	client, err := configLoader.ClientFromPath(options.GetKubeConfigPath())
+	if err != nil {
+		ctx.Log.Fatalf("error creating k8s client for the kill switch: %v", err)
+	}
+	go runKillSwitchWorker(ctx, applyKillSwitch)
+	go killswitch.Watch(ctx, client, killswitch.WatcherOpts{
+		Namespace:     options.GetSyncNamespace(),
+		ConfigMapName: configMapName,
+		ResyncPeriod:  options.GetCacheRefreshInterval(),
+		OnChange:      notifyKillSwitchChange,
+	})
+}
+
+// killSwitchChanges wakes up the kill switch worker. The changes received while it applies one are coalesced,
+// as the worker applies the kill switch state current when it runs.
+var killSwitchChanges = make(chan struct{}, 1)
+
+// notifyKillSwitchChange hands the kill switch change to the worker, so the config map watcher is not blocked while the clusters are updated.
+func notifyKillSwitchChange(_ context.Context, _ bool) {
+	select {
+	case killSwitchChanges <- struct{}{}:
+	default:
+	}
+}
+
+// runKillSwitchWorker applies the kill switch changes one at a time until the context is cancelled.
+func runKillSwitchWorker(ctx context.Context, apply func(ctx context.Context)) {
+	for {
+		select {
+		case <-ctx.Context.Done():
+			return
+		case <-killSwitchChanges:
+			apply(ctx)
+		}
+	}
+}
+
+// applyKillSwitch updates the throttle filters to the current kill switch state. It is a no-op until this instance can write,
+// the throttle filters are then rendered with the current kill switch state by the reconcile of all the traffic configs.
+func applyKillSwitch(ctx context.Context) {
+	if !cache.InformerSync.IsWarmedUp() || leasechecker.IsReadOnly() {
+		return
+	}
+	ctx.Log.Bool("engaged", killswitch.IsEngaged()).Info("Applying the rate limit kill switch to the throttle filters")
+	if err := trafficconfig_handler.ApplyKillSwitch(ctx); err != nil {
+		ctx.Log.Str(logger.ErrorKey, err.Error()).Error("error applying the rate limit kill switch")
+	}
+}
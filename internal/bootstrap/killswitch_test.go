@@ -0,0 +1,45 @@
+package bootstrap
+
+import (
+	gocontext "context"
+	"sync/atomic"
+
+	"github.com/intuit/naavik/internal/types/context"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test kill switch worker", func() {
+	It("should apply the kill switch changes off the config map watcher and coalesce the pending ones", func() {
+		ctx := context.NewContextWithLogger()
+		cctx, cancel := gocontext.WithCancel(gocontext.Background())
+		ctx.Context = cctx
+		var applied atomic.Int32
+		applying := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			runKillSwitchWorker(ctx, func(context.Context) {
+				applied.Add(1)
+				applying <- struct{}{}
+				<-release
+			})
+		}()
+
+		notifyKillSwitchChange(ctx, true)
+		Eventually(applying).Should(Receive())
+		// The watcher is not blocked while the first change is applied
+		notifyKillSwitchChange(ctx, false)
+		notifyKillSwitchChange(ctx, true)
+		notifyKillSwitchChange(ctx, false)
+		release <- struct{}{}
+		Eventually(applying).Should(Receive())
+		release <- struct{}{}
+		Consistently(applying).ShouldNot(Receive())
+		Expect(applied.Load()).To(BeEquivalentTo(2))
+
+		cancel()
+		Eventually(done).Should(BeClosed())
+	})
+})
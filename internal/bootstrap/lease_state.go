@@ -0,0 +1,37 @@
+package bootstrap
+
+import (
+	"github.com/intuit/naavik/internal/cache"
+	trafficconfig_handler "github.com/intuit/naavik/internal/handler/trafficconfig"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/override"
+	"github.com/intuit/naavik/internal/types/context"
+)
+
+// subscribeLeaseStateTransitions reconciles all the traffic configs when the instance switches from read only to read write.
+// Handlers only update the caches in read only mode, so the changes received in the meantime are re-applied after failover,
+// after deleting the resources of the clusters and assets that left the scope in the meantime
+// and restoring the override statuses saved by the previous leader.
+func subscribeLeaseStateTransitions(ctx context.Context) {
+	leasechecker.Subscribe(func(_ context.Context, previous leasechecker.LeaseState, current leasechecker.LeaseState) {
+		if previous.ReadOnly == current.ReadOnly {
+			return
+		}
+		// Only the leader saves the override statuses, they are restored from the previous leader before saving them again
+		override.SuspendSaves()
+		if current.ReadOnly {
+			return
+		}
+		// All the traffic configs are reconciled once the cache is warmed up
+		if !cache.InformerSync.IsWarmedUp() {
+			ctx.Log.Info("Switched to read write mode before cache warm up, skipping reconcile of all traffic configs")
+			return
+		}
+		ctx.Log.Info("Switched to read write mode, reconciling all traffic configs")
+		go func() {
+			recoverPendingScopeCleanup(ctx)
+			restoreOverrideStatuses(ctx)
+			trafficconfig_handler.NewTrafficConfigHandler().ReconcileAllTrafficConfigs(ctx)
+		}()
+	})
+}
@@ -0,0 +1,44 @@
+package bootstrap
+
+import (
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/killswitch"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/pkg/metrics"
+)
+
+// registerMetrics registers the gauges that are read from the caches and the lease state at scrape time.
+func registerMetrics() {
+	metrics.RegisterCacheSizeFunc("trafficconfig", cache.TrafficConfigCache.GetTotalTrafficConfigs)
+	metrics.RegisterCacheSizeFunc("dependency", cache.IdentityDependency.GetTotalDependencies)
+	metrics.RegisterCacheSizeFunc("remotecluster", func() int {
+		return len(cache.RemoteCluster.ListClusters())
+	})
+	metrics.RegisterCacheSizeFunc("propagation", cache.Propagation.GetTotalTracked)
+	metrics.RegisterGaugeFunc("propagation_unconverged_revisions", "Number of traffic config revisions not converged to all the clusters.", func() float64 {
+		return float64(len(cache.Propagation.ListUnconverged()))
+	})
+
+	metrics.RegisterGaugeFunc("lease_read_only", "1 if this instance is read only, 0 if it is read write.", func() float64 {
+		return boolToFloat(leasechecker.IsReadOnly())
+	})
+	metrics.RegisterGaugeFunc("lease_state_initialized", "1 once the state checker has initialized the lease state.", func() float64 {
+		return boolToFloat(leasechecker.IsStateInitialized())
+	})
+	metrics.RegisterGaugeFunc("cache_warmed_up", "1 once all the informers have synced and the caches are warmed up.", func() float64 {
+		return boolToFloat(cache.InformerSync.IsWarmedUp())
+	})
+	metrics.RegisterGaugeFunc("informers_unsynced", "Number of informers not synced, the cache is warmed up without them after the sync_period.", func() float64 {
+		return float64(len(cache.InformerSync.ListUnsynced()))
+	})
+	metrics.RegisterGaugeFunc("rate_limit_kill_switch_engaged", "1 if the rate limit kill switch is engaged and the throttle filters are not enforced.", func() float64 {
+		return boolToFloat(killswitch.IsEngaged())
+	})
+}
+
+func boolToFloat(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,93 @@
+package bootstrap
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	trafficconfig_handler "github.com/intuit/naavik/internal/handler/trafficconfig"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/override"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/logger"
+	"github.com/intuit/naavik/pkg/utils"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+const overrideReconcileReason = "override expired"
+
+var (
+	errOverrideNotWarmedUp = errors.New("cache is not warmed up yet")
+	errOverrideReadOnly    = errors.New("instance is in read only mode")
+)
+
+// startOverrideExpirer reverts the traffic config overrides at their expiry.
+func startOverrideExpirer(ctx context.Context) {
+	expirer := &override.Expirer{
+		OnExpiry:   revertOverride,
+		OnReverted: saveRevertedOverrides,
+	}
+	go expirer.Run(ctx)
+}
+
+// restoreOverrideStatuses tracks the overrides saved in the statuses of the cached traffic configs by the previous leader,
+// so their expiry is still reverted and their status still listed after a restart or a failover.
+// The statuses are saved again once restored, even if they failed to load, so the tracked overrides are not left unsaved.
+func restoreOverrideStatuses(ctx context.Context) {
+	defer override.ResumeSaves()
+	loaded, err := override.LoadStatuses(ctx.Context, options.GetTrafficConfigNamespace())
+	if err != nil {
+		ctx.Log.Str(logger.ErrorKey, err.Error()).Warn("failed to load some of the saved override statuses")
+	}
+	for _, identity := range cache.TrafficConfigCache.ListIdentities() {
+		entry := cache.TrafficConfigCache.GetTrafficConfigEntry(identity)
+		if entry == nil {
+			continue
+		}
+		for _, tc := range entry.EnvTrafficConfig {
+			statuses, found := loaded[k8stypes.NamespacedName{Namespace: tc.Namespace, Name: tc.Name}]
+			if !found {
+				continue
+			}
+			tcUtil := utils.TrafficConfigUtil(tc)
+			override.Restore(tcUtil.GetIdentity(), tcUtil.GetEnv(), statuses)
+		}
+	}
+}
+
+// revertOverride reconciles the traffic config of the identity and env without the expired override as a reconcile operation.
+// It returns an error unless the reconcile operation completed, so the override stays active and the expirer retries it.
+// The overrides are only tracked by the reconciles of this instance, so none expire during cache warm up.
+func revertOverride(ctx context.Context, identity string, env string) error {
+	if !cache.InformerSync.IsWarmedUp() {
+		return errOverrideNotWarmedUp
+	}
+	if leasechecker.IsReadOnly() {
+		return errOverrideReadOnly
+	}
+	operationID := uuid.New().String()
+	identities := []string{identity}
+	cache.ReconcileOperations.Start(operationID, cache.ReconcileScope{Identity: identity, Env: env, Reason: overrideReconcileReason}, identities)
+	ctx.Log.Str("operationId", operationID).Any("identities", identities).Str(logger.EnvKey, env).Info("Reverting the expired override")
+	trafficconfig_handler.RunReconcileOperation(ctx, operationID, identities, env)
+	operation := cache.ReconcileOperations.Get(operationID)
+	if operation == nil {
+		return fmt.Errorf("reconcile operation %s not found", operationID)
+	}
+	if operation.Status != cache.ReconcileOperationCompleted {
+		if len(operation.Error) > 0 {
+			return fmt.Errorf("reconcile operation %s failed: %s", operationID, operation.Error)
+		}
+		return fmt.Errorf("reconcile operation %s failed", operationID)
+	}
+	return nil
+}
+
+// saveRevertedOverrides saves the statuses of the overrides reverted by the expirer on the cached traffic config of the identity and env.
+func saveRevertedOverrides(ctx context.Context, identity string, env string) {
+	if err := override.SaveStatuses(ctx.Context, cache.TrafficConfigCache.Get(identity, env), identity, env); err != nil {
+		ctx.Log.Str(logger.WorkloadIdentifierKey, identity).Str(logger.EnvKey, env).Str(logger.ErrorKey, err.Error()).Warn("failed to save the override statuses")
+	}
+}
@@ -0,0 +1,120 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	resourcebuilder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/override"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+var _ = Describe("Test override revert", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		leasechecker.ResetState()
+		ctx = context.NewContextWithLogger()
+	})
+
+	AfterEach(func() {
+		cache.ResetAllCaches()
+		leasechecker.ResetState()
+	})
+
+	It("should not revert the override before the cache is warmed up", func() {
+		leasechecker.RunStateCheck(ctx, leasechecker.GetStateChecker(ctx, types.StateCheckerNone))
+		Expect(revertOverride(ctx, "app.overridden", "qal")).To(MatchError(errOverrideNotWarmedUp))
+		Expect(cache.ReconcileOperations.List()).To(BeEmpty())
+	})
+
+	It("should not revert the override in read only mode", func() {
+		cache.InformerSync.SetWarmedUp()
+		Expect(revertOverride(ctx, "app.overridden", "qal")).To(MatchError(errOverrideReadOnly))
+		Expect(cache.ReconcileOperations.List()).To(BeEmpty())
+	})
+
+	It("should revert the override once its reconcile operation completed", func() {
+		cache.InformerSync.SetWarmedUp()
+		leasechecker.RunStateCheck(ctx, leasechecker.GetStateChecker(ctx, types.StateCheckerNone))
+		Expect(revertOverride(ctx, "app.overridden", "qal")).To(Succeed())
+		operations := cache.ReconcileOperations.List()
+		Expect(operations).To(HaveLen(1))
+		Expect(operations[0].Status).To(Equal(cache.ReconcileOperationCompleted))
+		Expect(operations[0].Scope.Reason).To(Equal(overrideReconcileReason))
+	})
+})
+
+var _ = Describe("Test override status restore", func() {
+	const savedOverrides = `
+- name: incident-bump
+  expiresAt: 2099-01-01T00:00:00Z
+  quotas:
+  - quotaGroup: Total Throttling Plan
+    quota: Total
+    maxAmount: 500
+`
+	var (
+		ctx         context.Context
+		appliedTime = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	)
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		leasechecker.ResetState()
+		override.Reset()
+		ctx = context.NewContextWithLogger()
+
+		tc := resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.overridden", "qal", map[string]string{override.OverridesKey: savedOverrides})
+		tc.Name, tc.Namespace = "app.overridden", options.GetTrafficConfigNamespace()
+		cache.TrafficConfigCache.AddTrafficConfigToCache(tc)
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(tc)
+		Expect(err).NotTo(HaveOccurred())
+		obj := &unstructured.Unstructured{Object: content}
+		obj.SetAPIVersion(admiralv1.SchemeGroupVersion.String())
+		obj.SetKind("TrafficConfig")
+		Expect(unstructured.SetNestedSlice(obj.Object, []interface{}{map[string]interface{}{
+			"identity":    "app.overridden",
+			"env":         "qal",
+			"name":        "incident-bump",
+			"appliedTime": appliedTime.Format(time.RFC3339),
+			"expiresAt":   "2099-01-01T00:00:00Z",
+		}}, "status", override.StatusField)).To(Succeed())
+		override.SetStatusClient(dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{admiralv1.SchemeGroupVersion.WithResource("trafficconfigs"): "TrafficConfigList"}, obj))
+	})
+
+	AfterEach(func() {
+		cache.ResetAllCaches()
+		leasechecker.ResetState()
+		override.SetStatusClient(nil)
+		override.SuspendSaves()
+		override.Reset()
+	})
+
+	It("should restore the saved override statuses on the switch to read write before the full reconcile", func() {
+		cache.InformerSync.SetWarmedUp()
+		subscribeLeaseStateTransitions(ctx)
+		leasechecker.RunStateCheck(ctx, leasechecker.GetStateChecker(ctx, types.StateCheckerNone))
+		Eventually(func() []override.Status {
+			return override.Get("app.overridden", "qal")
+		}).Should(HaveLen(1))
+		Eventually(func() *cache.PropagationStatus {
+			return cache.Propagation.Get("app.overridden", "qal")
+		}).ShouldNot(BeNil())
+		Expect(override.Get("app.overridden", "qal")[0].AppliedTime).To(Equal(appliedTime))
+	})
+})
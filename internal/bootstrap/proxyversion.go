@@ -0,0 +1,30 @@
+package bootstrap
+
+import (
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	trafficconfig_handler "github.com/intuit/naavik/internal/handler/trafficconfig"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/proxyversion"
+	"github.com/intuit/naavik/internal/types/context"
+)
+
+// startProxyVersionDetector regenerates the throttle filters of the clusters whose proxy versions changed, when the detection is enabled.
+func startProxyVersionDetector(ctx context.Context) {
+	if !options.IsProxyVersionDetectionEnabled() {
+		return
+	}
+	detector := &proxyversion.Detector{
+		Interval: options.GetProxyVersionDetectionInterval(),
+		OnChange: regenerateProxyVersions,
+	}
+	go detector.Run(ctx)
+}
+
+// regenerateProxyVersions skips the changes during cache warm up, the reconcile of all the traffic configs uses the detected versions.
+func regenerateProxyVersions(ctx context.Context, clusterIDs []string) {
+	if !cache.InformerSync.IsWarmedUp() || leasechecker.IsReadOnly() {
+		return
+	}
+	trafficconfig_handler.RegenerateClusterThrottleFilters(ctx, clusterIDs)
+}
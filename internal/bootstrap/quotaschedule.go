@@ -0,0 +1,39 @@
+package bootstrap
+
+import (
+	"errors"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	trafficconfig_handler "github.com/intuit/naavik/internal/handler/trafficconfig"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/quotaschedule"
+	"github.com/intuit/naavik/internal/types/context"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+)
+
+// startQuotaScheduler regenerates the throttle filters of the traffic configs with quota schedules at the window boundaries.
+func startQuotaScheduler(ctx context.Context) {
+	scheduler := &quotaschedule.Scheduler{
+		Interval:   options.GetQuotaScheduleInterval(),
+		OnBoundary: regenerateScheduledQuotas,
+	}
+	go scheduler.Run(ctx)
+}
+
+var (
+	errQuotaScheduleNotWarmedUp = errors.New("cache is not warmed up yet")
+	errQuotaScheduleReadOnly    = errors.New("instance is in read only mode")
+)
+
+// regenerateScheduledQuotas regenerates the throttle filters of the traffic config at a window boundary.
+// It returns an error while the instance cannot write, so the scheduler retries the boundary until the filters are written.
+func regenerateScheduledQuotas(ctx context.Context, tc *admiralv1.TrafficConfig) error {
+	if !cache.InformerSync.IsWarmedUp() {
+		return errQuotaScheduleNotWarmedUp
+	}
+	if leasechecker.IsReadOnly() {
+		return errQuotaScheduleReadOnly
+	}
+	return trafficconfig_handler.RegenerateThrottleFilters(ctx, tc)
+}
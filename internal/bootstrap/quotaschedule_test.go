@@ -0,0 +1,37 @@
+package bootstrap
+
+import (
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	resourcebuilder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test scheduled quotas regeneration", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		leasechecker.ResetState()
+		ctx = context.NewContextWithLogger()
+	})
+
+	AfterEach(func() {
+		cache.ResetAllCaches()
+		leasechecker.ResetState()
+	})
+
+	It("should report the boundaries not handled until the instance can write", func() {
+		tc := resourcebuilder.GetFakeTrafficConfig("app.scheduled", "qal", "1", "namespace")
+		Expect(regenerateScheduledQuotas(ctx, tc)).To(MatchError(errQuotaScheduleNotWarmedUp))
+		cache.InformerSync.SetWarmedUp()
+		Expect(regenerateScheduledQuotas(ctx, tc)).To(MatchError(errQuotaScheduleReadOnly))
+		leasechecker.RunStateCheck(ctx, leasechecker.GetStateChecker(ctx, types.StateCheckerNone))
+		Expect(regenerateScheduledQuotas(ctx, tc)).To(Succeed())
+	})
+})
@@ -0,0 +1,124 @@
+package bootstrap
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	trafficconfig_handler "github.com/intuit/naavik/internal/handler/trafficconfig"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/scope"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/logger"
+)
+
+const scopeReconcileReason = "scope changed"
+
+// startScopeWatcher watches the scope config map, the resources out of scope are deleted and the ones joining the scope reconciled.
+func startScopeWatcher(ctx context.Context) {
+	configMapName := options.GetScopeConfigMap()
+	if len(configMapName) == 0 {
+		ctx.Log.Info("Scope config map is disabled")
+		return
+	}
+	client, err := configLoader.ClientFromPath(options.GetKubeConfigPath())
+	if err != nil {
+		ctx.Log.Fatalf("error creating k8s client for the scope config map: %v", err)
+	}
+	go scope.Watch(ctx, client, scope.WatcherOpts{
+		Namespace:     options.GetSyncNamespace(),
+		ConfigMapName: configMapName,
+		ResyncPeriod:  options.GetCacheRefreshInterval(),
+		OnChange:      applyScopeChange,
+	})
+}
+
+// scopeCleanupLock serializes the deferred cleanups of the scope, run off the scope watcher.
+var scopeCleanupLock sync.Mutex
+
+// applyScopeChange deletes the resources of the clusters and assets that left the scope,
+// and reconciles the identities of the ones that joined as a reconcile operation.
+// The deletes run in the background, so the scope watcher is not blocked on the remote clusters.
+// During cache warm up or in read only mode, the deletes are deferred and the joined identities are left to the reconcile of all the traffic configs.
+func applyScopeChange(ctx context.Context, change scope.Change) {
+	if !recordScopeCleanup(ctx, change) {
+		return
+	}
+	go runPendingScopeCleanup(ctx)
+
+	identities := scope.AffectedIdentities(change)
+	if len(identities) == 0 {
+		return
+	}
+	operationID := uuid.New().String()
+	cache.ReconcileOperations.Start(operationID, cache.ReconcileScope{Reason: scopeReconcileReason}, identities)
+	ctx.Log.Str("operationId", operationID).Any("identities", identities).Info("Reconciling the identities joining the scope")
+	go trafficconfig_handler.RunReconcileOperation(ctx, operationID, identities, "")
+}
+
+// recordScopeCleanup records the clusters and assets that left the scope as pending, it returns false if this instance cannot write yet.
+// The deletes are then deferred to recoverPendingScopeCleanup, as the reconcile of all the traffic configs never deletes out of scope resources.
+func recordScopeCleanup(ctx context.Context, change scope.Change) bool {
+	left := len(change.LeftClusters) > 0 || len(change.LeftAssets) > 0
+	if left {
+		scope.RecordPendingCleanup(change)
+	}
+	if !canWriteScope() {
+		if left {
+			ctx.Log.Any("leftClusters", change.LeftClusters).Any("leftAssets", change.LeftAssets).Info("Cannot write yet, deferring the cleanup of the scope")
+		}
+		return false
+	}
+	return true
+}
+
+// canWriteScope returns false during cache warm up and in read only mode.
+func canWriteScope() bool {
+	return cache.InformerSync.IsWarmedUp() && !leasechecker.IsReadOnly()
+}
+
+// recoverPendingScopeCleanup records as pending the clusters and assets out of scope that still hold resources written by naavik,
+// and deletes their resources. It runs before the reconcile of all the traffic configs after cache warm up and failover,
+// as the cleanups deferred by another instance or a previous run are lost.
+func recoverPendingScopeCleanup(ctx context.Context) {
+	if !canWriteScope() {
+		return
+	}
+	leftovers, err := trafficconfig_handler.FindScopeLeftovers(ctx)
+	if err != nil {
+		ctx.Log.Str(logger.ErrorKey, err.Error()).Error("error finding the resources out of scope")
+	}
+	scope.RecordPendingCleanup(leftovers)
+	runPendingScopeCleanup(ctx)
+}
+
+// runPendingScopeCleanup deletes the resources of the clusters and assets that left the scope and are still out of scope.
+// The cleanup stays pending while this instance cannot write, and the clusters and assets whose deletes failed are pending again,
+// so the next scope event or resync retries them.
+func runPendingScopeCleanup(ctx context.Context) {
+	scopeCleanupLock.Lock()
+	defer scopeCleanupLock.Unlock()
+	if !canWriteScope() {
+		return
+	}
+	change := scope.TakePendingCleanup()
+	if len(change.LeftClusters) == 0 && len(change.LeftAssets) == 0 {
+		return
+	}
+	ctx.Log.Any("leftClusters", change.LeftClusters).Any("leftAssets", change.LeftAssets).Info("Running the deferred cleanup of the scope")
+	failed := scope.Change{}
+	for _, clusterID := range change.LeftClusters {
+		if err := trafficconfig_handler.CleanupCluster(ctx, clusterID); err != nil {
+			ctx.Log.Str(logger.ClusterKey, clusterID).Str(logger.ErrorKey, err.Error()).Error("error deleting the resources of the cluster that left the scope, retrying on the next scope event")
+			failed.LeftClusters = append(failed.LeftClusters, clusterID)
+		}
+	}
+	for _, asset := range change.LeftAssets {
+		if err := trafficconfig_handler.CleanupAsset(ctx, asset); err != nil {
+			ctx.Log.Str(logger.WorkloadIdentifierKey, asset).Str(logger.ErrorKey, err.Error()).Error("error deleting the resources of the asset that left the scope, retrying on the next scope event")
+			failed.LeftAssets = append(failed.LeftAssets, asset)
+		}
+	}
+	scope.RecordPendingCleanup(failed)
+}
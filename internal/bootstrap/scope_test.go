@@ -0,0 +1,148 @@
+package bootstrap
+
+import (
+	"errors"
+	"sort"
+	"sync/atomic"
+
+	fakeargoclientset "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/fake/builder"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/scope"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/internal/types/remotecluster"
+	fakeadmiralclientset "github.com/istio-ecosystem/admiral-api/pkg/client/clientset/versioned/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	fakeistioclientset "istio.io/client-go/pkg/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Test scope cleanup", func() {
+	var (
+		ctx  context.Context
+		west remotecluster.RemoteCluster
+		east remotecluster.RemoteCluster
+	)
+
+	// addNaavikResources adds an envoy filter and a virtual service written by naavik for the identity to the cluster.
+	addNaavikResources := func(rc remotecluster.RemoteCluster, identity string) {
+		resourceLabels := map[string]string{types.CreatedByKey: types.NaavikName, types.CreatedForKey: identity}
+		_, err := rc.IstioClient().CreateEnvoyFilter(ctx, &v1alpha3.EnvoyFilter{ObjectMeta: metav1.ObjectMeta{
+			Name: identity + "-filter", Namespace: types.NamespaceIstioSystem, Labels: resourceLabels, Annotations: map[string]string{},
+		}}, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = rc.IstioClient().CreateVirtualService(ctx, &v1alpha3.VirtualService{ObjectMeta: metav1.ObjectMeta{
+			Name: identity + "-vs", Namespace: options.GetSyncNamespace(), Labels: resourceLabels, Annotations: map[string]string{},
+		}}, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	// listResourceNames returns the sorted names of the envoy filters and virtual services of the cluster.
+	listResourceNames := func(rc remotecluster.RemoteCluster) []string {
+		names := []string{}
+		filterList, err := rc.IstioClient().ListEnvoyFilters(ctx, types.NamespaceIstioSystem, metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		for _, envoyFilter := range filterList.Items {
+			names = append(names, envoyFilter.Name)
+		}
+		vsList, err := rc.IstioClient().ListVirtualServices(ctx, options.GetSyncNamespace(), metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		for _, vs := range vsList.Items {
+			names = append(names, vs.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	setLists := func(lists scope.Lists) {
+		Expect(scope.SetState(scope.State{ConfigMapFound: true, Lists: lists})).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		scope.Reset()
+		cache.InformerSync.SetWarmedUp()
+		ctx = context.NewContextWithLogger()
+		west = builder.BuildRemoteCluster("west-1")
+		east = builder.BuildRemoteCluster("east-1")
+		cache.RemoteCluster.AddCluster(west)
+		cache.RemoteCluster.AddCluster(east)
+		addNaavikResources(west, "foo")
+		addNaavikResources(east, "foo")
+		addNaavikResources(east, "bar")
+	})
+
+	AfterEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		scope.Reset()
+		leasechecker.ResetState()
+	})
+
+	It("should delete the resources of the clusters and assets that left the scope in the background", func() {
+		leasechecker.RunStateCheck(ctx, leasechecker.GetStateChecker(ctx, types.StateCheckerNone))
+		setLists(scope.Lists{DeniedClusters: []string{"west-1"}, DeniedAssets: []string{"bar"}})
+
+		applyScopeChange(ctx, scope.Change{LeftClusters: []string{"west-1"}, LeftAssets: []string{"bar"}})
+		Eventually(func() []string { return listResourceNames(west) }).Should(BeEmpty())
+		Eventually(func() []string { return listResourceNames(east) }).Should(Equal([]string{"foo-filter", "foo-vs"}))
+		Expect(scope.TakePendingCleanup()).To(Equal(scope.Change{LeftClusters: []string{}, LeftAssets: []string{}}))
+	})
+
+	It("should defer the cleanup in read only mode", func() {
+		Expect(leasechecker.IsReadOnly()).To(BeTrue())
+		setLists(scope.Lists{DeniedClusters: []string{"west-1"}})
+
+		applyScopeChange(ctx, scope.Change{LeftClusters: []string{"west-1"}})
+		Consistently(func() []string { return listResourceNames(west) }).Should(Equal([]string{"foo-filter", "foo-vs"}))
+		Expect(scope.TakePendingCleanup()).To(Equal(scope.Change{LeftClusters: []string{"west-1"}, LeftAssets: []string{}}))
+	})
+
+	It("should retry the cleanup of a cluster whose deletes failed", func() {
+		istioClient := fakeistioclientset.NewSimpleClientset()
+		south := remotecluster.CreateRemoteCluster("south-1", "south-1", "south-1", "south-1", nil, k8sfake.NewSimpleClientset(),
+			istioClient, fakeargoclientset.NewSimpleClientset(), fakeadmiralclientset.NewSimpleClientset())
+		cache.RemoteCluster.AddCluster(south)
+		addNaavikResources(south, "foo")
+		var deletesFail atomic.Bool
+		deletesFail.Store(true)
+		istioClient.PrependReactor("delete", "envoyfilters", func(k8stesting.Action) (bool, runtime.Object, error) {
+			if deletesFail.Load() {
+				return true, nil, errors.New("delete failed")
+			}
+			return false, nil, nil
+		})
+		leasechecker.RunStateCheck(ctx, leasechecker.GetStateChecker(ctx, types.StateCheckerNone))
+		setLists(scope.Lists{DeniedClusters: []string{"west-1", "south-1"}})
+
+		scope.RecordPendingCleanup(scope.Change{LeftClusters: []string{"west-1", "south-1"}})
+		runPendingScopeCleanup(ctx)
+		Expect(listResourceNames(west)).To(BeEmpty())
+		Expect(listResourceNames(south)).To(Equal([]string{"foo-filter"}))
+		Expect(scope.HasPendingCleanup()).To(BeTrue())
+
+		deletesFail.Store(false)
+		runPendingScopeCleanup(ctx)
+		Expect(listResourceNames(south)).To(BeEmpty())
+		Expect(scope.HasPendingCleanup()).To(BeFalse())
+	})
+
+	It("should find the resources out of scope left by another instance on takeover", func() {
+		setLists(scope.Lists{DeniedClusters: []string{"west-1"}, DeniedAssets: []string{"bar"}})
+		leasechecker.RunStateCheck(ctx, leasechecker.GetStateChecker(ctx, types.StateCheckerNone))
+
+		// Nothing was recorded as pending by this instance
+		recoverPendingScopeCleanup(ctx)
+		Expect(listResourceNames(west)).To(BeEmpty())
+		Expect(listResourceNames(east)).To(Equal([]string{"foo-filter", "foo-vs"}))
+	})
+})
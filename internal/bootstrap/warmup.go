@@ -0,0 +1,53 @@
+package bootstrap
+
+import (
+	gocontext "context"
+	"errors"
+	"time"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	trafficconfig_handler "github.com/intuit/naavik/internal/handler/trafficconfig"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/logger"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const warmUpPollInterval = time.Second
+
+// waitForCacheWarmUp waits for all the local and remote informers to sync and process their initial list.
+// Handlers skip processing until the caches are warmed up, so all the cached traffic configs are
+// reconciled once warmed up to apply the changes received during startup.
+// The wait is bounded by the cache refresh interval, so an informer that never syncs, e.g. of an
+// unreachable remote cluster, does not keep every handler skipping its writes.
+func waitForCacheWarmUp(ctx context.Context) {
+	warmUpCache(ctx, options.GetCacheRefreshInterval())
+}
+
+func warmUpCache(ctx context.Context, timeout time.Duration) {
+	startTime := time.Now()
+	pollCtx, cancel := gocontext.WithTimeout(ctx.Context, timeout)
+	defer cancel()
+	err := wait.PollUntilContextCancel(pollCtx, warmUpPollInterval, true, func(_ gocontext.Context) (bool, error) {
+		synced := cache.InformerSync.HasSynced()
+		if !synced {
+			ctx.Log.Any("informers", cache.InformerSync.ListUnsynced()).Debug("Waiting for informers to sync")
+		}
+		return synced, nil
+	})
+	switch {
+	case err == nil:
+		ctx.Log.Int(logger.TimeTakenMSKey, int(time.Since(startTime).Milliseconds())).Info("Cache warmed up")
+	case errors.Is(pollCtx.Err(), gocontext.DeadlineExceeded) && ctx.Context.Err() == nil:
+		ctx.Log.Any("informers", cache.InformerSync.ListUnsynced()).Int(logger.TimeTakenMSKey, int(time.Since(startTime).Milliseconds())).
+			Warn("Timed out waiting for informers to sync, warming up the cache without them")
+	default:
+		ctx.Log.Str(logger.ErrorKey, err.Error()).Error("Stopped waiting for cache warm up")
+		return
+	}
+	cache.InformerSync.SetWarmedUp()
+
+	recoverPendingScopeCleanup(ctx)
+	restoreOverrideStatuses(ctx)
+	trafficconfig_handler.NewTrafficConfigHandler().ReconcileAllTrafficConfigs(ctx)
+}
@@ -0,0 +1,55 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/types/context"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test cache warm up", func() {
+	var (
+		ctx         context.Context
+		logMessages []string
+	)
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		ctx = context.NewContextWithLogger()
+		logMessages = []string{}
+		ctx.Log = ctx.Log.Hook(func(_ string, msg string) {
+			logMessages = append(logMessages, msg)
+		})
+	})
+
+	AfterEach(func() {
+		cache.ResetAllCaches()
+		leasechecker.ResetState()
+	})
+
+	It("should warm up the cache once all the informers have synced", func() {
+		cache.InformerSync.Register("local", func() bool { return true })
+		cache.InformerSync.Register("remote", func() bool { return true })
+
+		warmUpCache(ctx, time.Minute)
+		Expect(cache.InformerSync.IsWarmedUp()).To(BeTrue())
+		Expect(logMessages).To(ContainElement("Cache warmed up"))
+		Expect(logMessages).To(ContainElement("Reconciling all traffic configs completed"))
+	})
+
+	It("should warm up the cache without the informers that never sync after the timeout", func() {
+		cache.InformerSync.Register("local", func() bool { return true })
+		cache.InformerSync.Register("unreachable", func() bool { return false })
+
+		warmUpCache(ctx, 100*time.Millisecond)
+		Expect(cache.InformerSync.IsWarmedUp()).To(BeTrue())
+		Expect(cache.InformerSync.ListUnsynced()).To(Equal([]string{"unreachable"}))
+		Expect(logMessages).To(ContainElement("Timed out waiting for informers to sync, warming up the cache without them"))
+		Expect(logMessages).To(ContainElement("Reconciling all traffic configs completed"))
+	})
+})
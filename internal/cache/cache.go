@@ -19,5 +19,9 @@ func ResetAllCaches() {
 	IdentityDependency.Reset()
 	RemoteCluster.Reset()
 	TrafficConfigCache.Reset()
+	InformerSync.Reset()
+	Propagation.Reset()
+	ReconcileOperations.Reset()
+	OutboundFilters.Reset()
 	fake_k8s_utils.NewFakeConfigLoader().ResetFakeClients()
 }
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type InformerSyncCacheInterface interface {
+	BaseCache
+	// Register registers the sync function of a controller informer
+	Register(name string, hasSynced func() bool)
+	// DeRegister removes the controller informer, used when a controller is stopped
+	DeRegister(name string)
+	// HasSynced returns true when all the registered informers have synced and processed their initial list
+	HasSynced() bool
+	// ListUnsynced returns the names of the informers that have not synced yet
+	ListUnsynced() []string
+	// SetWarmedUp marks the cache as warmed up, it is set once all the informers have synced at startup
+	SetWarmedUp()
+	// IsWarmedUp returns true once the caches are warmed up
+	IsWarmedUp() bool
+}
+
+var InformerSync = newInformerSyncCache()
+
+type informerSyncCache struct {
+	mutex     sync.RWMutex
+	informers map[string]func() bool // map[controllerName]hasSynced
+	warmedUp  atomic.Bool
+}
+
+func newInformerSyncCache() InformerSyncCacheInterface {
+	return &informerSyncCache{
+		informers: make(map[string]func() bool),
+	}
+}
+
+func (isc *informerSyncCache) Register(name string, hasSynced func() bool) {
+	name = strings.ToLower(name)
+	isc.mutex.Lock()
+	defer isc.mutex.Unlock()
+	isc.informers[name] = hasSynced
+}
+
+func (isc *informerSyncCache) DeRegister(name string) {
+	name = strings.ToLower(name)
+	isc.mutex.Lock()
+	defer isc.mutex.Unlock()
+	delete(isc.informers, name)
+}
+
+func (isc *informerSyncCache) HasSynced() bool {
+	isc.mutex.RLock()
+	defer isc.mutex.RUnlock()
+	if len(isc.informers) == 0 {
+		return false
+	}
+	for _, hasSynced := range isc.informers {
+		if !hasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+func (isc *informerSyncCache) ListUnsynced() []string {
+	isc.mutex.RLock()
+	defer isc.mutex.RUnlock()
+	unsynced := []string{}
+	for name, hasSynced := range isc.informers {
+		if !hasSynced() {
+			unsynced = append(unsynced, name)
+		}
+	}
+	sort.Strings(unsynced)
+	return unsynced
+}
+
+func (isc *informerSyncCache) SetWarmedUp() {
+	isc.warmedUp.Store(true)
+}
+
+func (isc *informerSyncCache) IsWarmedUp() bool {
+	return isc.warmedUp.Load()
+}
+
+func (isc *informerSyncCache) Reset() {
+	isc.mutex.Lock()
+	defer isc.mutex.Unlock()
+	isc.informers = make(map[string]func() bool)
+	isc.warmedUp.Store(false)
+}
@@ -0,0 +1,60 @@
+package cache_test
+
+import (
+	"sync/atomic"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test Informer Sync Cache", Label("informer_sync_cache_test"), func() {
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.InformerSync.Reset()
+	})
+
+	AfterEach(func() {
+		cache.InformerSync.Reset()
+	})
+
+	When("no informers are registered", func() {
+		It("should not be synced", func() {
+			Expect(cache.InformerSync.HasSynced()).To(BeFalse())
+			Expect(cache.InformerSync.IsWarmedUp()).To(BeFalse())
+		})
+	})
+
+	When("informers are registered", func() {
+		It("should be synced only when all the informers are synced", func() {
+			synced := atomic.Bool{}
+			cache.InformerSync.Register("controller1", func() bool { return true })
+			cache.InformerSync.Register("Controller2", synced.Load)
+			Expect(cache.InformerSync.HasSynced()).To(BeFalse())
+			Expect(cache.InformerSync.ListUnsynced()).To(Equal([]string{"controller2"}))
+
+			synced.Store(true)
+			Expect(cache.InformerSync.HasSynced()).To(BeTrue())
+			Expect(cache.InformerSync.ListUnsynced()).To(BeEmpty())
+		})
+
+		It("should ignore the deregistered informers", func() {
+			cache.InformerSync.Register("controller1", func() bool { return true })
+			cache.InformerSync.Register("controller2", func() bool { return false })
+			Expect(cache.InformerSync.HasSynced()).To(BeFalse())
+
+			cache.InformerSync.DeRegister("Controller2")
+			Expect(cache.InformerSync.HasSynced()).To(BeTrue())
+		})
+	})
+
+	When("cache is marked as warmed up", func() {
+		It("should stay warmed up until reset", func() {
+			cache.InformerSync.SetWarmedUp()
+			Expect(cache.InformerSync.IsWarmedUp()).To(BeTrue())
+			cache.InformerSync.Reset()
+			Expect(cache.InformerSync.IsWarmedUp()).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+type OutboundFilterCacheInterface interface {
+	BaseCache
+	// SetCluster records whether the cluster holds outbound throttle filters of the traffic config of the identity and env.
+	SetCluster(identity string, env string, clusterID string, present bool)
+	// GetClusters returns the sorted clusters holding outbound throttle filters of the traffic config of the identity and env.
+	GetClusters(identity string, env string) []string
+}
+
+// OutboundFilters indexes the clusters holding the outbound throttle filters of the traffic configs, so the filters of the associated apps
+// removed from a traffic config are found without listing the envoy filters of every cluster.
+var OutboundFilters = newOutboundFilterCache()
+
+type outboundFilterCache struct {
+	clusters map[string]map[string]map[string]bool // map[Identity]map[env]map[cluster]
+	mutex    sync.RWMutex
+}
+
+func newOutboundFilterCache() OutboundFilterCacheInterface {
+	return &outboundFilterCache{clusters: make(map[string]map[string]map[string]bool)}
+}
+
+func (c *outboundFilterCache) SetCluster(identity string, env string, clusterID string, present bool) {
+	identity = strings.ToLower(identity)
+	env = strings.ToLower(env)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !present {
+		delete(c.clusters[identity][env], clusterID)
+		if len(c.clusters[identity][env]) == 0 {
+			delete(c.clusters[identity], env)
+		}
+		if len(c.clusters[identity]) == 0 {
+			delete(c.clusters, identity)
+		}
+		return
+	}
+	if c.clusters[identity] == nil {
+		c.clusters[identity] = make(map[string]map[string]bool)
+	}
+	if c.clusters[identity][env] == nil {
+		c.clusters[identity][env] = make(map[string]bool)
+	}
+	c.clusters[identity][env][clusterID] = true
+}
+
+func (c *outboundFilterCache) GetClusters(identity string, env string) []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	envClusters := c.clusters[strings.ToLower(identity)][strings.ToLower(env)]
+	clusters := make([]string, 0, len(envClusters))
+	for clusterID := range envClusters {
+		clusters = append(clusters, clusterID)
+	}
+	sort.Strings(clusters)
+	return clusters
+}
+
+func (c *outboundFilterCache) Reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.clusters = make(map[string]map[string]map[string]bool)
+}
@@ -0,0 +1,243 @@
+package cache
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intuit/naavik/pkg/metrics"
+)
+
+type PropagationCacheInterface interface {
+	BaseCache
+	// Received starts tracking the propagation of a traffic config revision, a new revision replaces the tracked one.
+	// Tracking of an already tracked revision is reopened, the received time of the first event is kept.
+	Received(identity string, env string, revision string, transactionID string, receivedAt time.Time)
+	// Expect records that a resource of the kind has to be written to the cluster for the revision to converge.
+	Expect(identity string, env string, revision string, cluster string, kind string)
+	// Completed records the outcome of a resource write to the cluster.
+	Completed(identity string, env string, revision string, cluster string, kind string, err error)
+	// Seal marks the end of a reconcile, the revision converges once sealed and all the expected writes succeeded.
+	Seal(identity string, env string, revision string)
+	// Delete stops tracking the propagation of the traffic config of the identity and env, e.g. once deleted.
+	Delete(identity string, env string)
+	Get(identity string, env string) *PropagationStatus
+	ListUnconverged() []*PropagationStatus
+	GetTotalTracked() int
+}
+
+// PropagationWrite is the outcome of a resource write to a cluster for a revision.
+type PropagationWrite struct {
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	LatencyMS   int64      `json:"latencyMs,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+func (pw *PropagationWrite) isDone() bool {
+	return pw.CompletedAt != nil && len(pw.Error) == 0
+}
+
+// PropagationStatus is the propagation state of a traffic config revision to the dependent clusters.
+type PropagationStatus struct {
+	Identity      string                                  `json:"identity"`
+	Env           string                                  `json:"env"`
+	Revision      string                                  `json:"revision"`
+	TransactionID string                                  `json:"transactionId"`
+	ReceivedAt    time.Time                               `json:"receivedAt"`
+	Sealed        bool                                    `json:"sealed"`
+	Converged     bool                                    `json:"converged"`
+	ConvergedAt   *time.Time                              `json:"convergedAt,omitempty"`
+	LatencyMS     int64                                   `json:"latencyMs,omitempty"`
+	Clusters      map[string]map[string]*PropagationWrite `json:"clusters"` // map[cluster]map[kind]*PropagationWrite
+}
+
+func (ps *PropagationStatus) copy() *PropagationStatus {
+	copyPs := *ps
+	copyPs.Clusters = make(map[string]map[string]*PropagationWrite, len(ps.Clusters))
+	for cluster, writes := range ps.Clusters {
+		copyPs.Clusters[cluster] = make(map[string]*PropagationWrite, len(writes))
+		for kind, write := range writes {
+			copyWrite := *write
+			copyPs.Clusters[cluster][kind] = &copyWrite
+		}
+	}
+	return &copyPs
+}
+
+// evaluate marks the revision converged when it is sealed and all the expected writes succeeded.
+func (ps *PropagationStatus) evaluate(now time.Time) {
+	if ps.Converged || !ps.Sealed {
+		return
+	}
+	convergedAt := ps.ReceivedAt
+	for _, writes := range ps.Clusters {
+		for _, write := range writes {
+			if !write.isDone() {
+				return
+			}
+			if write.CompletedAt.After(convergedAt) {
+				convergedAt = *write.CompletedAt
+			}
+		}
+	}
+	if len(ps.Clusters) == 0 {
+		// Nothing to propagate, no latency is observed
+		ps.Converged = true
+		ps.ConvergedAt = &now
+		return
+	}
+	ps.Converged = true
+	ps.ConvergedAt = &convergedAt
+	ps.LatencyMS = convergedAt.Sub(ps.ReceivedAt).Milliseconds()
+	metrics.PropagationConvergenceLatency.Observe(convergedAt.Sub(ps.ReceivedAt).Seconds())
+}
+
+var Propagation = newPropagationCache()
+
+type propagationCache struct {
+	cache map[string]*PropagationStatus // map[identity/env]*PropagationStatus
+	mutex sync.Mutex
+}
+
+func newPropagationCache() PropagationCacheInterface {
+	return &propagationCache{
+		cache: make(map[string]*PropagationStatus),
+	}
+}
+
+func propagationKey(identity string, env string) string {
+	return strings.ToLower(identity) + "/" + strings.ToLower(env)
+}
+
+func (pc *propagationCache) Received(identity string, env string, revision string, transactionID string, receivedAt time.Time) {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+	key := propagationKey(identity, env)
+	existing, found := pc.cache[key]
+	if found && existing.Revision == revision {
+		existing.Sealed = false
+		if receivedAt.Before(existing.ReceivedAt) {
+			existing.ReceivedAt = receivedAt
+		}
+		return
+	}
+	pc.cache[key] = &PropagationStatus{
+		Identity:      strings.ToLower(identity),
+		Env:           strings.ToLower(env),
+		Revision:      revision,
+		TransactionID: transactionID,
+		ReceivedAt:    receivedAt,
+		Clusters:      make(map[string]map[string]*PropagationWrite),
+	}
+}
+
+// getLocked returns the tracked status of the revision, nil when another revision is tracked.
+func (pc *propagationCache) getLocked(identity string, env string, revision string) *PropagationStatus {
+	status, found := pc.cache[propagationKey(identity, env)]
+	if !found || status.Revision != revision {
+		return nil
+	}
+	return status
+}
+
+func (pc *propagationCache) Expect(identity string, env string, revision string, cluster string, kind string) {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+	status := pc.getLocked(identity, env, revision)
+	if status == nil || status.Converged {
+		return
+	}
+	if _, found := status.Clusters[cluster]; !found {
+		status.Clusters[cluster] = make(map[string]*PropagationWrite)
+	}
+	if _, found := status.Clusters[cluster][kind]; !found {
+		status.Clusters[cluster][kind] = &PropagationWrite{}
+	}
+}
+
+func (pc *propagationCache) Completed(identity string, env string, revision string, cluster string, kind string, err error) {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+	status := pc.getLocked(identity, env, revision)
+	if status == nil || status.Converged {
+		return
+	}
+	if _, found := status.Clusters[cluster]; !found {
+		status.Clusters[cluster] = make(map[string]*PropagationWrite)
+	}
+	write, found := status.Clusters[cluster][kind]
+	if !found {
+		write = &PropagationWrite{}
+		status.Clusters[cluster][kind] = write
+	}
+	if err != nil {
+		write.Error = err.Error()
+		return
+	}
+	if write.isDone() {
+		// Keep the first successful write of the revision
+		return
+	}
+	now := time.Now()
+	write.CompletedAt = &now
+	write.Error = ""
+	write.LatencyMS = now.Sub(status.ReceivedAt).Milliseconds()
+	metrics.PropagationClusterLatency.WithLabelValues(cluster, kind).Observe(now.Sub(status.ReceivedAt).Seconds())
+	status.evaluate(now)
+}
+
+func (pc *propagationCache) Seal(identity string, env string, revision string) {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+	status := pc.getLocked(identity, env, revision)
+	if status == nil {
+		return
+	}
+	status.Sealed = true
+	status.evaluate(time.Now())
+}
+
+func (pc *propagationCache) Delete(identity string, env string) {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+	delete(pc.cache, propagationKey(identity, env))
+}
+
+func (pc *propagationCache) Get(identity string, env string) *PropagationStatus {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+	status, found := pc.cache[propagationKey(identity, env)]
+	if !found {
+		return nil
+	}
+	return status.copy()
+}
+
+// ListUnconverged returns the tracked revisions not converged yet, oldest first.
+func (pc *propagationCache) ListUnconverged() []*PropagationStatus {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+	list := []*PropagationStatus{}
+	for _, status := range pc.cache {
+		if !status.Converged {
+			list = append(list, status.copy())
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ReceivedAt.Before(list[j].ReceivedAt)
+	})
+	return list
+}
+
+func (pc *propagationCache) GetTotalTracked() int {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+	return len(pc.cache)
+}
+
+func (pc *propagationCache) Reset() {
+	pc.mutex.Lock()
+	defer pc.mutex.Unlock()
+	pc.cache = make(map[string]*PropagationStatus)
+}
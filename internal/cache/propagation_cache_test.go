@@ -0,0 +1,102 @@
+package cache_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/pkg/metrics"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test Propagation Cache", Label("propagation_cache_test"), func() {
+	BeforeEach(func() {
+		cache.Propagation.Reset()
+	})
+
+	AfterEach(func() {
+		cache.Propagation.Reset()
+	})
+
+	When("all the expected writes of a sealed revision succeed", func() {
+		It("should converge the revision", func() {
+			receivedAt := time.Now().Add(-time.Second)
+			cache.Propagation.Received("Identity-1", "qal", "1", "tx-1", receivedAt)
+			cache.Propagation.Expect("identity-1", "qal", "1", "cluster-1", metrics.KindEnvoyFilter)
+			cache.Propagation.Expect("identity-1", "qal", "1", "cluster-2", metrics.KindVirtualService)
+			cache.Propagation.Completed("identity-1", "qal", "1", "cluster-1", metrics.KindEnvoyFilter, nil)
+			Expect(cache.Propagation.ListUnconverged()).To(HaveLen(1))
+
+			cache.Propagation.Completed("identity-1", "qal", "1", "cluster-2", metrics.KindVirtualService, nil)
+			// Not converged until the reconcile is complete
+			Expect(cache.Propagation.ListUnconverged()).To(HaveLen(1))
+
+			cache.Propagation.Seal("identity-1", "qal", "1")
+			Expect(cache.Propagation.ListUnconverged()).To(BeEmpty())
+			status := cache.Propagation.Get("identity-1", "qal")
+			Expect(status).ToNot(BeNil())
+			Expect(status.Converged).To(BeTrue())
+			Expect(status.TransactionID).To(Equal("tx-1"))
+			Expect(status.ConvergedAt).ToNot(BeNil())
+			Expect(*status.ConvergedAt).To(Equal(*status.Clusters["cluster-2"][metrics.KindVirtualService].CompletedAt))
+			Expect(status.LatencyMS).To(BeNumerically(">=", 1000))
+		})
+	})
+
+	When("a write fails", func() {
+		It("should not converge until the write is retried successfully", func() {
+			cache.Propagation.Received("identity-1", "qal", "1", "tx-1", time.Now())
+			cache.Propagation.Expect("identity-1", "qal", "1", "cluster-1", metrics.KindVirtualService)
+			cache.Propagation.Completed("identity-1", "qal", "1", "cluster-1", metrics.KindVirtualService, errors.New("conflict"))
+			cache.Propagation.Seal("identity-1", "qal", "1")
+
+			unconverged := cache.Propagation.ListUnconverged()
+			Expect(unconverged).To(HaveLen(1))
+			Expect(unconverged[0].Clusters["cluster-1"][metrics.KindVirtualService].Error).To(Equal("conflict"))
+
+			cache.Propagation.Received("identity-1", "qal", "1", "tx-1", time.Now())
+			cache.Propagation.Completed("identity-1", "qal", "1", "cluster-1", metrics.KindVirtualService, nil)
+			cache.Propagation.Seal("identity-1", "qal", "1")
+			Expect(cache.Propagation.ListUnconverged()).To(BeEmpty())
+			Expect(cache.Propagation.Get("identity-1", "qal").Clusters["cluster-1"][metrics.KindVirtualService].Error).To(BeEmpty())
+		})
+	})
+
+	When("a new revision is received", func() {
+		It("should replace the tracked revision and ignore the writes of the old one", func() {
+			cache.Propagation.Received("identity-1", "qal", "1", "tx-1", time.Now())
+			cache.Propagation.Expect("identity-1", "qal", "1", "cluster-1", metrics.KindVirtualService)
+			cache.Propagation.Received("identity-1", "qal", "2", "tx-2", time.Now())
+			cache.Propagation.Completed("identity-1", "qal", "1", "cluster-1", metrics.KindVirtualService, nil)
+
+			status := cache.Propagation.Get("identity-1", "qal")
+			Expect(status.Revision).To(Equal("2"))
+			Expect(status.Clusters).To(BeEmpty())
+			Expect(cache.Propagation.GetTotalTracked()).To(Equal(1))
+		})
+	})
+
+	When("there is nothing to propagate", func() {
+		It("should converge once sealed", func() {
+			cache.Propagation.Received("identity-1", "qal", "1", "tx-1", time.Now())
+			cache.Propagation.Seal("identity-1", "qal", "1")
+			Expect(cache.Propagation.Get("identity-1", "qal").Converged).To(BeTrue())
+			Expect(cache.Propagation.Get("identity-notpresent", "qal")).To(BeNil())
+		})
+	})
+
+	When("the traffic config is deleted", func() {
+		It("should stop tracking its propagation", func() {
+			cache.Propagation.Received("Identity-1", "qal", "1", "tx-1", time.Now())
+			cache.Propagation.Expect("identity-1", "qal", "1", "cluster-1", metrics.KindVirtualService)
+			cache.Propagation.Received("identity-1", "prd", "1", "tx-2", time.Now())
+
+			cache.Propagation.Delete("identity-1", "QAL")
+			cache.Propagation.Completed("identity-1", "qal", "1", "cluster-1", metrics.KindVirtualService, nil)
+			Expect(cache.Propagation.Get("identity-1", "qal")).To(BeNil())
+			Expect(cache.Propagation.GetTotalTracked()).To(Equal(1))
+			Expect(cache.Propagation.ListUnconverged()).To(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,212 @@
+package cache
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	ReconcileOperationRunning   = "Running"
+	ReconcileOperationCompleted = "Completed"
+	ReconcileOperationFailed    = "Failed"
+
+	// maxReconcileOperations is the number of operations kept, the oldest completed operations are dropped first.
+	maxReconcileOperations = 100
+	// maxClusterErrors is the number of errors kept per cluster.
+	maxClusterErrors = 10
+)
+
+type ReconcileOperationCacheInterface interface {
+	BaseCache
+	// Start records a new running operation for the identities.
+	Start(id string, scope ReconcileScope, identities []string) *ReconcileOperation
+	// RecordWrite records the outcome of a resource write to a cluster.
+	RecordWrite(id string, cluster string, err error)
+	// IdentityDone records that the identity has been reconciled.
+	IdentityDone(id string, identity string)
+	// Complete records the end of the operation, the operation failed if any write failed or if err is set.
+	Complete(id string, err error)
+	Get(id string) *ReconcileOperation
+	List() []*ReconcileOperation
+}
+
+// ReconcileScope is what an on-demand reconcile was requested for.
+type ReconcileScope struct {
+	Identity string `json:"identity,omitempty"`
+	Env      string `json:"env,omitempty"`
+	Cluster  string `json:"cluster,omitempty"`
+	// Reason is set when the reconcile is not requested through the API, e.g. a feature gates change.
+	Reason string `json:"reason,omitempty"`
+}
+
+// ReconcileClusterResult is the outcome of the resource writes to a cluster.
+type ReconcileClusterResult struct {
+	Writes int      `json:"writes"`
+	Failed int      `json:"failed"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// ReconcileOperation is the progress of an on-demand reconcile.
+type ReconcileOperation struct {
+	ID                 string                             `json:"id"`
+	Scope              ReconcileScope                     `json:"scope"`
+	Status             string                             `json:"status"`
+	Error              string                             `json:"error,omitempty"`
+	CreatedAt          time.Time                          `json:"createdAt"`
+	CompletedAt        *time.Time                         `json:"completedAt,omitempty"`
+	Identities         []string                           `json:"identities"`
+	IdentitiesDone     int                                `json:"identitiesDone"`
+	Clusters           map[string]*ReconcileClusterResult `json:"clusters"` // map[cluster]*ReconcileClusterResult
+	identitiesDoneList map[string]bool
+}
+
+func (ro *ReconcileOperation) copy() *ReconcileOperation {
+	copyRo := *ro
+	copyRo.Identities = append([]string{}, ro.Identities...)
+	copyRo.Clusters = make(map[string]*ReconcileClusterResult, len(ro.Clusters))
+	for cluster, result := range ro.Clusters {
+		copyResult := *result
+		copyResult.Errors = append([]string{}, result.Errors...)
+		copyRo.Clusters[cluster] = &copyResult
+	}
+	copyRo.identitiesDoneList = nil
+	return &copyRo
+}
+
+var ReconcileOperations = newReconcileOperationCache()
+
+type reconcileOperationCache struct {
+	cache map[string]*ReconcileOperation // map[operationID]*ReconcileOperation
+	mutex sync.Mutex
+}
+
+func newReconcileOperationCache() ReconcileOperationCacheInterface {
+	return &reconcileOperationCache{
+		cache: make(map[string]*ReconcileOperation),
+	}
+}
+
+func (roc *reconcileOperationCache) Start(id string, scope ReconcileScope, identities []string) *ReconcileOperation {
+	roc.mutex.Lock()
+	defer roc.mutex.Unlock()
+	roc.evictLocked()
+	operation := &ReconcileOperation{
+		ID:                 id,
+		Scope:              scope,
+		Status:             ReconcileOperationRunning,
+		CreatedAt:          time.Now(),
+		Identities:         append([]string{}, identities...),
+		Clusters:           make(map[string]*ReconcileClusterResult),
+		identitiesDoneList: make(map[string]bool),
+	}
+	roc.cache[id] = operation
+	return operation.copy()
+}
+
+// evictLocked drops the oldest completed operations once the cache is full, the caller must hold the lock.
+func (roc *reconcileOperationCache) evictLocked() {
+	if len(roc.cache) < maxReconcileOperations {
+		return
+	}
+	completed := []*ReconcileOperation{}
+	for _, operation := range roc.cache {
+		if operation.CompletedAt != nil {
+			completed = append(completed, operation)
+		}
+	}
+	sort.Slice(completed, func(i, j int) bool {
+		return completed[i].CreatedAt.Before(completed[j].CreatedAt)
+	})
+	for _, operation := range completed {
+		if len(roc.cache) < maxReconcileOperations {
+			return
+		}
+		delete(roc.cache, operation.ID)
+	}
+}
+
+func (roc *reconcileOperationCache) RecordWrite(id string, cluster string, err error) {
+	roc.mutex.Lock()
+	defer roc.mutex.Unlock()
+	operation, found := roc.cache[id]
+	if !found {
+		return
+	}
+	result, found := operation.Clusters[cluster]
+	if !found {
+		result = &ReconcileClusterResult{}
+		operation.Clusters[cluster] = result
+	}
+	result.Writes++
+	if err != nil {
+		result.Failed++
+		if len(result.Errors) < maxClusterErrors {
+			result.Errors = append(result.Errors, err.Error())
+		}
+	}
+}
+
+func (roc *reconcileOperationCache) IdentityDone(id string, identity string) {
+	roc.mutex.Lock()
+	defer roc.mutex.Unlock()
+	operation, found := roc.cache[id]
+	if !found || operation.identitiesDoneList[identity] {
+		return
+	}
+	operation.identitiesDoneList[identity] = true
+	operation.IdentitiesDone++
+}
+
+func (roc *reconcileOperationCache) Complete(id string, err error) {
+	roc.mutex.Lock()
+	defer roc.mutex.Unlock()
+	operation, found := roc.cache[id]
+	if !found {
+		return
+	}
+	now := time.Now()
+	operation.CompletedAt = &now
+	operation.Status = ReconcileOperationCompleted
+	if err != nil {
+		operation.Status = ReconcileOperationFailed
+		operation.Error = err.Error()
+		return
+	}
+	for _, result := range operation.Clusters {
+		if result.Failed > 0 {
+			operation.Status = ReconcileOperationFailed
+			return
+		}
+	}
+}
+
+func (roc *reconcileOperationCache) Get(id string) *ReconcileOperation {
+	roc.mutex.Lock()
+	defer roc.mutex.Unlock()
+	operation, found := roc.cache[id]
+	if !found {
+		return nil
+	}
+	return operation.copy()
+}
+
+// List returns the operations, most recent first.
+func (roc *reconcileOperationCache) List() []*ReconcileOperation {
+	roc.mutex.Lock()
+	defer roc.mutex.Unlock()
+	list := make([]*ReconcileOperation, 0, len(roc.cache))
+	for _, operation := range roc.cache {
+		list = append(list, operation.copy())
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list
+}
+
+func (roc *reconcileOperationCache) Reset() {
+	roc.mutex.Lock()
+	defer roc.mutex.Unlock()
+	roc.cache = make(map[string]*ReconcileOperation)
+}
@@ -0,0 +1,67 @@
+package cache_test
+
+import (
+	"errors"
+
+	"github.com/intuit/naavik/internal/cache"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test Reconcile Operation Cache", Label("reconcile_operation_cache_test"), func() {
+	BeforeEach(func() {
+		cache.ReconcileOperations.Reset()
+	})
+
+	AfterEach(func() {
+		cache.ReconcileOperations.Reset()
+	})
+
+	When("an operation is started", func() {
+		It("should track the progress and the per-cluster results", func() {
+			operation := cache.ReconcileOperations.Start("op-1", cache.ReconcileScope{Cluster: "cluster-1"}, []string{"identity-1", "identity-2"})
+			Expect(operation.Status).To(Equal(cache.ReconcileOperationRunning))
+
+			cache.ReconcileOperations.RecordWrite("op-1", "cluster-1", nil)
+			cache.ReconcileOperations.RecordWrite("op-1", "cluster-2", nil)
+			cache.ReconcileOperations.IdentityDone("op-1", "identity-1")
+			cache.ReconcileOperations.IdentityDone("op-1", "identity-1")
+
+			operation = cache.ReconcileOperations.Get("op-1")
+			Expect(operation.IdentitiesDone).To(Equal(1))
+			Expect(operation.Clusters).To(HaveLen(2))
+			Expect(operation.CompletedAt).To(BeNil())
+
+			cache.ReconcileOperations.IdentityDone("op-1", "identity-2")
+			cache.ReconcileOperations.Complete("op-1", nil)
+			operation = cache.ReconcileOperations.Get("op-1")
+			Expect(operation.Status).To(Equal(cache.ReconcileOperationCompleted))
+			Expect(operation.IdentitiesDone).To(Equal(2))
+			Expect(operation.CompletedAt).ToNot(BeNil())
+		})
+
+		It("should fail the operation when a write fails", func() {
+			cache.ReconcileOperations.Start("op-1", cache.ReconcileScope{Identity: "identity-1"}, []string{"identity-1"})
+			cache.ReconcileOperations.RecordWrite("op-1", "cluster-1", nil)
+			cache.ReconcileOperations.RecordWrite("op-1", "cluster-1", errors.New("conflict"))
+			cache.ReconcileOperations.Complete("op-1", nil)
+
+			operation := cache.ReconcileOperations.Get("op-1")
+			Expect(operation.Status).To(Equal(cache.ReconcileOperationFailed))
+			Expect(operation.Clusters["cluster-1"].Writes).To(Equal(2))
+			Expect(operation.Clusters["cluster-1"].Failed).To(Equal(1))
+			Expect(operation.Clusters["cluster-1"].Errors).To(Equal([]string{"conflict"}))
+		})
+
+		It("should drop the oldest completed operations once full", func() {
+			for i := 0; i < 150; i++ {
+				id := string(rune('a'+i/26)) + string(rune('a'+i%26))
+				cache.ReconcileOperations.Start(id, cache.ReconcileScope{}, nil)
+				cache.ReconcileOperations.Complete(id, nil)
+			}
+			Expect(cache.ReconcileOperations.List()).To(HaveLen(100))
+			Expect(cache.ReconcileOperations.Get("ft")).ToNot(BeNil())
+			Expect(cache.ReconcileOperations.Get("notpresent")).To(BeNil())
+		})
+	})
+})
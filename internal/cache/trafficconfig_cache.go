@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"sort"
 	"strings"
 	"sync"
 
@@ -19,19 +20,24 @@ type TrafficConfigCacheInterface interface {
 	AddTrafficConfigToCache(trafficConfig *admiralv1.TrafficConfig)
 	DeleteTrafficConfigFromCache(trafficConfig *admiralv1.TrafficConfig)
 	GetTotalTrafficConfigs() int
+	ListIdentities() []string
+	GetIdentitiesForAssociatedApp(associatedApp string) []string
 }
 
 var TrafficConfigCache = newTrafficConfigCache()
 
 var trafficConfigCache = &trafficConfigCacheItem{
-	cache: make(map[string]*TrafficConfigEntry),
+	cache:          make(map[string]*TrafficConfigEntry),
+	associatedApps: make(map[string]map[string]map[string]bool),
 }
 
 type trafficConfigCacheReceiver struct{}
 
 type trafficConfigCacheItem struct {
 	cache map[string]*TrafficConfigEntry // map[Identity]*TrafficConfigEntry
-	mutex sync.Mutex
+	// associatedApps indexes the traffic configs by the associated apps of their app quota groups
+	associatedApps map[string]map[string]map[string]bool // map[associatedApp]map[Identity]map[env]
+	mutex          sync.Mutex
 }
 
 type TrafficConfigEntry struct {
@@ -110,6 +116,7 @@ func (tcc *trafficConfigCacheReceiver) AddTrafficConfigToCache(trafficConfig *ad
 		tce.EnvTrafficConfig[env] = trafficConfig
 		tce.EnvServiceRoutesConfig[env] = getRoutesFromEdgeService(tcutil, trafficConfig)
 		trafficConfigCache.cache[tce.Identity] = tce
+		indexAssociatedApps(identity, env, trafficConfig)
 	}
 }
 
@@ -133,6 +140,7 @@ func (tcc *trafficConfigCacheReceiver) DeleteTrafficConfigFromCache(trafficConfi
 				if tce.EnvServiceRoutesConfig[env] != nil {
 					delete(tce.EnvServiceRoutesConfig, env)
 				}
+				indexAssociatedApps(identity, env, nil)
 			}
 		}
 	}
@@ -148,6 +156,61 @@ func (tcc *trafficConfigCacheReceiver) GetTotalTrafficConfigs() int {
 	return counter
 }
 
+// ListIdentities returns the identities that have at least one traffic config in the cache.
+func (tcc *trafficConfigCacheReceiver) ListIdentities() []string {
+	defer trafficConfigCache.mutex.Unlock()
+	trafficConfigCache.mutex.Lock()
+	identities := make([]string, 0, len(trafficConfigCache.cache))
+	for identity, tce := range trafficConfigCache.cache {
+		if len(tce.EnvTrafficConfig) > 0 {
+			identities = append(identities, identity)
+		}
+	}
+	return identities
+}
+
+// GetIdentitiesForAssociatedApp returns the sorted identities with a traffic config listing the associated app in its app quota groups.
+func (tcc *trafficConfigCacheReceiver) GetIdentitiesForAssociatedApp(associatedApp string) []string {
+	defer trafficConfigCache.mutex.Unlock()
+	trafficConfigCache.mutex.Lock()
+	identityEnvs := trafficConfigCache.associatedApps[strings.ToLower(associatedApp)]
+	identities := make([]string, 0, len(identityEnvs))
+	for identity := range identityEnvs {
+		identities = append(identities, identity)
+	}
+	sort.Strings(identities)
+	return identities
+}
+
+// indexAssociatedApps replaces the associated apps of the traffic config of the identity and env in the index, the traffic config is nil once deleted.
+// The cache mutex must be held.
+func indexAssociatedApps(identity string, env string, trafficConfig *admiralv1.TrafficConfig) {
+	for associatedApp, identityEnvs := range trafficConfigCache.associatedApps {
+		delete(identityEnvs[identity], env)
+		if len(identityEnvs[identity]) == 0 {
+			delete(identityEnvs, identity)
+		}
+		if len(identityEnvs) == 0 {
+			delete(trafficConfigCache.associatedApps, associatedApp)
+		}
+	}
+	if trafficConfig == nil || trafficConfig.Spec.QuotaGroup == nil {
+		return
+	}
+	for _, aqg := range trafficConfig.Spec.QuotaGroup.AppQuotaGroups {
+		for _, associatedApp := range aqg.AssociatedApps {
+			associatedApp = strings.ToLower(associatedApp)
+			if trafficConfigCache.associatedApps[associatedApp] == nil {
+				trafficConfigCache.associatedApps[associatedApp] = make(map[string]map[string]bool)
+			}
+			if trafficConfigCache.associatedApps[associatedApp][identity] == nil {
+				trafficConfigCache.associatedApps[associatedApp][identity] = make(map[string]bool)
+			}
+			trafficConfigCache.associatedApps[associatedApp][identity][env] = true
+		}
+	}
+}
+
 func getRoutesFromEdgeService(tcutil utils.TrafficConfigInterface, tc *admiralv1.TrafficConfig) *trafficconfig.ServiceRouteConfig {
 	src := &trafficconfig.ServiceRouteConfig{WorkloadEnvRevision: map[string]string{}}
 	routes := []*admiralv1.Route{}
@@ -175,4 +238,5 @@ func (trafficConfigCacheReceiver) Reset() {
 	defer trafficConfigCache.mutex.Unlock()
 	trafficConfigCache.mutex.Lock()
 	trafficConfigCache.cache = make(map[string]*TrafficConfigEntry)
+	trafficConfigCache.associatedApps = make(map[string]map[string]map[string]bool)
 }
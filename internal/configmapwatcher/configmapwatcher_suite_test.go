@@ -0,0 +1,13 @@
+package configmapwatcher
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestConfigMapWatcher(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "configmapwatcher_test")
+}
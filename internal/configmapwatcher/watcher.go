@@ -0,0 +1,120 @@
+package configmapwatcher
+
+import (
+	"sync"
+	"time"
+
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	k8scache "k8s.io/client-go/tools/cache"
+)
+
+// Opts configures the watcher of a config map whose data is parsed into a T.
+type Opts[T any] struct {
+	// Name names the watcher in the logs, e.g. "feature gates".
+	Name          string
+	Namespace     string
+	ConfigMapName string
+	ResyncPeriod  time.Duration
+	// Parse parses the data of the config map.
+	Parse func(data map[string]string) (T, error)
+	// OnChange is called with each version of the config map received, one at a time.
+	OnChange func(ctx context.Context, event Event[T])
+	// NotifyNotFound calls OnChange with a not found event once synced if the config map does not exist,
+	// no event is received otherwise until it is created.
+	NotifyNotFound bool
+}
+
+// Event is a version of the config map.
+type Event[T any] struct {
+	// Found is false once the config map is deleted.
+	Found           bool
+	ResourceVersion string
+	// Value is the parsed data of the config map, the zero value if not found or invalid.
+	Value T
+	// Err is set if the data of the config map is invalid.
+	Err error
+}
+
+// NewEvent returns the event of the config map parsed with parse, nil means the config map was deleted.
+func NewEvent[T any](cm *corev1.ConfigMap, parse func(data map[string]string) (T, error)) Event[T] {
+	event := Event[T]{}
+	if cm == nil {
+		return event
+	}
+	event.Found = true
+	event.ResourceVersion = cm.ResourceVersion
+	event.Value, event.Err = parse(cm.Data)
+	return event
+}
+
+type watcher[T any] struct {
+	opts Opts[T]
+	// mutex serializes the evaluation of config map events
+	mutex sync.Mutex
+}
+
+// Watch watches the config map until the context is cancelled.
+func Watch[T any](ctx context.Context, client kubernetes.Interface, opts Opts[T]) {
+	w := &watcher[T]{opts: opts}
+	log := ctx.Log.Str(logger.NameKey, opts.ConfigMapName).Str(logger.NamespaceKey, opts.Namespace)
+
+	factory := informers.NewSharedInformerFactoryWithOptions(client, opts.ResyncPeriod,
+		informers.WithNamespace(opts.Namespace),
+		informers.WithTweakListOptions(func(listOpts *metav1.ListOptions) {
+			listOpts.FieldSelector = fields.OneTermEqualSelector(metav1.ObjectNameField, opts.ConfigMapName).String()
+		}))
+	informer := factory.Core().V1().ConfigMaps().Informer()
+	_, err := informer.AddEventHandler(k8scache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			w.evaluate(ctx, obj)
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			w.evaluate(ctx, newObj)
+		},
+		DeleteFunc: func(_ interface{}) {
+			w.evaluate(ctx, nil)
+		},
+	})
+	if err != nil {
+		log.Str(logger.ErrorKey, err.Error()).Errorf("error initializing %s watcher", opts.Name)
+		return
+	}
+
+	log.Infof("Starting %s watcher", opts.Name)
+	factory.Start(ctx.Context.Done())
+	if opts.NotifyNotFound && k8scache.WaitForCacheSync(ctx.Context.Done(), informer.HasSynced) {
+		w.evaluateIfNotFound(ctx, informer.GetStore())
+	}
+	<-ctx.Context.Done()
+	factory.Shutdown()
+	log.Infof("%s watcher stopped", opts.Name)
+}
+
+// evaluate parses the config map and calls OnChange, nil means the config map was deleted.
+func (w *watcher[T]) evaluate(ctx context.Context, obj interface{}) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.evaluateLocked(ctx, obj)
+}
+
+// evaluateIfNotFound calls OnChange with a not found event if the synced store has no config map.
+func (w *watcher[T]) evaluateIfNotFound(ctx context.Context, store k8scache.Store) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if len(store.ListKeys()) == 0 {
+		w.evaluateLocked(ctx, nil)
+	}
+}
+
+func (w *watcher[T]) evaluateLocked(ctx context.Context, obj interface{}) {
+	cm, _ := obj.(*corev1.ConfigMap)
+	if w.opts.OnChange != nil {
+		w.opts.OnChange(ctx, NewEvent(cm, w.opts.Parse))
+	}
+}
@@ -0,0 +1,99 @@
+package configmapwatcher
+
+import (
+	gocontext "context"
+	"fmt"
+	"strconv"
+
+	"github.com/intuit/naavik/internal/types/context"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// parseLimit parses the limit key of the config map data.
+func parseLimit(data map[string]string) (int, error) {
+	limit, err := strconv.Atoi(data["limit"])
+	if err != nil {
+		return 0, fmt.Errorf("invalid limit: %w", err)
+	}
+	return limit, nil
+}
+
+var _ = Describe("Test config map watcher", func() {
+	buildConfigMap := func(limit string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "naavik-limit", Namespace: "admiral-sync", ResourceVersion: "1"},
+			Data:       map[string]string{"limit": limit},
+		}
+	}
+
+	It("should parse the config map of the event", func() {
+		event := NewEvent(buildConfigMap("10"), parseLimit)
+		Expect(event).To(Equal(Event[int]{Found: true, ResourceVersion: "1", Value: 10}))
+		event = NewEvent(buildConfigMap("ten"), parseLimit)
+		Expect(event.Found).To(BeTrue())
+		Expect(event.Err).To(MatchError(ContainSubstring("invalid limit")))
+		Expect(NewEvent(nil, parseLimit)).To(Equal(Event[int]{}))
+	})
+
+	It("should call OnChange with each version of the config map until the context is cancelled", func() {
+		client := fake.NewSimpleClientset()
+		events := make(chan Event[int], 10)
+		ctx := context.NewContextWithLogger()
+		cctx, cancel := gocontext.WithCancel(gocontext.Background())
+		ctx.Context = cctx
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			Watch(ctx, client, Opts[int]{
+				Name:          "limit",
+				Namespace:     "admiral-sync",
+				ConfigMapName: "naavik-limit",
+				Parse:         parseLimit,
+				OnChange: func(_ context.Context, event Event[int]) {
+					events <- event
+				},
+			})
+		}()
+
+		configMaps := client.CoreV1().ConfigMaps("admiral-sync")
+		_, err := configMaps.Create(gocontext.Background(), buildConfigMap("10"), metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(events).Should(Receive(Equal(Event[int]{Found: true, ResourceVersion: "1", Value: 10})))
+
+		_, err = configMaps.Update(gocontext.Background(), buildConfigMap("ten"), metav1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		var event Event[int]
+		Eventually(events).Should(Receive(&event))
+		Expect(event.Err).To(HaveOccurred())
+
+		Expect(configMaps.Delete(gocontext.Background(), "naavik-limit", metav1.DeleteOptions{})).To(Succeed())
+		Eventually(events).Should(Receive(Equal(Event[int]{})))
+
+		cancel()
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("should notify a config map not found once synced", func() {
+		events := make(chan Event[int], 10)
+		ctx := context.NewContextWithLogger()
+		cctx, cancel := gocontext.WithCancel(gocontext.Background())
+		defer cancel()
+		ctx.Context = cctx
+		go Watch(ctx, fake.NewSimpleClientset(), Opts[int]{
+			Name:           "limit",
+			Namespace:      "admiral-sync",
+			ConfigMapName:  "naavik-limit",
+			Parse:          parseLimit,
+			NotifyNotFound: true,
+			OnChange: func(_ context.Context, event Event[int]) {
+				events <- event
+			},
+		})
+		Eventually(events).Should(Receive(Equal(Event[int]{})))
+		Consistently(events).ShouldNot(Receive())
+	})
+})
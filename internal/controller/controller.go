@@ -4,6 +4,7 @@ import (
 	contxt "context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,7 +12,11 @@ import (
 	internalCache "github.com/intuit/naavik/internal/cache"
 	"github.com/intuit/naavik/internal/types"
 	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/eventhistory"
 	"github.com/intuit/naavik/pkg/logger"
+	"github.com/intuit/naavik/pkg/metrics"
+	"github.com/intuit/naavik/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 
 	"k8s.io/apimachinery/pkg/util/runtime"
 
@@ -38,6 +43,10 @@ type InformerCacheObj struct {
 	processStart     time.Time
 	statusChan       chan EventProcessStatus
 	onStatusOverride func(context.Context, EventProcessStatus)
+	// initialList is set for the events received as part of the initial informer list
+	initialList atomic.Bool
+	// isChild is set for the child events created by the handlers
+	isChild bool
 }
 
 type Controller struct {
@@ -46,6 +55,10 @@ type Controller struct {
 	queue             workqueue.RateLimitingInterface
 	informer          cache.SharedIndexInformer
 	workerConcurrency int
+	// keyMutex makes sure events of the same object are not handled concurrently by multiple workers
+	keyMutex *KeyedMutex
+	// pendingInitialEvents is the number of events from the initial informer list that are not processed yet
+	pendingInitialEvents atomic.Int64
 }
 
 type Opts struct {
@@ -66,6 +79,7 @@ func NewController(opts Opts) Delegator {
 		queue:             workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
 		workerConcurrency: options.GetWorkerConcurrency(),
 		informer:          opts.Delegator.GetInformer(),
+		keyMutex:          NewKeyedMutex(),
 	}
 	// Override worker concurrency if provided else use global value from options.GetWorkerConcurrency()
 	if opts.WorkerConcurrency > 0 {
@@ -78,13 +92,20 @@ func NewController(opts Opts) Delegator {
 		ctx = opts.Context
 	}
 
-	controller.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: func(obj interface{}) {
+	metrics.QueueDepth.RegisterQueue(controller.name, controller.queue.Len)
+
+	registration, err := controller.informer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj interface{}, isInInitialList bool) {
 			eventStatus := make(chan EventProcessStatus, DefaultEventStatusBufferedChannelSize)
 			key, err := cache.MetaNamespaceKeyFunc(obj)
 			if err == nil {
 				logger.NewLogger().WithStr(logger.ControllerNameKey, controller.name).With("obj", key).Info("Informer add event received")
-				controller.queue.Add(&InformerCacheObj{key: key, eventType: types.Add, obj: obj, addTime: time.Now(), statusChan: eventStatus})
+				item := &InformerCacheObj{key: key, eventType: types.Add, obj: obj, addTime: time.Now(), statusChan: eventStatus}
+				if isInInitialList {
+					item.initialList.Store(true)
+					controller.pendingInitialEvents.Add(1)
+				}
+				controller.queue.Add(item)
 			}
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
@@ -104,6 +125,14 @@ func NewController(opts Opts) Delegator {
 			}
 		},
 	})
+	if err != nil {
+		ctx.Log.WithStr(logger.ControllerNameKey, controller.name).Str(logger.ErrorKey, err.Error()).Error("error adding event handler to informer")
+	} else {
+		// The controller is synced only when the informer has synced and all the events from the initial list are processed
+		internalCache.InformerSync.Register(controller.name, func() bool {
+			return registration.HasSynced() && controller.pendingInitialEvents.Load() == 0
+		})
+	}
 
 	go controller.Run(ctx)
 
@@ -171,7 +200,19 @@ func (c *Controller) processNextItem(ctx context.Context) bool {
 
 func (c *Controller) processItem(informerCacheObj *InformerCacheObj) {
 	ctx := context.NewContextWithLogger()
-	ctx.Log.WithStr(logger.EventIDKey, uuid.New().String()).
+	eventID := uuid.New().String()
+	// The event span is ended once the event status channel is closed
+	ctx.Context, _ = tracing.StartSpan(ctx.Context, fmt.Sprintf("%s event", informerCacheObj.eventType.String()),
+		attribute.String(logger.EventIDKey, eventID),
+		attribute.String(logger.ControllerNameKey, c.name),
+		attribute.String(logger.ResourceIdentifierKey, informerCacheObj.key),
+		attribute.String(logger.EventType, informerCacheObj.eventType.String()),
+		attribute.Int(logger.RetryCountKey, informerCacheObj.retryCount),
+	)
+	ctx.Context = eventhistory.WithRecorder(ctx.Context, eventhistory.NewRecorder(eventID, c.name, informerCacheObj.key, informerCacheObj.eventType.String(), informerCacheObj.retryCount))
+	// Handlers measure the propagation of the event from the time it was received
+	ctx.Context = contxt.WithValue(ctx.Context, types.EventReceivedTimeKey, informerCacheObj.addTime)
+	ctx.Log.WithStr(logger.EventIDKey, eventID).
 		Str(logger.ResourceIdentifierKey, informerCacheObj.key).
 		Str(logger.EventType, informerCacheObj.eventType.String()).
 		Str(logger.ControllerNameKey, c.name).
@@ -180,10 +221,15 @@ func (c *Controller) processItem(informerCacheObj *InformerCacheObj) {
 		Info("Processing started.")
 
 	informerCacheObj.processStart = time.Now()
+	metrics.QueueLatency.WithLabelValues(c.name).Observe(informerCacheObj.processStart.Sub(informerCacheObj.addTime).Seconds())
 
 	// Initiate satatus callback handler
 	c.handleStatus(ctx, informerCacheObj)
 
+	// Only one worker can handle an object at a time, the other workers wait for it to complete
+	unlock := c.keyMutex.Lock(informerCacheObj.key)
+	defer unlock()
+
 	if informerCacheObj.eventType == types.Delete {
 		c.Deleted(ctx, informerCacheObj.obj, informerCacheObj.statusChan)
 	} else if informerCacheObj.eventType == types.Update {
@@ -201,6 +247,8 @@ func StopAllControllers() {
 			defer wg.Done()
 			startTime := time.Now()
 			logger.Log.Str(logger.ControllerNameKey, key.(string)).Info("Stopping controller")
+			internalCache.InformerSync.DeRegister(key.(string))
+			metrics.DeleteControllerMetrics(key.(string))
 			ctlrContext := value.(internalCache.ControllerContext)
 			close(ctlrContext.StopCh)
 			for i, closeCtx := range ctlrContext.WorkerCtx {
@@ -219,9 +267,21 @@ func StopAllControllers() {
 func (c *Controller) handleStatus(ctx context.Context, item *InformerCacheObj) {
 	go func(ctx context.Context, item *InformerCacheObj) {
 		defer c.queue.Done(item)
+		span := tracing.SpanFromContext(ctx.Context)
+		var spanErr error
+		defer func() { tracing.EndSpan(span, spanErr) }()
+		var result EventStatus
+		recorder := eventhistory.RecorderFromContext(ctx.Context)
+		// The event, or child event, is added to the history once its status channel is closed
+		defer func() { recorder.Finish(result.String(), spanErr) }()
+		recorder.PublishStatus(EventProcessing.String(), nil)
 		startTime := time.Now()
 		for eventStatus := range item.statusChan {
 			ctx.Log.Str(logger.EventStatusKey, eventStatus.Status.String()).Info("OnStatus triggered.")
+			span.AddEvent(eventStatus.Status.String())
+			if eventStatus.Status != EventCreateChild {
+				result = eventStatus.Status
+			}
 			if eventStatus.Status == EventCreateChild {
 				newChildItem := &InformerCacheObj{
 					key:          item.key,
@@ -234,14 +294,21 @@ func (c *Controller) handleStatus(ctx context.Context, item *InformerCacheObj) {
 					// Use the new child event status channel and onStatus callback
 					statusChan:       eventStatus.ChildEventChan,
 					onStatusOverride: eventStatus.ChildOnStatus,
+					isChild:          true,
 				}
 				c.handleStatus(eventStatus.ChildEventContext, newChildItem)
+				recorder.PublishStatus(eventStatus.Status.String(), nil)
 			} else if eventStatus.Status == EventSkip {
 				ctx.Log.Trace("Skipping OnStatus callback")
+				recorder.PublishStatus(eventStatus.Status.String(), nil)
 			} else if (eventStatus.Status == EventCompleted || eventStatus.Status == EventFailure || eventStatus.Status == EventPartialCompleted) && !eventStatus.Retry {
+				if eventStatus.Status == EventFailure {
+					spanErr = eventStatus.Error
+				}
 				c.triggerOnStatusCallback(ctx, eventStatus, item)
 			} else if eventStatus.Retry && item.retryCount < eventStatus.MaxRetryCount {
 				ctx.Log.Errorf("event will be retried. %d/%d", item.retryCount, eventStatus.MaxRetryCount)
+				metrics.Retries.WithLabelValues(c.name).Inc()
 				eventStatus.RetryCount = item.retryCount
 				c.triggerOnStatusCallback(ctx, eventStatus, item)
 				// Increment the retry count
@@ -256,6 +323,9 @@ func (c *Controller) handleStatus(ctx context.Context, item *InformerCacheObj) {
 			} else if eventStatus.Retry && item.retryCount >= eventStatus.MaxRetryCount {
 				eventStatus.RetryCount = item.retryCount
 				eventStatus.Status = EventMaxRetryReached
+				result = EventMaxRetryReached
+				spanErr = fmt.Errorf("max retry reached %d/%d", item.retryCount, eventStatus.MaxRetryCount)
+				metrics.MaxRetries.WithLabelValues(c.name).Inc()
 				c.triggerOnStatusCallback(ctx, eventStatus, item)
 				ctx.Log.Errorf("error processing item max retry reached %d/%d, giving up", item.retryCount, eventStatus.MaxRetryCount)
 			} else {
@@ -263,11 +333,20 @@ func (c *Controller) handleStatus(ctx context.Context, item *InformerCacheObj) {
 			}
 			ctx.Log.Int(logger.TimeTakenMSKey, int(time.Since(startTime).Milliseconds())).Str(logger.EventStatusKey, eventStatus.Status.String()).Info("OnStatus completed.")
 		}
+		// Count the initial list event as processed only once, even if it is retried
+		if item.initialList.CompareAndSwap(true, false) {
+			c.pendingInitialEvents.Add(-1)
+		}
+		// Child events are part of the parent event processing
+		if !item.isChild {
+			metrics.ObserveDuration(metrics.ProcessingLatency.WithLabelValues(c.name), item.processStart)
+		}
 		ctx.Log.Str(logger.ControllerNameKey, c.name).Str(logger.ResourceIdentifierKey, item.key).Any(logger.TimeTakenMSKey, time.Since(item.processStart).Milliseconds()).Info("Processing completed.")
 	}(ctx, item)
 }
 
 func (c *Controller) triggerOnStatusCallback(ctx context.Context, eventStatus EventProcessStatus, informerCacheObj *InformerCacheObj) {
+	eventhistory.RecorderFromContext(ctx.Context).PublishStatus(eventStatus.Status.String(), eventStatus.Error)
 	// If the onStatus callback is overridden, use the overridden callback else use the delegator callback
 	if informerCacheObj.onStatusOverride != nil {
 		informerCacheObj.onStatusOverride(ctx, eventStatus)
@@ -14,12 +14,41 @@ import (
 	fake_handler "github.com/intuit/naavik/internal/fake/handler"
 	fake_k8s_utils "github.com/intuit/naavik/internal/fake/utils/k8s"
 	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/eventhistory"
+	"github.com/intuit/naavik/pkg/metrics"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
 )
 
+// queueDepth returns the queue depth reported for the controller.
+func queueDepth(controllerName string) (float64, error) {
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(metrics.QueueDepth); err != nil {
+		return 0, err
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		return 0, err
+	}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == metrics.ControllerLabel && label.GetValue() == controllerName {
+					return metric.GetGauge().GetValue(), nil
+				}
+			}
+		}
+	}
+	return 0, fmt.Errorf("no queue depth reported for controller %s", controllerName)
+}
+
 var _ = Describe("Test controller", Label("deployments_cache_test"), func() {
 	When("New controller is created", func() {
 		var config *rest.Config
@@ -107,6 +136,38 @@ var _ = Describe("Test controller", Label("deployments_cache_test"), func() {
 			Eventually(informer.HasSynced, 5*time.Second).Should(BeTrue())
 			Eventually(len(cache.ControllerCache.List())).Should(Equal(1))
 		})
+
+		It("should be synced only after the initial list of objects is processed", func() {
+			cache.InformerSync.Reset()
+			controllerName := fmt.Sprintf("mock-controller/%s", config.Host)
+			client, _ := fake_k8s_utils.NewFakeConfigLoader().ClientFromConfig(config)
+			mockNamesapce := "fake_namespace"
+
+			// Objects created before the controller starts are part of the initial list
+			dep1 := fake_builder.BuildFakeDeployment("fake_deployment-1", "app", "app", "env", mockNamesapce)
+			dep2 := fake_builder.BuildFakeDeployment("fake_deployment-2", "app", "app", "env", mockNamesapce)
+			client.AppsV1().Deployments(mockNamesapce).Create(context.Background(), dep1, metav1.CreateOptions{})
+			client.AppsV1().Deployments(mockNamesapce).Create(context.Background(), dep2, metav1.CreateOptions{})
+
+			handler := fake_handler.NewFakeNoOpHandler(config.ServerName, 500*time.Millisecond)
+			fakeController := &fake_controller.FakeController{
+				Clientset: client,
+				Namespace: mockNamesapce,
+				ListOpts:  metav1.ListOptions{},
+				Handler:   handler,
+			}
+			informer := fakeController.GetInformer()
+			controller.NewController(controller.Opts{
+				Name:      controllerName,
+				Delegator: fakeController,
+				Informer:  informer,
+			})
+			Eventually(informer.HasSynced, 5*time.Second).Should(BeTrue())
+			Expect(cache.InformerSync.HasSynced()).To(BeFalse())
+			Eventually(cache.InformerSync.HasSynced, 5*time.Second).Should(BeTrue())
+			Expect(handler.AddCalled.Load()).To(Equal(int64(2)))
+			cache.InformerSync.Reset()
+		})
 	})
 
 	When("Handler creates child event with its context", func() {
@@ -161,6 +222,157 @@ var _ = Describe("Test controller", Label("deployments_cache_test"), func() {
 			Eventually(called.Load, 5*time.Second).Should(BeTrue(), "Child status handler should be triggered")
 			Eventually(handler.OnStatusCalled.Load, 5*time.Second).Should(Equal(int64(1)), "Parent Status handler should be triggered")
 		})
+
+		It("should link the child event span to the parent event span", func() {
+			spanRecorder := tracetest.NewSpanRecorder()
+			previousTracerProvider := otel.GetTracerProvider()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+			defer otel.SetTracerProvider(previousTracerProvider)
+
+			controllerName := fmt.Sprintf("mock-controller/%s", config.Host)
+			client, _ := fake_k8s_utils.NewFakeConfigLoader().ClientFromConfig(config)
+			mockNamesapce := "fake_namespace"
+			handler := fake_handler.NewFakeNoOpHandlerWithChildEvent(config.ServerName, func(_ context.Context, _ controller.EventProcessStatus) {})
+			fakeController := &fake_controller.FakeController{
+				Clientset: client,
+				Namespace: mockNamesapce,
+				ListOpts:  metav1.ListOptions{},
+				Handler:   handler,
+			}
+			informer := fakeController.GetInformer()
+			controller.NewController(controller.Opts{
+				Name:      controllerName,
+				Delegator: fakeController,
+				Informer:  informer,
+			})
+			Eventually(informer.HasSynced, 5*time.Second).Should(BeTrue())
+
+			dep1 := fake_builder.BuildFakeDeployment("fake_deployment-1", "app", "app", "env", mockNamesapce)
+			client.AppsV1().Deployments(mockNamesapce).Create(context.Background(), dep1, metav1.CreateOptions{})
+			Eventually(func() int { return len(spanRecorder.Ended()) }, 5*time.Second).Should(Equal(2), "Parent and child event spans should be ended")
+
+			spans := map[string]sdktrace.ReadOnlySpan{}
+			for _, span := range spanRecorder.Ended() {
+				spans[span.Name()] = span
+			}
+			Expect(spans).To(HaveKey("Add event"))
+			Expect(spans).To(HaveKey("child event"))
+			Expect(spans["child event"].Parent().SpanID()).To(Equal(spans["Add event"].SpanContext().SpanID()))
+			Expect(spans["child event"].SpanContext().TraceID()).To(Equal(spans["Add event"].SpanContext().TraceID()))
+		})
+	})
+
+	When("Handler processes an event with a child event", func() {
+		var config *rest.Config
+		var previousHistory eventhistory.Store
+
+		BeforeEach(func() {
+			options.InitializeNaavikArgs(nil)
+			config, _ = fake_k8s_utils.NewFakeConfigLoader().GetConfigFromPath("fake_cluster")
+			previousHistory = eventhistory.History
+			eventhistory.History = eventhistory.NewMemoryStore(10, time.Hour)
+		})
+
+		AfterEach(func() {
+			fake_k8s_utils.NewFakeConfigLoader().ResetFakeClients()
+			controller.StopAllControllers()
+			cache.ControllerCache.Reset()
+			eventhistory.History = previousHistory
+		})
+
+		It("should record the event and the child event in the event history", func() {
+			controllerName := fmt.Sprintf("mock-controller/%s", config.Host)
+			client, _ := fake_k8s_utils.NewFakeConfigLoader().ClientFromConfig(config)
+			mockNamesapce := "fake_namespace"
+			handler := fake_handler.NewFakeNoOpHandlerWithChildEvent(config.ServerName, func(_ context.Context, _ controller.EventProcessStatus) {})
+			fakeController := &fake_controller.FakeController{
+				Clientset: client,
+				Namespace: mockNamesapce,
+				ListOpts:  metav1.ListOptions{},
+				Handler:   handler,
+			}
+			informer := fakeController.GetInformer()
+			controller.NewController(controller.Opts{
+				Name:      controllerName,
+				Delegator: fakeController,
+				Informer:  informer,
+			})
+			Eventually(informer.HasSynced, 5*time.Second).Should(BeTrue())
+
+			dep1 := fake_builder.BuildFakeDeployment("fake_deployment-1", "app", "app", "env", mockNamesapce)
+			client.AppsV1().Deployments(mockNamesapce).Create(context.Background(), dep1, metav1.CreateOptions{})
+			Eventually(func() int {
+				return len(eventhistory.History.List(eventhistory.Filter{Controller: controllerName}))
+			}, 5*time.Second).Should(Equal(2), "Parent and child events should be recorded")
+
+			entries := eventhistory.History.List(eventhistory.Filter{Controller: controllerName})
+			for _, entry := range entries {
+				Expect(entry.Key).To(Equal(mockNamesapce + "/fake_deployment-1"))
+				Expect(entry.EventType).To(Equal("Add"))
+				Expect(entry.Result).To(Equal(controller.EventCompleted.String()))
+				Expect(entry.EventID).To(Equal(entries[0].EventID))
+			}
+			childEntries := 0
+			for _, entry := range entries {
+				if len(entry.ChildEventID) > 0 {
+					childEntries++
+				}
+			}
+			Expect(childEntries).To(Equal(1))
+		})
+	})
+
+	When("a watcher subscribes to the event stream", func() {
+		var config *rest.Config
+
+		BeforeEach(func() {
+			options.InitializeNaavikArgs(nil)
+			config, _ = fake_k8s_utils.NewFakeConfigLoader().GetConfigFromPath("fake_cluster")
+		})
+
+		AfterEach(func() {
+			fake_k8s_utils.NewFakeConfigLoader().ResetFakeClients()
+			controller.StopAllControllers()
+			cache.ControllerCache.Reset()
+		})
+
+		It("should stream the status transitions of the event", func() {
+			controllerName := fmt.Sprintf("mock-controller/%s", config.Host)
+			subscription := eventhistory.Stream.Subscribe(eventhistory.StreamFilter{Controller: controllerName}, 10)
+			defer subscription.Close()
+
+			client, _ := fake_k8s_utils.NewFakeConfigLoader().ClientFromConfig(config)
+			mockNamesapce := "fake_namespace"
+			handler := fake_handler.NewFakeNoOpHandler(config.ServerName, 0)
+			fakeController := &fake_controller.FakeController{
+				Clientset: client,
+				Namespace: mockNamesapce,
+				ListOpts:  metav1.ListOptions{},
+				Handler:   handler,
+			}
+			informer := fakeController.GetInformer()
+			controller.NewController(controller.Opts{
+				Name:      controllerName,
+				Delegator: fakeController,
+				Informer:  informer,
+			})
+			Eventually(informer.HasSynced, 5*time.Second).Should(BeTrue())
+
+			dep1 := fake_builder.BuildFakeDeployment("fake_deployment-1", "app", "app", "env", mockNamesapce)
+			client.AppsV1().Deployments(mockNamesapce).Create(context.Background(), dep1, metav1.CreateOptions{})
+
+			statuses := []string{}
+			for len(statuses) < 2 {
+				var event eventhistory.StreamEvent
+				Eventually(subscription.Events(), 5*time.Second).Should(Receive(&event))
+				Expect(event.Type).To(Equal(eventhistory.StreamEventStatus))
+				Expect(event.Controller).To(Equal(controllerName))
+				Expect(event.Key).To(Equal(mockNamesapce + "/fake_deployment-1"))
+				statuses = append(statuses, event.Status)
+			}
+			Expect(statuses).To(Equal([]string{controller.EventProcessing.String(), controller.EventCompleted.String()}))
+			Expect(subscription.Dropped()).To(BeZero())
+		})
 	})
 
 	When("handler sends an event status to retry the obj must be retried", func() {
@@ -205,6 +417,16 @@ var _ = Describe("Test controller", Label("deployments_cache_test"), func() {
 			dep1 := fake_builder.BuildFakeDeployment("fake_deployment-1", "app", "app", "env", mockNamesapce)
 			client.AppsV1().Deployments(mockNamesapce).Create(context.Background(), dep1, metav1.CreateOptions{})
 			Eventually(handler.OnStatusCalled.Load, 5*time.Second).Should(Equal(int64(6)), "Parent Status handler should be triggered")
+			Eventually(func() float64 {
+				return testutil.ToFloat64(metrics.MaxRetries.WithLabelValues(controllerName))
+			}, 5*time.Second).Should(Equal(float64(1)), "Max retries should be counted")
+			Expect(testutil.ToFloat64(metrics.Retries.WithLabelValues(controllerName))).To(Equal(float64(eventStatus.MaxRetryCount)))
+			Eventually(func() int {
+				return testutil.CollectAndCount(metrics.ProcessingLatency)
+			}, 5*time.Second).Should(BeNumerically(">", 0), "Processing latency should be observed")
+			Eventually(func() (float64, error) {
+				return queueDepth(controllerName)
+			}, 5*time.Second).Should(BeZero(), "Queue depth should be read from the drained queue")
 		})
 	})
 
@@ -6,7 +6,10 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/eventhistory"
 	"github.com/intuit/naavik/pkg/logger"
+	"github.com/intuit/naavik/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -134,8 +137,12 @@ func (eps EventProcessStatus) SendClose(statusChan chan EventProcessStatus) Even
 func (eps EventProcessStatus) CreateChildEvent(ctx context.Context, childOnStatus func(ctx context.Context, status EventProcessStatus), statusChan chan EventProcessStatus) (childContext context.Context, childStatusChan chan EventProcessStatus) {
 	eps.Status = EventCreateChild
 	if statusChan != nil {
+		childEventID := uuid.New().String()
 		eps.ChildEventContext = context.NewContextWithLogger()
-		eps.ChildEventContext.Log = ctx.Log.Str(logger.ChildEventIDKey, uuid.New().String())
+		eps.ChildEventContext.Log = ctx.Log.Str(logger.ChildEventIDKey, childEventID)
+		// The child event span is ended by the controller once the child event status channel is closed
+		eps.ChildEventContext.Context, _ = tracing.StartSpan(ctx.Context, "child event", attribute.String(logger.ChildEventIDKey, childEventID))
+		eps.ChildEventContext.Context = eventhistory.WithRecorder(eps.ChildEventContext.Context, eventhistory.RecorderFromContext(ctx.Context).NewChildRecorder(childEventID))
 		eps.ChildOnStatus = childOnStatus
 		eps.ChildEventChan = make(chan EventProcessStatus, DefaultEventStatusBufferedChannelSize)
 		statusChan <- eps
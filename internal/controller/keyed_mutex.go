@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"strings"
+	"sync"
+)
+
+// IdentityMutex serializes reconciles of the same identity across all controllers and workers.
+// Handlers that write resources for an identity must hold the identity lock while writing.
+var IdentityMutex = NewKeyedMutex()
+
+// KeyedMutex provides mutual exclusion per key.
+// Locks are reference counted and removed once no goroutine holds or waits on them,
+// so the number of tracked keys does not grow with the number of keys seen.
+type KeyedMutex struct {
+	mutex sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	refs int
+}
+
+func NewKeyedMutex() *KeyedMutex {
+	return &KeyedMutex{
+		locks: make(map[string]*keyedLock),
+	}
+}
+
+// Lock blocks until the lock for the key is acquired and returns the function to release it.
+// Keys are case insensitive.
+func (km *KeyedMutex) Lock(key string) (unlock func()) {
+	key = strings.ToLower(key)
+	km.mutex.Lock()
+	lock, ok := km.locks[key]
+	if !ok {
+		lock = &keyedLock{}
+		km.locks[key] = lock
+	}
+	lock.refs++
+	km.mutex.Unlock()
+
+	lock.Lock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			lock.Unlock()
+			km.mutex.Lock()
+			defer km.mutex.Unlock()
+			lock.refs--
+			if lock.refs == 0 {
+				delete(km.locks, key)
+			}
+		})
+	}
+}
+
+// Len returns the number of keys currently locked or waited on.
+func (km *KeyedMutex) Len() int {
+	km.mutex.Lock()
+	defer km.mutex.Unlock()
+	return len(km.locks)
+}
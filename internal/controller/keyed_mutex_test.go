@@ -0,0 +1,75 @@
+package controller_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/intuit/naavik/internal/controller"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test keyed mutex", Label("keyed_mutex_test"), func() {
+	When("multiple goroutines lock the same key", func() {
+		It("should allow only one goroutine to hold the lock at a time", func() {
+			km := controller.NewKeyedMutex()
+			var active atomic.Int32
+			overlapped := atomic.Bool{}
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					unlock := km.Lock("identity")
+					defer unlock()
+					if active.Add(1) > 1 {
+						overlapped.Store(true)
+					}
+					time.Sleep(5 * time.Millisecond)
+					active.Add(-1)
+				}()
+			}
+			wg.Wait()
+			Expect(overlapped.Load()).To(BeFalse())
+			Expect(km.Len()).To(Equal(0))
+		})
+
+		It("should treat keys case insensitively", func() {
+			km := controller.NewKeyedMutex()
+			unlock := km.Lock("Identity")
+			acquired := atomic.Bool{}
+			go func() {
+				defer km.Lock("identity")()
+				acquired.Store(true)
+			}()
+			Consistently(acquired.Load, 100*time.Millisecond).Should(BeFalse())
+			unlock()
+			Eventually(acquired.Load, time.Second).Should(BeTrue())
+		})
+	})
+
+	When("goroutines lock different keys", func() {
+		It("should not block each other", func() {
+			km := controller.NewKeyedMutex()
+			unlock := km.Lock("identity-1")
+			defer unlock()
+			acquired := atomic.Bool{}
+			go func() {
+				defer km.Lock("identity-2")()
+				acquired.Store(true)
+			}()
+			Eventually(acquired.Load, time.Second).Should(BeTrue())
+		})
+	})
+
+	When("unlock is called more than once", func() {
+		It("should release the lock only once", func() {
+			km := controller.NewKeyedMutex()
+			unlock := km.Lock("identity")
+			unlock()
+			unlock()
+			Expect(km.Len()).To(Equal(0))
+		})
+	})
+})
@@ -78,6 +78,15 @@ func GetFakeTrafficConfig(assetAlias string, env string, revision string, traffi
 	return tc
 }
 
+// GetFakeTrafficConfigWithAnnotations returns the fake traffic config of the asset alias and env with the annotations added.
+func GetFakeTrafficConfigWithAnnotations(assetAlias string, env string, annotations map[string]string) *admiralv1.TrafficConfig {
+	tc := GetFakeTrafficConfig(assetAlias, env, "1", "namespace")
+	for key, value := range annotations {
+		tc.Annotations[key] = value
+	}
+	return tc
+}
+
 func GetPreloadedTrafficConfig() *admiralv1.TrafficConfig {
 	tc := &admiralv1.TrafficConfig{}
 	tcData, err := content.ReadFile("fake_tc_data.json")
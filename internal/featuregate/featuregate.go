@@ -0,0 +1,178 @@
+package featuregate
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/types"
+	"sigs.k8s.io/yaml"
+)
+
+// GatesKey is the config map key holding the YAML list of gates.
+const GatesKey = "gates"
+
+// Gate enables or disables a feature for the traffic configs it matches.
+// Empty Identity, Env and Cluster match everything. Gates are evaluated in order, the last matching gate wins
+// over the --disabled_features flag.
+type Gate struct {
+	Feature types.FeatureName `json:"feature"`
+	Enabled bool              `json:"enabled"`
+	// Identity is the traffic config identity, case insensitive.
+	Identity string `json:"identity,omitempty"`
+	// Env is the traffic config env, case insensitive.
+	Env string `json:"env,omitempty"`
+	// Cluster is a regex matching the clusters the resources are written to, case insensitive.
+	Cluster string `json:"cluster,omitempty"`
+
+	clusterRegex *regexp.Regexp
+}
+
+// State is the last state read from the feature gates config map.
+type State struct {
+	ConfigMapFound  bool      `json:"configMapFound"`
+	ResourceVersion string    `json:"resourceVersion,omitempty"`
+	LastUpdated     time.Time `json:"lastUpdated"`
+	// Error is set when the config map is invalid, the last valid gates are kept.
+	Error string `json:"error,omitempty"`
+	Gates []Gate `json:"gates"`
+}
+
+var (
+	currentState = State{Gates: []Gate{}}
+	stateLock    = sync.RWMutex{}
+)
+
+// Parse parses the gates of the config map data.
+func Parse(data map[string]string) ([]Gate, error) {
+	gates := []Gate{}
+	content, ok := data[GatesKey]
+	if !ok || len(strings.TrimSpace(content)) == 0 {
+		return gates, nil
+	}
+	if err := yaml.UnmarshalStrict([]byte(content), &gates); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", GatesKey, err)
+	}
+	for i := range gates {
+		gate := &gates[i]
+		if !slices.Contains(options.AvailableFeatures, gate.Feature) {
+			return nil, fmt.Errorf("gates[%d]: unknown feature %q, expected one of %v", i, gate.Feature, options.AvailableFeatures)
+		}
+		if len(gate.Cluster) > 0 {
+			regex, err := regexp.Compile("(?i)" + strings.TrimSpace(gate.Cluster))
+			if err != nil {
+				return nil, fmt.Errorf("gates[%d]: invalid cluster regex %q: %w", i, gate.Cluster, err)
+			}
+			gate.clusterRegex = regex
+		}
+	}
+	return gates, nil
+}
+
+// SetState replaces the gates, and returns the previous ones.
+func SetState(state State) []Gate {
+	stateLock.Lock()
+	defer stateLock.Unlock()
+	previous := currentState.Gates
+	if state.Gates == nil {
+		state.Gates = []Gate{}
+	}
+	currentState = state
+	return previous
+}
+
+// GetState returns the last state read from the config map.
+func GetState() State {
+	stateLock.RLock()
+	defer stateLock.RUnlock()
+	return currentState
+}
+
+// Reset removes all the gates.
+func Reset() {
+	SetState(State{})
+}
+
+// IsEnabled returns true if the feature is enabled for the traffic config identity and env in the cluster.
+func IsEnabled(feature types.FeatureName, identity string, env string, clusterID string) bool {
+	stateLock.RLock()
+	defer stateLock.RUnlock()
+	enabled := options.IsFeatureEnabled(feature)
+	for _, gate := range currentState.Gates {
+		if gate.matches(feature, identity, env) && (gate.clusterRegex == nil || gate.clusterRegex.MatchString(clusterID)) {
+			enabled = gate.Enabled
+		}
+	}
+	return enabled
+}
+
+// IsEnabledInAnyCluster returns true if the feature may be enabled for the traffic config identity and env in a cluster,
+// IsEnabled is then checked per cluster.
+func IsEnabledInAnyCluster(feature types.FeatureName, identity string, env string) bool {
+	stateLock.RLock()
+	defer stateLock.RUnlock()
+	enabled := options.IsFeatureEnabled(feature)
+	for _, gate := range currentState.Gates {
+		if !gate.matches(feature, identity, env) {
+			continue
+		}
+		if gate.clusterRegex == nil {
+			enabled = gate.Enabled
+		} else if gate.Enabled {
+			enabled = true
+		}
+	}
+	return enabled
+}
+
+// IsGated returns true if a gate applies to the feature for the traffic config identity and env.
+// The resources of a feature disabled by a gate are deleted, the ones of a feature only disabled by --disabled_features are left as is.
+func IsGated(feature types.FeatureName, identity string, env string) bool {
+	stateLock.RLock()
+	defer stateLock.RUnlock()
+	for _, gate := range currentState.Gates {
+		if gate.matches(feature, identity, env) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns true if the gate applies to the feature for the identity and env, in at least one cluster.
+func (g Gate) matches(feature types.FeatureName, identity string, env string) bool {
+	return g.Feature == feature &&
+		(len(g.Identity) == 0 || strings.EqualFold(g.Identity, identity)) &&
+		(len(g.Env) == 0 || strings.EqualFold(g.Env, env))
+}
+
+// equal compares the gates as written in the config map.
+func (g Gate) equal(other Gate) bool {
+	return g.Feature == other.Feature && g.Enabled == other.Enabled && strings.EqualFold(g.Identity, other.Identity) &&
+		strings.EqualFold(g.Env, other.Env) && g.Cluster == other.Cluster
+}
+
+// FeatureStatus is the evaluation of a feature for a traffic config identity and env.
+type FeatureStatus struct {
+	EnabledInAnyCluster bool            `json:"enabledInAnyCluster"`
+	Clusters            map[string]bool `json:"clusters"`
+}
+
+// Evaluate returns the status of the available features for the traffic config identity and env in the clusters.
+func Evaluate(identity string, env string, clusters []string) map[types.FeatureName]*FeatureStatus {
+	features := make(map[types.FeatureName]*FeatureStatus, len(options.AvailableFeatures))
+	for _, feature := range options.AvailableFeatures {
+		status := &FeatureStatus{
+			EnabledInAnyCluster: IsEnabledInAnyCluster(feature, identity, env),
+			Clusters:            make(map[string]bool, len(clusters)),
+		}
+		for _, clusterID := range clusters {
+			status.Clusters[clusterID] = IsEnabled(feature, identity, env, clusterID)
+		}
+		features[feature] = status
+	}
+	return features
+}
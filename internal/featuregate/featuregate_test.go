@@ -0,0 +1,139 @@
+package featuregate
+
+import (
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/configmapwatcher"
+	k8s_builder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func setGates(content string) {
+	gates, err := Parse(map[string]string{GatesKey: content})
+	Expect(err).NotTo(HaveOccurred())
+	SetState(State{ConfigMapFound: true, Gates: gates})
+}
+
+var _ = Describe("Test feature gates", func() {
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		Reset()
+	})
+
+	It("should follow the disabled features without gates", func() {
+		Expect(IsEnabled(types.FeatureThrottleFilter, "foo", "qa", "cluster1")).To(BeTrue())
+		options.InitializeNaavikArgs(&options.NaavikArgs{DisabledFeatures: []string{types.FeatureThrottleFilter.String()}})
+		Expect(IsEnabled(types.FeatureThrottleFilter, "foo", "qa", "cluster1")).To(BeFalse())
+		Expect(IsEnabledInAnyCluster(types.FeatureThrottleFilter, "foo", "qa")).To(BeFalse())
+		Expect(IsEnabled(types.FeatureVirtualService, "foo", "qa", "cluster1")).To(BeTrue())
+	})
+
+	It("should disable a feature for an identity and env only", func() {
+		setGates(`
+- feature: throttlefilter
+  enabled: false
+  identity: Foo
+  env: qa
+`)
+		Expect(IsEnabled(types.FeatureThrottleFilter, "foo", "qa", "cluster1")).To(BeFalse())
+		Expect(IsEnabledInAnyCluster(types.FeatureThrottleFilter, "foo", "qa")).To(BeFalse())
+		Expect(IsEnabled(types.FeatureThrottleFilter, "foo", "e2e", "cluster1")).To(BeTrue())
+		Expect(IsEnabled(types.FeatureThrottleFilter, "bar", "qa", "cluster1")).To(BeTrue())
+		Expect(IsEnabled(types.FeatureVirtualService, "foo", "qa", "cluster1")).To(BeTrue())
+		Expect(IsGated(types.FeatureThrottleFilter, "foo", "qa")).To(BeTrue())
+		Expect(IsGated(types.FeatureThrottleFilter, "foo", "e2e")).To(BeFalse())
+		Expect(IsGated(types.FeatureVirtualService, "foo", "qa")).To(BeFalse())
+	})
+
+	It("should apply the last matching gate per cluster", func() {
+		setGates(`
+- feature: virtualservice
+  enabled: false
+- feature: virtualservice
+  enabled: true
+  identity: foo
+  cluster: west-.*
+`)
+		Expect(IsEnabled(types.FeatureVirtualService, "foo", "qa", "west-1")).To(BeTrue())
+		Expect(IsEnabled(types.FeatureVirtualService, "foo", "qa", "east-1")).To(BeFalse())
+		Expect(IsEnabled(types.FeatureVirtualService, "bar", "qa", "west-1")).To(BeFalse())
+		Expect(IsEnabledInAnyCluster(types.FeatureVirtualService, "foo", "qa")).To(BeTrue())
+		Expect(IsEnabledInAnyCluster(types.FeatureVirtualService, "bar", "qa")).To(BeFalse())
+
+		features := Evaluate("foo", "qa", []string{"west-1", "east-1"})
+		Expect(features[types.FeatureVirtualService].Clusters).To(Equal(map[string]bool{"west-1": true, "east-1": false}))
+		Expect(features[types.FeatureThrottleFilter].EnabledInAnyCluster).To(BeTrue())
+	})
+
+	It("should reject unknown features, invalid regexes and fields", func() {
+		_, err := Parse(map[string]string{GatesKey: "- feature: unknown\n  enabled: false\n"})
+		Expect(err).To(MatchError(ContainSubstring(`unknown feature "unknown"`)))
+		_, err = Parse(map[string]string{GatesKey: "- feature: throttlefilter\n  cluster: '['\n"})
+		Expect(err).To(MatchError(ContainSubstring("invalid cluster regex")))
+		_, err = Parse(map[string]string{GatesKey: "- feature: throttlefilter\n  identities: [foo]\n"})
+		Expect(err).To(HaveOccurred())
+		gates, err := Parse(map[string]string{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gates).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Test feature gates config map changes", func() {
+	var (
+		w          *watcher
+		identities []string
+	)
+	ctx := context.NewContextWithLogger()
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		Reset()
+		identities = nil
+		w = &watcher{opts: WatcherOpts{ConfigMapName: "naavik-feature-gates", OnChange: func(_ context.Context, changed []string) {
+			identities = changed
+		}}}
+		cache.TrafficConfigCache.AddTrafficConfigToCache(k8s_builder.GetFakeTrafficConfig("foo", "qa", "1", "admiral"))
+		cache.TrafficConfigCache.AddTrafficConfigToCache(k8s_builder.GetFakeTrafficConfig("bar", "e2e", "1", "admiral"))
+	})
+
+	buildConfigMap := func(gates string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "naavik-feature-gates", ResourceVersion: "1"},
+			Data:       map[string]string{GatesKey: gates},
+		}
+	}
+
+	It("should reconcile the identities the changed gates apply to", func() {
+		w.apply(ctx, configmapwatcher.NewEvent(buildConfigMap("- feature: throttlefilter\n  enabled: false\n  env: qa\n"), Parse))
+		Expect(identities).To(ConsistOf("foo"))
+		Expect(GetState().Gates).To(HaveLen(1))
+
+		identities = nil
+		w.apply(ctx, configmapwatcher.NewEvent(buildConfigMap("- feature: throttlefilter\n  enabled: false\n  env: qa\n"), Parse))
+		Expect(identities).To(BeNil())
+
+		w.apply(ctx, configmapwatcher.NewEvent(nil, Parse))
+		Expect(identities).To(ConsistOf("foo"))
+		Expect(GetState().ConfigMapFound).To(BeFalse())
+		Expect(GetState().Gates).To(BeEmpty())
+	})
+
+	It("should keep the last valid gates on an invalid config map", func() {
+		w.apply(ctx, configmapwatcher.NewEvent(buildConfigMap("- feature: virtualservice\n  enabled: false\n"), Parse))
+		Expect(identities).To(ConsistOf("foo", "bar"))
+
+		identities = nil
+		w.apply(ctx, configmapwatcher.NewEvent(buildConfigMap("- feature: virtualservices\n  enabled: true\n"), Parse))
+		Expect(identities).To(BeNil())
+		state := GetState()
+		Expect(state.Error).To(ContainSubstring("unknown feature"))
+		Expect(state.Gates).To(HaveLen(1))
+		Expect(IsEnabled(types.FeatureVirtualService, "foo", "qa", "cluster1")).To(BeFalse())
+	})
+})
@@ -0,0 +1,124 @@
+package featuregate
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/configmapwatcher"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/logger"
+	"k8s.io/client-go/kubernetes"
+)
+
+type WatcherOpts struct {
+	Namespace     string
+	ConfigMapName string
+	ResyncPeriod  time.Duration
+	// OnChange is called with the identities of the cached traffic configs the changed gates apply to.
+	OnChange func(ctx context.Context, identities []string)
+}
+
+type watcher struct {
+	opts WatcherOpts
+}
+
+// Watch watches the feature gates config map until the context is cancelled.
+func Watch(ctx context.Context, client kubernetes.Interface, opts WatcherOpts) {
+	w := &watcher{opts: opts}
+	configmapwatcher.Watch(ctx, client, configmapwatcher.Opts[[]Gate]{
+		Name:          "feature gates",
+		Namespace:     opts.Namespace,
+		ConfigMapName: opts.ConfigMapName,
+		ResyncPeriod:  opts.ResyncPeriod,
+		Parse:         Parse,
+		OnChange:      w.apply,
+	})
+}
+
+// apply applies the gates of the config map and reconciles the traffic configs the changed gates apply to.
+func (w *watcher) apply(ctx context.Context, event configmapwatcher.Event[[]Gate]) {
+	log := ctx.Log.Str(logger.NameKey, w.opts.ConfigMapName).Str(logger.NamespaceKey, w.opts.Namespace)
+	state := State{LastUpdated: time.Now(), ConfigMapFound: event.Found, ResourceVersion: event.ResourceVersion, Gates: event.Value}
+	if event.Err != nil {
+		// Keep the last valid gates, a typo must not flip the features of the whole mesh
+		log.Str(logger.ErrorKey, event.Err.Error()).Error("Invalid feature gates config map, keeping the last valid gates")
+		state.Gates = GetState().Gates
+		state.Error = event.Err.Error()
+		SetState(state)
+		return
+	}
+	previous := SetState(state)
+	identities := AffectedIdentities(previous, state.Gates)
+	log.Int("gates", len(state.Gates)).Int("affectedIdentities", len(identities)).Info("Feature gates updated")
+	if len(identities) > 0 && w.opts.OnChange != nil {
+		w.opts.OnChange(ctx, identities)
+	}
+}
+
+// AffectedIdentities returns the identities of the cached traffic configs the changed gates apply to.
+func AffectedIdentities(previous []Gate, current []Gate) []string {
+	changed := changedGates(previous, current)
+	if len(changed) == 0 {
+		return nil
+	}
+	identities := []string{}
+	for _, identity := range cache.TrafficConfigCache.ListIdentities() {
+		entry := cache.TrafficConfigCache.GetTrafficConfigEntry(identity)
+		if entry == nil {
+			continue
+		}
+		if isAffected(changed, identity, entry) {
+			identities = append(identities, identity)
+		}
+	}
+	sort.Strings(identities)
+	return identities
+}
+
+func isAffected(changed []Gate, identity string, entry *cache.TrafficConfigEntry) bool {
+	for env := range entry.EnvTrafficConfig {
+		for _, gate := range changed {
+			if gate.matches(gate.Feature, identity, env) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// changedGates returns the gates added or removed, or all the gates when only their order changed.
+func changedGates(previous []Gate, current []Gate) []Gate {
+	changed := slices.Concat(missingGates(previous, current), missingGates(current, previous))
+	if len(changed) > 0 {
+		return changed
+	}
+	if len(previous) != len(current) {
+		return slices.Concat(previous, current)
+	}
+	for i := range current {
+		if !current[i].equal(previous[i]) {
+			return slices.Concat(previous, current)
+		}
+	}
+	return nil
+}
+
+// missingGates returns the gates missing from others.
+func missingGates(gates []Gate, others []Gate) []Gate {
+	missing := []Gate{}
+	for _, gate := range gates {
+		found := false
+		for _, other := range others {
+			if gate.equal(other) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, gate)
+		}
+	}
+	return missing
+}
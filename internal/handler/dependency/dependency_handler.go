@@ -1,7 +1,6 @@
 package dependencyhandler
 
 import (
-	"github.com/intuit/naavik/cmd/options"
 	"github.com/intuit/naavik/internal/cache"
 	"github.com/intuit/naavik/internal/controller"
 	"github.com/intuit/naavik/internal/handler"
@@ -62,7 +61,7 @@ func (s *dependencyHandler) Updated(ctx context.Context, newObj interface{}, old
 		newDestinations[dIdentity] = nil
 	}
 
-	if !options.IsCacheWarmedUp() {
+	if !cache.InformerSync.IsWarmedUp() {
 		ctx.Log.Info("Cache not warmed up, skipping handling.")
 		return controller.NewEventProcessStatus().SkipClose(statusChan)
 	}
@@ -2,9 +2,7 @@ package dependencyhandler
 
 import (
 	"testing"
-	"time"
 
-	"github.com/intuit/naavik/cmd/options"
 	"github.com/intuit/naavik/internal/cache"
 	"github.com/intuit/naavik/internal/controller"
 	k8s_builder "github.com/intuit/naavik/internal/fake/builder/resource"
@@ -26,6 +24,7 @@ var _ = Describe("Test dependency handler operations", func() {
 
 	BeforeEach(func() {
 		cache.ResetAllCaches()
+		cache.InformerSync.SetWarmedUp()
 		dependenyHandler = NewDependencyHandler(Opts{})
 		ctx = context.NewContextWithLogger()
 		logMessages = []string{}
@@ -150,11 +149,7 @@ var _ = Describe("Test dependency handler operations", func() {
 
 		When("cache not warmed up", func() {
 			It("should update new dependency to cache and not trigger handlers", func() {
-				// Set the startup time to 1 minute ago and cache refresh interval to 5 minutes
-				options.StartUpTime = time.Now().Add(time.Minute * -1)
-				options.InitializeNaavikArgs(&options.NaavikArgs{
-					CacheRefreshInterval: 5 * time.Minute,
-				})
+				cache.InformerSync.Reset()
 
 				statusChan := make(chan controller.EventProcessStatus, 1)
 				olddepcy := k8s_builder.BuildFakeDependency("namespace", "soUrce", []string{"destination1", "destination2", "destination3"})
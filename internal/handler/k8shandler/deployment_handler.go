@@ -3,7 +3,6 @@ package k8shandler
 import (
 	"fmt"
 
-	"github.com/intuit/naavik/cmd/options"
 	"github.com/intuit/naavik/internal/cache"
 	"github.com/intuit/naavik/internal/controller"
 	"github.com/intuit/naavik/internal/handler"
@@ -52,7 +51,7 @@ func (d *deploymentHandler) Added(ctx context.Context, obj interface{}, statusCh
 	cache.IdentityCluster.AddClusterToIdentity(workloadIdentifier, d.clusterID)
 	cache.Deployments.Add(d.clusterID, deploy)
 
-	if !options.IsCacheWarmedUp() {
+	if !cache.InformerSync.IsWarmedUp() {
 		ctx.Log.Info("Cache not warmed up, skipping handling.")
 		return controller.NewEventProcessStatus().SkipClose(statusChan)
 	}
@@ -95,6 +94,16 @@ func (d *deploymentHandler) Updated(ctx context.Context, newObj interface{}, old
 	cache.IdentityCluster.AddClusterToIdentity(newWrkloadIdentifier, d.clusterID)
 	cache.Deployments.Add(d.clusterID, newDeploy)
 
+	// The principal of the workload is matched by the app quotas of the identities listing it as associated app
+	if cache.InformerSync.IsWarmedUp() && (newWrkloadIdentifier != oldWrkloadIdentifier ||
+		newDeploy.Spec.Template.Spec.ServiceAccountName != oldDeploy.Spec.Template.Spec.ServiceAccountName) {
+		tcHandler := traffic_config.NewTrafficConfigHandler()
+		tcHandler.TriggerAppQuotaTrafficConfigs(ctx, newWrkloadIdentifier, statusChan)
+		if len(oldWrkloadIdentifier) > 0 && newWrkloadIdentifier != oldWrkloadIdentifier {
+			tcHandler.TriggerAppQuotaTrafficConfigs(ctx, oldWrkloadIdentifier, statusChan)
+		}
+	}
+
 	return controller.NewEventProcessStatus().SkipClose(statusChan)
 }
 
@@ -119,6 +128,11 @@ func (d *deploymentHandler) Deleted(ctx context.Context, obj interface{}, status
 
 	cache.Deployments.Delete(d.clusterID, deploy)
 	cache.IdentityCluster.DeleteClusterFromIdentity(wrkloadIdentifier, d.clusterID)
+
+	// The principal and the cluster of the workload are dropped from the app quotas of the identities listing it as associated app
+	if cache.InformerSync.IsWarmedUp() {
+		traffic_config.NewTrafficConfigHandler().TriggerAppQuotaTrafficConfigs(ctx, wrkloadIdentifier, statusChan)
+	}
 	return controller.NewEventProcessStatus().SkipClose(statusChan)
 }
 
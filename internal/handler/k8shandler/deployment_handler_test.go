@@ -1,14 +1,13 @@
 package k8shandler
 
 import (
-	"time"
-
 	"github.com/intuit/naavik/cmd/options"
 	"github.com/intuit/naavik/internal/cache"
 	"github.com/intuit/naavik/internal/controller"
 	k8s_builder "github.com/intuit/naavik/internal/fake/builder/resource"
 	"github.com/intuit/naavik/internal/handler"
 	"github.com/intuit/naavik/internal/types/context"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -60,10 +59,6 @@ var _ = Describe("Test deployment handler operations", func() {
 
 		When("valid deployment added and cache not warmed up", func() {
 			It("should update deployment to cache and not trigger handler", func() {
-				options.StartUpTime = time.Now()
-				options.InitializeNaavikArgs(&options.NaavikArgs{
-					CacheRefreshInterval: 1 * time.Minute,
-				})
 				statusChan := make(chan controller.EventProcessStatus, 1)
 				deploy := k8s_builder.BuildFakeDeployment("deployment", "assetAlias", "appName", "env", "namespace")
 				deploymentHandler.Added(ctx, deploy, statusChan)
@@ -78,10 +73,7 @@ var _ = Describe("Test deployment handler operations", func() {
 
 		When("valid deployment added and cache warmed up", func() {
 			It("should update deployment to cache and trigger handler", func() {
-				options.StartUpTime = time.Now().Add(-5 * time.Minute)
-				options.InitializeNaavikArgs(&options.NaavikArgs{
-					CacheRefreshInterval: 1 * time.Second,
-				})
+				cache.InformerSync.SetWarmedUp()
 				statusChan := make(chan controller.EventProcessStatus, 1)
 				deploy := k8s_builder.BuildFakeDeployment("deployment", "assetAlias", "appName", "env", "namespace")
 				deploymentHandler.Added(ctx, deploy, statusChan)
@@ -146,6 +138,20 @@ var _ = Describe("Test deployment handler operations", func() {
 				Expect(statusChan).To(BeClosed())
 			})
 		})
+		When("valid deployment updated with new service account and cache warmed up", func() {
+			It("should trigger handler for the traffic configs listing it as associated app", func() {
+				cache.InformerSync.SetWarmedUp()
+				tc := k8s_builder.GetFakeTrafficConfig("identity", "env", "1", "namespace")
+				tc.Spec.QuotaGroup.AppQuotaGroups = []*admiralv1.AppQuotaGroup{{Name: "apps", AssociatedApps: []string{"assetAlias"}}}
+				cache.TrafficConfigCache.AddTrafficConfigToCache(tc)
+				statusChan := make(chan controller.EventProcessStatus, 10)
+				deploy := k8s_builder.BuildFakeDeployment("deployment", "assetAlias", "appName", "env", "namespace")
+				newDeploy := k8s_builder.BuildFakeDeployment("deployment", "assetAlias", "appName", "env", "namespace")
+				newDeploy.Spec.Template.Spec.ServiceAccountName = "serviceAccount"
+				deploymentHandler.Updated(ctx, newDeploy, deploy, statusChan)
+				Expect(logMessages).To(ContainElement("Triggering traffic config handler for associated app"))
+			})
+		})
 	})
 
 	Context("deployment is deleted", func() {
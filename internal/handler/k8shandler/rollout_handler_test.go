@@ -1,8 +1,6 @@
 package k8shandler
 
 import (
-	"time"
-
 	"github.com/intuit/naavik/cmd/options"
 	"github.com/intuit/naavik/internal/cache"
 	"github.com/intuit/naavik/internal/controller"
@@ -60,10 +58,6 @@ var _ = Describe("Test rollout handler operations", func() {
 
 		When("valid rollout added and cache not warmed up", func() {
 			It("should update rollout to cache and not trigger handler", func() {
-				options.StartUpTime = time.Now()
-				options.InitializeNaavikArgs(&options.NaavikArgs{
-					CacheRefreshInterval: 1 * time.Minute,
-				})
 				statusChan := make(chan controller.EventProcessStatus, 1)
 				rollout := k8s_builder.BuildFakeRollout("rollout", "assetAlias", "appName", "env", "namespace")
 				rolloutHandler.Added(ctx, rollout, statusChan)
@@ -78,10 +72,7 @@ var _ = Describe("Test rollout handler operations", func() {
 
 		When("valid rollout added and cache warmed up", func() {
 			It("should update rollout to cache and trigger handler", func() {
-				options.StartUpTime = time.Now().Add(-5 * time.Minute)
-				options.InitializeNaavikArgs(&options.NaavikArgs{
-					CacheRefreshInterval: 1 * time.Second,
-				})
+				cache.InformerSync.SetWarmedUp()
 				statusChan := make(chan controller.EventProcessStatus, 1)
 				rollout := k8s_builder.BuildFakeRollout("rollout", "assetAlias", "appName", "env", "namespace")
 				rolloutHandler.Added(ctx, rollout, statusChan)
@@ -5,7 +5,6 @@ import (
 
 	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 
-	"github.com/intuit/naavik/cmd/options"
 	"github.com/intuit/naavik/internal/cache"
 	"github.com/intuit/naavik/internal/controller"
 	"github.com/intuit/naavik/internal/handler"
@@ -54,7 +53,7 @@ func (r *rolloutHandler) Added(ctx context.Context, obj interface{}, statusChan
 	cache.IdentityCluster.AddClusterToIdentity(workloadIdentifier, r.clusterID)
 	cache.Rollouts.Add(r.clusterID, rollout)
 
-	if !options.IsCacheWarmedUp() {
+	if !cache.InformerSync.IsWarmedUp() {
 		ctx.Log.Info("Cache not warmed up, skipping handling.")
 		return controller.NewEventProcessStatus().SkipClose(statusChan)
 	}
@@ -97,6 +96,16 @@ func (r *rolloutHandler) Updated(ctx context.Context, newObj interface{}, oldObj
 	cache.IdentityCluster.AddClusterToIdentity(newWrkloadIdentifier, r.clusterID)
 	cache.Rollouts.Add(r.clusterID, newRollout)
 
+	// The principal of the workload is matched by the app quotas of the identities listing it as associated app
+	if cache.InformerSync.IsWarmedUp() && (newWrkloadIdentifier != oldWrkloadIdentifier ||
+		newRollout.Spec.Template.Spec.ServiceAccountName != oldRollout.Spec.Template.Spec.ServiceAccountName) {
+		tcHandler := traffic_config.NewTrafficConfigHandler()
+		tcHandler.TriggerAppQuotaTrafficConfigs(ctx, newWrkloadIdentifier, statusChan)
+		if len(oldWrkloadIdentifier) > 0 && newWrkloadIdentifier != oldWrkloadIdentifier {
+			tcHandler.TriggerAppQuotaTrafficConfigs(ctx, oldWrkloadIdentifier, statusChan)
+		}
+	}
+
 	return controller.NewEventProcessStatus().SkipClose(statusChan)
 }
 
@@ -121,6 +130,11 @@ func (r *rolloutHandler) Deleted(ctx context.Context, obj interface{}, statusCha
 
 	cache.Rollouts.Delete(r.clusterID, rollout)
 	cache.IdentityCluster.DeleteClusterFromIdentity(wrkloadIdentifier, r.clusterID)
+
+	// The principal and the cluster of the workload are dropped from the app quotas of the identities listing it as associated app
+	if cache.InformerSync.IsWarmedUp() {
+		traffic_config.NewTrafficConfigHandler().TriggerAppQuotaTrafficConfigs(ctx, wrkloadIdentifier, statusChan)
+	}
 	return controller.NewEventProcessStatus().SkipClose(statusChan)
 }
 
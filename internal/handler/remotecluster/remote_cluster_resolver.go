@@ -15,6 +15,7 @@ import (
 	"github.com/intuit/naavik/internal/types/remotecluster"
 	k8s_utils "github.com/intuit/naavik/internal/utils/k8s"
 	"github.com/intuit/naavik/pkg/logger"
+	"github.com/intuit/naavik/pkg/metrics"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/clientcmd"
@@ -166,6 +167,8 @@ func (rcr *remoteClusterResolver) StopRemoteClusterControllers(ctx context.Conte
 				go func(ctx context.Context, controller cache.ControllerContext) {
 					startTime := time.Now()
 					cache.ControllerCache.DeRegister(controllerName)
+					cache.InformerSync.DeRegister(controllerName)
+					metrics.DeleteControllerMetrics(controllerName)
 					ctx.Log.Str(logger.ControllerNameKey, controllerName).Infof("Stopping controller")
 					close(controllerCtx.StopCh)
 					for i, closeCtx := range controllerCtx.WorkerCtx {
@@ -0,0 +1,145 @@
+package trafficconfig
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/controller"
+	"github.com/intuit/naavik/internal/killswitch"
+	"github.com/intuit/naavik/internal/scope"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/internal/types/remotecluster"
+	"github.com/intuit/naavik/pkg/logger"
+	"google.golang.org/protobuf/types/known/structpb"
+	networkingv1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/util/retry"
+)
+
+// ApplyKillSwitch sets the local_rate_limit_enforced runtime default of the throttle filters written to the clusters in scope
+// to the kill switch state. The filters are updated in place and the clusters concurrently, so the switch applies within seconds.
+func ApplyKillSwitch(ctx context.Context) error {
+	startTime := time.Now()
+	percentage := killswitch.GetEnforcedPercentage()
+	var (
+		wg      sync.WaitGroup
+		lock    sync.Mutex
+		errs    error
+		updated int
+	)
+	for _, rc := range cache.RemoteCluster.ListClusters() {
+		if !scope.IsClusterInScope(rc.GetClusterID()) {
+			continue
+		}
+		wg.Add(1)
+		go func(rc remotecluster.RemoteCluster) {
+			defer wg.Done()
+			count, err := applyKillSwitchToCluster(ctx, rc, percentage)
+			lock.Lock()
+			defer lock.Unlock()
+			updated += count
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("cluster %s: %w", rc.GetClusterID(), err))
+			}
+		}(rc)
+	}
+	wg.Wait()
+	ctx.Log.Int("enforcedPercentage", int(percentage)).Int("updatedFilters", updated).Int(logger.TimeTakenMSKey, int(time.Since(startTime).Milliseconds())).Info("Kill switch applied")
+	return errs
+}
+
+func applyKillSwitchToCluster(ctx context.Context, rc remotecluster.RemoteCluster, percentage float64) (int, error) {
+	filterList, err := rc.IstioClient().ListEnvoyFilters(ctx, types.NamespaceIstioSystem, metav1.ListOptions{LabelSelector: throttleFiltersSelector().String()})
+	if err != nil {
+		return 0, err
+	}
+	var errs error
+	updated := 0
+	for _, envoyFilter := range filterList.Items {
+		if !setFilterEnforced(envoyFilter, percentage) {
+			continue
+		}
+		changed, err := applyKillSwitchToFilter(ctx, rc, envoyFilter.Name, envoyFilter.Labels[types.CreatedForKey], percentage)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		if changed {
+			updated++
+		}
+	}
+	return updated, errs
+}
+
+// applyKillSwitchToFilter updates the filter while holding the lock of the identity it was created for, so it does not race with
+// the reconciles of the identity. The filter is read again on each attempt, it returns false if the filter is unchanged or was deleted.
+func applyKillSwitchToFilter(ctx context.Context, rc remotecluster.RemoteCluster, name string, identity string, percentage float64) (bool, error) {
+	unlock := controller.IdentityMutex.Lock(identity)
+	defer unlock()
+	changed := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		envoyFilter, err := rc.IstioClient().GetEnvoyFilter(ctx, name, types.NamespaceIstioSystem, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		changed = setFilterEnforced(envoyFilter, percentage)
+		if !changed {
+			return nil
+		}
+		_, err = rc.IstioClient().UpdateEnvoyFilter(ctx, envoyFilter, metav1.UpdateOptions{})
+		return err
+	})
+	if k8serrors.IsNotFound(err) {
+		return false, nil
+	}
+	return changed && err == nil, err
+}
+
+// throttleFiltersSelector selects the inbound and outbound throttle filters written by naavik.
+func throttleFiltersSelector() labels.Selector {
+	// The requirement of constant valid values cannot fail
+	typeRequirement, _ := labels.NewRequirement(types.CreatedTypeKey, selection.In, []string{throttleFilterType, outboundThrottleFilterType})
+	return labels.SelectorFromSet(labels.Set{types.CreatedByKey: types.NaavikName}).Add(*typeRequirement)
+}
+
+// setFilterEnforced sets the filter_enforced default of the local rate limit filters of the route patches, it returns false if the filter is unchanged.
+func setFilterEnforced(envoyFilter *networkingv1alpha3.EnvoyFilter, percentage float64) bool {
+	changed := false
+	for _, patch := range envoyFilter.Spec.ConfigPatches {
+		if patch.GetPatch().GetValue() == nil {
+			continue
+		}
+		filterConfigs := getStructField(patch.GetPatch().GetValue(), "typed_per_filter_config")
+		for filterName := range filterConfigs.GetFields() {
+			numerator := getStructField(filterConfigs, filterName, "value", "filter_enforced", "default_value")
+			if numerator == nil {
+				continue
+			}
+			if value, found := numerator.Fields["numerator"]; found && value.GetNumberValue() == percentage {
+				continue
+			}
+			numerator.Fields["numerator"] = structpb.NewNumberValue(percentage)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// getStructField returns the nested struct at the path, nil if missing.
+func getStructField(value *structpb.Struct, path ...string) *structpb.Struct {
+	for _, name := range path {
+		field, found := value.GetFields()[name]
+		if !found || field.GetStructValue() == nil {
+			return nil
+		}
+		value = field.GetStructValue()
+	}
+	return value
+}
@@ -0,0 +1,69 @@
+package trafficconfig
+
+import (
+	fakeargoclientset "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/controller"
+	resourcebuilder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/killswitch"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/internal/types/remotecluster"
+	fakeadmiralclientset "github.com/istio-ecosystem/admiral-api/pkg/client/clientset/versioned/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	fakeistioclientset "istio.io/client-go/pkg/clientset/versioned/fake"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Test kill switch", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		killswitch.Reset()
+		ctx = context.NewContextWithLogger()
+	})
+
+	AfterEach(func() {
+		cache.ResetAllCaches()
+		killswitch.Reset()
+	})
+
+	It("should retry the update of a filter written concurrently", func() {
+		istioClient := fakeistioclientset.NewSimpleClientset()
+		rc := remotecluster.CreateRemoteCluster("cluster1", "cluster1", "cluster1", "cluster1", nil, k8sfake.NewSimpleClientset(),
+			istioClient, fakeargoclientset.NewSimpleClientset(), fakeadmiralclientset.NewSimpleClientset())
+		cache.RemoteCluster.AddCluster(rc)
+		addWorkload("cluster1", "identity1", "qa")
+		HandleRateLimiter(ctx, resourcebuilder.GetFakeTrafficConfig("identity1", "qa", "1", "namespace"), types.Add)
+		filterNames := listEnvoyFilterNames(rc)
+		Expect(filterNames).NotTo(BeEmpty())
+
+		conflicts := 0
+		istioClient.PrependReactor("update", "envoyfilters", func(k8stesting.Action) (bool, runtime.Object, error) {
+			if conflicts > 0 {
+				return false, nil, nil
+			}
+			conflicts++
+			return true, nil, k8serrors.NewConflict(schema.GroupResource{Resource: "envoyfilters"}, filterNames[0], nil)
+		})
+
+		killswitch.SetState(killswitch.State{Engaged: true})
+		Expect(ApplyKillSwitch(ctx)).To(Succeed())
+		Expect(conflicts).To(Equal(1))
+		for _, name := range filterNames {
+			envoyFilter, err := rc.IstioClient().GetEnvoyFilter(ctx, name, types.NamespaceIstioSystem, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(setFilterEnforced(envoyFilter, killswitch.NotEnforcedPercentage)).To(BeFalse())
+		}
+		Expect(controller.IdentityMutex.Len()).To(BeZero())
+	})
+})
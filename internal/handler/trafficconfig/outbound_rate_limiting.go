@@ -0,0 +1,266 @@
+package trafficconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/proxyversion"
+	"github.com/intuit/naavik/internal/quotaenforcement"
+	"github.com/intuit/naavik/internal/scope"
+	"github.com/intuit/naavik/internal/tokenbucket"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/logger"
+	"github.com/intuit/naavik/pkg/utils"
+	"google.golang.org/protobuf/types/known/structpb"
+	"istio.io/api/networking/v1alpha3"
+	networkingv1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// outboundThrottleFilterType is the created type label of the throttle filters on the outbound sidecars of the associated apps.
+const outboundThrottleFilterType = "outbound_throttle_filter"
+
+// meshPort is the port of the mesh hosts, the outbound virtual hosts of the sidecars are named host:port.
+const meshPort = 80
+
+// getRateLimitingClusters returns the clusters of the identity followed by the other clusters of the associated apps of its app quota groups.
+// The clusters of the associated apps are returned whatever the enforcement, so their outbound filters are deleted once it is inbound only.
+func getRateLimitingClusters(tcUtil utils.TrafficConfigInterface) []string {
+	clusters := cache.IdentityCluster.GetClustersForIdentity(tcUtil.GetIdentity())
+	seen := make(map[string]bool, len(clusters))
+	for _, clusterID := range clusters {
+		seen[clusterID] = true
+	}
+	for _, associatedApp := range getAssociatedApps(tcUtil) {
+		appClusters := cache.IdentityCluster.GetClustersForIdentity(associatedApp)
+		sort.Strings(appClusters)
+		for _, clusterID := range appClusters {
+			if !seen[clusterID] {
+				seen[clusterID] = true
+				clusters = append(clusters, clusterID)
+			}
+		}
+	}
+	return clusters
+}
+
+// getThrottleFilterClusters returns the rate limiting clusters of the traffic config followed by the other clusters indexed as holding its
+// outbound throttle filters, e.g. the clusters of an associated app removed from the app quota groups, so the filters no longer built are deleted.
+func getThrottleFilterClusters(tcUtil utils.TrafficConfigInterface) []string {
+	clusters := getRateLimitingClusters(tcUtil)
+	for _, clusterID := range cache.OutboundFilters.GetClusters(tcUtil.GetIdentity(), tcUtil.GetEnv()) {
+		if !contains(clusters, clusterID) {
+			clusters = append(clusters, clusterID)
+		}
+	}
+	return clusters
+}
+
+// IndexOutboundThrottleFilters indexes the clusters holding outbound throttle filters with one list per cluster in scope,
+// so the filters written before a restart or by the previous leader are found when their traffic config is reconciled.
+func IndexOutboundThrottleFilters(ctx context.Context) {
+	selector := labels.Set{types.CreatedByKey: types.NaavikName, types.CreatedTypeKey: outboundThrottleFilterType}
+	for _, rc := range cache.RemoteCluster.ListClusters() {
+		if !scope.IsClusterInScope(rc.GetClusterID()) {
+			continue
+		}
+		filterList, err := rc.IstioClient().ListEnvoyFilters(ctx, types.NamespaceIstioSystem, metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			ctx.Log.Str(logger.ClusterKey, rc.GetClusterID()).Str(logger.ErrorKey, err.Error()).Warn("failed to list outbound envoy filters")
+			continue
+		}
+		for _, envoyFilter := range filterList.Items {
+			cache.OutboundFilters.SetCluster(envoyFilter.Labels[types.CreatedForKey], envoyFilter.Labels[types.CreatedForTrafficEnvKey], rc.GetClusterID(), true)
+		}
+	}
+}
+
+// hasOutboundThrottleFilter returns true if one of the filters is an outbound throttle filter.
+func hasOutboundThrottleFilter(envoyFilters []*networkingv1alpha3.EnvoyFilter) bool {
+	for _, envoyFilter := range envoyFilters {
+		if envoyFilter.Labels[types.CreatedTypeKey] == outboundThrottleFilterType {
+			return true
+		}
+	}
+	return false
+}
+
+// getAssociatedApps returns the sorted associated apps of the app quota groups of the traffic config.
+func getAssociatedApps(tcUtil utils.TrafficConfigInterface) []string {
+	if tcUtil.GetQuotaGroup() == nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	apps := []string{}
+	for _, aqg := range tcUtil.GetQuotaGroup().AppQuotaGroups {
+		for _, associatedApp := range aqg.AssociatedApps {
+			if !seen[associatedApp] {
+				seen[associatedApp] = true
+				apps = append(apps, associatedApp)
+			}
+		}
+	}
+	sort.Strings(apps)
+	return apps
+}
+
+// buildOutboundRateLimitingFilters builds the throttle filters enforcing the app quota groups on the outbound sidecars of the associated apps
+// running in the cluster, one per associated app workload env and envoy filter version. The excess requests are rejected by the client sidecar
+// before reaching the identity, each sidecar of the associated app gets the whole quota.
+func buildOutboundRateLimitingFilters(ctx context.Context, clusterID string, tcUtil utils.TrafficConfigInterface) ([]*networkingv1alpha3.EnvoyFilter, error) {
+	newList := make([]*networkingv1alpha3.EnvoyFilter, 0)
+	if !quotaenforcement.Get(tcUtil.GetTrafficConfig()).IsOutbound() {
+		return newList, nil
+	}
+
+	resolver := newQuotaResolver(ctx, tcUtil.GetTrafficConfig())
+	for _, associatedApp := range getAssociatedApps(tcUtil) {
+		if !cache.IdentityCluster.IsClusterPresentInIdentity(associatedApp, clusterID) {
+			continue
+		}
+		routePatches, err := createOutboundRoutePatches(ctx, resolver, associatedApp, tcUtil)
+		if err != nil {
+			return nil, err
+		}
+		if len(routePatches) == 0 {
+			continue
+		}
+
+		for _, appEnv := range getClusterWorkloadEnvs(clusterID, associatedApp) {
+			workloadLabels, err := getWorkLoadLabels(ctx, clusterID, associatedApp, appEnv)
+			if err != nil {
+				ctx.Log.Str(logger.ClusterKey, clusterID).Warnf("skipping, %s", err.Error())
+				continue
+			}
+
+			for _, version := range proxyversion.ForCluster(clusterID) {
+				envoyFilterName := utils.EnvoyFilterUtil().GetName(tcUtil.GetIdentity(), "throttle-outbound", associatedApp+"-"+appEnv+"-"+version)
+				patches := []*v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{
+					createFilterPatch(v1alpha3.EnvoyFilter_SIDECAR_OUTBOUND, version, getOutboundRateLimitFilterName(tcUtil.GetIdentity())),
+				}
+
+				envoyFilter := &networkingv1alpha3.EnvoyFilter{
+					TypeMeta: metav1.TypeMeta{
+						Kind:       "EnvoyFilter",
+						APIVersion: "networking.istio.io/v1alpha3",
+					},
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      envoyFilterName,
+						Namespace: types.NamespaceIstioSystem,
+						Annotations: map[string]string{
+							types.RevisionNumberKey: tcUtil.GetRevision(),
+							types.TransactionIDKey:  tcUtil.GetTransactionID(),
+						},
+						Labels: map[string]string{
+							types.CreatedForKey:           strings.ToLower(tcUtil.GetIdentity()),
+							types.CreatedByKey:            types.NaavikName,
+							types.CreatedForEnvKey:        appEnv,
+							types.CreatedForAppKey:        strings.ToLower(associatedApp),
+							types.CreatedTypeKey:          outboundThrottleFilterType,
+							types.CreatedForTrafficEnvKey: tcUtil.GetEnv(),
+						},
+					},
+					Spec: v1alpha3.EnvoyFilter{
+						Priority:         0,
+						WorkloadSelector: &v1alpha3.WorkloadSelector{Labels: workloadLabels},
+						ConfigPatches:    append(patches, routePatches...),
+					},
+				}
+
+				newList = append(newList, envoyFilter)
+			}
+		}
+	}
+	return newList, nil
+}
+
+// createOutboundRoutePatches creates the route patches of the mesh hosts of the identity, one per workload env of the app quota groups
+// of the associated app. The requests of the sidecar are all sent by the associated app, the descriptors are not keyed by app.
+func createOutboundRoutePatches(ctx context.Context, resolver *quotaResolver, associatedApp string, tcUtil utils.TrafficConfigInterface) ([]*v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch, error) {
+	envs := []string{}
+	for _, aqg := range tcUtil.GetQuotaGroup().AppQuotaGroups {
+		if !contains(aqg.AssociatedApps, associatedApp) {
+			continue
+		}
+		for _, env := range aqg.WorkloadEnvSelectors {
+			if !contains(envs, env) {
+				envs = append(envs, env)
+			}
+		}
+	}
+	sort.Strings(envs)
+
+	routePatches := []*v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{}
+	for _, env := range envs {
+		rateLimits := &structpb.ListValue{}
+		descriptors := &structpb.ListValue{}
+		for _, aqg := range tcUtil.GetQuotaGroup().AppQuotaGroups {
+			if !contains(aqg.AssociatedApps, associatedApp) || !contains(aqg.WorkloadEnvSelectors, env) {
+				continue
+			}
+			for _, quota := range aqg.Quotas {
+				if err := resolver.appendQuota(ctx, rateLimits, descriptors, aqg.Name, "", quota); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if len(descriptors.Values) == 0 {
+			continue
+		}
+
+		// The vhost bucket of the traffic config is the capacity of the identity, the client sidecars use the vhost flags
+		routePatches = append(routePatches, &v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{
+			ApplyTo: v1alpha3.EnvoyFilter_HTTP_ROUTE,
+			Match:   createOutboundRouteMatch(types.GetHost(env, tcUtil.GetIdentityLowerCase(), options.GetHostnameSuffix())),
+			Patch:   createRoutePatch(getOutboundRateLimitFilterName(tcUtil.GetIdentity()), rateLimits, descriptors, tokenbucket.DefaultVhost()),
+		})
+	}
+	return routePatches, nil
+}
+
+func createOutboundRouteMatch(host string) *v1alpha3.EnvoyFilter_EnvoyConfigObjectMatch {
+	return &v1alpha3.EnvoyFilter_EnvoyConfigObjectMatch{
+		Context: v1alpha3.EnvoyFilter_SIDECAR_OUTBOUND,
+		ObjectTypes: &v1alpha3.EnvoyFilter_EnvoyConfigObjectMatch_RouteConfiguration{
+			RouteConfiguration: &v1alpha3.EnvoyFilter_RouteConfigurationMatch{
+				PortNumber: meshPort,
+				Vhost: &v1alpha3.EnvoyFilter_RouteConfigurationMatch_VirtualHostMatch{
+					Name: fmt.Sprintf("%s:%d", host, meshPort),
+					Route: &v1alpha3.EnvoyFilter_RouteConfigurationMatch_RouteMatch{
+						Action: v1alpha3.EnvoyFilter_RouteConfigurationMatch_RouteMatch_ANY,
+					},
+				},
+			},
+		},
+	}
+}
+
+// getOutboundRateLimitFilterName returns the name of the local rate limit filter of the identity on the outbound sidecars.
+// A sidecar may call several identities, each one gets its own filter, configured on the routes of the identity only.
+func getOutboundRateLimitFilterName(identity string) string {
+	return "naavik.outbound_ratelimit." + strings.ToLower(identity)
+}
+
+// getClusterWorkloadEnvs returns the sorted envs of the deployments and rollouts of the identity in the cluster.
+func getClusterWorkloadEnvs(clusterID string, identity string) []string {
+	envs := []string{}
+	if entry := cache.Deployments.GetByClusterIdentity(clusterID, identity); entry != nil {
+		for env := range entry.Deployments {
+			envs = append(envs, env)
+		}
+	}
+	if entry := cache.Rollouts.GetByClusterIdentity(clusterID, identity); options.IsArgoRolloutsEnabled() && entry != nil {
+		for env := range entry.Rollouts {
+			if !contains(envs, env) {
+				envs = append(envs, env)
+			}
+		}
+	}
+	sort.Strings(envs)
+	return envs
+}
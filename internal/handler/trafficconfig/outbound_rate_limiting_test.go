@@ -0,0 +1,103 @@
+package trafficconfig
+
+import (
+	fakeargoclientset "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	resourcebuilder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/quotaenforcement"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/internal/types/remotecluster"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	fakeadmiralclientset "github.com/istio-ecosystem/admiral-api/pkg/client/clientset/versioned/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	fakeistioclientset "istio.io/client-go/pkg/clientset/versioned/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+// getOutboundTrafficConfig returns a traffic config of identity1 enforcing an app quota group of the associated apps on their sidecars.
+func getOutboundTrafficConfig(associatedApps ...string) *admiralv1.TrafficConfig {
+	tc := resourcebuilder.GetFakeTrafficConfigWithAnnotations("identity1", "env", map[string]string{quotaenforcement.EnforcementKey: types.EnforceOutbound})
+	tc.Spec.QuotaGroup.AppQuotaGroups = []*admiralv1.AppQuotaGroup{{
+		Name:                 "apps",
+		AssociatedApps:       associatedApps,
+		WorkloadEnvSelectors: []string{"env"},
+		Quotas:               []*admiralv1.Quota{{Name: "app", MaxAmount: 10, TimePeriod: "1s", Rule: "/*"}},
+	}}
+	return tc
+}
+
+var _ = Describe("Test outbound throttle filters", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		ctx = context.NewContextWithLogger()
+	})
+
+	AfterEach(func() {
+		cache.ResetAllCaches()
+	})
+
+	It("should delete the outbound filters of an associated app removed from the app quota groups", func() {
+		rc1 := addRemoteCluster("cluster1")
+		rc2 := addRemoteCluster("cluster2")
+		addWorkload("cluster1", "identity1", "env")
+		addWorkload("cluster1", "app1", "env")
+		addWorkload("cluster2", "app2", "env")
+
+		HandleRateLimiter(ctx, getOutboundTrafficConfig("app1", "app2"), types.Add)
+		Expect(listEnvoyFilterNames(rc1)).To(HaveLen(2))
+		Expect(listEnvoyFilterNames(rc2)).To(HaveLen(1))
+
+		HandleRateLimiter(ctx, getOutboundTrafficConfig("app1"), types.Update)
+		Expect(listEnvoyFilterNames(rc1)).To(HaveLen(2))
+		Expect(listEnvoyFilterNames(rc2)).To(BeEmpty())
+	})
+
+	It("should delete the outbound filters of the removed associated apps with the traffic config", func() {
+		rc2 := addRemoteCluster("cluster2")
+		addRemoteCluster("cluster1")
+		addWorkload("cluster1", "identity1", "env")
+		addWorkload("cluster2", "app2", "env")
+
+		HandleRateLimiter(ctx, getOutboundTrafficConfig("app2"), types.Add)
+		Expect(listEnvoyFilterNames(rc2)).To(HaveLen(1))
+
+		HandleRateLimiter(ctx, getOutboundTrafficConfig(), types.Delete)
+		Expect(listEnvoyFilterNames(rc2)).To(BeEmpty())
+	})
+
+	It("should not list the envoy filters of other clusters for a traffic config without outbound quotas", func() {
+		addRemoteCluster("cluster1")
+		addWorkload("cluster1", "identity1", "env")
+		istioClient := fakeistioclientset.NewSimpleClientset()
+		cache.RemoteCluster.AddCluster(remotecluster.CreateRemoteCluster("cluster2", "cluster2", "cluster2", "cluster2", nil, k8sfake.NewSimpleClientset(),
+			istioClient, fakeargoclientset.NewSimpleClientset(), fakeadmiralclientset.NewSimpleClientset()))
+
+		HandleRateLimiter(ctx, resourcebuilder.GetFakeTrafficConfig("identity1", "env", "1", "admiral"), types.Add)
+		HandleRateLimiter(ctx, resourcebuilder.GetFakeTrafficConfig("identity1", "env", "2", "admiral"), types.Update)
+		Expect(istioClient.Actions()).To(BeEmpty())
+	})
+
+	It("should delete the outbound filters written before a restart once the clusters are indexed", func() {
+		rc2 := addRemoteCluster("cluster2")
+		addRemoteCluster("cluster1")
+		addWorkload("cluster1", "identity1", "env")
+		addWorkload("cluster2", "app2", "env")
+
+		HandleRateLimiter(ctx, getOutboundTrafficConfig("app2"), types.Add)
+		Expect(listEnvoyFilterNames(rc2)).To(HaveLen(1))
+
+		cache.OutboundFilters.Reset()
+		IndexOutboundThrottleFilters(ctx)
+		Expect(cache.OutboundFilters.GetClusters("identity1", "env")).To(ConsistOf("cluster2"))
+
+		HandleRateLimiter(ctx, getOutboundTrafficConfig(), types.Update)
+		Expect(listEnvoyFilterNames(rc2)).To(BeEmpty())
+		Expect(cache.OutboundFilters.GetClusters("identity1", "env")).To(BeEmpty())
+	})
+})
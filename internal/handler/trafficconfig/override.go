@@ -0,0 +1,21 @@
+package trafficconfig
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/intuit/naavik/internal/override"
+	"github.com/intuit/naavik/internal/utils/clock"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+)
+
+// applyOverrides returns the traffic config with its active overrides applied, and a warning per active override
+// so the render and preview show why the resources differ from the traffic config.
+func applyOverrides(trafficConfig *admiralv1.TrafficConfig) (*admiralv1.TrafficConfig, []string) {
+	trafficConfig, active := override.Apply(trafficConfig, clock.Get().Now())
+	warnings := make([]string, 0, len(active))
+	for _, activeOverride := range active {
+		warnings = append(warnings, fmt.Sprintf("override %s is applied until %s", activeOverride.Name, activeOverride.ExpiresAt.Format(time.RFC3339)))
+	}
+	return trafficConfig, warnings
+}
@@ -0,0 +1,279 @@
+package trafficconfig
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/featuregate"
+	"github.com/intuit/naavik/internal/scope"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/internal/types/remotecluster"
+	"github.com/intuit/naavik/internal/validation"
+	"github.com/intuit/naavik/pkg/metrics"
+	pkgtypes "github.com/intuit/naavik/pkg/types"
+	"github.com/intuit/naavik/pkg/utils"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	"github.com/pmezard/go-difflib/difflib"
+	networkingv1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	PreviewActionCreate    = "create"
+	PreviewActionUpdate    = "update"
+	PreviewActionDelete    = "delete"
+	PreviewActionUnchanged = "unchanged"
+)
+
+// Preview is what applying a traffic config would write, per target cluster.
+type Preview struct {
+	Identity   string                     `json:"identity"`
+	Env        string                     `json:"env"`
+	Revision   string                     `json:"revision"`
+	Clusters   map[string]*ClusterPreview `json:"clusters"` // map[cluster]*ClusterPreview
+	Warnings   []string                   `json:"warnings,omitempty"`
+	Validation *validation.Result         `json:"validation"`
+}
+
+// ClusterPreview is the resources rendered for a cluster.
+type ClusterPreview struct {
+	Resources []*ResourcePreview `json:"resources"`
+	Errors    []string           `json:"errors,omitempty"`
+}
+
+// ResourcePreview is a rendered resource and its diff against the deployed resource.
+type ResourcePreview struct {
+	Kind      string      `json:"kind"`
+	Name      string      `json:"name"`
+	Namespace string      `json:"namespace"`
+	Action    string      `json:"action"`
+	Object    interface{} `json:"object,omitempty"`
+	Diff      string      `json:"diff,omitempty"`
+}
+
+// previewObject is the part of a resource compared with the deployed resource.
+type previewObject struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Spec        interface{}       `json:"spec"`
+}
+
+func (p *Preview) cluster(clusterID string) *ClusterPreview {
+	clusterPreview, found := p.Clusters[clusterID]
+	if !found {
+		clusterPreview = &ClusterPreview{Resources: make([]*ResourcePreview, 0)}
+		p.Clusters[clusterID] = clusterPreview
+	}
+	return clusterPreview
+}
+
+// PreviewTrafficConfig renders the throttle filters and the virtual service of the traffic config against the caches, without writing anything.
+// The rendered resources are compared with the resources deployed in each target cluster.
+func PreviewTrafficConfig(ctx context.Context, trafficConfig *admiralv1.TrafficConfig) (*Preview, error) {
+	tcUtil := utils.TrafficConfigUtil(trafficConfig)
+	if len(tcUtil.GetIdentity()) == 0 {
+		return nil, errors.New("no identity present in traffic config")
+	}
+	if len(tcUtil.GetEnv()) == 0 {
+		return nil, errors.New("no env present in traffic config")
+	}
+	validationResult := validation.ValidateTrafficConfig(trafficConfig)
+	trafficConfig, warnings := applyOverrides(trafficConfig)
+	tcUtil = utils.TrafficConfigUtil(trafficConfig)
+	preview := &Preview{
+		Identity:   tcUtil.GetIdentity(),
+		Env:        tcUtil.GetEnv(),
+		Revision:   tcUtil.GetRevision(),
+		Clusters:   make(map[string]*ClusterPreview),
+		Warnings:   warnings,
+		Validation: validationResult,
+	}
+	if !scope.IsAssetInScope(tcUtil.GetIdentity()) {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("asset %s is not in scope, no resources written", tcUtil.GetIdentity()))
+		return preview, nil
+	}
+
+	if isFeatureHandled(types.FeatureThrottleFilter, tcUtil) {
+		clusters := getThrottleFilterClusters(tcUtil)
+		if len(clusters) == 0 {
+			preview.Warnings = append(preview.Warnings, "no clusters found for identity and its associated apps, no throttle filters rendered")
+		}
+		for _, clusterID := range clusters {
+			rc, enabled := getPreviewCluster(preview, tcUtil, types.FeatureThrottleFilter, clusterID)
+			if rc != nil {
+				previewRateLimitingFilters(ctx, rc, tcUtil, enabled, preview.cluster(clusterID))
+			}
+		}
+	}
+
+	if isFeatureHandled(types.FeatureVirtualService, tcUtil) && tcUtil.GetEdgeService() != nil {
+		dependentClusters := getDependentClusters(ctx, cache.IdentityDependency.GetDependentsForIdentity(tcUtil.GetIdentity()))
+		if len(dependentClusters) == 0 {
+			preview.Warnings = append(preview.Warnings, "no dependent clusters found, no virtual service rendered")
+			return preview, nil
+		}
+		vs, err := buildVirtualServiceForMeshDependents(ctx, tcUtil)
+		if err != nil {
+			return nil, fmt.Errorf("error constructing virtual service: %w", err)
+		}
+		if len(vs.Name) == 0 {
+			preview.Warnings = append(preview.Warnings, fmt.Sprintf("no virtual service name for env %s, no virtual service rendered", tcUtil.GetEnv()))
+			return preview, nil
+		}
+		clusterIDs := make([]string, 0, len(dependentClusters))
+		for clusterID := range dependentClusters {
+			clusterIDs = append(clusterIDs, clusterID)
+		}
+		sort.Strings(clusterIDs)
+		for _, clusterID := range clusterIDs {
+			rc, enabled := getPreviewCluster(preview, tcUtil, types.FeatureVirtualService, clusterID)
+			if rc != nil {
+				previewVirtualService(ctx, rc, vs.DeepCopy(), tcUtil, enabled, preview.cluster(clusterID))
+			}
+		}
+	}
+	return preview, nil
+}
+
+// getPreviewCluster returns the remote cluster and whether the feature is enabled in it, clusters not in the allowed scope or not found
+// are added to the warnings. Clusters with the feature disabled are also added to the warnings, their resources are previewed as deleted.
+func getPreviewCluster(preview *Preview, tcUtil utils.TrafficConfigInterface, feature types.FeatureName, clusterID string) (remotecluster.RemoteCluster, bool) {
+	if !scope.IsClusterInScope(clusterID) {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("cluster %s is not in allowed scope, skipped", clusterID))
+		return nil, false
+	}
+	rc, found := cache.RemoteCluster.GetCluster(clusterID)
+	if !found {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("cluster %s not found, skipped", clusterID))
+		return nil, false
+	}
+	if !featuregate.IsEnabled(feature, tcUtil.GetIdentity(), tcUtil.GetEnv(), clusterID) {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("%s feature is disabled in cluster %s, its resources are deleted", feature, clusterID))
+		return rc, false
+	}
+	return rc, true
+}
+
+func previewRateLimitingFilters(ctx context.Context, rc remotecluster.RemoteCluster, tcUtil utils.TrafficConfigInterface, featureEnabled bool, clusterPreview *ClusterPreview) {
+	existingList, err := listRateLimitingFilters(ctx, rc, tcUtil)
+	if err != nil {
+		clusterPreview.Errors = append(clusterPreview.Errors, fmt.Sprintf("error listing envoy filters: %s", err.Error()))
+		return
+	}
+	existingFilters := make(map[string]*networkingv1alpha3.EnvoyFilter, len(existingList.Items))
+	for _, existingFilter := range existingList.Items {
+		existingFilters[existingFilter.Name] = existingFilter
+	}
+
+	if featureEnabled && !tcUtil.IsDisabled() {
+		envoyFilters, err := buildRateLimitingFilters(ctx, rc.GetClusterID(), tcUtil)
+		if err != nil {
+			clusterPreview.Errors = append(clusterPreview.Errors, fmt.Sprintf("error building envoy filters: %s", err.Error()))
+			return
+		}
+		for _, envoyFilter := range envoyFilters {
+			existingFilter, found := existingFilters[envoyFilter.Name]
+			delete(existingFilters, envoyFilter.Name)
+			var existing *metav1.ObjectMeta
+			var existingSpec interface{}
+			if found {
+				existing, existingSpec = &existingFilter.ObjectMeta, &existingFilter.Spec
+			}
+			clusterPreview.add(metrics.KindEnvoyFilter, envoyFilter, &envoyFilter.ObjectMeta, &envoyFilter.Spec, existing, existingSpec)
+		}
+	}
+
+	names := make([]string, 0, len(existingFilters))
+	for name := range existingFilters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		existingFilter := existingFilters[name]
+		clusterPreview.add(metrics.KindEnvoyFilter, nil, nil, nil, &existingFilter.ObjectMeta, &existingFilter.Spec)
+	}
+}
+
+func previewVirtualService(ctx context.Context, rc remotecluster.RemoteCluster, vs *networkingv1alpha3.VirtualService, tcUtil utils.TrafficConfigInterface, featureEnabled bool, clusterPreview *ClusterPreview) {
+	existingVs, err := rc.IstioClient().GetVirtualService(ctx, vs.Name, options.GetSyncNamespace(), metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		clusterPreview.Errors = append(clusterPreview.Errors, fmt.Sprintf("error getting virtual service %s: %s", vs.Name, err.Error()))
+		return
+	}
+	var existing *metav1.ObjectMeta
+	var existingSpec interface{}
+	if err == nil && existingVs != nil {
+		existing, existingSpec = &existingVs.ObjectMeta, &existingVs.Spec
+	}
+	if !featureEnabled || tcUtil.IsDisabled() {
+		if existing != nil {
+			clusterPreview.add(metrics.KindVirtualService, nil, nil, nil, existing, existingSpec)
+		}
+		return
+	}
+	clusterPreview.add(metrics.KindVirtualService, vs, &vs.ObjectMeta, &vs.Spec, existing, existingSpec)
+}
+
+// add adds the resource with the action to get from the existing to the rendered resource.
+// The resource is deleted when nothing is rendered, created when nothing exists.
+func (cp *ClusterPreview) add(kind string, object interface{}, rendered *metav1.ObjectMeta, renderedSpec interface{}, existing *metav1.ObjectMeta, existingSpec interface{}) {
+	resource := &ResourcePreview{Kind: kind, Object: object}
+	var from, to string
+	var err error
+	if existing != nil {
+		resource.Name, resource.Namespace = existing.Name, existing.Namespace
+		if from, err = toPreviewYAML(existing, existingSpec); err != nil {
+			cp.Errors = append(cp.Errors, fmt.Sprintf("error rendering deployed %s %s: %s", kind, existing.Name, err.Error()))
+			return
+		}
+	}
+	if rendered != nil {
+		resource.Name, resource.Namespace = rendered.Name, rendered.Namespace
+		if to, err = toPreviewYAML(rendered, renderedSpec); err != nil {
+			cp.Errors = append(cp.Errors, fmt.Sprintf("error rendering %s %s: %s", kind, rendered.Name, err.Error()))
+			return
+		}
+	}
+	switch {
+	case rendered == nil:
+		resource.Action = PreviewActionDelete
+	case existing == nil:
+		resource.Action = PreviewActionCreate
+	case from == to:
+		resource.Action = PreviewActionUnchanged
+	default:
+		resource.Action = PreviewActionUpdate
+	}
+	if from != to {
+		resource.Diff, _ = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(from),
+			B:        difflib.SplitLines(to),
+			FromFile: "deployed",
+			ToFile:   "rendered",
+			Context:  3,
+		})
+	}
+	cp.Resources = append(cp.Resources, resource)
+}
+
+// toPreviewYAML renders the compared part of a resource, the annotations set by the istio client on write are left out.
+func toPreviewYAML(objectMeta *metav1.ObjectMeta, spec interface{}) (string, error) {
+	annotations := make(map[string]string, len(objectMeta.Annotations))
+	for key, value := range objectMeta.Annotations {
+		if key != pkgtypes.LastUpdatedTimestampKey {
+			annotations[key] = value
+		}
+	}
+	content, err := yaml.Marshal(previewObject{
+		Labels:      objectMeta.Labels,
+		Annotations: annotations,
+		Spec:        spec,
+	})
+	return string(content), err
+}
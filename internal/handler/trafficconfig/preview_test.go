@@ -0,0 +1,157 @@
+package trafficconfig
+
+import (
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	resourcebuilder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/featuregate"
+	"github.com/intuit/naavik/internal/scope"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/internal/types/remotecluster"
+	"github.com/intuit/naavik/pkg/metrics"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// getPreviewActions returns the actions of the previewed resources of the kind in the cluster, by resource name.
+func getPreviewActions(preview *Preview, clusterID string, kind string) map[string]string {
+	actions := map[string]string{}
+	clusterPreview, found := preview.Clusters[clusterID]
+	if !found {
+		return actions
+	}
+	for _, resource := range clusterPreview.Resources {
+		if resource.Kind == kind {
+			actions[resource.Name] = resource.Action
+		}
+	}
+	return actions
+}
+
+var _ = Describe("Test traffic config preview", func() {
+	var (
+		ctx context.Context
+		rc  remotecluster.RemoteCluster
+		tc  *admiralv1.TrafficConfig
+	)
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		featuregate.Reset()
+		scope.Reset()
+		ctx = context.NewContextWithLogger()
+		rc = addRemoteCluster("cluster1")
+		addWorkload("cluster1", "identity1", "qa")
+		cache.IdentityDependency.AddDependentToIdentity("identity1", "dependent1")
+		cache.IdentityCluster.AddClusterToIdentity("dependent1", "cluster1")
+		tc = resourcebuilder.GetFakeTrafficConfig("identity1", "qa", "1", "namespace")
+	})
+
+	AfterEach(func() {
+		cache.ResetAllCaches()
+		featuregate.Reset()
+		scope.Reset()
+	})
+
+	It("should preview the resources to create without writing them", func() {
+		preview, err := PreviewTrafficConfig(ctx, tc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(preview.Warnings).To(BeEmpty())
+		filterActions := getPreviewActions(preview, "cluster1", metrics.KindEnvoyFilter)
+		Expect(filterActions).NotTo(BeEmpty())
+		for _, action := range filterActions {
+			Expect(action).To(Equal(PreviewActionCreate))
+		}
+		vsName := getVirtualServiceName("qa", "identity1")
+		Expect(getPreviewActions(preview, "cluster1", metrics.KindVirtualService)).To(Equal(map[string]string{vsName: PreviewActionCreate}))
+		Expect(listEnvoyFilterNames(rc)).To(BeEmpty())
+	})
+
+	It("should preview the unchanged and the updated resources with their diff", func() {
+		HandleRateLimiter(ctx, tc, types.Add)
+		HandleVirtualServiceForTrafficConfig(ctx, tc, nil)
+
+		preview, err := PreviewTrafficConfig(ctx, tc)
+		Expect(err).NotTo(HaveOccurred())
+		for _, resource := range preview.Clusters["cluster1"].Resources {
+			Expect(resource.Action).To(Equal(PreviewActionUnchanged), resource.Name)
+			Expect(resource.Diff).To(BeEmpty())
+		}
+
+		updated := tc.DeepCopy()
+		updated.Spec.QuotaGroup.TotalQuotaGroup[0].Quotas[0].MaxAmount = 200
+		preview, err = PreviewTrafficConfig(ctx, updated)
+		Expect(err).NotTo(HaveOccurred())
+		updatedFilters := 0
+		for _, resource := range preview.Clusters["cluster1"].Resources {
+			if resource.Action == PreviewActionUpdate {
+				updatedFilters++
+				Expect(resource.Kind).To(Equal(metrics.KindEnvoyFilter))
+				Expect(resource.Diff).To(ContainSubstring("--- deployed"))
+				Expect(resource.Diff).To(ContainSubstring("+++ rendered"))
+				Expect(resource.Diff).To(ContainSubstring("200"))
+			}
+		}
+		Expect(updatedFilters).To(BeNumerically(">", 0))
+	})
+
+	It("should preview the resources of a disabled traffic config as deleted", func() {
+		HandleRateLimiter(ctx, tc, types.Add)
+		HandleVirtualServiceForTrafficConfig(ctx, tc, nil)
+		filterNames := listEnvoyFilterNames(rc)
+
+		disabled := tc.DeepCopy()
+		disabled.Annotations[types.IsDisabledKey] = types.IsTrue
+		preview, err := PreviewTrafficConfig(ctx, disabled)
+		Expect(err).NotTo(HaveOccurred())
+		filterActions := getPreviewActions(preview, "cluster1", metrics.KindEnvoyFilter)
+		Expect(filterActions).To(HaveLen(len(filterNames)))
+		for _, name := range filterNames {
+			Expect(filterActions[name]).To(Equal(PreviewActionDelete))
+		}
+		vsName := getVirtualServiceName("qa", "identity1")
+		Expect(getPreviewActions(preview, "cluster1", metrics.KindVirtualService)).To(Equal(map[string]string{vsName: PreviewActionDelete}))
+		Expect(listEnvoyFilterNames(rc)).To(Equal(filterNames))
+	})
+
+	It("should warn about the clusters out of scope or with a feature disabled", func() {
+		options.InitializeNaavikArgs(&options.NaavikArgs{AllowedClusterScope: []string{"cluster1"}})
+		addRemoteCluster("cluster2")
+		addWorkload("cluster2", "identity1", "qa")
+		gates, err := featuregate.Parse(map[string]string{featuregate.GatesKey: `
+- feature: virtualservice
+  enabled: false
+  cluster: cluster1
+`})
+		Expect(err).NotTo(HaveOccurred())
+		featuregate.SetState(featuregate.State{ConfigMapFound: true, Gates: gates})
+
+		preview, err := PreviewTrafficConfig(ctx, tc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(preview.Warnings).To(ContainElements(
+			"cluster cluster2 is not in allowed scope, skipped",
+			"virtualservice feature is disabled in cluster cluster1, its resources are deleted",
+		))
+		Expect(preview.Clusters).NotTo(HaveKey("cluster2"))
+		Expect(getPreviewActions(preview, "cluster1", metrics.KindEnvoyFilter)).NotTo(BeEmpty())
+		Expect(getPreviewActions(preview, "cluster1", metrics.KindVirtualService)).To(BeEmpty())
+	})
+
+	It("should warn when no virtual service name is known for the env", func() {
+		addWorkload("cluster1", "identity1", "env")
+		preview, err := PreviewTrafficConfig(ctx, resourcebuilder.GetFakeTrafficConfig("identity1", "env", "1", "namespace"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(preview.Warnings).To(ContainElement("no virtual service name for env env, no virtual service rendered"))
+		Expect(getPreviewActions(preview, "cluster1", metrics.KindVirtualService)).To(BeEmpty())
+		Expect(getPreviewActions(preview, "cluster1", metrics.KindEnvoyFilter)).NotTo(BeEmpty())
+	})
+
+	It("should reject a traffic config without identity or env", func() {
+		tc.Labels[options.GetTrafficConfigIdentityKey()] = ""
+		_, err := PreviewTrafficConfig(ctx, tc)
+		Expect(err).To(MatchError("no identity present in traffic config"))
+	})
+})
@@ -0,0 +1,70 @@
+package trafficconfig
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/controller"
+	"github.com/intuit/naavik/internal/featuregate"
+	"github.com/intuit/naavik/internal/override"
+	"github.com/intuit/naavik/internal/scope"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/internal/utils/clock"
+	"github.com/intuit/naavik/pkg/logger"
+	"github.com/intuit/naavik/pkg/utils"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+)
+
+// RegenerateThrottleFilters rewrites the throttle filters of the traffic config in its clusters, when a quota schedule window starts or ends or the proxy versions changed.
+// The latest traffic config of the cache is used, the traffic configs disabled or out of scope are left to the traffic config handler.
+// The errors of the clusters whose filters failed to be written are returned joined.
+func RegenerateThrottleFilters(ctx context.Context, trafficConfig *admiralv1.TrafficConfig) error {
+	tcUtil := utils.TrafficConfigUtil(trafficConfig)
+	unlock := controller.IdentityMutex.Lock(tcUtil.GetIdentityLowerCase())
+	defer unlock()
+	latestTc := cache.TrafficConfigCache.Get(tcUtil.GetIdentity(), tcUtil.GetEnv())
+	if latestTc == nil {
+		return nil
+	}
+	latestTc, _ = override.Apply(latestTc, clock.Get().Now())
+	tcUtil = utils.TrafficConfigUtil(latestTc)
+	if tcUtil.IsDisabled() || !scope.IsAssetInScope(tcUtil.GetIdentity()) {
+		return nil
+	}
+	var errs []error
+	for _, clusterID := range getThrottleFilterClusters(tcUtil) {
+		if !scope.IsClusterInScope(clusterID) || !featuregate.IsEnabled(types.FeatureThrottleFilter, tcUtil.GetIdentity(), tcUtil.GetEnv(), clusterID) {
+			continue
+		}
+		rc, found := cache.RemoteCluster.GetCluster(clusterID)
+		if !found {
+			continue
+		}
+		if err := createRateLimitingFilters(ctx, rc, tcUtil); err != nil {
+			ctx.Log.Str(logger.ClusterKey, clusterID).Str(logger.WorkloadIdentifierKey, tcUtil.GetIdentity()).Str(logger.EnvKey, tcUtil.GetEnv()).
+				Str(logger.ErrorKey, err.Error()).Error("error regenerating the throttle filters")
+			errs = append(errs, fmt.Errorf("cluster %s: %w", clusterID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RegenerateClusterThrottleFilters rewrites the throttle filters of the cached traffic configs with filters in one of the clusters,
+// when the proxy versions of the clusters changed. The filters of the versions no longer running are deleted.
+func RegenerateClusterThrottleFilters(ctx context.Context, clusterIDs []string) {
+	for _, identity := range cache.TrafficConfigCache.ListIdentities() {
+		entry := cache.TrafficConfigCache.GetTrafficConfigEntry(identity)
+		if entry == nil {
+			continue
+		}
+		for _, tc := range entry.EnvTrafficConfig {
+			if slices.ContainsFunc(getRateLimitingClusters(utils.TrafficConfigUtil(tc)), func(clusterID string) bool { return slices.Contains(clusterIDs, clusterID) }) {
+				// The errors are logged per cluster, the filters are written again on the next traffic config event
+				_ = RegenerateThrottleFilters(ctx, tc)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package trafficconfig
+
+import (
+	"errors"
+
+	fakeargoclientset "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	resourcebuilder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/internal/types/remotecluster"
+	fakeadmiralclientset "github.com/istio-ecosystem/admiral-api/pkg/client/clientset/versioned/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	fakeistioclientset "istio.io/client-go/pkg/clientset/versioned/fake"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Test throttle filters regeneration", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		cache.InformerSync.SetWarmedUp()
+		ctx = context.NewContextWithLogger()
+		leasechecker.RunStateCheck(ctx, leasechecker.GetStateChecker(ctx, types.StateCheckerNone))
+	})
+
+	AfterEach(func() {
+		cache.ResetAllCaches()
+		leasechecker.ResetState()
+	})
+
+	It("should return the errors of the clusters whose throttle filters failed to be written", func() {
+		istioClient := fakeistioclientset.NewSimpleClientset()
+		istioClient.PrependReactor("create", "envoyfilters", func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("create failed")
+		})
+		cache.RemoteCluster.AddCluster(remotecluster.CreateRemoteCluster("cluster2", "cluster2", "cluster2", "cluster2", nil, k8sfake.NewSimpleClientset(),
+			istioClient, fakeargoclientset.NewSimpleClientset(), fakeadmiralclientset.NewSimpleClientset()))
+		rc := addRemoteCluster("cluster1")
+		addWorkload("cluster1", "identity1", "env")
+		addWorkload("cluster2", "identity1", "env")
+		tc := resourcebuilder.GetFakeTrafficConfig("identity1", "env", "1", "namespace")
+		cache.TrafficConfigCache.AddTrafficConfigToCache(tc)
+
+		err := RegenerateThrottleFilters(ctx, tc)
+		Expect(err).To(MatchError(ContainSubstring("cluster cluster2")))
+		Expect(err).NotTo(MatchError(ContainSubstring("cluster cluster1")))
+		Expect(listEnvoyFilterNames(rc)).NotTo(BeEmpty())
+	})
+
+	It("should not return an error for a traffic config no longer in the cache", func() {
+		Expect(RegenerateThrottleFilters(ctx, resourcebuilder.GetFakeTrafficConfig("identity1", "env", "1", "namespace"))).To(Succeed())
+	})
+})
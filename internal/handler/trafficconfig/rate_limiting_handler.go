@@ -5,37 +5,49 @@ import (
 	"strings"
 	"time"
 
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	localratelimit "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	exprv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/rate_limit_descriptors/expr/v3"
 	"github.com/intuit/naavik/cmd/options"
 	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/featuregate"
+	"github.com/intuit/naavik/internal/killswitch"
+	"github.com/intuit/naavik/internal/override"
+	"github.com/intuit/naavik/internal/peeridentity"
+	"github.com/intuit/naavik/internal/proxyversion"
+	"github.com/intuit/naavik/internal/quotaenforcement"
+	"github.com/intuit/naavik/internal/quotaschedule"
+	"github.com/intuit/naavik/internal/scope"
+	"github.com/intuit/naavik/internal/tokenbucket"
 	"github.com/intuit/naavik/internal/types"
 	"github.com/intuit/naavik/internal/types/context"
 	"github.com/intuit/naavik/internal/types/remotecluster"
+	"github.com/intuit/naavik/internal/utils/clock"
 	"github.com/intuit/naavik/pkg/logger"
+	"github.com/intuit/naavik/pkg/metrics"
 	"github.com/intuit/naavik/pkg/utils"
 	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	"google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/structpb"
 	"istio.io/api/networking/v1alpha3"
 	networkingv1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-var globalBucket = getTokenBucket(1000000, 1000000, time.Second)
-
 func HandleRateLimiter(ctx context.Context, trafficConfig *admiralv1.TrafficConfig, eventType types.EventType) {
 	tcUtil := utils.TrafficConfigUtil(trafficConfig)
 
-	clusters := cache.IdentityCluster.GetClustersForIdentity(tcUtil.GetIdentity())
+	clusters := getThrottleFilterClusters(tcUtil)
 
 	if len(clusters) == 0 {
-		ctx.Log.Str(logger.WorkloadIdentifierKey, tcUtil.GetIdentity()).Warn("no clusters found for identity.")
+		ctx.Log.Str(logger.WorkloadIdentifierKey, tcUtil.GetIdentity()).Warn("no clusters found for identity and its associated apps.")
 		return
 	}
 	ctx.Log.Any(logger.ClusterKey, clusters).Str(logger.WorkloadIdentifierKey, tcUtil.GetIdentity()).Info("clusters for the identity")
 
 	for _, clusterID := range clusters {
-		if !options.IsClusterInAllowedScope(clusterID) {
+		if !scope.IsClusterInScope(clusterID) {
 			ctx.Log.Str(logger.ClusterKey, clusterID).Warn("cluster is not in allowed scope. skipping thorttle filter processing.")
 			continue
 		}
@@ -45,33 +57,85 @@ func HandleRateLimiter(ctx context.Context, trafficConfig *admiralv1.TrafficConf
 			continue
 		}
 
-		if tcUtil.IsDisabled() || eventType == types.Delete {
+		cache.Propagation.Expect(tcUtil.GetIdentity(), tcUtil.GetEnv(), tcUtil.GetRevision(), clusterID, metrics.KindEnvoyFilter)
+
+		featureEnabled := featuregate.IsEnabled(types.FeatureThrottleFilter, tcUtil.GetIdentity(), tcUtil.GetEnv(), clusterID)
+		if !featureEnabled {
+			ctx.Log.Str(logger.ClusterKey, clusterID).Info("throttle filter feature is disabled for the cluster. deleting the throttle filters.")
+		}
+		if tcUtil.IsDisabled() || eventType == types.Delete || !featureEnabled {
 			filterList, err := listRateLimitingFilters(ctx, rc, tcUtil)
 			if err != nil {
 				ctx.Log.Str(logger.ClusterKey, rc.GetClusterID()).Str(logger.WorkloadIdentifierKey, tcUtil.GetIdentity()).Str(logger.EnvKey, tcUtil.GetEnv()).Str(logger.ErrorKey, err.Error()).Warn("failed to list envoy filters for identity with Latest LabelSet")
+				cache.Propagation.Completed(tcUtil.GetIdentity(), tcUtil.GetEnv(), tcUtil.GetRevision(), clusterID, metrics.KindEnvoyFilter, err)
+				continue
 			}
-			if err := rc.IstioClient().DeleteEnvoyFilters(ctx, filterList.Items); err != nil {
+			err = rc.IstioClient().DeleteEnvoyFilters(ctx, filterList.Items)
+			if err != nil {
 				ctx.Log.Str(logger.ClusterKey, rc.GetClusterID()).Str(logger.WorkloadIdentifierKey, tcUtil.GetIdentity()).Str(logger.EnvKey, tcUtil.GetEnv()).Str(logger.ErrorKey, err.Error()).Warn("failed to delete envoy filters for identity with Latest LabelSet")
+			} else {
+				cache.OutboundFilters.SetCluster(tcUtil.GetIdentity(), tcUtil.GetEnv(), clusterID, false)
 			}
+			cache.Propagation.Completed(tcUtil.GetIdentity(), tcUtil.GetEnv(), tcUtil.GetRevision(), clusterID, metrics.KindEnvoyFilter, err)
 			continue
 		}
 
-		createRateLimitingFilters(ctx, rc, tcUtil)
+		err := createRateLimitingFilters(ctx, rc, tcUtil)
+		cache.Propagation.Completed(tcUtil.GetIdentity(), tcUtil.GetEnv(), tcUtil.GetRevision(), clusterID, metrics.KindEnvoyFilter, err)
+	}
+}
+
+func createRateLimitingFilters(ctx context.Context, rc remotecluster.RemoteCluster, tcUtil utils.TrafficConfigInterface) error {
+	newList, err := buildRateLimitingFilters(ctx, rc.GetClusterID(), tcUtil)
+	if err != nil {
+		ctx.Log.Str(logger.ClusterKey, rc.GetClusterID()).Str(logger.WorkloadIdentifierKey, tcUtil.GetIdentity()).Str(logger.EnvKey, tcUtil.GetEnv()).
+			Str(logger.ErrorKey, err.Error()).Error("failed to build envoy filters for identity")
+		return err
+	}
+	for _, envoyFilter := range newList {
+		f, _ := rc.IstioClient().GetEnvoyFilter(ctx, envoyFilter.Name, types.NamespaceIstioSystem, metav1.GetOptions{})
+		if f != nil {
+			envoyFilter.ResourceVersion = f.ResourceVersion
+		}
+	}
+
+	oldList, err := listRateLimitingFilters(ctx, rc, tcUtil)
+	if err != nil {
+		ctx.Log.Str(logger.ClusterKey, rc.GetClusterID()).Str(logger.WorkloadIdentifierKey, tcUtil.GetIdentity()).Str(logger.EnvKey, tcUtil.GetEnv()).
+			Str(logger.ErrorKey, err.Error()).Warn("failed to list envoy filters for identity with Latest LabelSet")
+	}
+
+	err = rc.IstioClient().ApplyEnvoyFilters(ctx, newList, oldList)
+	// A cluster is kept indexed until its outbound filters are known to be deleted
+	if hasOutbound := hasOutboundThrottleFilter(newList); hasOutbound || err == nil {
+		cache.OutboundFilters.SetCluster(tcUtil.GetIdentity(), tcUtil.GetEnv(), rc.GetClusterID(), hasOutbound)
 	}
+	return err
 }
 
-func createRateLimitingFilters(ctx context.Context, rc remotecluster.RemoteCluster, tcUtil utils.TrafficConfigInterface) {
+// buildRateLimitingFilters builds the throttle filters of the traffic config for the cluster, one per workload env and envoy filter version,
+// followed by the outbound throttle filters of the associated apps running in the cluster. Workload envs without a workload in the cluster are skipped.
+func buildRateLimitingFilters(ctx context.Context, clusterID string, tcUtil utils.TrafficConfigInterface) ([]*networkingv1alpha3.EnvoyFilter, error) {
 	newList := make([]*networkingv1alpha3.EnvoyFilter, 0)
 
-	for _, env := range tcUtil.GetWorkloadEnvs() {
-		workloadLabels, err := getWorkLoadLabels(ctx, rc.GetClusterID(), tcUtil.GetIdentity(), env)
+	// The clusters of the associated apps may not run the identity
+	workloadEnvs := tcUtil.GetWorkloadEnvs()
+	if !cache.IdentityCluster.IsClusterPresentInIdentity(tcUtil.GetIdentity(), clusterID) {
+		workloadEnvs = nil
+	}
+	for _, env := range workloadEnvs {
+		workloadLabels, err := getWorkLoadLabels(ctx, clusterID, tcUtil.GetIdentity(), env)
 		if err != nil {
-			ctx.Log.Str(logger.ClusterKey, rc.GetClusterID()).Warnf("skipping, %s", err.Error())
+			ctx.Log.Str(logger.ClusterKey, clusterID).Warnf("skipping, %s", err.Error())
 			continue
 		}
 
-		for _, version := range options.GetEnvoyFilterVersions() {
+		for _, version := range proxyversion.ForCluster(clusterID) {
 			envoyFilterName := utils.EnvoyFilterUtil().GetName(tcUtil.GetIdentity(), "throttle", env+"-"+version)
+			configPatches, err := createConfigPatches(ctx, env, version, tcUtil, clusterID)
+			if err != nil {
+				return nil, err
+			}
 
 			envoyFilter := &networkingv1alpha3.EnvoyFilter{
 				TypeMeta: metav1.TypeMeta{
@@ -89,46 +153,42 @@ func createRateLimitingFilters(ctx context.Context, rc remotecluster.RemoteClust
 						types.CreatedForKey:           strings.ToLower(tcUtil.GetIdentity()),
 						types.CreatedByKey:            types.NaavikName,
 						types.CreatedForEnvKey:        env,
-						types.CreatedTypeKey:          "throttle_filter",
+						types.CreatedTypeKey:          throttleFilterType,
 						types.CreatedForTrafficEnvKey: tcUtil.GetEnv(),
 					},
 				},
 				Spec: v1alpha3.EnvoyFilter{
 					Priority:         0,
 					WorkloadSelector: &v1alpha3.WorkloadSelector{Labels: workloadLabels},
-					ConfigPatches:    createConfigPatches(env, version, tcUtil, rc),
+					ConfigPatches:    configPatches,
 				},
 			}
 
-			f, _ := rc.IstioClient().GetEnvoyFilter(ctx, envoyFilterName, types.NamespaceIstioSystem, metav1.GetOptions{})
-			if f != nil {
-				envoyFilter.ResourceVersion = f.ResourceVersion
-			}
-
 			newList = append(newList, envoyFilter)
 		}
 	}
-
-	oldList, err := listRateLimitingFilters(ctx, rc, tcUtil)
+	outboundList, err := buildOutboundRateLimitingFilters(ctx, clusterID, tcUtil)
 	if err != nil {
-		ctx.Log.Str(logger.ClusterKey, rc.GetClusterID()).Str(logger.WorkloadIdentifierKey, tcUtil.GetIdentity()).Str(logger.EnvKey, tcUtil.GetEnv()).
-			Str(logger.ErrorKey, err.Error()).Warn("failed to list envoy filters for identity with Latest LabelSet")
+		return nil, err
 	}
-
-	rc.IstioClient().ApplyEnvoyFilters(ctx, newList, oldList)
+	return append(newList, outboundList...), nil
 }
 
-func createConfigPatches(env, proxyVersion string, tcUtil utils.TrafficConfigInterface, rc remotecluster.RemoteCluster) []*v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch {
-	patches := []*v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{createFilterPatch(proxyVersion)}
-	patches = append(patches, createRoutePatches(env, tcUtil, rc)...)
-	return patches
+func createConfigPatches(ctx context.Context, env, proxyVersion string, tcUtil utils.TrafficConfigInterface, clusterID string) ([]*v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch, error) {
+	routePatches, err := createRoutePatches(ctx, env, tcUtil, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	patches := []*v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{createFilterPatch(v1alpha3.EnvoyFilter_SIDECAR_INBOUND, proxyVersion, inboundRateLimitFilterName)}
+	return append(patches, routePatches...), nil
 }
 
-func createFilterPatch(proxyVersion string) *v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch {
+// createFilterPatch inserts the local rate limit filter named filterName before the router, the route patches configure it per route.
+func createFilterPatch(patchContext v1alpha3.EnvoyFilter_PatchContext, proxyVersion string, filterName string) *v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch {
 	return &v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{
 		ApplyTo: v1alpha3.EnvoyFilter_HTTP_FILTER,
 		Match: &v1alpha3.EnvoyFilter_EnvoyConfigObjectMatch{
-			Context: v1alpha3.EnvoyFilter_SIDECAR_INBOUND,
+			Context: patchContext,
 			Proxy:   createProxyMatch(proxyVersion),
 			ObjectTypes: &v1alpha3.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
 				Listener: &v1alpha3.EnvoyFilter_ListenerMatch{
@@ -146,7 +206,7 @@ func createFilterPatch(proxyVersion string) *v1alpha3.EnvoyFilter_EnvoyConfigObj
 		Patch: &v1alpha3.EnvoyFilter_Patch{
 			Operation: v1alpha3.EnvoyFilter_Patch_INSERT_BEFORE,
 			Value: &structpb.Struct{Fields: map[string]*structpb.Value{
-				"name": {Kind: &structpb.Value_StringValue{StringValue: "envoy.filters.http.local_ratelimit"}},
+				"name": {Kind: &structpb.Value_StringValue{StringValue: filterName}},
 				"typed_config": {Kind: &structpb.Value_StructValue{StructValue: &structpb.Struct{
 					Fields: map[string]*structpb.Value{
 						"@type":    {Kind: &structpb.Value_StringValue{StringValue: "type.googleapis.com/udpa.type.v1.TypedStruct"}},
@@ -161,9 +221,10 @@ func createFilterPatch(proxyVersion string) *v1alpha3.EnvoyFilter_EnvoyConfigObj
 	}
 }
 
-func createRoutePatches(env string, tcUtil utils.TrafficConfigInterface, rc remotecluster.RemoteCluster) []*v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch {
+func createRoutePatches(ctx context.Context, env string, tcUtil utils.TrafficConfigInterface, clusterID string) ([]*v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch, error) {
 	rateLimits := &structpb.ListValue{}
 	descriptors := &structpb.ListValue{}
+	resolver := newQuotaResolver(ctx, tcUtil.GetTrafficConfig())
 
 	for _, tcg := range tcUtil.GetQuotaGroup().TotalQuotaGroup {
 		if !contains(tcg.WorkloadEnvSelectors, env) {
@@ -171,49 +232,32 @@ func createRoutePatches(env string, tcUtil utils.TrafficConfigInterface, rc remo
 		}
 
 		for _, quota := range tcg.Quotas {
-			timePeriod, err := time.ParseDuration(quota.TimePeriod)
-			if err != nil {
-				fmt.Printf("error parsing time period %q for total quota %q : %+v", quota.TimePeriod, quota.Name, err)
-				continue
+			if err := resolver.appendQuota(ctx, rateLimits, descriptors, tcg.Name, "", quota); err != nil {
+				return nil, err
 			}
-
-			descriptor := getDescriptorConfig(tcg.Name, "", quota, timePeriod)
-			descriptorValue := structpb.NewStructValue(getProtoStructFromProtoMessage(descriptor))
-			descriptors.Values = append(descriptors.Values, descriptorValue)
-
-			rateLimit := getRateLimitConfig(tcg.Name, "", quota)
-			rateLimitValue := structpb.NewStructValue(getProtoStructFromProtoMessage(rateLimit))
-			rateLimits.Values = append(rateLimits.Values, rateLimitValue)
 		}
 	}
 
-	for _, aqg := range tcUtil.GetQuotaGroup().AppQuotaGroups {
-		if !contains(aqg.WorkloadEnvSelectors, env) {
-			continue
-		}
+	// The app quota groups enforced on the outbound sidecars of the associated apps only are left out
+	if quotaenforcement.Get(tcUtil.GetTrafficConfig()).IsInbound() {
+		for _, aqg := range tcUtil.GetQuotaGroup().AppQuotaGroups {
+			if !contains(aqg.WorkloadEnvSelectors, env) {
+				continue
+			}
 
-		for _, associatedApp := range aqg.AssociatedApps {
-			for _, quota := range aqg.Quotas {
-				timePeriod, err := time.ParseDuration(quota.TimePeriod)
-				if err != nil {
-					fmt.Printf("error parsing time period %q for app quota %q : %+v", quota.TimePeriod, quota.Name, err)
-					continue
+			for _, associatedApp := range aqg.AssociatedApps {
+				for _, quota := range aqg.Quotas {
+					if err := resolver.appendQuota(ctx, rateLimits, descriptors, aqg.Name, associatedApp, quota); err != nil {
+						return nil, err
+					}
 				}
-
-				descriptor := getDescriptorConfig(aqg.Name, associatedApp, quota, timePeriod)
-				descriptorValue := structpb.NewStructValue(getProtoStructFromProtoMessage(descriptor))
-				descriptors.Values = append(descriptors.Values, descriptorValue)
-
-				rateLimit := getRateLimitConfig(aqg.Name, associatedApp, quota)
-				rateLimitValue := structpb.NewStructValue(getProtoStructFromProtoMessage(rateLimit))
-				rateLimits.Values = append(rateLimits.Values, rateLimitValue)
 			}
 		}
 	}
 
-	routePatch := createRoutePatch(rateLimits, descriptors)
+	routePatch := createRoutePatch(inboundRateLimitFilterName, rateLimits, descriptors, resolver.buckets.ForVhost())
 
-	inboundPorts := getInboundPorts(rc.GetClusterID(), tcUtil.GetIdentity(), env)
+	inboundPorts := getInboundPorts(clusterID, tcUtil.GetIdentity(), env)
 	routePatches := []*v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{}
 	for _, inboundPort := range inboundPorts {
 		routePatches = append(routePatches, &v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{
@@ -222,7 +266,7 @@ func createRoutePatches(env string, tcUtil utils.TrafficConfigInterface, rc remo
 			Patch:   routePatch,
 		})
 	}
-	return routePatches
+	return routePatches, nil
 }
 
 func getInboundPorts(clusterID, identity, env string) []string {
@@ -241,6 +285,58 @@ func getInboundPorts(clusterID, identity, env string) []string {
 	return nil
 }
 
+// quotaResolver resolves the limit and token bucket of the quotas at now, from the quota schedules, overrides and token buckets of the traffic config.
+type quotaResolver struct {
+	now       time.Time
+	schedules []*quotaschedule.QuotaSchedule
+	overrides []*override.Override
+	buckets   *tokenbucket.Buckets
+}
+
+func newQuotaResolver(ctx context.Context, tc *admiralv1.TrafficConfig) *quotaResolver {
+	resolver := &quotaResolver{now: clock.Get().Now()}
+	var err error
+	resolver.schedules, err = quotaschedule.Parse(tc)
+	if err != nil {
+		ctx.Log.Str(logger.ErrorKey, err.Error()).Error("error parsing quota schedules, using the quotas as is.")
+	}
+	resolver.overrides = override.Active(tc, resolver.now)
+	resolver.buckets, err = tokenbucket.Parse(tc)
+	if err != nil {
+		ctx.Log.Str(logger.ErrorKey, err.Error()).Error("error parsing quota token buckets, the quotas cannot burst.")
+	}
+	return resolver
+}
+
+// appendQuota appends the rate limit and descriptor of the quota of the quota group, per associated app if set.
+// The quotas with an active schedule window use the limit of the window, unless an active override replaced it.
+// The quotas that cannot be parsed are skipped, an error is only returned if the rate limit of the quota cannot be built.
+func (r *quotaResolver) appendQuota(ctx context.Context, rateLimits, descriptors *structpb.ListValue, quotaGroup, associatedApp string, quota *admiralv1.Quota) error {
+	// Without workload, the principals of the associated app are unknown and its requests cannot be matched, its first workload event adds the quota
+	if len(associatedApp) > 0 && options.IsAppQuotaPeerIdentityEnabled() && len(peeridentity.Principals(associatedApp)) == 0 {
+		ctx.Log.Str("quotaGroup", quotaGroup).Str("associatedApp", associatedApp).Warn("no workload found for associated app, its peer identity is unknown. skipping app quota.")
+		return nil
+	}
+	if !override.IsQuotaOverridden(r.overrides, quotaGroup, quota.Name) {
+		quota = quotaschedule.EffectiveQuota(r.schedules, quotaGroup, quota, r.now)
+	}
+	timePeriod, err := time.ParseDuration(quota.TimePeriod)
+	if err != nil {
+		ctx.Log.Str("quotaGroup", quotaGroup).Str("quota", quota.Name).Str("timePeriod", quota.TimePeriod).Str(logger.ErrorKey, err.Error()).Error("error parsing time period for quota, skipping quota.")
+		return nil
+	}
+
+	descriptor := getDescriptorConfig(quotaGroup, associatedApp, quota, r.buckets.ForQuota(quotaGroup, quota, timePeriod))
+	descriptors.Values = append(descriptors.Values, structpb.NewStructValue(getProtoStructFromProtoMessage(descriptor)))
+
+	rateLimit, err := getRateLimitConfig(quotaGroup, associatedApp, quota)
+	if err != nil {
+		return fmt.Errorf("error building rate limit of quota %s of quota group %s: %w", quota.Name, quotaGroup, err)
+	}
+	rateLimits.Values = append(rateLimits.Values, structpb.NewStructValue(getProtoStructFromProtoMessage(rateLimit)))
+	return nil
+}
+
 func createProxyMatch(proxyVersion string) *v1alpha3.EnvoyFilter_ProxyMatch {
 	return &v1alpha3.EnvoyFilter_ProxyMatch{
 		ProxyVersion: "^" + strings.ReplaceAll(proxyVersion, ".", "\\.") + ".*",
@@ -273,7 +369,7 @@ func getRouteConfig(inboundPort string) *v1alpha3.EnvoyFilter_EnvoyConfigObjectM
 	}
 }
 
-func createRoutePatch(rateLimits, descriptors *structpb.ListValue) *v1alpha3.EnvoyFilter_Patch {
+func createRoutePatch(filterName string, rateLimits, descriptors *structpb.ListValue, vhostBucket tokenbucket.Settings) *v1alpha3.EnvoyFilter_Patch {
 	return &v1alpha3.EnvoyFilter_Patch{
 		Operation: v1alpha3.EnvoyFilter_Patch_MERGE,
 		Value: &structpb.Struct{Fields: map[string]*structpb.Value{
@@ -284,7 +380,7 @@ func createRoutePatch(rateLimits, descriptors *structpb.ListValue) *v1alpha3.Env
 			}),
 			"typed_per_filter_config": structpb.NewStructValue(&structpb.Struct{
 				Fields: map[string]*structpb.Value{
-					"envoy.filters.http.local_ratelimit": structpb.NewStructValue(&structpb.Struct{
+					filterName: structpb.NewStructValue(&structpb.Struct{
 						Fields: map[string]*structpb.Value{
 							"@type":    structpb.NewStringValue("type.googleapis.com/udpa.type.v1.TypedStruct"),
 							"type_url": structpb.NewStringValue("type.googleapis.com/envoy.extensions.filters.http.local_ratelimit.v3.LocalRateLimit"),
@@ -292,7 +388,7 @@ func createRoutePatch(rateLimits, descriptors *structpb.ListValue) *v1alpha3.Env
 								Fields: map[string]*structpb.Value{
 									"stat_prefix":  structpb.NewStringValue("http_local_rate_limiter"),
 									"descriptors":  structpb.NewListValue(descriptors),
-									"token_bucket": structpb.NewStructValue(getProtoStructFromProtoMessage(globalBucket)),
+									"token_bucket": structpb.NewStructValue(getProtoStructFromProtoMessage(getTokenBucket(vhostBucket))),
 									"filter_enabled": structpb.NewStructValue(&structpb.Struct{
 										Fields: map[string]*structpb.Value{
 											"runtime_key": structpb.NewStringValue("local_rate_limit_enabled"),
@@ -309,7 +405,7 @@ func createRoutePatch(rateLimits, descriptors *structpb.ListValue) *v1alpha3.Env
 											"runtime_key": structpb.NewStringValue("local_rate_limit_enforced"),
 											"default_value": structpb.NewStructValue(&structpb.Struct{
 												Fields: map[string]*structpb.Value{
-													"numerator":   structpb.NewNumberValue(100),
+													"numerator":   structpb.NewNumberValue(killswitch.GetEnforcedPercentage()),
 													"denominator": structpb.NewStringValue("HUNDRED"),
 												},
 											}),
@@ -325,7 +421,7 @@ func createRoutePatch(rateLimits, descriptors *structpb.ListValue) *v1alpha3.Env
 	}
 }
 
-func getDescriptorConfig(tcgName, associatedApp string, quota *admiralv1.Quota, timePeriod time.Duration) *localratelimit.LocalRateLimitDescriptor {
+func getDescriptorConfig(tcgName, associatedApp string, quota *admiralv1.Quota, bucket tokenbucket.Settings) *localratelimit.LocalRateLimitDescriptor {
 	quotaEntry := getQuotaDescriptorEntry(tcgName, quota.Name)
 	appEntry := getAssociatedAppDescriptorEntry(tcgName, quota.Name, associatedApp)
 
@@ -335,7 +431,7 @@ func getDescriptorConfig(tcgName, associatedApp string, quota *admiralv1.Quota,
 	}
 
 	return &localratelimit.LocalRateLimitDescriptor{
-		TokenBucket: getTokenBucket(quota.MaxAmount, quota.MaxAmount, timePeriod),
+		TokenBucket: getTokenBucket(bucket),
 		Entries:     entries,
 	}
 }
@@ -354,15 +450,18 @@ func getAssociatedAppDescriptorEntry(tcgName, quotaName, associatedApp string) *
 	return &localratelimit.RateLimitDescriptor_Entry{Key: descriptorKey, Value: descriptorKey}
 }
 
-func getRateLimitConfig(tcgName, associatedApp string, quota *admiralv1.Quota) *routev3.RateLimit {
+func getRateLimitConfig(tcgName, associatedApp string, quota *admiralv1.Quota) (*routev3.RateLimit, error) {
 	quotaAction := getQuotaAction(tcgName, quota)
-	appAction := getAssociatedAppAction(tcgName, quota.Name, associatedApp)
+	appAction, err := getAssociatedAppAction(tcgName, quota.Name, associatedApp)
+	if err != nil {
+		return nil, err
+	}
 
 	actions := []*routev3.RateLimit_Action{quotaAction}
 	if appAction != nil {
 		actions = append(actions, appAction)
 	}
-	return &routev3.RateLimit{Actions: actions}
+	return &routev3.RateLimit{Actions: actions}, nil
 }
 
 func getQuotaAction(tcgName string, quota *admiralv1.Quota) *routev3.RateLimit_Action {
@@ -392,9 +491,14 @@ func getQuotaAction(tcgName string, quota *admiralv1.Quota) *routev3.RateLimit_A
 	}
 }
 
-func getAssociatedAppAction(tcgName, quotaName, associatedApp string) *routev3.RateLimit_Action {
+// getAssociatedAppAction matches the requests of the associated app, identified by the traffic config identity header,
+// or by the principal of the mTLS peer certificate when app_quota_peer_identity is enabled, as the header can be forged.
+func getAssociatedAppAction(tcgName, quotaName, associatedApp string) (*routev3.RateLimit_Action, error) {
 	if associatedApp == "" {
-		return nil
+		return nil, nil
+	}
+	if options.IsAppQuotaPeerIdentityEnabled() {
+		return getPeerIdentityAction(getDescriptorKey(tcgName, quotaName, associatedApp), peeridentity.Principals(associatedApp))
 	}
 
 	return &routev3.RateLimit_Action{
@@ -407,5 +511,28 @@ func getAssociatedAppAction(tcgName, quotaName, associatedApp string) *routev3.R
 				},
 			},
 		},
+	}, nil
+}
+
+// getPeerIdentityAction generates the descriptor key entry for the connections whose mTLS peer has one of the principals.
+// The connections without peer certificate fail the expression and skip the descriptor, they fall through to the other quotas.
+func getPeerIdentityAction(descriptorKey string, principals []string) (*routev3.RateLimit_Action, error) {
+	descriptor := &exprv3.Descriptor{
+		DescriptorKey: descriptorKey,
+		SkipIfError:   true,
+		ExprSpecifier: &exprv3.Descriptor_Text{Text: peeridentity.Expression(principals, descriptorKey)},
+	}
+	typedConfig, err := anypb.New(descriptor)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling peer identity descriptor %s: %w", descriptorKey, err)
 	}
+
+	return &routev3.RateLimit_Action{
+		ActionSpecifier: &routev3.RateLimit_Action_Extension{
+			Extension: &corev3.TypedExtensionConfig{
+				Name:        "envoy.rate_limit_descriptors.expr",
+				TypedConfig: typedConfig,
+			},
+		},
+	}, nil
 }
@@ -0,0 +1,107 @@
+package trafficconfig
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/controller"
+	resourcebuilder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/internal/types/remotecluster"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// getEnvoyFilterSpec returns the JSON spec of the envoy filter of the cluster.
+func getEnvoyFilterSpec(rc remotecluster.RemoteCluster, name string) string {
+	envoyFilter, err := rc.IstioClient().GetEnvoyFilter(context.NewContextWithLogger(), name, types.NamespaceIstioSystem, metav1.GetOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	spec, err := json.Marshal(&envoyFilter.Spec)
+	Expect(err).NotTo(HaveOccurred())
+	return string(spec)
+}
+
+var _ = Describe("Test throttle filters with peer identity", func() {
+	var (
+		ctx context.Context
+		rc  remotecluster.RemoteCluster
+	)
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(&options.NaavikArgs{AppQuotaPeerIdentity: true})
+		cache.ResetAllCaches()
+		cache.InformerSync.SetWarmedUp()
+		ctx = context.NewContextWithLogger()
+		leasechecker.RunStateCheck(ctx, leasechecker.GetStateChecker(ctx, types.StateCheckerNone))
+		rc = addRemoteCluster("cluster1")
+		addWorkload("cluster1", "identity1", "env")
+		tc := resourcebuilder.GetFakeTrafficConfig("identity1", "env", "1", "namespace")
+		tc.Spec.QuotaGroup.AppQuotaGroups = []*admiralv1.AppQuotaGroup{{
+			Name:                 "apps",
+			AssociatedApps:       []string{"app2"},
+			WorkloadEnvSelectors: []string{"env"},
+			Quotas:               []*admiralv1.Quota{{Name: "app", MaxAmount: 10, TimePeriod: "1s", Rule: "/*"}},
+		}}
+		cache.TrafficConfigCache.AddTrafficConfigToCache(tc)
+		HandleRateLimiter(ctx, tc, types.Add)
+	})
+
+	AfterEach(func() {
+		cache.ResetAllCaches()
+		leasechecker.ResetState()
+	})
+
+	It("should add the app quota once the deployment of the associated app arrives", func() {
+		Expect(getEnvoyFilterSpec(rc, "identity1-throttle-env-1.21")).NotTo(ContainSubstring("ns/namespace/sa/default"))
+
+		addWorkload("cluster1", "app2", "env")
+		triggerTrafficConfigHandler(ctx, "app2")
+		Expect(getEnvoyFilterSpec(rc, "identity1-throttle-env-1.21")).To(ContainSubstring("ns/namespace/sa/default"))
+	})
+
+	It("should only match the peers of the mesh trust domain and skip the connections without peer certificate", func() {
+		addWorkload("cluster1", "app2", "env")
+		triggerTrafficConfigHandler(ctx, "app2")
+		spec := getEnvoyFilterSpec(rc, "identity1-throttle-env-1.21")
+		Expect(spec).To(ContainSubstring(`^spiffe://cluster\\.local/(ns/namespace/sa/default)$`))
+		Expect(spec).To(ContainSubstring(`"skip_if_error":true`))
+	})
+
+	It("should skip the descriptor for the callers not matching the principals", func() {
+		addWorkload("cluster1", "app2", "env")
+		triggerTrafficConfigHandler(ctx, "app2")
+		spec := getEnvoyFilterSpec(rc, "identity1-throttle-env-1.21")
+		expression := regexp.MustCompile(`"text":"(\(connection[^"]*)"`).FindStringSubmatch(spec)
+		Expect(expression).To(HaveLen(2))
+		// A caller matching none of the principals indexes the empty list, the expression fails and skip_if_error drops the descriptor
+		Expect(expression[1]).To(MatchRegexp(`^\(connection\.uri_san_peer_certificate\.matches\(r'.*'\) \? \['[^']+'\] : \[\]\)\[0\]$`))
+		Expect(expression[1]).NotTo(ContainSubstring(`: ''`))
+		pattern := regexp.MustCompile(`matches\(r'(.*)'\)`).FindStringSubmatch(strings.ReplaceAll(expression[1], `\\`, `\`))
+		Expect(pattern).To(HaveLen(2))
+		Expect(regexp.MustCompile(pattern[1]).MatchString("spiffe://cluster.local/ns/namespace/sa/default")).To(BeTrue())
+		Expect(regexp.MustCompile(pattern[1]).MatchString("spiffe://cluster.local/ns/namespace/sa/other")).To(BeFalse())
+		Expect(regexp.MustCompile(pattern[1]).MatchString("spiffe://other.domain/ns/namespace/sa/default")).To(BeFalse())
+		Expect(spec).To(ContainSubstring(`"skip_if_error":true`))
+	})
+
+	It("should follow the service account of the associated app", func() {
+		addWorkload("cluster1", "app2", "env")
+		triggerTrafficConfigHandler(ctx, "app2")
+
+		deployment := resourcebuilder.BuildFakeDeployment("app2-env", "app2", "app2", "env", "namespace")
+		deployment.Spec.Template.Spec.ServiceAccountName = "app2-sa"
+		cache.Deployments.Add("cluster1", deployment)
+		statusChan := make(chan controller.EventProcessStatus, 100)
+		NewTrafficConfigHandler().TriggerAppQuotaTrafficConfigs(ctx, "app2", statusChan)
+		spec := getEnvoyFilterSpec(rc, "identity1-throttle-env-1.21")
+		Expect(spec).To(ContainSubstring("ns/namespace/sa/app2-sa"))
+		Expect(spec).NotTo(ContainSubstring("ns/namespace/sa/default"))
+	})
+})
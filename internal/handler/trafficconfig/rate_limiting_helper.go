@@ -4,13 +4,13 @@ import (
 	"encoding/base64"
 	"fmt"
 	"strings"
-	"time"
 
 	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	matcherv3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/intuit/naavik/cmd/options"
 	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/tokenbucket"
 	"github.com/intuit/naavik/internal/types"
 	"github.com/intuit/naavik/internal/types/context"
 	"github.com/intuit/naavik/internal/types/remotecluster"
@@ -25,6 +25,12 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 )
 
+// throttleFilterType is the created type label of the throttle filters.
+const throttleFilterType = "throttle_filter"
+
+// inboundRateLimitFilterName is the name of the local rate limit filter of the throttle filters.
+const inboundRateLimitFilterName = "envoy.filters.http.local_ratelimit"
+
 func listRateLimitingFilters(ctx context.Context, rc remotecluster.RemoteCluster, tcUtil utils.TrafficConfigInterface) (*networkingv1alpha3.EnvoyFilterList, error) {
 	labelSet := metav1.LabelSelector{
 		MatchLabels: map[string]string{
@@ -152,10 +158,10 @@ func getProtoStructFromProtoMessage(msg protoreflect.ProtoMessage) *structpb.Str
 	return s
 }
 
-func getTokenBucket(maxTokens, tokensPerFill int, fillInterval time.Duration) *typev3.TokenBucket {
+func getTokenBucket(bucket tokenbucket.Settings) *typev3.TokenBucket {
 	return &typev3.TokenBucket{
-		MaxTokens:     uint32(maxTokens),
-		FillInterval:  durationpb.New(fillInterval),
-		TokensPerFill: wrapperspb.UInt32(uint32(tokensPerFill)),
+		MaxTokens:     uint32(bucket.MaxTokens),
+		FillInterval:  durationpb.New(bucket.FillInterval),
+		TokensPerFill: wrapperspb.UInt32(uint32(bucket.TokensPerFill)),
 	}
 }
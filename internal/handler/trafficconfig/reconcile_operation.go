@@ -0,0 +1,115 @@
+package trafficconfig
+
+import (
+	goctx "context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/controller"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/eventhistory"
+	"github.com/intuit/naavik/pkg/logger"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+const (
+	// ReconcileOperationController is the controller name of the on-demand reconciles in the event history.
+	ReconcileOperationController = "api/reconcile"
+	reconcileOperationEventType  = "Reconcile"
+)
+
+var (
+	errReadOnly  = errors.New("switched to read only mode, reconcile stopped")
+	errCancelled = errors.New("reconcile operation cancelled")
+)
+
+// RunReconcileOperation triggers the traffic config handler for each identity and records the per-cluster results in the operation.
+// Only the traffic configs of env are handled when env is set. Identities are triggered at most options.GetFullReconcileQPS() per second.
+// The operation stops waiting for its next identity once the context is cancelled or the instance switches to read only mode.
+func RunReconcileOperation(ctx context.Context, operationID string, identities []string, env string) {
+	startTime := time.Now()
+	log := ctx.Log.Str("operationId", operationID)
+	log.Int("identities", len(identities)).Info("Reconcile operation started")
+	runCtx, cancel := goctx.WithCancel(ctx.Context)
+	defer cancel()
+	unsubscribe := leasechecker.Subscribe(func(_ context.Context, _ leasechecker.LeaseState, current leasechecker.LeaseState) {
+		if current.ReadOnly {
+			cancel()
+		}
+	})
+	defer unsubscribe()
+	tcHandler := NewTrafficConfigHandler()
+	rateLimiter := flowcontrol.NewTokenBucketRateLimiter(float32(options.GetFullReconcileQPS()), 1)
+	defer rateLimiter.Stop()
+	for _, identity := range identities {
+		if err := rateLimiter.Wait(runCtx); err != nil && !leasechecker.IsReadOnly() {
+			log.Info("Reconcile operation cancelled")
+			cache.ReconcileOperations.Complete(operationID, errCancelled)
+			return
+		}
+		if leasechecker.IsReadOnly() {
+			log.Info("Switched to read only mode, stopping reconcile operation")
+			cache.ReconcileOperations.Complete(operationID, errReadOnly)
+			return
+		}
+		identityCtx := context.NewContextWithLogger()
+		identityCtx.Log = log.Str(logger.WorkloadIdentifierKey, identity)
+		if len(env) > 0 {
+			identityCtx.Context = goctx.WithValue(identityCtx.Context, types.TargetEnvKey, env)
+		}
+		reconcileIdentity(identityCtx, tcHandler, operationID, identity)
+		cache.ReconcileOperations.IdentityDone(operationID, identity)
+	}
+	cache.ReconcileOperations.Complete(operationID, nil)
+	log.Int("identities", len(identities)).Int(logger.TimeTakenMSKey, int(time.Since(startTime).Milliseconds())).Info("Reconcile operation completed")
+}
+
+// reconcileIdentity triggers the traffic config handler for the identity and waits for all its child events.
+// The writes of each child event are recorded in the operation and the child events are added to the event history.
+func reconcileIdentity(ctx context.Context, tcHandler TrafficConfigHandler, operationID string, identity string) {
+	recorder := eventhistory.NewRecorder(operationID, ReconcileOperationController, identity, reconcileOperationEventType, 0)
+	ctx.Context = eventhistory.WithRecorder(ctx.Context, recorder)
+	statusChan := make(chan controller.EventProcessStatus, controller.DefaultEventStatusBufferedChannelSize)
+
+	childEvents := sync.WaitGroup{}
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		for status := range statusChan {
+			if status.Status != controller.EventCreateChild {
+				continue
+			}
+			childEvents.Add(1)
+			go func(status controller.EventProcessStatus) {
+				defer childEvents.Done()
+				var result controller.EventStatus
+				for childStatus := range status.ChildEventChan {
+					result = childStatus.Status
+				}
+				childRecorder := eventhistory.RecorderFromContext(status.ChildEventContext.Context)
+				if childRecorder == nil {
+					return
+				}
+				for _, write := range childRecorder.Entry(result.String(), nil).Resources {
+					var err error
+					if len(write.Error) > 0 {
+						err = errors.New(write.Error)
+					}
+					cache.ReconcileOperations.RecordWrite(operationID, write.Cluster, err)
+				}
+				childRecorder.Finish(result.String(), nil)
+			}(status)
+		}
+	}()
+
+	tcHandler.TriggerTrafficConfigHandlerForIdentity(ctx, identity, statusChan)
+	close(statusChan)
+	<-drained
+	childEvents.Wait()
+	recorder.Finish(controller.EventCompleted.String(), nil)
+}
@@ -0,0 +1,92 @@
+package trafficconfig
+
+import (
+	gocontext "context"
+	"time"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	resourcebuilder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test reconcile operation", func() {
+	var ctx context.Context
+	identities := []string{"identity1", "identity2", "identity3"}
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(&options.NaavikArgs{FullReconcileQPS: 10})
+		cache.ResetAllCaches()
+		cache.InformerSync.SetWarmedUp()
+		ctx = context.NewContextWithLogger()
+		leasechecker.RunStateCheck(ctx, leasechecker.GetStateChecker(ctx, types.StateCheckerNone))
+		addRemoteCluster("cluster1")
+		for _, identity := range identities {
+			addWorkload("cluster1", identity, "env")
+			cache.TrafficConfigCache.AddTrafficConfigToCache(resourcebuilder.GetFakeTrafficConfig(identity, "env", "1", "admiral"))
+		}
+	})
+
+	AfterEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		leasechecker.ResetState()
+	})
+
+	It("should record the progress and the writes of the operation", func() {
+		cache.ReconcileOperations.Start("operation1", cache.ReconcileScope{Cluster: "cluster1"}, identities)
+		RunReconcileOperation(ctx, "operation1", identities, "")
+
+		operation := cache.ReconcileOperations.Get("operation1")
+		Expect(operation.Status).To(Equal(cache.ReconcileOperationCompleted))
+		Expect(operation.IdentitiesDone).To(Equal(3))
+		Expect(operation.CompletedAt).NotTo(BeNil())
+		Expect(operation.Clusters).To(HaveKey("cluster1"))
+		Expect(operation.Clusters["cluster1"].Writes).To(BeNumerically(">=", 3))
+		Expect(operation.Clusters["cluster1"].Failed).To(BeZero())
+	})
+
+	It("should trigger the identities at most full_reconcile_qps per second", func() {
+		cache.ReconcileOperations.Start("operation1", cache.ReconcileScope{Cluster: "cluster1"}, identities)
+		startTime := time.Now()
+		RunReconcileOperation(ctx, "operation1", identities, "")
+		// The first identity is triggered right away, the next ones every 100ms
+		Expect(time.Since(startTime)).To(BeNumerically(">=", 190*time.Millisecond))
+		Expect(cache.ReconcileOperations.Get("operation1").IdentitiesDone).To(Equal(3))
+	})
+
+	It("should stop the operation when switching to read only mode", func() {
+		leasechecker.ResetState()
+		cache.ReconcileOperations.Start("operation1", cache.ReconcileScope{Cluster: "cluster1"}, identities)
+		RunReconcileOperation(ctx, "operation1", identities, "")
+
+		operation := cache.ReconcileOperations.Get("operation1")
+		Expect(operation.Status).To(Equal(cache.ReconcileOperationFailed))
+		Expect(operation.Error).To(Equal(errReadOnly.Error()))
+		Expect(operation.IdentitiesDone).To(BeZero())
+	})
+
+	It("should stop waiting for the next identity when the context is cancelled", func() {
+		options.InitializeNaavikArgs(&options.NaavikArgs{FullReconcileQPS: 1})
+		cache.ReconcileOperations.Start("operation1", cache.ReconcileScope{Cluster: "cluster1"}, identities)
+		cctx, cancel := gocontext.WithCancel(gocontext.Background())
+		ctx.Context = cctx
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			RunReconcileOperation(ctx, "operation1", identities, "")
+		}()
+		Eventually(func() int { return cache.ReconcileOperations.Get("operation1").IdentitiesDone }).Should(Equal(1))
+		cancel()
+		Eventually(done).Should(BeClosed())
+
+		operation := cache.ReconcileOperations.Get("operation1")
+		Expect(operation.Status).To(Equal(cache.ReconcileOperationFailed))
+		Expect(operation.Error).To(Equal(errCancelled.Error()))
+		Expect(operation.IdentitiesDone).To(Equal(1))
+	})
+})
@@ -0,0 +1,132 @@
+package trafficconfig
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/featuregate"
+	"github.com/intuit/naavik/internal/scope"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/utils"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	networkingv1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RenderedTrafficConfig is the resources a traffic config renders to, per target cluster.
+type RenderedTrafficConfig struct {
+	Clusters map[string]*RenderedResources // map[cluster]*RenderedResources
+	Warnings []string
+}
+
+// RenderedResources is the resources rendered for a cluster.
+type RenderedResources struct {
+	VirtualServices []*networkingv1alpha3.VirtualService
+	EnvoyFilters    []*networkingv1alpha3.EnvoyFilter
+}
+
+func (r *RenderedTrafficConfig) cluster(clusterID string) *RenderedResources {
+	resources, found := r.Clusters[clusterID]
+	if !found {
+		resources = &RenderedResources{
+			VirtualServices: make([]*networkingv1alpha3.VirtualService, 0),
+			EnvoyFilters:    make([]*networkingv1alpha3.EnvoyFilter, 0),
+		}
+		r.Clusters[clusterID] = resources
+	}
+	return resources
+}
+
+// RenderTrafficConfig renders the throttle filters and the virtual service of the traffic config from the caches only,
+// the remote clusters are neither required nor read. The same builders as the traffic config handler are used.
+func RenderTrafficConfig(ctx context.Context, trafficConfig *admiralv1.TrafficConfig) (*RenderedTrafficConfig, error) {
+	tcUtil := utils.TrafficConfigUtil(trafficConfig)
+	if len(tcUtil.GetIdentity()) == 0 {
+		return nil, errors.New("no identity present in traffic config")
+	}
+	if len(tcUtil.GetEnv()) == 0 {
+		return nil, errors.New("no env present in traffic config")
+	}
+	trafficConfig, warnings := applyOverrides(trafficConfig)
+	tcUtil = utils.TrafficConfigUtil(trafficConfig)
+	rendered := &RenderedTrafficConfig{
+		Clusters: make(map[string]*RenderedResources),
+		Warnings: warnings,
+	}
+	if tcUtil.IsDisabled() {
+		rendered.Warnings = append(rendered.Warnings, "traffic config is disabled, its resources are deleted")
+		return rendered, nil
+	}
+	if !scope.IsAssetInScope(tcUtil.GetIdentity()) {
+		rendered.Warnings = append(rendered.Warnings, fmt.Sprintf("asset %s is not in scope, no resources rendered", tcUtil.GetIdentity()))
+		return rendered, nil
+	}
+
+	if featuregate.IsEnabledInAnyCluster(types.FeatureThrottleFilter, tcUtil.GetIdentity(), tcUtil.GetEnv()) {
+		clusters := getRateLimitingClusters(tcUtil)
+		if len(clusters) == 0 {
+			rendered.Warnings = append(rendered.Warnings, "no clusters found for identity and its associated apps, no throttle filters rendered")
+		}
+		for _, clusterID := range clusters {
+			if !scope.IsClusterInScope(clusterID) {
+				rendered.Warnings = append(rendered.Warnings, fmt.Sprintf("cluster %s is not in allowed scope, skipped", clusterID))
+				continue
+			}
+			if !featuregate.IsEnabled(types.FeatureThrottleFilter, tcUtil.GetIdentity(), tcUtil.GetEnv(), clusterID) {
+				rendered.Warnings = append(rendered.Warnings, fmt.Sprintf("throttle filter feature is disabled in cluster %s, its resources are deleted", clusterID))
+				continue
+			}
+			envoyFilters, err := buildRateLimitingFilters(ctx, clusterID, tcUtil)
+			if err != nil {
+				return nil, fmt.Errorf("error constructing throttle filters for cluster %s: %w", clusterID, err)
+			}
+			if len(envoyFilters) == 0 {
+				rendered.Warnings = append(rendered.Warnings, fmt.Sprintf("no workload found in cluster %s for workload envs %v, no throttle filters rendered", clusterID, tcUtil.GetWorkloadEnvs()))
+				continue
+			}
+			rendered.cluster(clusterID).EnvoyFilters = envoyFilters
+		}
+	}
+
+	if featuregate.IsEnabledInAnyCluster(types.FeatureVirtualService, tcUtil.GetIdentity(), tcUtil.GetEnv()) && tcUtil.GetEdgeService() != nil {
+		dependentClusters := getDependentClusters(ctx, cache.IdentityDependency.GetDependentsForIdentity(tcUtil.GetIdentity()))
+		if len(dependentClusters) == 0 {
+			rendered.Warnings = append(rendered.Warnings, "no dependent clusters found, no virtual service rendered")
+			return rendered, nil
+		}
+		vs, err := buildVirtualServiceForMeshDependents(ctx, tcUtil)
+		if err != nil {
+			return nil, fmt.Errorf("error constructing virtual service: %w", err)
+		}
+		if len(vs.Name) == 0 {
+			rendered.Warnings = append(rendered.Warnings, fmt.Sprintf("no virtual service name for env %s, no virtual service rendered", tcUtil.GetEnv()))
+			return rendered, nil
+		}
+		// The virtual service builder leaves the type meta empty, it is required in the rendered manifests
+		vs.TypeMeta = metav1.TypeMeta{
+			Kind:       "VirtualService",
+			APIVersion: "networking.istio.io/v1alpha3",
+		}
+		clusterIDs := make([]string, 0, len(dependentClusters))
+		for clusterID := range dependentClusters {
+			clusterIDs = append(clusterIDs, clusterID)
+		}
+		sort.Strings(clusterIDs)
+		for _, clusterID := range clusterIDs {
+			if !scope.IsClusterInScope(clusterID) {
+				rendered.Warnings = append(rendered.Warnings, fmt.Sprintf("cluster %s is not in allowed scope, skipped", clusterID))
+				continue
+			}
+			if !featuregate.IsEnabled(types.FeatureVirtualService, tcUtil.GetIdentity(), tcUtil.GetEnv(), clusterID) {
+				rendered.Warnings = append(rendered.Warnings, fmt.Sprintf("virtual service feature is disabled in cluster %s, its resources are deleted", clusterID))
+				continue
+			}
+			resources := rendered.cluster(clusterID)
+			resources.VirtualServices = append(resources.VirtualServices, vs.DeepCopy())
+		}
+	}
+	return rendered, nil
+}
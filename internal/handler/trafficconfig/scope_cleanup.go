@@ -0,0 +1,147 @@
+package trafficconfig
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/controller"
+	"github.com/intuit/naavik/internal/scope"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/internal/types/remotecluster"
+	"github.com/intuit/naavik/pkg/logger"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// CleanupCluster deletes the envoy filters and virtual services written by naavik to a cluster that left the scope.
+func CleanupCluster(ctx context.Context, clusterID string) error {
+	rc, found := cache.RemoteCluster.GetCluster(clusterID)
+	if !found {
+		return fmt.Errorf("cluster %s not found", clusterID)
+	}
+	ctx.Log.Str(logger.ClusterKey, clusterID).Info("Cluster left the scope, deleting the resources written by naavik")
+	return deleteNaavikResources(ctx, rc, labels.Set{types.CreatedByKey: types.NaavikName}, true)
+}
+
+// CleanupAsset deletes the resources of an asset that left the scope: the resources written for its traffic configs in all the clusters,
+// and the virtual services of the identities it depends on in its clusters when no other dependent in scope remains there.
+func CleanupAsset(ctx context.Context, asset string) error {
+	asset = strings.ToLower(asset)
+	ctx.Log.Str(logger.WorkloadIdentifierKey, asset).Info("Asset left the scope, deleting the resources written by naavik")
+	var errs error
+	unlock := controller.IdentityMutex.Lock(asset)
+	for _, rc := range cache.RemoteCluster.ListClusters() {
+		selector := labels.Set{types.CreatedByKey: types.NaavikName, types.CreatedForKey: asset}
+		errs = errors.Join(errs, deleteNaavikResources(ctx, rc, selector, true))
+	}
+	unlock()
+
+	for _, dependency := range cache.IdentityDependency.GetDependenciesForIdentity(asset) {
+		dependency = strings.ToLower(dependency)
+		unlock := controller.IdentityMutex.Lock(dependency)
+		for _, clusterID := range cache.IdentityCluster.GetClustersForIdentity(asset) {
+			if hasDependentInScope(dependency, asset, clusterID) {
+				continue
+			}
+			rc, found := cache.RemoteCluster.GetCluster(clusterID)
+			if !found {
+				continue
+			}
+			selector := labels.Set{types.CreatedByKey: types.NaavikName, types.CreatedForKey: dependency}
+			errs = errors.Join(errs, deleteNaavikResources(ctx, rc, selector, false))
+		}
+		unlock()
+	}
+	return errs
+}
+
+// FindScopeLeftovers returns the clusters and assets out of scope that still hold resources written by naavik.
+// The deferred cleanups are only kept in memory, so the ones of another instance or of a previous run are found this way after failover.
+func FindScopeLeftovers(ctx context.Context) (scope.Change, error) {
+	change := scope.Change{LeftClusters: []string{}, LeftAssets: []string{}}
+	assets := map[string]bool{}
+	var errs error
+	listOptions := metav1.ListOptions{LabelSelector: labels.Set{types.CreatedByKey: types.NaavikName}.String()}
+	for _, rc := range cache.RemoteCluster.ListClusters() {
+		resourceLabels := []map[string]string{}
+		filterList, err := rc.IstioClient().ListEnvoyFilters(ctx, types.NamespaceIstioSystem, listOptions)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("error listing envoy filters in cluster %s: %w", rc.GetClusterID(), err))
+			continue
+		}
+		for _, envoyFilter := range filterList.Items {
+			resourceLabels = append(resourceLabels, envoyFilter.Labels)
+		}
+		vsList, err := rc.IstioClient().ListVirtualServices(ctx, options.GetSyncNamespace(), listOptions)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("error listing virtual services in cluster %s: %w", rc.GetClusterID(), err))
+			continue
+		}
+		for _, vs := range vsList.Items {
+			resourceLabels = append(resourceLabels, vs.Labels)
+		}
+
+		if !scope.IsClusterInScope(rc.GetClusterID()) {
+			if len(resourceLabels) > 0 {
+				change.LeftClusters = append(change.LeftClusters, rc.GetClusterID())
+			}
+			continue
+		}
+		for _, resourceLabel := range resourceLabels {
+			asset := resourceLabel[types.CreatedForKey]
+			if len(asset) > 0 && !scope.IsAssetInScope(asset) {
+				assets[asset] = true
+			}
+		}
+	}
+	for asset := range assets {
+		change.LeftAssets = append(change.LeftAssets, asset)
+	}
+	sort.Strings(change.LeftClusters)
+	sort.Strings(change.LeftAssets)
+	return change, errs
+}
+
+// hasDependentInScope returns true if a dependent of the identity other than excluded is in scope and has workloads in the cluster.
+func hasDependentInScope(identity string, excluded string, clusterID string) bool {
+	for _, dependent := range cache.IdentityDependency.GetDependentsForIdentity(identity) {
+		if strings.EqualFold(dependent, excluded) || !scope.IsAssetInScope(dependent) {
+			continue
+		}
+		if cache.IdentityCluster.IsClusterPresentInIdentity(dependent, clusterID) {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteNaavikResources deletes the virtual services, and the envoy filters if requested, matching the labels in the cluster.
+func deleteNaavikResources(ctx context.Context, rc remotecluster.RemoteCluster, selector labels.Set, envoyFilters bool) error {
+	var errs error
+	listOptions := metav1.ListOptions{LabelSelector: selector.String()}
+	if envoyFilters {
+		filterList, err := rc.IstioClient().ListEnvoyFilters(ctx, types.NamespaceIstioSystem, listOptions)
+		if err != nil {
+			errs = errors.Join(errs, err)
+		} else {
+			errs = errors.Join(errs, rc.IstioClient().DeleteEnvoyFilters(ctx, filterList.Items))
+		}
+	}
+	vsList, err := rc.IstioClient().ListVirtualServices(ctx, options.GetSyncNamespace(), listOptions)
+	if err != nil {
+		return errors.Join(errs, err)
+	}
+	for _, vs := range vsList.Items {
+		err := rc.IstioClient().DeleteVirtualService(ctx, vs.Name, vs.Namespace, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			errs = errors.Join(errs, err)
+		}
+	}
+	return errs
+}
@@ -2,24 +2,40 @@ package trafficconfig
 
 import (
 	goctx "context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/intuit/naavik/cmd/options"
 	"github.com/intuit/naavik/internal/cache"
 	"github.com/intuit/naavik/internal/controller"
+	"github.com/intuit/naavik/internal/featuregate"
 	"github.com/intuit/naavik/internal/handler"
 	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/override"
+	"github.com/intuit/naavik/internal/scope"
 	"github.com/intuit/naavik/internal/types"
 	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/internal/utils/clock"
+	"github.com/intuit/naavik/internal/validation"
+	"github.com/intuit/naavik/pkg/eventhistory"
 	"github.com/intuit/naavik/pkg/logger"
+	"github.com/intuit/naavik/pkg/tracing"
 	"github.com/intuit/naavik/pkg/utils"
 	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/client-go/util/flowcontrol"
 )
 
 //nolint:revive
 type TrafficConfigHandler interface {
 	handler.Handler
 	TriggerTrafficConfigHandlerForIdentity(ctx context.Context, identity string, statusChan chan controller.EventProcessStatus)
+	TriggerAppQuotaTrafficConfigs(ctx context.Context, associatedApp string, statusChan chan controller.EventProcessStatus)
+	ReconcileAllTrafficConfigs(ctx context.Context)
 }
 
 func NewTrafficConfigHandler() TrafficConfigHandler {
@@ -46,41 +62,10 @@ func (tch *DefaultTrafficConfigHandler) Added(ctx context.Context, obj interface
 
 	cache.TrafficConfigCache.AddTrafficConfigToCache(tc)
 
-	// Update the cache before checking for read only
-	if leasechecker.IsReadOnly() {
-		ctx.Log.Info("Updated cache, but in read only mode, skipping handling.")
-		return controller.NewEventProcessStatus().SkipClose(statusChan)
-	}
-
-	// Don't process during cache warmup time as it might cause applying filters with invalid data
-	if !options.IsCacheWarmedUp() {
-		ctx.Log.Str(logger.ResourceIdentifierKey, tc.Name).Info("cache is not warmed up yet, skipping processing")
-		return controller.NewEventProcessStatus().SkipClose(statusChan)
-	}
-
-	// handle rate limiting filter
-	if options.IsFeatureEnabled(types.FeatureThrottleFilter) {
-		startTime := time.Now()
-		newCtx := context.NewContextWithLogger()
-		newCtx.Log = ctx.Log.Str(logger.HandlerNameKey, types.FeatureThrottleFilter.String())
-		newCtx.Log.Str("txId", utils.TrafficConfigUtil(tc).GetTransactionID()).Str("revision", utils.TrafficConfigUtil(tc).GetRevision()).Info("Throttle filter processing started")
-		HandleRateLimiter(newCtx, tc, types.Add)
-		newCtx.Log.Int(logger.TimeTakenMSKey, int(time.Since(startTime).Milliseconds())).Info("Throttle filter processing completed")
-	}
-
-	if options.IsFeatureEnabled(types.FeatureVirtualService) {
-		startTime := time.Now()
-		newCtx := context.NewContextWithLogger()
-		newCtx.Log = ctx.Log.Str(logger.HandlerNameKey, types.FeatureVirtualService.String())
-		newCtx.Log.Str("txId", utils.TrafficConfigUtil(tc).GetTransactionID()).Str("revision", utils.TrafficConfigUtil(tc).GetRevision()).Info("Virtual service processing started")
-		HandleVirtualServiceForTrafficConfig(newCtx, tc, statusChan)
-		newCtx.Log.Int(logger.TimeTakenMSKey, int(time.Since(startTime).Milliseconds())).Info("Virtual service processing completed")
-	}
-
-	return controller.NewEventProcessStatus().SkipClose(statusChan)
+	return tch.reconcile(ctx, tc, types.Add, statusChan)
 }
 
-func (tch *DefaultTrafficConfigHandler) Updated(ctx context.Context, newObj interface{}, _ interface{}, statusChan chan controller.EventProcessStatus) controller.EventStatus {
+func (tch *DefaultTrafficConfigHandler) Updated(ctx context.Context, newObj interface{}, oldObj interface{}, statusChan chan controller.EventProcessStatus) controller.EventStatus {
 	tc, ok := newObj.(*admiralv1.TrafficConfig)
 	if !ok {
 		ctx.Log.Error("error casting TrafficConfig object, skipping handling.")
@@ -94,38 +79,23 @@ func (tch *DefaultTrafficConfigHandler) Updated(ctx context.Context, newObj inte
 		return controller.NewEventProcessStatus().SkipClose(statusChan)
 	}
 
-	// Update the cache before checking for read only
-	if leasechecker.IsReadOnly() {
-		ctx.Log.Info("Updated cache, but in read only mode, skipping handling.")
-		return controller.NewEventProcessStatus().SkipClose(statusChan)
-	}
+	cache.TrafficConfigCache.AddTrafficConfigToCache(tc)
 
-	// Don't process during cache warmup time as it might cause applying filters with invalid data
-	if !options.IsCacheWarmedUp() {
-		ctx.Log.Str(logger.ResourceIdentifierKey, tc.Name).Info("cache is not warmed up yet, skipping processing")
+	// The override statuses saved by the reconciles only change the status, there is nothing to apply
+	if oldTc, ok := oldObj.(*admiralv1.TrafficConfig); ok && isStatusUpdate(oldTc, tc) {
+		ctx.Log.Str(logger.ResourceIdentifierKey, tc.Name).Debug("Only the traffic config status changed, skipping handling.")
 		return controller.NewEventProcessStatus().SkipClose(statusChan)
 	}
 
-	// handle rate limiting filter
-	if options.IsFeatureEnabled(types.FeatureThrottleFilter) {
-		startTime := time.Now()
-		newCtx := context.NewContextWithLogger()
-		newCtx.Log = ctx.Log.Str(logger.HandlerNameKey, types.FeatureThrottleFilter.String())
-		newCtx.Log.Str("txId", utils.TrafficConfigUtil(tc).GetTransactionID()).Str("revision", utils.TrafficConfigUtil(tc).GetRevision()).Info("Throttle filter processing started")
-		HandleRateLimiter(newCtx, tc, types.Update)
-		newCtx.Log.Int(logger.TimeTakenMSKey, int(time.Since(startTime).Milliseconds())).Info("Throttle filter processing completed")
-	}
-
-	if options.IsFeatureEnabled(types.FeatureVirtualService) {
-		startTime := time.Now()
-		newCtx := context.NewContextWithLogger()
-		newCtx.Log = ctx.Log.Str(logger.HandlerNameKey, types.FeatureVirtualService.String())
-		newCtx.Log.Str("txId", utils.TrafficConfigUtil(tc).GetTransactionID()).Str("revision", utils.TrafficConfigUtil(tc).GetRevision()).Info("Virtual service processing started")
-		HandleVirtualServiceForTrafficConfig(newCtx, tc, statusChan)
-		newCtx.Log.Int(logger.TimeTakenMSKey, int(time.Since(startTime).Milliseconds())).Info("Virtual service processing completed")
-	}
+	return tch.reconcile(ctx, tc, types.Update, statusChan)
+}
 
-	return controller.NewEventProcessStatus().SkipClose(statusChan)
+// isStatusUpdate returns true if only the status of the traffic config changed, the resyncs keep the same resource version.
+func isStatusUpdate(oldTc *admiralv1.TrafficConfig, newTc *admiralv1.TrafficConfig) bool {
+	return oldTc.ResourceVersion != newTc.ResourceVersion &&
+		reflect.DeepEqual(oldTc.Spec, newTc.Spec) &&
+		reflect.DeepEqual(oldTc.Annotations, newTc.Annotations) &&
+		reflect.DeepEqual(oldTc.Labels, newTc.Labels)
 }
 
 func (tch *DefaultTrafficConfigHandler) Deleted(ctx context.Context, obj interface{}, statusChan chan controller.EventProcessStatus) controller.EventStatus {
@@ -137,45 +107,157 @@ func (tch *DefaultTrafficConfigHandler) Deleted(ctx context.Context, obj interfa
 
 	cache.TrafficConfigCache.DeleteTrafficConfigFromCache(tc)
 
-	// Update the cache before checking for read only
+	status := tch.reconcile(ctx, tc, types.Delete, statusChan)
+	// The propagation of a deleted traffic config is no longer tracked, unless it was added back in the meantime
+	tcUtil := utils.TrafficConfigUtil(tc)
+	if cache.TrafficConfigCache.Get(tcUtil.GetIdentity(), tcUtil.GetEnv()) == nil {
+		cache.Propagation.Delete(tcUtil.GetIdentity(), tcUtil.GetEnv())
+	}
+	return status
+}
+
+// reconcile applies all the enabled features for the traffic config.
+// Only one reconcile runs per identity at a time, the traffic config is re-read from the cache
+// once the identity lock is acquired so the last writer always uses the freshest cache state.
+func (tch *DefaultTrafficConfigHandler) reconcile(ctx context.Context, tc *admiralv1.TrafficConfig, eventType types.EventType, statusChan chan controller.EventProcessStatus) controller.EventStatus {
+	// Cache is updated before checking for read only
 	if leasechecker.IsReadOnly() {
 		ctx.Log.Info("Updated cache, but in read only mode, skipping handling.")
 		return controller.NewEventProcessStatus().SkipClose(statusChan)
 	}
 
 	// Don't process during cache warmup time as it might cause applying filters with invalid data
-	if !options.IsCacheWarmedUp() {
+	if !cache.InformerSync.IsWarmedUp() {
 		ctx.Log.Str(logger.ResourceIdentifierKey, tc.Name).Info("cache is not warmed up yet, skipping processing")
 		return controller.NewEventProcessStatus().SkipClose(statusChan)
 	}
 
+	tcUtil := utils.TrafficConfigUtil(tc)
+	var span trace.Span
+	ctx.Context, span = tracing.StartSpan(ctx.Context, "reconcile traffic config",
+		attribute.String(logger.WorkloadIdentifierKey, tcUtil.GetIdentity()),
+		attribute.String(logger.EnvKey, tcUtil.GetEnv()),
+		attribute.String(logger.EventType, eventType.String()))
+	defer span.End()
+
+	// The override statuses are saved once the identity lock is released, deferred first so it runs after unlock
+	var savedTc *admiralv1.TrafficConfig
+	defer func() {
+		if savedTc == nil {
+			return
+		}
+		savedTcUtil := utils.TrafficConfigUtil(savedTc)
+		if err := override.SaveStatuses(ctx.Context, savedTc, savedTcUtil.GetIdentity(), savedTcUtil.GetEnv()); err != nil {
+			ctx.Log.Str(logger.WorkloadIdentifierKey, savedTcUtil.GetIdentity()).Str(logger.EnvKey, savedTcUtil.GetEnv()).Str(logger.ErrorKey, err.Error()).Warn("failed to save the override statuses")
+		}
+	}()
+
+	waitStartTime := time.Now()
+	unlock := controller.IdentityMutex.Lock(tcUtil.GetIdentityLowerCase())
+	defer unlock()
+	ctx.Log.Str(logger.WorkloadIdentifierKey, tcUtil.GetIdentity()).Int(logger.WaitTimeMSKey, int(time.Since(waitStartTime).Milliseconds())).Debug("Acquired identity lock")
+
+	latestTc := cache.TrafficConfigCache.Get(tcUtil.GetIdentity(), tcUtil.GetEnv())
+	switch {
+	case latestTc != nil && eventType == types.Delete:
+		// Traffic config was added back while waiting for the lock, apply the latest one instead of deleting
+		ctx.Log.Str(logger.ResourceIdentifierKey, latestTc.Name).Info("Traffic config found in cache after delete, applying the latest traffic config.")
+		tc, eventType = latestTc, types.Update
+	case latestTc != nil:
+		tc = latestTc
+	case eventType != types.Delete:
+		ctx.Log.Str(logger.ResourceIdentifierKey, tc.Name).Info("Traffic config no longer in cache, skipping handling.")
+		return controller.NewEventProcessStatus().SkipClose(statusChan)
+	}
+	tcUtil = utils.TrafficConfigUtil(tc)
+	if !scope.IsAssetInScope(tcUtil.GetIdentity()) {
+		// The resources of the asset are deleted when it leaves the scope
+		ctx.Log.Str(logger.WorkloadIdentifierKey, tcUtil.GetIdentity()).Info("asset is not in scope, skipping handling.")
+		return controller.NewEventProcessStatus().SkipClose(statusChan)
+	}
+	// The active overrides apply on top of the traffic config until they expire, the expirer reconciles it again at expiry.
+	// The tracked overrides are saved in the traffic config status, so the next leader resumes them.
+	now := clock.Get().Now()
+	if eventType == types.Delete {
+		override.Track(tcUtil.GetIdentity(), tcUtil.GetEnv(), nil, now)
+	} else {
+		savedTc = tc
+		var activeOverrides []*override.Override
+		tc, activeOverrides = override.Apply(tc, now)
+		override.Track(tcUtil.GetIdentity(), tcUtil.GetEnv(), activeOverrides, now)
+	}
+	span.SetAttributes(attribute.String(logger.RevisionKey, tcUtil.GetRevision()), attribute.String(types.TransactionIDKey, tcUtil.GetTransactionID()))
+
+	// Track the propagation of the revision to the clusters, it converges once all the writes of a reconcile succeed
+	receivedAt, ok := ctx.Context.Value(types.EventReceivedTimeKey).(time.Time)
+	if !ok {
+		receivedAt = time.Now()
+	}
+	eventhistory.RecorderFromContext(ctx.Context).SetTrafficConfig(tcUtil.GetIdentity(), tcUtil.GetEnv(), tcUtil.GetRevision(), tcUtil.GetTransactionID())
+	cache.Propagation.Received(tcUtil.GetIdentity(), tcUtil.GetEnv(), tcUtil.GetRevision(), tcUtil.GetTransactionID(), receivedAt)
+	defer cache.Propagation.Seal(tcUtil.GetIdentity(), tcUtil.GetEnv(), tcUtil.GetRevision())
+
+	// Invalid fields are only reported, the features handle what they can of the traffic config
+	logValidationIssues(ctx, validation.ValidateTrafficConfig(tc))
+
 	// handle rate limiting filter
-	if options.IsFeatureEnabled(types.FeatureThrottleFilter) {
+	if isFeatureHandled(types.FeatureThrottleFilter, tcUtil) {
 		startTime := time.Now()
 		newCtx := context.NewContextWithLogger()
 		newCtx.Log = ctx.Log.Str(logger.HandlerNameKey, types.FeatureThrottleFilter.String())
-		newCtx.Log.Str("txId", utils.TrafficConfigUtil(tc).GetTransactionID()).Str("revision", utils.TrafficConfigUtil(tc).GetRevision()).Info("Throttle filter processing started")
-		HandleRateLimiter(newCtx, tc, types.Delete)
+		stageSpan := startStageSpan(ctx, &newCtx, types.FeatureThrottleFilter)
+		newCtx.Log.Str("txId", tcUtil.GetTransactionID()).Str("revision", tcUtil.GetRevision()).Info("Throttle filter processing started")
+		HandleRateLimiter(newCtx, tc, eventType)
 		newCtx.Log.Int(logger.TimeTakenMSKey, int(time.Since(startTime).Milliseconds())).Info("Throttle filter processing completed")
+		stageSpan.End()
 	}
 
-	if options.IsFeatureEnabled(types.FeatureVirtualService) {
+	if isFeatureHandled(types.FeatureVirtualService, tcUtil) {
 		startTime := time.Now()
 		newCtx := context.NewContextWithLogger()
 		newCtx.Log = ctx.Log.Str(logger.HandlerNameKey, types.FeatureVirtualService.String())
-		newCtx.Log.Str("txId", utils.TrafficConfigUtil(tc).GetTransactionID()).Str("revision", utils.TrafficConfigUtil(tc).GetRevision()).Info("Virtual service processing started")
+		stageSpan := startStageSpan(ctx, &newCtx, types.FeatureVirtualService)
+		newCtx.Log.Str("txId", tcUtil.GetTransactionID()).Str("revision", tcUtil.GetRevision()).Info("Virtual service processing started")
 		HandleVirtualServiceForTrafficConfig(newCtx, tc, statusChan)
 		newCtx.Log.Int(logger.TimeTakenMSKey, int(time.Since(startTime).Milliseconds())).Info("Virtual service processing completed")
+		stageSpan.End()
 	}
 
 	return controller.NewEventProcessStatus().SkipClose(statusChan)
 }
 
+// isFeatureHandled returns true if the feature may be enabled in a cluster, or is gated for the traffic config.
+// The handlers delete the resources of the clusters where a gate disables the feature.
+func isFeatureHandled(feature types.FeatureName, tcUtil utils.TrafficConfigInterface) bool {
+	return featuregate.IsEnabledInAnyCluster(feature, tcUtil.GetIdentity(), tcUtil.GetEnv()) ||
+		featuregate.IsGated(feature, tcUtil.GetIdentity(), tcUtil.GetEnv())
+}
+
+func logValidationIssues(ctx context.Context, result *validation.Result) {
+	for _, issue := range result.Errors {
+		ctx.Log.Str(logger.FieldKey, issue.Field).Str(logger.ErrorKey, issue.Message).Error("Invalid traffic config field")
+	}
+	for _, issue := range result.Warnings {
+		ctx.Log.Str(logger.FieldKey, issue.Field).Str(logger.ErrorKey, issue.Message).Warn("Suspicious traffic config field")
+	}
+}
+
+// startStageSpan starts the span of a handler stage as a child of the reconcile span.
+// Stages run with a new context, only the stage span and the event history recorder are carried over to it.
+func startStageSpan(ctx context.Context, stageCtx *context.Context, feature types.FeatureName) trace.Span {
+	_, span := tracing.StartSpan(ctx.Context, fmt.Sprintf("handle %s", feature.String()), attribute.String(logger.HandlerNameKey, feature.String()))
+	stageCtx.Context = tracing.ContextWithSpan(stageCtx.Context, span)
+	stageCtx.Context = eventhistory.WithRecorder(stageCtx.Context, eventhistory.RecorderFromContext(ctx.Context))
+	return span
+}
+
 func (tch *DefaultTrafficConfigHandler) OnStatus(_ context.Context, _ controller.EventProcessStatus) {
 }
 
 func (tch *DefaultTrafficConfigHandler) TriggerTrafficConfigHandlerForIdentity(ctx context.Context, identity string, statusChan chan controller.EventProcessStatus) {
 	ctx.Log.Str(logger.WorkloadIdentifierKey, identity).Info("Triggering traffic config handler for identity started")
+	// Handle only the traffic configs of the target env, if set in the context
+	targetEnv, _ := ctx.Context.Value(types.TargetEnvKey).(string)
 
 	// Trigger traffic config handler for self
 	tcEntry := cache.TrafficConfigCache.GetTrafficConfigEntry(identity)
@@ -183,9 +265,12 @@ func (tch *DefaultTrafficConfigHandler) TriggerTrafficConfigHandlerForIdentity(c
 		ctx.Log.Str(logger.WorkloadIdentifierKey, identity).Trace("No traffic config found for identity")
 	} else {
 		for env, tc := range tcEntry.EnvTrafficConfig {
+			if len(targetEnv) > 0 && !strings.EqualFold(targetEnv, env) {
+				continue
+			}
 			childCtx, childStatusChan := controller.NewEventProcessStatus().CreateChildEvent(ctx, tch.OnStatus, statusChan)
 			childCtx.Log.Str(logger.WorkloadIdentifierKey, identity).Str(logger.NameKey, tc.Name).Str(logger.EnvKey, env).Info("Triggering traffic config handler for self")
-			tch.Updated(childCtx, tc, nil, childStatusChan)
+			tch.reconcile(childCtx, tc, types.Update, childStatusChan)
 		}
 	}
 
@@ -196,15 +281,119 @@ func (tch *DefaultTrafficConfigHandler) TriggerTrafficConfigHandlerForIdentity(c
 		dependentTrafficConfigEntry := cache.TrafficConfigCache.GetTrafficConfigEntry(dependent)
 		if dependentTrafficConfigEntry != nil {
 			for env, tc := range dependentTrafficConfigEntry.EnvTrafficConfig {
+				if len(targetEnv) > 0 && !strings.EqualFold(targetEnv, env) {
+					continue
+				}
 				childCtx, childStatusChan := controller.NewEventProcessStatus().CreateChildEvent(ctx, tch.OnStatus, statusChan)
 				// Add source identity to context so that the traffic config will be handled only for the triggered source identity
 				childCtx.Context = goctx.WithValue(childCtx.Context, types.SourceIdentityKey, identity)
 				childCtx.Log.Str(logger.WorkloadIdentifierKey, dependent).Str(logger.NameKey, tc.Name).Str(logger.EnvKey, env).Info("Triggering traffic config handler for dependent")
-				tch.Updated(childCtx, tc, nil, childStatusChan)
+				tch.reconcile(childCtx, tc, types.Update, childStatusChan)
 			}
 		} else {
 			ctx.Log.Str(logger.WorkloadIdentifierKey, dependent).Trace("No traffic config found for dependent identity")
 		}
 	}
+
+	// Trigger traffic config handler for the identities listing the identity in their app quota groups,
+	// the self and dependents traffic configs were already handled
+	tch.triggerAppQuotaTrafficConfigs(ctx, identity, dependents, statusChan)
 	ctx.Log.Str(logger.WorkloadIdentifierKey, identity).Info("Triggering traffic config handler for identity completed")
 }
+
+// TriggerAppQuotaTrafficConfigs reconciles the traffic configs of the identities listing the associated app in their app quota groups,
+// so their outbound filters follow the clusters and envs of the app and their peer identity matchers its principals.
+func (tch *DefaultTrafficConfigHandler) TriggerAppQuotaTrafficConfigs(ctx context.Context, associatedApp string, statusChan chan controller.EventProcessStatus) {
+	tch.triggerAppQuotaTrafficConfigs(ctx, associatedApp, nil, statusChan)
+}
+
+func (tch *DefaultTrafficConfigHandler) triggerAppQuotaTrafficConfigs(ctx context.Context, associatedApp string, skipped []string, statusChan chan controller.EventProcessStatus) {
+	// Handle only the traffic configs of the target env, if set in the context
+	targetEnv, _ := ctx.Context.Value(types.TargetEnvKey).(string)
+	for _, appQuotaIdentity := range cache.TrafficConfigCache.GetIdentitiesForAssociatedApp(associatedApp) {
+		if strings.EqualFold(appQuotaIdentity, associatedApp) || contains(skipped, appQuotaIdentity) {
+			continue
+		}
+		appQuotaTrafficConfigEntry := cache.TrafficConfigCache.GetTrafficConfigEntry(appQuotaIdentity)
+		if appQuotaTrafficConfigEntry == nil {
+			continue
+		}
+		for env, tc := range appQuotaTrafficConfigEntry.EnvTrafficConfig {
+			if len(targetEnv) > 0 && !strings.EqualFold(targetEnv, env) {
+				continue
+			}
+			childCtx, childStatusChan := controller.NewEventProcessStatus().CreateChildEvent(ctx, tch.OnStatus, statusChan)
+			childCtx.Context = goctx.WithValue(childCtx.Context, types.SourceIdentityKey, associatedApp)
+			childCtx.Log.Str(logger.WorkloadIdentifierKey, appQuotaIdentity).Str(logger.NameKey, tc.Name).Str(logger.EnvKey, env).Info("Triggering traffic config handler for associated app")
+			tch.reconcile(childCtx, tc, types.Update, childStatusChan)
+		}
+	}
+}
+
+// fullReconcile serializes the reconciles of all the traffic configs, a new reconcile cancels the one in flight.
+var fullReconcile = &fullReconcileGuard{}
+
+type fullReconcileGuard struct {
+	// mutex guards cancel
+	mutex  sync.Mutex
+	cancel goctx.CancelFunc
+	// running is held by the reconcile in flight
+	running sync.Mutex
+}
+
+// start cancels the reconcile in flight and waits for it to stop. It returns the context of the new reconcile, cancelled by the next one,
+// and the func to call once it is done.
+func (f *fullReconcileGuard) start(parent goctx.Context) (goctx.Context, func()) {
+	runCtx, cancel := goctx.WithCancel(parent)
+	f.mutex.Lock()
+	if f.cancel != nil {
+		f.cancel()
+	}
+	f.cancel = cancel
+	f.mutex.Unlock()
+	f.running.Lock()
+	return runCtx, func() {
+		cancel()
+		f.running.Unlock()
+	}
+}
+
+// ReconcileAllTrafficConfigs reconciles every traffic config in the cache.
+// This is used to apply the changes that were skipped while handlers were not processing events, e.g. during cache warm up or in read only mode.
+// Identities are reconciled at most options.GetFullReconcileQPS() per second to avoid hammering all the clusters at once,
+// the reconcile is stopped if the instance switches to read only mode. The clusters holding outbound throttle filters are indexed first.
+// A single reconcile runs at a time, a new one cancels the one in flight and starts over once it stopped, e.g. on failover during cache warm up.
+func (tch *DefaultTrafficConfigHandler) ReconcileAllTrafficConfigs(ctx context.Context) {
+	runCtx, done := fullReconcile.start(ctx.Context)
+	defer done()
+	if runCtx.Err() != nil {
+		ctx.Log.Info("Reconciling all traffic configs cancelled")
+		return
+	}
+	startTime := time.Now()
+	IndexOutboundThrottleFilters(ctx)
+	identities := cache.TrafficConfigCache.ListIdentities()
+	ctx.Log.Int("identities", len(identities)).Info("Reconciling all traffic configs started")
+	rateLimiter := flowcontrol.NewTokenBucketRateLimiter(float32(options.GetFullReconcileQPS()), 1)
+	defer rateLimiter.Stop()
+	for _, identity := range identities {
+		if err := rateLimiter.Wait(runCtx); err != nil {
+			ctx.Log.Info("Reconciling all traffic configs cancelled")
+			return
+		}
+		if leasechecker.IsReadOnly() {
+			ctx.Log.Info("Switched to read only mode, stopping reconcile of all traffic configs")
+			return
+		}
+		tcEntry := cache.TrafficConfigCache.GetTrafficConfigEntry(identity)
+		if tcEntry == nil {
+			continue
+		}
+		for env, tc := range tcEntry.EnvTrafficConfig {
+			childCtx := context.NewContextWithLogger()
+			childCtx.Log = ctx.Log.Str(logger.WorkloadIdentifierKey, identity).Str(logger.NameKey, tc.Name).Str(logger.EnvKey, env)
+			tch.reconcile(childCtx, tc, types.Update, nil)
+		}
+	}
+	ctx.Log.Int("identities", len(identities)).Int(logger.TimeTakenMSKey, int(time.Since(startTime).Milliseconds())).Info("Reconciling all traffic configs completed")
+}
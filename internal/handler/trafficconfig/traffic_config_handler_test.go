@@ -0,0 +1,222 @@
+package trafficconfig
+
+import (
+	gocontext "context"
+	"sync"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/controller"
+	resourcebuilder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/featuregate"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Test traffic config handler trigger for identity", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		cache.InformerSync.SetWarmedUp()
+		ctx = context.NewContextWithLogger()
+		leasechecker.RunStateCheck(ctx, leasechecker.GetStateChecker(ctx, types.StateCheckerNone))
+	})
+
+	AfterEach(func() {
+		cache.ResetAllCaches()
+		leasechecker.ResetState()
+	})
+
+	It("should index the traffic configs by associated app", func() {
+		cache.TrafficConfigCache.AddTrafficConfigToCache(getOutboundTrafficConfig("App1", "app2"))
+		Expect(cache.TrafficConfigCache.GetIdentitiesForAssociatedApp("app1")).To(Equal([]string{"identity1"}))
+		Expect(cache.TrafficConfigCache.GetIdentitiesForAssociatedApp("app2")).To(Equal([]string{"identity1"}))
+
+		cache.TrafficConfigCache.AddTrafficConfigToCache(getOutboundTrafficConfig("app1"))
+		Expect(cache.TrafficConfigCache.GetIdentitiesForAssociatedApp("app2")).To(BeEmpty())
+
+		cache.TrafficConfigCache.DeleteTrafficConfigFromCache(getOutboundTrafficConfig("app1"))
+		Expect(cache.TrafficConfigCache.GetIdentitiesForAssociatedApp("app1")).To(BeEmpty())
+	})
+
+	It("should reconcile the traffic configs listing the identity as associated app", func() {
+		addRemoteCluster("cluster1")
+		rc2 := addRemoteCluster("cluster2")
+		addWorkload("cluster1", "identity1", "env")
+		cache.TrafficConfigCache.AddTrafficConfigToCache(getOutboundTrafficConfig("app2"))
+
+		// The associated app moves to a new cluster
+		addWorkload("cluster2", "app2", "env")
+		triggerTrafficConfigHandler(ctx, "app2")
+		Expect(listEnvoyFilterNames(rc2)).To(HaveLen(1))
+	})
+
+	It("should stop tracking the propagation of a deleted traffic config", func() {
+		addRemoteCluster("cluster1")
+		addWorkload("cluster1", "identity1", "qa")
+		tc := resourcebuilder.GetFakeTrafficConfig("identity1", "qa", "1", "namespace")
+		NewTrafficConfigHandler().Added(ctx, tc, make(chan controller.EventProcessStatus, 100))
+		Expect(cache.Propagation.Get("identity1", "qa")).NotTo(BeNil())
+
+		NewTrafficConfigHandler().Deleted(ctx, tc, make(chan controller.EventProcessStatus, 100))
+		Expect(cache.Propagation.Get("identity1", "qa")).To(BeNil())
+	})
+
+	It("should only cache the traffic configs whose status alone changed", func() {
+		addRemoteCluster("cluster1")
+		addWorkload("cluster1", "identity1", "qa")
+		tc := resourcebuilder.GetFakeTrafficConfig("identity1", "qa", "1", "namespace")
+		tc.ResourceVersion = "1"
+		statusTc := tc.DeepCopy()
+		statusTc.ResourceVersion = "2"
+		statusTc.Status.Message = "saved"
+		NewTrafficConfigHandler().Updated(ctx, statusTc, tc, make(chan controller.EventProcessStatus, 100))
+		Expect(cache.TrafficConfigCache.Get("identity1", "qa").Status.Message).To(Equal("saved"))
+		Expect(cache.Propagation.Get("identity1", "qa")).To(BeNil())
+
+		// A resync keeps the resource version and is reconciled
+		NewTrafficConfigHandler().Updated(ctx, statusTc, statusTc, make(chan controller.EventProcessStatus, 100))
+		Expect(cache.Propagation.Get("identity1", "qa")).NotTo(BeNil())
+	})
+})
+
+var _ = Describe("Test feature gates in the traffic config handler", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		featuregate.Reset()
+		cache.InformerSync.SetWarmedUp()
+		ctx = context.NewContextWithLogger()
+		leasechecker.RunStateCheck(ctx, leasechecker.GetStateChecker(ctx, types.StateCheckerNone))
+	})
+
+	AfterEach(func() {
+		cache.ResetAllCaches()
+		featuregate.Reset()
+		leasechecker.ResetState()
+	})
+
+	It("should delete the throttle filters and the virtual service of the clusters where a gate disables them", func() {
+		rc := addRemoteCluster("cluster1")
+		addWorkload("cluster1", "identity1", "qa")
+		cache.IdentityDependency.AddDependentToIdentity("identity1", "dependent1")
+		cache.IdentityCluster.AddClusterToIdentity("dependent1", "cluster1")
+		cache.TrafficConfigCache.AddTrafficConfigToCache(resourcebuilder.GetFakeTrafficConfig("identity1", "qa", "1", "namespace"))
+		vsName := getVirtualServiceName("qa", "identity1")
+
+		triggerTrafficConfigHandler(ctx, "identity1")
+		Expect(listEnvoyFilterNames(rc)).NotTo(BeEmpty())
+		_, err := rc.IstioClient().GetVirtualService(ctx, vsName, options.GetSyncNamespace(), metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		gates, err := featuregate.Parse(map[string]string{featuregate.GatesKey: `
+- feature: throttlefilter
+  enabled: false
+  identity: identity1
+- feature: virtualservice
+  enabled: false
+  identity: identity1
+  cluster: cluster1
+`})
+		Expect(err).NotTo(HaveOccurred())
+		featuregate.SetState(featuregate.State{ConfigMapFound: true, Gates: gates})
+
+		triggerTrafficConfigHandler(ctx, "identity1")
+		Expect(listEnvoyFilterNames(rc)).To(BeEmpty())
+		_, err = rc.IstioClient().GetVirtualService(ctx, vsName, options.GetSyncNamespace(), metav1.GetOptions{})
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should leave the resources as is when a feature is only disabled by the flag", func() {
+		rc := addRemoteCluster("cluster1")
+		addWorkload("cluster1", "identity1", "qa")
+		cache.TrafficConfigCache.AddTrafficConfigToCache(resourcebuilder.GetFakeTrafficConfig("identity1", "qa", "1", "namespace"))
+
+		triggerTrafficConfigHandler(ctx, "identity1")
+		filterNames := listEnvoyFilterNames(rc)
+		Expect(filterNames).NotTo(BeEmpty())
+
+		options.InitializeNaavikArgs(&options.NaavikArgs{DisabledFeatures: []string{types.FeatureThrottleFilter.String()}})
+		triggerTrafficConfigHandler(ctx, "identity1")
+		Expect(listEnvoyFilterNames(rc)).To(Equal(filterNames))
+	})
+})
+
+var _ = Describe("Test reconcile of all traffic configs", func() {
+	var (
+		ctx         context.Context
+		logMessages []string
+		logLock     sync.Mutex
+	)
+
+	// countLogMessages returns the number of times the message was logged.
+	countLogMessages := func(message string) int {
+		logLock.Lock()
+		defer logLock.Unlock()
+		count := 0
+		for _, logMessage := range logMessages {
+			if logMessage == message {
+				count++
+			}
+		}
+		return count
+	}
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(&options.NaavikArgs{FullReconcileQPS: 5})
+		cache.ResetAllCaches()
+		cache.InformerSync.SetWarmedUp()
+		ctx = context.NewContextWithLogger()
+		leasechecker.RunStateCheck(ctx, leasechecker.GetStateChecker(ctx, types.StateCheckerNone))
+		logMessages = []string{}
+		ctx.Log = ctx.Log.Hook(func(_ string, msg string) {
+			logLock.Lock()
+			defer logLock.Unlock()
+			logMessages = append(logMessages, msg)
+		})
+		addRemoteCluster("cluster1")
+		for _, identity := range []string{"identity1", "identity2", "identity3"} {
+			addWorkload("cluster1", identity, "qa")
+			cache.TrafficConfigCache.AddTrafficConfigToCache(resourcebuilder.GetFakeTrafficConfig(identity, "qa", "1", "namespace"))
+		}
+	})
+
+	AfterEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		leasechecker.ResetState()
+	})
+
+	It("should cancel the reconcile in flight when a new one starts", func() {
+		first := make(chan struct{})
+		go func() {
+			defer close(first)
+			NewTrafficConfigHandler().ReconcileAllTrafficConfigs(ctx)
+		}()
+		Eventually(func() int { return countLogMessages("Reconciling all traffic configs started") }).Should(Equal(1))
+
+		NewTrafficConfigHandler().ReconcileAllTrafficConfigs(ctx)
+		Eventually(first).Should(BeClosed())
+		Expect(countLogMessages("Reconciling all traffic configs cancelled")).To(Equal(1))
+		Expect(countLogMessages("Reconciling all traffic configs started")).To(Equal(2))
+		Expect(countLogMessages("Reconciling all traffic configs completed")).To(Equal(1))
+	})
+
+	It("should stop the reconcile when the context is cancelled", func() {
+		cctx, cancel := gocontext.WithCancel(ctx.Context)
+		ctx.Context = cctx
+		cancel()
+		NewTrafficConfigHandler().ReconcileAllTrafficConfigs(ctx)
+		Expect(countLogMessages("Reconciling all traffic configs cancelled")).To(Equal(1))
+		Expect(countLogMessages("Reconciling all traffic configs completed")).To(BeZero())
+	})
+})
@@ -0,0 +1,60 @@
+package trafficconfig
+
+import (
+	"sort"
+	"testing"
+
+	fakeargoclientset "github.com/argoproj/argo-rollouts/pkg/client/clientset/versioned/fake"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/controller"
+	resourcebuilder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/internal/types/remotecluster"
+	fakeadmiralclientset "github.com/istio-ecosystem/admiral-api/pkg/client/clientset/versioned/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	fakeistioclientset "istio.io/client-go/pkg/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestTrafficConfigHandler(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "trafficconfig_handler_test")
+}
+
+// addRemoteCluster adds a remote cluster with empty fake clients to the cache.
+func addRemoteCluster(clusterID string) remotecluster.RemoteCluster {
+	rc := remotecluster.CreateRemoteCluster(clusterID, clusterID, clusterID, clusterID, nil, k8sfake.NewSimpleClientset(),
+		fakeistioclientset.NewSimpleClientset(), fakeargoclientset.NewSimpleClientset(), fakeadmiralclientset.NewSimpleClientset())
+	cache.RemoteCluster.AddCluster(rc)
+	return rc
+}
+
+// addWorkload adds a deployment of the identity and env in the cluster to the caches.
+func addWorkload(clusterID string, identity string, env string) {
+	cache.Deployments.Add(clusterID, resourcebuilder.BuildFakeDeployment(identity+"-"+env, identity, identity, env, "namespace"))
+	cache.IdentityCluster.AddClusterToIdentity(identity, clusterID)
+}
+
+// listEnvoyFilterNames returns the sorted names of the envoy filters of the cluster.
+func listEnvoyFilterNames(rc remotecluster.RemoteCluster) []string {
+	filterList, err := rc.IstioClient().ListEnvoyFilters(context.NewContextWithLogger(), types.NamespaceIstioSystem, metav1.ListOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	names := []string{}
+	for _, envoyFilter := range filterList.Items {
+		names = append(names, envoyFilter.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// triggerTrafficConfigHandler triggers the traffic config handler for the identity and drains the child events.
+func triggerTrafficConfigHandler(ctx context.Context, identity string) {
+	statusChan := make(chan controller.EventProcessStatus, 100)
+	NewTrafficConfigHandler().TriggerTrafficConfigHandlerForIdentity(ctx, identity, statusChan)
+	close(statusChan)
+	for range statusChan {
+	}
+}
@@ -8,15 +8,19 @@ import (
 	"github.com/intuit/naavik/cmd/options"
 	"github.com/intuit/naavik/internal/cache"
 	"github.com/intuit/naavik/internal/controller"
+	"github.com/intuit/naavik/internal/featuregate"
+	"github.com/intuit/naavik/internal/scope"
 	"github.com/intuit/naavik/internal/types"
 	"github.com/intuit/naavik/internal/types/context"
 	"github.com/intuit/naavik/internal/types/remotecluster"
 	"github.com/intuit/naavik/pkg/logger"
+	"github.com/intuit/naavik/pkg/metrics"
 	"github.com/intuit/naavik/pkg/utils"
 	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
 	"google.golang.org/protobuf/types/known/durationpb"
 	networkingv1alpha3 "istio.io/api/networking/v1alpha3"
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -58,29 +62,7 @@ func HandleVirtualServiceForTrafficConfig(ctx context.Context, trafficconfig *ad
 }
 
 func handleVirtualServiceForMeshDependents(ctx context.Context, dependents []string, tc utils.TrafficConfigInterface) {
-	dependentClusters := make(map[string]string)
-	for _, depIdentity := range dependents {
-		isIgnoredAsset := options.IsAssetIgnored(depIdentity)
-		if options.IsAssetIgnored(depIdentity) {
-			ctx.Log.Str(logger.WorkloadIdentifierKey, depIdentity).Bool("isIgnoredAsset", isIgnoredAsset).Infof("ignoring this dependent identity")
-			continue
-		}
-		// Check if the event should be handled only for a sourceIdentity
-		sourceIdentityCtx := ctx.Context.Value(types.SourceIdentityKey)
-		var sourceIdentity string
-		if sourceIdentityCtx != nil {
-			sourceIdentity = sourceIdentityCtx.(string)
-		}
-		if len(sourceIdentity) > 0 && sourceIdentity != depIdentity {
-			ctx.Log.Str(logger.DependentIdentityKey, depIdentity).Str(logger.SourceAssetKey, sourceIdentity).Info("Handling only for dependent is equal to sourceIdentity, skipping.")
-			continue
-		}
-
-		clusters := cache.IdentityCluster.GetClustersForIdentity(depIdentity)
-		for _, cluster := range clusters {
-			dependentClusters[cluster] = cluster
-		}
-	}
+	dependentClusters := getDependentClusters(ctx, dependents)
 	if len(dependentClusters) == 0 {
 		ctx.Log.Warn("no dependent clusters found")
 		return
@@ -92,7 +74,7 @@ func handleVirtualServiceForMeshDependents(ctx context.Context, dependents []str
 		return
 	}
 	for clusterID := range dependentClusters {
-		if !options.IsClusterInAllowedScope(clusterID) {
+		if !scope.IsClusterInScope(clusterID) {
 			ctx.Log.Str(logger.HandlerNameKey, "VirtualService").Str(logger.ClusterKey, clusterID).Warnf("cluster not in allowed scope, skipping.")
 			continue
 		}
@@ -101,10 +83,46 @@ func handleVirtualServiceForMeshDependents(ctx context.Context, dependents []str
 			ctx.Log.Str(logger.ClusterKey, clusterID).Info("remote cluster not found, skipping.")
 			continue
 		}
-		createUpdateDeleteVirtualServices(ctx, rc, vsMap, tc)
+		cache.Propagation.Expect(tc.GetIdentity(), tc.GetEnv(), tc.GetRevision(), clusterID, metrics.KindVirtualService)
+		var err error
+		if featuregate.IsEnabled(types.FeatureVirtualService, tc.GetIdentity(), tc.GetEnv(), clusterID) {
+			err = createUpdateDeleteVirtualServices(ctx, rc, vsMap, tc)
+		} else {
+			ctx.Log.Str(logger.HandlerNameKey, "VirtualService").Str(logger.ClusterKey, clusterID).Info("virtual service feature is disabled for the cluster, deleting the virtual service.")
+			err = deleteVirtualService(ctx, rc, vsMap.Name)
+		}
+		cache.Propagation.Completed(tc.GetIdentity(), tc.GetEnv(), tc.GetRevision(), clusterID, metrics.KindVirtualService, err)
 	}
 }
 
+// getDependentClusters returns the clusters of the dependents the virtual service is created in.
+// Ignored dependents are skipped, only the source identity is considered when it is set in the context.
+func getDependentClusters(ctx context.Context, dependents []string) map[string]string {
+	dependentClusters := make(map[string]string)
+	for _, depIdentity := range dependents {
+		if !scope.IsAssetInScope(depIdentity) {
+			ctx.Log.Str(logger.WorkloadIdentifierKey, depIdentity).Bool("isIgnoredAsset", true).Infof("ignoring this dependent identity")
+			continue
+		}
+		// Check if the event should be handled only for a sourceIdentity
+		sourceIdentityCtx := ctx.Context.Value(types.SourceIdentityKey)
+		var sourceIdentity string
+		if sourceIdentityCtx != nil {
+			sourceIdentity = sourceIdentityCtx.(string)
+		}
+		if len(sourceIdentity) > 0 && sourceIdentity != depIdentity {
+			ctx.Log.Str(logger.DependentIdentityKey, depIdentity).Str(logger.SourceAssetKey, sourceIdentity).Info("Handling only for dependent is equal to sourceIdentity, skipping.")
+			continue
+		}
+
+		clusters := cache.IdentityCluster.GetClustersForIdentity(depIdentity)
+		for _, cluster := range clusters {
+			dependentClusters[cluster] = cluster
+		}
+	}
+	return dependentClusters
+}
+
 // Form match rules here
 // we create virtual service per workloadEnv , but scan through all routes in edgeSpec ,
 // we will just keep constructing virtual service by going through all routes and see if that route is present in that env, If yes then we will add that route in that VS's route.
@@ -524,17 +542,8 @@ func constructHostsForVirtualService(envs []string, assetAlias string) []string
 
 func getVirtualServiceName(env string, assetAlias string) string {
 	const vsSuffix = "vs"
-	var envPrefix string
-	switch env {
-	case "qa":
-		envPrefix = "qal"
-	case "e2e":
-		envPrefix = "e2e"
-	case "prd":
-		envPrefix = "prd"
-	case "prf":
-		envPrefix = "prf"
-	default:
+	envPrefix, found := types.VirtualServiceEnvPrefixes[env]
+	if !found {
 		return ""
 	}
 	return fmt.Sprintf("%s-%s", types.GetHost(envPrefix, assetAlias, options.GetHostnameSuffix()), vsSuffix)
@@ -561,17 +570,26 @@ func buildVirtualServiceObject(tc utils.TrafficConfigInterface, vsRoutes []*netw
 	return vs
 }
 
-func createUpdateDeleteVirtualServices(ctx context.Context, rc remotecluster.RemoteCluster, vs *v1alpha3.VirtualService, tc utils.TrafficConfigInterface) {
+func createUpdateDeleteVirtualServices(ctx context.Context, rc remotecluster.RemoteCluster, vs *v1alpha3.VirtualService, tc utils.TrafficConfigInterface) error {
 	if tc.IsDisabled() {
-		rc.IstioClient().DeleteVirtualService(ctx, vs.Name, options.GetSyncNamespace(), metav1.DeleteOptions{})
-		return
+		return deleteVirtualService(ctx, rc, vs.Name)
 	}
 	existingVs, err := rc.IstioClient().GetVirtualService(ctx, vs.Name, options.GetSyncNamespace(), metav1.GetOptions{})
 
 	if existingVs != nil && err == nil {
 		vs.ObjectMeta.SetResourceVersion(existingVs.ResourceVersion)
-		rc.IstioClient().UpdateVirtualService(ctx, vs, metav1.UpdateOptions{})
+		_, err = rc.IstioClient().UpdateVirtualService(ctx, vs, metav1.UpdateOptions{})
 	} else {
-		rc.IstioClient().CreateVirtualService(ctx, vs, metav1.CreateOptions{})
+		_, err = rc.IstioClient().CreateVirtualService(ctx, vs, metav1.CreateOptions{})
+	}
+	return err
+}
+
+// deleteVirtualService deletes the virtual service from the sync namespace, a missing virtual service is not an error.
+func deleteVirtualService(ctx context.Context, rc remotecluster.RemoteCluster, name string) error {
+	err := rc.IstioClient().DeleteVirtualService(ctx, name, options.GetSyncNamespace(), metav1.DeleteOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
 	}
+	return err
 }
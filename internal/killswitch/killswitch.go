@@ -0,0 +1,127 @@
+package killswitch
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intuit/naavik/cmd/options"
+	"sigs.k8s.io/yaml"
+)
+
+// StateKey is the config map key holding the YAML kill switch record.
+const StateKey = "state"
+
+// maxAuditEntries is the max number of audit entries kept in the config map, the oldest ones are dropped.
+const maxAuditEntries = 100
+
+// Enforced percentages of the generated throttle filters.
+const (
+	EnforcedPercentage    = 100
+	NotEnforcedPercentage = 0
+)
+
+type Action string
+
+const (
+	ActionEngage  Action = "engage"
+	ActionRestore Action = "restore"
+)
+
+// AuditEntry records a change of the kill switch.
+type AuditEntry struct {
+	Action   Action    `json:"action"`
+	Actor    string    `json:"actor"`
+	Reason   string    `json:"reason"`
+	ClientIP string    `json:"clientIp,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Record is the kill switch as persisted in the config map.
+type Record struct {
+	Engaged bool         `json:"engaged"`
+	Audit   []AuditEntry `json:"audit,omitempty"`
+}
+
+// State is the last state read from the kill switch config map.
+type State struct {
+	// Engaged is true when the generated throttle filters are not enforced, by the flag or the config map.
+	Engaged         bool      `json:"engaged"`
+	EngagedByFlag   bool      `json:"engagedByFlag"`
+	ConfigMapFound  bool      `json:"configMapFound"`
+	ResourceVersion string    `json:"resourceVersion,omitempty"`
+	LastUpdated     time.Time `json:"lastUpdated"`
+	// Error is set when the config map is invalid, the last valid record is kept.
+	Error string `json:"error,omitempty"`
+	// Audit lists the changes of the kill switch, most recent last.
+	Audit []AuditEntry `json:"audit"`
+}
+
+var (
+	currentState = State{Audit: []AuditEntry{}}
+	stateLock    = sync.RWMutex{}
+)
+
+// Parse parses the kill switch record of the config map data.
+func Parse(data map[string]string) (*Record, error) {
+	record := &Record{}
+	content, ok := data[StateKey]
+	if !ok || len(strings.TrimSpace(content)) == 0 {
+		return record, nil
+	}
+	if err := yaml.UnmarshalStrict([]byte(content), record); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", StateKey, err)
+	}
+	return record, nil
+}
+
+// Apply returns the record with the action applied and audited, keeping the last maxAuditEntries entries.
+func (r Record) Apply(entry AuditEntry) Record {
+	r.Engaged = entry.Action == ActionEngage
+	audit := append(make([]AuditEntry, 0, len(r.Audit)+1), r.Audit...)
+	audit = append(audit, entry)
+	if len(audit) > maxAuditEntries {
+		audit = audit[len(audit)-maxAuditEntries:]
+	}
+	r.Audit = audit
+	return r
+}
+
+// SetState replaces the state read from the config map.
+func SetState(state State) {
+	stateLock.Lock()
+	defer stateLock.Unlock()
+	if state.Audit == nil {
+		state.Audit = []AuditEntry{}
+	}
+	currentState = state
+}
+
+// GetState returns the last state read from the config map, with the flag applied.
+func GetState() State {
+	stateLock.RLock()
+	defer stateLock.RUnlock()
+	state := currentState
+	state.EngagedByFlag = options.IsRateLimitKillSwitchEngaged()
+	state.Engaged = state.Engaged || state.EngagedByFlag
+	return state
+}
+
+// Reset clears the state read from the config map.
+func Reset() {
+	SetState(State{})
+}
+
+// IsEngaged returns true if the generated throttle filters must not be enforced.
+func IsEngaged() bool {
+	return GetState().Engaged
+}
+
+// GetEnforcedPercentage returns the local_rate_limit_enforced runtime default of the generated throttle filters.
+func GetEnforcedPercentage() float64 {
+	if IsEngaged() {
+		return NotEnforcedPercentage
+	}
+	return EnforcedPercentage
+}
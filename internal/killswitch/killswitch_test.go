@@ -0,0 +1,124 @@
+package killswitch
+
+import (
+	gocontext "context"
+	"fmt"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/configmapwatcher"
+	"github.com/intuit/naavik/internal/types/context"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Test kill switch state", func() {
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		Reset()
+	})
+
+	It("should enforce the throttle filters unless engaged", func() {
+		Expect(IsEngaged()).To(BeFalse())
+		Expect(GetEnforcedPercentage()).To(BeEquivalentTo(EnforcedPercentage))
+		SetState(State{Engaged: true})
+		Expect(GetEnforcedPercentage()).To(BeEquivalentTo(NotEnforcedPercentage))
+	})
+
+	It("should stay engaged by the flag", func() {
+		options.InitializeNaavikArgs(&options.NaavikArgs{RateLimitKillSwitch: true})
+		state := GetState()
+		Expect(state.Engaged).To(BeTrue())
+		Expect(state.EngagedByFlag).To(BeTrue())
+		Expect(GetEnforcedPercentage()).To(BeEquivalentTo(NotEnforcedPercentage))
+	})
+
+	It("should audit the changes and keep the last entries", func() {
+		record := Record{}
+		for i := 0; i < maxAuditEntries+1; i++ {
+			record = record.Apply(AuditEntry{Action: ActionEngage, Actor: fmt.Sprintf("user%d", i), Reason: "incident"})
+		}
+		record = record.Apply(AuditEntry{Action: ActionRestore, Actor: "oncall", Reason: "resolved"})
+		Expect(record.Engaged).To(BeFalse())
+		Expect(record.Audit).To(HaveLen(maxAuditEntries))
+		Expect(record.Audit[0].Actor).To(Equal("user2"))
+		Expect(record.Audit[maxAuditEntries-1].Actor).To(Equal("oncall"))
+	})
+
+	It("should reject unknown fields", func() {
+		_, err := Parse(map[string]string{StateKey: "enabled: true\n"})
+		Expect(err).To(HaveOccurred())
+		record, err := Parse(map[string]string{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(record.Engaged).To(BeFalse())
+	})
+})
+
+var _ = Describe("Test kill switch config map changes", func() {
+	var (
+		w       *watcher
+		changes []bool
+	)
+	ctx := context.NewContextWithLogger()
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		Reset()
+		changes = nil
+		w = &watcher{opts: WatcherOpts{ConfigMapName: "naavik-kill-switch", OnChange: func(_ context.Context, engaged bool) {
+			changes = append(changes, engaged)
+		}}}
+	})
+
+	buildConfigMap := func(state string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "naavik-kill-switch", ResourceVersion: "1"},
+			Data:       map[string]string{StateKey: state},
+		}
+	}
+
+	It("should apply the kill switch when engaged or restored", func() {
+		w.apply(ctx, configmapwatcher.NewEvent(buildConfigMap("engaged: true\naudit:\n- action: engage\n  actor: oncall\n  reason: incident\n"), Parse))
+		Expect(changes).To(Equal([]bool{true}))
+		Expect(GetState().Audit).To(HaveLen(1))
+
+		w.apply(ctx, configmapwatcher.NewEvent(buildConfigMap("engaged: true\n"), Parse))
+		Expect(changes).To(Equal([]bool{true}))
+
+		w.apply(ctx, configmapwatcher.NewEvent(buildConfigMap("engaged: maybe\n"), Parse))
+		Expect(changes).To(Equal([]bool{true}))
+		Expect(GetState().Error).NotTo(BeEmpty())
+		Expect(IsEngaged()).To(BeTrue())
+
+		w.apply(ctx, configmapwatcher.NewEvent(nil, Parse))
+		Expect(changes).To(Equal([]bool{true, false}))
+		Expect(GetState().ConfigMapFound).To(BeFalse())
+	})
+
+	It("should persist and audit the changes in the config map", func() {
+		_, err := Set(gocontext.Background(), AuditEntry{Action: ActionEngage})
+		Expect(err).To(MatchError(ErrNotWatching))
+
+		client := fake.NewSimpleClientset()
+		w.client = client
+		w.opts.Namespace = "admiral-sync"
+		activeWatcher.Store(w)
+		defer activeWatcher.Store(nil)
+
+		record, err := Set(gocontext.Background(), AuditEntry{Action: ActionEngage, Actor: "oncall", Reason: "incident"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(record.Engaged).To(BeTrue())
+		_, err = Set(gocontext.Background(), AuditEntry{Action: ActionRestore, Actor: "oncall", Reason: "resolved"})
+		Expect(err).NotTo(HaveOccurred())
+
+		cm, err := client.CoreV1().ConfigMaps("admiral-sync").Get(gocontext.Background(), "naavik-kill-switch", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		stored, err := Parse(cm.Data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stored.Engaged).To(BeFalse())
+		Expect(stored.Audit).To(HaveLen(2))
+		Expect(stored.Audit[0].Time.IsZero()).To(BeFalse())
+	})
+})
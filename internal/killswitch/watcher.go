@@ -0,0 +1,144 @@
+package killswitch
+
+import (
+	gocontext "context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/intuit/naavik/internal/configmapwatcher"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/yaml"
+)
+
+// ErrNotWatching is returned when the kill switch is changed while its config map is not watched.
+var ErrNotWatching = errors.New("kill switch config map is not watched")
+
+type WatcherOpts struct {
+	Namespace     string
+	ConfigMapName string
+	ResyncPeriod  time.Duration
+	// OnChange is called when the kill switch is engaged or restored. It is called by the config map watcher and must not block.
+	OnChange func(ctx context.Context, engaged bool)
+}
+
+type watcher struct {
+	opts   WatcherOpts
+	client kubernetes.Interface
+}
+
+// activeWatcher is the watcher the kill switch is changed with.
+var activeWatcher atomic.Pointer[watcher]
+
+// Watch watches the kill switch config map until the context is cancelled.
+func Watch(ctx context.Context, client kubernetes.Interface, opts WatcherOpts) {
+	w := &watcher{opts: opts, client: client}
+	activeWatcher.Store(w)
+	defer activeWatcher.CompareAndSwap(w, nil)
+	configmapwatcher.Watch(ctx, client, configmapwatcher.Opts[*Record]{
+		Name:          "kill switch",
+		Namespace:     opts.Namespace,
+		ConfigMapName: opts.ConfigMapName,
+		ResyncPeriod:  opts.ResyncPeriod,
+		Parse:         Parse,
+		OnChange:      w.apply,
+	})
+}
+
+// apply applies the kill switch record of the config map and reports when the kill switch is engaged or restored.
+func (w *watcher) apply(ctx context.Context, event configmapwatcher.Event[*Record]) {
+	log := ctx.Log.Str(logger.NameKey, w.opts.ConfigMapName).Str(logger.NamespaceKey, w.opts.Namespace)
+	previous := GetState()
+	state := State{LastUpdated: time.Now(), ConfigMapFound: event.Found, ResourceVersion: event.ResourceVersion}
+	if event.Err != nil {
+		// Keep the last valid record, an edit gone wrong must not flip the enforcement of the whole mesh
+		log.Str(logger.ErrorKey, event.Err.Error()).Error("Invalid kill switch config map, keeping the last valid state")
+		state.Engaged = getStoredEngaged()
+		state.Audit = previous.Audit
+		state.Error = event.Err.Error()
+		SetState(state)
+		return
+	}
+	if event.Value != nil {
+		state.Engaged = event.Value.Engaged
+		state.Audit = event.Value.Audit
+	}
+	SetState(state)
+	current := GetState()
+	if len(current.Audit) > 0 {
+		last := current.Audit[len(current.Audit)-1]
+		log = log.Str("action", string(last.Action)).Str("actor", last.Actor).Str("reason", last.Reason)
+	}
+	log.Bool("engaged", current.Engaged).Bool("engagedByFlag", current.EngagedByFlag).Info("Kill switch state updated")
+	if current.Engaged != previous.Engaged && w.opts.OnChange != nil {
+		w.opts.OnChange(ctx, current.Engaged)
+	}
+}
+
+// getStoredEngaged returns the engaged state read from the config map, without the flag.
+func getStoredEngaged() bool {
+	stateLock.RLock()
+	defer stateLock.RUnlock()
+	return currentState.Engaged
+}
+
+// Set engages or restores the kill switch in the config map, creating it if missing, and audits the change.
+// The watcher applies the change once the update is received.
+func Set(ctx gocontext.Context, entry AuditEntry) (*Record, error) {
+	w := activeWatcher.Load()
+	if w == nil {
+		return nil, ErrNotWatching
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	configMaps := w.client.CoreV1().ConfigMaps(w.opts.Namespace)
+	var saved Record
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := configMaps.Get(ctx, w.opts.ConfigMapName, metav1.GetOptions{})
+		notFound := k8serrors.IsNotFound(err)
+		if err != nil && !notFound {
+			return err
+		}
+		if notFound {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      w.opts.ConfigMapName,
+					Namespace: w.opts.Namespace,
+					Labels:    map[string]string{types.CreatedByKey: types.NaavikName},
+				},
+			}
+		}
+		record, err := Parse(cm.Data)
+		if err != nil {
+			// The switch must work during an incident, the invalid record and its audit trail are replaced
+			record = &Record{}
+		}
+		saved = record.Apply(entry)
+		content, err := yaml.Marshal(saved)
+		if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[StateKey] = string(content)
+		if notFound {
+			_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+		} else {
+			_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
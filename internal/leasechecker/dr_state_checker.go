@@ -0,0 +1,171 @@
+package leasechecker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/intuit/naavik/internal/configmapwatcher"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DRActiveRegionKey is the config map key naming the region that is allowed to write.
+	DRActiveRegionKey = "activeRegion"
+	// DRForceReadOnlyKey is the config map key to force all the regions to read only, e.g. during maintenance.
+	DRForceReadOnlyKey = "forceReadOnly"
+
+	invalidDRConfigMapReason = "invalid dr config map"
+)
+
+// DRState is the active/passive state read from the DR config map.
+type DRState struct {
+	Region          string    `json:"region"`
+	ActiveRegion    string    `json:"activeRegion"`
+	ForceReadOnly   bool      `json:"forceReadOnly"`
+	ConfigMapFound  bool      `json:"configMapFound"`
+	ResourceVersion string    `json:"resourceVersion,omitempty"`
+	LastUpdated     time.Time `json:"lastUpdated"`
+}
+
+// IsActive returns true when this region is allowed to write.
+func (d DRState) IsActive() bool {
+	return d.ConfigMapFound && !d.ForceReadOnly && strings.EqualFold(d.ActiveRegion, d.Region)
+}
+
+// reason describes why the region is active or passive.
+func (d DRState) reason() string {
+	switch {
+	case !d.ConfigMapFound:
+		return "dr config map not found"
+	case d.ForceReadOnly:
+		return "read only forced by dr config map"
+	case d.IsActive():
+		return fmt.Sprintf("region %s is active", d.Region)
+	default:
+		return fmt.Sprintf("region %s is passive, active region is %s", d.Region, d.ActiveRegion)
+	}
+}
+
+var currentDRState *DRState
+
+type DRStateCheckerOpts struct {
+	Namespace     string
+	ConfigMapName string
+	Region        string
+	ResyncPeriod  time.Duration
+}
+
+/*
+Active/passive implementation of the interface defined for DR.
+Watches a config map naming the active region, only the instances in the active region are read/write.
+Controllers keep running in the passive region so the caches are warm when the region becomes active.
+*/
+type drStateChecker struct {
+	client kubernetes.Interface
+	opts   DRStateCheckerOpts
+}
+
+// drConfig is the data of the DR config map.
+type drConfig struct {
+	ActiveRegion  string
+	ForceReadOnly bool
+}
+
+// parseDRConfig parses the data of the DR config map, an invalid forceReadOnly value is an error.
+func parseDRConfig(data map[string]string) (drConfig, error) {
+	config := drConfig{ActiveRegion: strings.TrimSpace(data[DRActiveRegionKey])}
+	if forceReadOnly, ok := data[DRForceReadOnlyKey]; ok && len(forceReadOnly) > 0 {
+		force, err := strconv.ParseBool(strings.TrimSpace(forceReadOnly))
+		if err != nil {
+			return drConfig{}, fmt.Errorf("invalid %s value %q: %w", DRForceReadOnlyKey, forceReadOnly, err)
+		}
+		config.ForceReadOnly = force
+	}
+	return config, nil
+}
+
+func NewDRStateChecker(client kubernetes.Interface, opts DRStateCheckerOpts) LeaseStateChecker {
+	return &drStateChecker{
+		client: client,
+		opts:   opts,
+	}
+}
+
+func (*drStateChecker) ShouldRunOnIndependentGoRoutine() bool {
+	return true
+}
+
+// InitStateCache records the DR state from the config map and switches the lease state to match it, nil means the config map does not exist.
+// The controllers keep running whatever the lease state, so the caches of the passive region stay warm.
+// It is not given a context, the config map events of RunStateCheck are applied with the context of the state checker.
+func (d *drStateChecker) InitStateCache(obj interface{}) error {
+	cm, ok := obj.(*corev1.ConfigMap)
+	if obj != nil && !ok {
+		return fmt.Errorf("invalid dr state object of type %T, expected config map", obj)
+	}
+	return d.apply(context.NewContextWithLogger(), configmapwatcher.NewEvent(cm, parseDRConfig))
+}
+
+// apply records the DR state of the config map event and the lease state it results in.
+func (d *drStateChecker) apply(ctx context.Context, event configmapwatcher.Event[drConfig]) error {
+	state := DRState{
+		Region:          d.opts.Region,
+		ConfigMapFound:  event.Found,
+		ResourceVersion: event.ResourceVersion,
+		LastUpdated:     time.Now(),
+	}
+	if event.Err != nil {
+		// Fail safe, an invalid config map must not leave the region writable
+		state.ForceReadOnly = true
+		setDRState(state)
+		updateState(ctx, readOnlyEnabled, invalidDRConfigMapReason)
+		return event.Err
+	}
+	state.ActiveRegion = event.Value.ActiveRegion
+	state.ForceReadOnly = event.Value.ForceReadOnly
+	setDRState(state)
+	updateState(ctx, !state.IsActive(), state.reason())
+	return nil
+}
+
+// RunStateCheck watches the DR config map until the context is cancelled.
+func (d *drStateChecker) RunStateCheck(ctx context.Context) {
+	ctx.Log = ctx.Log.Str("region", d.opts.Region)
+	// Instance is read only until the DR config map is read
+	updateState(ctx, readOnlyEnabled, "waiting for dr config map")
+	configmapwatcher.Watch(ctx, d.client, configmapwatcher.Opts[drConfig]{
+		Name:           "DR state",
+		Namespace:      d.opts.Namespace,
+		ConfigMapName:  d.opts.ConfigMapName,
+		ResyncPeriod:   d.opts.ResyncPeriod,
+		Parse:          parseDRConfig,
+		NotifyNotFound: true,
+		OnChange: func(ctx context.Context, event configmapwatcher.Event[drConfig]) {
+			if err := d.apply(ctx, event); err != nil {
+				ctx.Log.Str(logger.NameKey, d.opts.ConfigMapName).Str(logger.ErrorKey, err.Error()).Error("Invalid DR config map, switching to read only mode")
+			}
+		},
+	})
+}
+
+func setDRState(state DRState) {
+	leaseLock.Lock()
+	defer leaseLock.Unlock()
+	currentDRState = &state
+}
+
+// GetDRState returns the last DR state read from the config map, nil when the DR state checker is not used.
+func GetDRState() *DRState {
+	leaseLock.Lock()
+	defer leaseLock.Unlock()
+	if currentDRState == nil {
+		return nil
+	}
+	state := *currentDRState
+	return &state
+}
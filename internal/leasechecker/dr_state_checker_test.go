@@ -0,0 +1,170 @@
+package leasechecker_test
+
+import (
+	gocontext "context"
+	"sync/atomic"
+	"time"
+
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/types/context"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Test DR State Checker", Label("lease_checker_test"), func() {
+	var ctx context.Context
+	var cancel gocontext.CancelFunc
+	var opts leasechecker.DRStateCheckerOpts
+	var stopped chan struct{}
+
+	buildConfigMap := func(data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "naavik-dr", Namespace: "admiral-sync"},
+			Data:       data,
+		}
+	}
+
+	runStateCheck := func(stateChecker leasechecker.LeaseStateChecker) {
+		stopped = make(chan struct{})
+		go func() {
+			defer close(stopped)
+			stateChecker.RunStateCheck(ctx)
+		}()
+	}
+
+	BeforeEach(func() {
+		leasechecker.ResetState()
+		ctx = context.NewContextWithLogger()
+		var newctx gocontext.Context
+		newctx, cancel = gocontext.WithCancel(ctx.Context)
+		ctx.Context = newctx
+		opts = leasechecker.DRStateCheckerOpts{
+			Namespace:     "admiral-sync",
+			ConfigMapName: "naavik-dr",
+			Region:        "us-west-2",
+		}
+		stopped = nil
+	})
+
+	AfterEach(func() {
+		cancel()
+		if stopped != nil {
+			Eventually(stopped, 5*time.Second).Should(BeClosed())
+		}
+		leasechecker.ResetState()
+	})
+
+	When("the config map names this region as active", func() {
+		It("should switch to read write mode and back to read only on failover", func() {
+			client := fake.NewSimpleClientset(buildConfigMap(map[string]string{leasechecker.DRActiveRegionKey: "us-west-2"}))
+			runStateCheck(leasechecker.NewDRStateChecker(client, opts))
+			Eventually(leasechecker.IsReadOnly, 5*time.Second).Should(BeFalse())
+			Expect(leasechecker.GetDRState().ActiveRegion).To(Equal("us-west-2"))
+
+			_, err := client.CoreV1().ConfigMaps("admiral-sync").Update(ctx.Context,
+				buildConfigMap(map[string]string{leasechecker.DRActiveRegionKey: "us-east-2"}), metav1.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Eventually(leasechecker.IsReadOnly, 5*time.Second).Should(BeTrue())
+
+			transitions := leasechecker.GetStateTransitions()
+			Expect(transitions).To(HaveLen(3))
+			Expect(transitions[0].ReadOnly).To(BeTrue())
+			Expect(transitions[0].Reason).To(Equal("waiting for dr config map"))
+			Expect(transitions[1].ReadOnly).To(BeFalse())
+			Expect(transitions[1].Reason).To(Equal("region us-west-2 is active"))
+			Expect(transitions[2].ReadOnly).To(BeTrue())
+			Expect(transitions[2].Reason).To(Equal("region us-west-2 is passive, active region is us-east-2"))
+		})
+	})
+
+	When("the config map becomes invalid", func() {
+		It("should switch from read write to read only mode", func() {
+			client := fake.NewSimpleClientset(buildConfigMap(map[string]string{leasechecker.DRActiveRegionKey: "us-west-2"}))
+			runStateCheck(leasechecker.NewDRStateChecker(client, opts))
+			Eventually(leasechecker.IsReadOnly, 5*time.Second).Should(BeFalse())
+
+			_, err := client.CoreV1().ConfigMaps("admiral-sync").Update(ctx.Context, buildConfigMap(map[string]string{
+				leasechecker.DRActiveRegionKey:  "us-west-2",
+				leasechecker.DRForceReadOnlyKey: "maybe",
+			}), metav1.UpdateOptions{})
+			Expect(err).To(BeNil())
+			Eventually(leasechecker.IsReadOnly, 5*time.Second).Should(BeTrue())
+			Consistently(leasechecker.IsReadOnly, 500*time.Millisecond).Should(BeTrue())
+			transitions := leasechecker.GetStateTransitions()
+			Expect(transitions[len(transitions)-1].Reason).To(Equal("invalid dr config map"))
+		})
+	})
+
+	When("the config map events are applied", func() {
+		It("should log the lease state changes with the context of the state checker", func() {
+			var stateChanges atomic.Int32
+			ctx.Log = ctx.Log.Hook(func(_ string, msg string) {
+				if msg == "Lease state changed" {
+					stateChanges.Add(1)
+				}
+			})
+			client := fake.NewSimpleClientset(buildConfigMap(map[string]string{leasechecker.DRActiveRegionKey: "us-west-2"}))
+			runStateCheck(leasechecker.NewDRStateChecker(client, opts))
+			Eventually(leasechecker.IsReadOnly, 5*time.Second).Should(BeFalse())
+			// Waiting for the config map, then read write
+			Expect(stateChanges.Load()).To(BeEquivalentTo(2))
+		})
+	})
+
+	When("read only is forced in the config map", func() {
+		It("should stay in read only mode", func() {
+			client := fake.NewSimpleClientset(buildConfigMap(map[string]string{
+				leasechecker.DRActiveRegionKey:  "us-west-2",
+				leasechecker.DRForceReadOnlyKey: "true",
+			}))
+			runStateCheck(leasechecker.NewDRStateChecker(client, opts))
+			Eventually(leasechecker.GetDRState, 5*time.Second).ShouldNot(BeNil())
+			Expect(leasechecker.GetDRState().ForceReadOnly).To(BeTrue())
+			Expect(leasechecker.IsReadOnly()).To(BeTrue())
+			Expect(leasechecker.IsStateInitialized()).To(BeTrue())
+		})
+	})
+
+	When("the config map does not exist", func() {
+		It("should stay in read only mode", func() {
+			runStateCheck(leasechecker.NewDRStateChecker(fake.NewSimpleClientset(), opts))
+			Eventually(leasechecker.GetDRState, 5*time.Second).ShouldNot(BeNil())
+			Expect(leasechecker.GetDRState().ConfigMapFound).To(BeFalse())
+			Expect(leasechecker.IsReadOnly()).To(BeTrue())
+		})
+	})
+
+	When("the state cache is initialized with the config map", func() {
+		It("should expose the lease state of the DR state", func() {
+			stateChecker := leasechecker.NewDRStateChecker(fake.NewSimpleClientset(), opts)
+			Expect(stateChecker.InitStateCache(buildConfigMap(map[string]string{leasechecker.DRActiveRegionKey: "us-west-2"}))).To(Succeed())
+			Expect(leasechecker.IsReadOnly()).To(BeFalse())
+			Expect(leasechecker.GetStateTransitions()).NotTo(BeEmpty())
+
+			Expect(stateChecker.InitStateCache(buildConfigMap(map[string]string{leasechecker.DRActiveRegionKey: "us-east-2"}))).To(Succeed())
+			Expect(leasechecker.IsReadOnly()).To(BeTrue())
+			transitions := leasechecker.GetStateTransitions()
+			Expect(transitions[len(transitions)-1].Reason).To(Equal("region us-west-2 is passive, active region is us-east-2"))
+		})
+	})
+
+	When("the state cache is initialized with an invalid object", func() {
+		It("should return an error", func() {
+			stateChecker := leasechecker.NewDRStateChecker(fake.NewSimpleClientset(), opts)
+			Expect(stateChecker.InitStateCache("invalid")).NotTo(BeNil())
+		})
+
+		It("should force read only for an invalid override", func() {
+			stateChecker := leasechecker.NewDRStateChecker(fake.NewSimpleClientset(), opts)
+			err := stateChecker.InitStateCache(buildConfigMap(map[string]string{
+				leasechecker.DRActiveRegionKey:  "us-west-2",
+				leasechecker.DRForceReadOnlyKey: "maybe",
+			}))
+			Expect(err).NotTo(BeNil())
+			Expect(leasechecker.GetDRState().IsActive()).To(BeFalse())
+		})
+	})
+})
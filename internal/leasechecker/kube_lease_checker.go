@@ -0,0 +1,105 @@
+package leasechecker
+
+import (
+	gocontext "context"
+	"time"
+
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/logger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+type KubeLeaseStateCheckerOpts struct {
+	Namespace     string
+	Name          string
+	Identity      string
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+}
+
+/*
+Lease based implementation of the interface defined for DR.
+Uses a coordination.k8s.io Lease to elect a single read/write instance, all the other instances are read only.
+*/
+type kubeLeaseStateChecker struct {
+	client kubernetes.Interface
+	opts   KubeLeaseStateCheckerOpts
+}
+
+func NewKubeLeaseStateChecker(client kubernetes.Interface, opts KubeLeaseStateCheckerOpts) LeaseStateChecker {
+	return &kubeLeaseStateChecker{
+		client: client,
+		opts:   opts,
+	}
+}
+
+func (kubeLeaseStateChecker) ShouldRunOnIndependentGoRoutine() bool {
+	return true
+}
+
+func (kubeLeaseStateChecker) InitStateCache(_ interface{}) error {
+	return nil
+}
+
+// RunStateCheck campaigns for the lease until the context is cancelled.
+// The instance is read/write while it holds the lease and switches to read only as soon as the lease is lost.
+func (k *kubeLeaseStateChecker) RunStateCheck(ctx context.Context) {
+	log := ctx.Log.Str(logger.NameKey, k.opts.Name).Str(logger.NamespaceKey, k.opts.Namespace).Str("identity", k.opts.Identity)
+	// Instance is read only until the lease is acquired
+	updateState(ctx, readOnlyEnabled, "waiting to acquire lease")
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      k.opts.Name,
+			Namespace: k.opts.Namespace,
+		},
+		Client: k.client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: k.opts.Identity,
+		},
+	}
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   k.opts.LeaseDuration,
+		RenewDeadline:   k.opts.RenewDeadline,
+		RetryPeriod:     k.opts.RetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            k.opts.Name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(_ gocontext.Context) {
+				log.Info("Acquired lease, switching to read write mode")
+				updateState(ctx, readWriteEnabled, "lease acquired")
+			},
+			OnStoppedLeading: func() {
+				// Called every time the elector stops, even if the lease was never acquired
+				if !IsReadOnly() {
+					log.Info("Lost lease, switching to read only mode")
+				}
+				updateState(ctx, readOnlyEnabled, "lease not held")
+			},
+			OnNewLeader: func(holder string) {
+				log.Str("holder", holder).Info("Lease holder changed")
+				setLeaseHolder(holder)
+			},
+		},
+	})
+	if err != nil {
+		log.Fatalf("error initializing lease state checker: %v", err)
+		return
+	}
+
+	// Run returns when the lease is lost, campaign again until the context is cancelled.
+	// The lease is released on cancel, so the standby does not wait out the lease duration.
+	for ctx.Context.Err() == nil {
+		elector.Run(ctx.Context)
+	}
+	if GetLeaseHolder() == k.opts.Identity {
+		setLeaseHolder("")
+	}
+	log.Info("Lease state checker stopped")
+}
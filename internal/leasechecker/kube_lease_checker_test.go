@@ -0,0 +1,104 @@
+package leasechecker_test
+
+import (
+	gocontext "context"
+	"time"
+
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/types/context"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+)
+
+var _ = Describe("Test Kube Lease State Checker", Label("lease_checker_test"), func() {
+	var ctx context.Context
+	var cancel gocontext.CancelFunc
+	var opts leasechecker.KubeLeaseStateCheckerOpts
+	var stopped chan struct{}
+
+	runStateCheck := func(leaseChecker leasechecker.LeaseStateChecker) {
+		stopped = make(chan struct{})
+		go func() {
+			defer close(stopped)
+			leaseChecker.RunStateCheck(ctx)
+		}()
+	}
+
+	BeforeEach(func() {
+		leasechecker.ResetState()
+		ctx = context.NewContextWithLogger()
+		var newctx gocontext.Context
+		newctx, cancel = gocontext.WithCancel(ctx.Context)
+		ctx.Context = newctx
+		opts = leasechecker.KubeLeaseStateCheckerOpts{
+			Namespace:     "admiral-sync",
+			Name:          "naavik-lease",
+			Identity:      "naavik-0",
+			LeaseDuration: 2 * time.Second,
+			RenewDeadline: time.Second,
+			RetryPeriod:   100 * time.Millisecond,
+		}
+	})
+
+	AfterEach(func() {
+		cancel()
+		// Wait for the checker to release the lease so the state is not updated after reset
+		Eventually(stopped, 5*time.Second).Should(BeClosed())
+		leasechecker.ResetState()
+	})
+
+	When("the lease is not held by any instance", func() {
+		It("should acquire the lease and switch to read write mode", func() {
+			client := fake.NewSimpleClientset()
+			leaseChecker := leasechecker.NewKubeLeaseStateChecker(client, opts)
+			Expect(leaseChecker.ShouldRunOnIndependentGoRoutine()).To(BeTrue())
+			runStateCheck(leaseChecker)
+
+			Eventually(leasechecker.IsReadOnly, 5*time.Second).Should(BeFalse())
+			Expect(leasechecker.IsStateInitialized()).To(BeTrue())
+			Eventually(leasechecker.GetLeaseHolder, 5*time.Second).Should(Equal("naavik-0"))
+
+			lease, err := client.CoordinationV1().Leases("admiral-sync").Get(ctx.Context, "naavik-lease", metav1.GetOptions{})
+			Expect(err).To(BeNil())
+			Expect(*lease.Spec.HolderIdentity).To(Equal("naavik-0"))
+		})
+
+		It("should release the lease when the context is cancelled", func() {
+			client := fake.NewSimpleClientset()
+			runStateCheck(leasechecker.NewKubeLeaseStateChecker(client, opts))
+			Eventually(leasechecker.GetLeaseHolder, 5*time.Second).Should(Equal("naavik-0"))
+
+			cancel()
+			Eventually(stopped, 5*time.Second).Should(BeClosed())
+			Expect(leasechecker.IsReadOnly()).To(BeTrue())
+			Expect(leasechecker.GetLeaseHolder()).To(BeEmpty())
+			lease, err := client.CoordinationV1().Leases("admiral-sync").Get(gocontext.Background(), "naavik-lease", metav1.GetOptions{})
+			Expect(err).To(BeNil())
+			Expect(lease.Spec.HolderIdentity).To(SatisfyAny(BeNil(), HaveValue(BeEmpty())))
+		})
+	})
+
+	When("the lease is held by another instance", func() {
+		It("should stay in read only mode and expose the holder", func() {
+			now := metav1.NewMicroTime(time.Now())
+			client := fake.NewSimpleClientset(&coordinationv1.Lease{
+				ObjectMeta: metav1.ObjectMeta{Name: "naavik-lease", Namespace: "admiral-sync"},
+				Spec: coordinationv1.LeaseSpec{
+					HolderIdentity:       ptr.To("naavik-1"),
+					LeaseDurationSeconds: ptr.To[int32](60),
+					AcquireTime:          &now,
+					RenewTime:            &now,
+				},
+			})
+			runStateCheck(leasechecker.NewKubeLeaseStateChecker(client, opts))
+
+			Eventually(leasechecker.GetLeaseHolder, 5*time.Second).Should(Equal("naavik-1"))
+			Consistently(leasechecker.IsReadOnly, 500*time.Millisecond).Should(BeTrue())
+			Expect(leasechecker.IsStateInitialized()).To(BeTrue())
+		})
+	})
+})
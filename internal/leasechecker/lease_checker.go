@@ -2,9 +2,12 @@ package leasechecker
 
 import (
 	"sync"
+	"time"
 
+	"github.com/intuit/naavik/cmd/options"
 	"github.com/intuit/naavik/internal/types"
 	"github.com/intuit/naavik/internal/types/context"
+	k8sutils "github.com/intuit/naavik/internal/utils/k8s"
 	"github.com/intuit/naavik/pkg/logger"
 )
 
@@ -13,6 +16,8 @@ const (
 	readOnlyEnabled     = true
 	stateNotInitialized = false
 	stateInitialized    = true
+
+	maxStateTransitions = 50
 )
 
 type LeaseState struct {
@@ -20,11 +25,32 @@ type LeaseState struct {
 	IsStateInitialized bool
 }
 
+// StateTransition records a change of the lease state.
+type StateTransition struct {
+	Time             time.Time `json:"time"`
+	PreviousReadOnly bool      `json:"previousReadOnly"`
+	ReadOnly         bool      `json:"readOnly"`
+	Reason           string    `json:"reason"`
+}
+
+// StateSubscriber is notified on every lease state transition with the previous and the current state.
+// Subscribers are called synchronously by the state checker and must not block.
+type StateSubscriber func(ctx context.Context, previous LeaseState, current LeaseState)
+
 var (
 	leaseLock         sync.Mutex
 	currentLeaseState = &LeaseState{
 		ReadOnly: readOnlyEnabled,
 	}
+	currentLeaseHolder string
+	stateTransitions   = []StateTransition{}
+
+	subscribersLock sync.Mutex
+	subscriberID    int
+	subscribers     = map[int]StateSubscriber{}
+
+	// stateCheckers tracks the state checkers running on their own go routine
+	stateCheckers sync.WaitGroup
 )
 
 type LeaseStateChecker interface {
@@ -41,18 +67,67 @@ func RunStateCheck(ctx context.Context, leaseStateChecker LeaseStateChecker) {
 	ctx.Log.Info("Starting state checker")
 	if leaseStateChecker.ShouldRunOnIndependentGoRoutine() {
 		ctx.Log.Info("Starting state checker on a Go Routine")
-		go leaseStateChecker.RunStateCheck(ctx)
+		stateCheckers.Add(1)
+		go func() {
+			defer stateCheckers.Done()
+			leaseStateChecker.RunStateCheck(ctx)
+		}()
 	} else {
 		ctx.Log.Info("Starting state checker on existing Go Routine")
 		leaseStateChecker.RunStateCheck(ctx)
 	}
 }
 
+// WaitForStateCheckers waits for the state checkers running on their own go routine to stop once their context is cancelled,
+// e.g. for the lease to be released on shutdown. Returns false if they did not stop within the timeout.
+func WaitForStateCheckers(timeout time.Duration) bool {
+	stopped := make(chan struct{})
+	go func() {
+		stateCheckers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
 func GetStateChecker(ctx context.Context, stateChecker string) LeaseStateChecker {
 	switch stateChecker {
 	case types.StateCheckerNone:
 		ctx.Log.Str(logger.NameKey, stateChecker).Info("Initializing NoOp based state checker")
 		return noOpStateChecker{}
+	case types.StateCheckerLease:
+		ctx.Log.Str(logger.NameKey, stateChecker).Info("Initializing kubernetes lease based state checker")
+		client, err := k8sutils.NewConfigLoader().ClientFromPath(options.GetKubeConfigPath())
+		if err != nil {
+			ctx.Log.Fatalf("error creating k8s client for lease state checker: %v", err)
+		}
+		return NewKubeLeaseStateChecker(client, KubeLeaseStateCheckerOpts{
+			Namespace:     options.GetSyncNamespace(),
+			Name:          options.GetLeaseName(),
+			Identity:      options.GetLeaseIdentity(),
+			LeaseDuration: options.GetLeaseDuration(),
+			RenewDeadline: options.GetLeaseRenewDeadline(),
+			RetryPeriod:   options.GetLeaseRetryPeriod(),
+		})
+	case types.StateCheckerDR:
+		ctx.Log.Str(logger.NameKey, stateChecker).Info("Initializing active/passive DR state checker")
+		if len(options.GetRegion()) == 0 {
+			ctx.Log.Fatalf("region must be set for %q state checker", stateChecker)
+		}
+		client, err := k8sutils.NewConfigLoader().ClientFromPath(options.GetKubeConfigPath())
+		if err != nil {
+			ctx.Log.Fatalf("error creating k8s client for DR state checker: %v", err)
+		}
+		return NewDRStateChecker(client, DRStateCheckerOpts{
+			Namespace:     options.GetSyncNamespace(),
+			ConfigMapName: options.GetDRConfigMapName(),
+			Region:        options.GetRegion(),
+			ResyncPeriod:  options.GetCacheRefreshInterval(),
+		})
 	default:
 		ctx.Log.Fatalf("invalid state checker %q", stateChecker)
 	}
@@ -60,12 +135,87 @@ func GetStateChecker(ctx context.Context, stateChecker string) LeaseStateChecker
 	return nil
 }
 
+// Subscribe registers a subscriber for lease state transitions and returns the function to unsubscribe.
+func Subscribe(subscriber StateSubscriber) (unsubscribe func()) {
+	subscribersLock.Lock()
+	defer subscribersLock.Unlock()
+	subscriberID++
+	id := subscriberID
+	subscribers[id] = subscriber
+	return func() {
+		subscribersLock.Lock()
+		defer subscribersLock.Unlock()
+		delete(subscribers, id)
+	}
+}
+
+// updateState updates the current lease state, records the transition and notifies the subscribers if the state changed.
+// State checkers must update the state only through this function.
+func updateState(ctx context.Context, readOnly bool, reason string) {
+	leaseLock.Lock()
+	previous := *currentLeaseState
+	currentLeaseState.ReadOnly = readOnly
+	currentLeaseState.IsStateInitialized = stateInitialized
+	current := *currentLeaseState
+	if previous != current {
+		stateTransitions = append(stateTransitions, StateTransition{
+			Time:             time.Now(),
+			PreviousReadOnly: previous.ReadOnly,
+			ReadOnly:         current.ReadOnly,
+			Reason:           reason,
+		})
+		if len(stateTransitions) > maxStateTransitions {
+			stateTransitions = stateTransitions[len(stateTransitions)-maxStateTransitions:]
+		}
+	}
+	leaseLock.Unlock()
+
+	if previous == current {
+		return
+	}
+	ctx.Log.Bool("previousReadOnly", previous.ReadOnly).Bool("readOnly", current.ReadOnly).Str("reason", reason).Info("Lease state changed")
+
+	subscribersLock.Lock()
+	stateSubscribers := make([]StateSubscriber, 0, len(subscribers))
+	for _, subscriber := range subscribers {
+		stateSubscribers = append(stateSubscribers, subscriber)
+	}
+	subscribersLock.Unlock()
+
+	for _, subscriber := range stateSubscribers {
+		subscriber(ctx, previous, current)
+	}
+}
+
 func IsReadOnly() bool {
 	leaseLock.Lock()
 	defer leaseLock.Unlock()
 	return currentLeaseState.ReadOnly
 }
 
+func setLeaseHolder(holder string) {
+	leaseLock.Lock()
+	defer leaseLock.Unlock()
+	currentLeaseHolder = holder
+}
+
+// GetLeaseHolder returns the identity of the instance currently holding the lease.
+// Empty when the state checker does not elect a holder.
+func GetLeaseHolder() string {
+	leaseLock.Lock()
+	defer leaseLock.Unlock()
+	return currentLeaseHolder
+}
+
+// GetStateTransitions returns the most recent lease state transitions, oldest first.
+func GetStateTransitions() []StateTransition {
+	leaseLock.Lock()
+	defer leaseLock.Unlock()
+	transitions := make([]StateTransition, len(stateTransitions))
+	copy(transitions, stateTransitions)
+	return transitions
+}
+
 func IsStateInitialized() bool {
 	leaseLock.Lock()
 	defer leaseLock.Unlock()
@@ -79,4 +229,10 @@ func ResetState() {
 	currentLeaseState = &LeaseState{
 		ReadOnly: readOnlyEnabled,
 	}
+	currentLeaseHolder = ""
+	stateTransitions = []StateTransition{}
+	currentDRState = nil
+	subscribersLock.Lock()
+	defer subscribersLock.Unlock()
+	subscribers = map[int]StateSubscriber{}
 }
@@ -41,4 +41,53 @@ var _ = Describe("Test Lease Checker Test", Label("lease_checker_test"), func()
 			Expect(leasechecker.IsStateInitialized()).To(BeTrue())
 		})
 	})
+
+	Context("Test lease state subscribers", func() {
+		var ctx context.Context
+		var cancel gocontext.CancelFunc
+
+		BeforeEach(func() {
+			ctx = context.NewContextWithLogger()
+			var newctx gocontext.Context
+			newctx, cancel = gocontext.WithCancel(ctx.Context)
+			ctx.Context = newctx
+		})
+
+		AfterEach(func() {
+			cancel()
+		})
+
+		It("should notify subscribers when the state changes", func() {
+			transitions := []leasechecker.LeaseState{}
+			leasechecker.Subscribe(func(_ context.Context, previous leasechecker.LeaseState, current leasechecker.LeaseState) {
+				transitions = append(transitions, previous, current)
+			})
+			leasechecker.RunStateCheck(ctx, leasechecker.GetStateChecker(ctx, types.StateCheckerNone))
+			Expect(transitions).To(Equal([]leasechecker.LeaseState{
+				{ReadOnly: true, IsStateInitialized: false},
+				{ReadOnly: false, IsStateInitialized: true},
+			}))
+		})
+
+		It("should not notify subscribers when the state did not change", func() {
+			leaseChecker := leasechecker.GetStateChecker(ctx, types.StateCheckerNone)
+			leasechecker.RunStateCheck(ctx, leaseChecker)
+			notified := 0
+			leasechecker.Subscribe(func(_ context.Context, _ leasechecker.LeaseState, _ leasechecker.LeaseState) {
+				notified++
+			})
+			leasechecker.RunStateCheck(ctx, leaseChecker)
+			Expect(notified).To(Equal(0))
+		})
+
+		It("should not notify subscribers after unsubscribe", func() {
+			notified := 0
+			unsubscribe := leasechecker.Subscribe(func(_ context.Context, _ leasechecker.LeaseState, _ leasechecker.LeaseState) {
+				notified++
+			})
+			unsubscribe()
+			leasechecker.RunStateCheck(ctx, leasechecker.GetStateChecker(ctx, types.StateCheckerNone))
+			Expect(notified).To(Equal(0))
+		})
+	})
 })
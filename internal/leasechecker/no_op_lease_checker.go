@@ -17,7 +17,6 @@ func (noOpStateChecker) InitStateCache(_ interface{}) error {
 	return nil
 }
 
-func (noOpStateChecker) RunStateCheck(_ context.Context) {
-	currentLeaseState.ReadOnly = readWriteEnabled
-	currentLeaseState.IsStateInitialized = stateInitialized
+func (noOpStateChecker) RunStateCheck(ctx context.Context) {
+	updateState(ctx, readWriteEnabled, "no-op state checker is always read write")
 }
@@ -0,0 +1,81 @@
+package override
+
+import (
+	"time"
+
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/internal/utils/clock"
+	"github.com/intuit/naavik/pkg/logger"
+	k8sclock "k8s.io/utils/clock"
+)
+
+// revertRetryInterval is how long the expirer waits before retrying the reverts that failed.
+const revertRetryInterval = 30 * time.Second
+
+// Expirer reverts the tracked overrides at their expiry.
+type Expirer struct {
+	// OnExpiry reconciles the traffic config of the identity and env without the expired overrides.
+	// The overrides are only marked reverted if it succeeds, otherwise it is called again on the next tick.
+	OnExpiry func(ctx context.Context, identity string, env string) error
+	// OnReverted is called once the expired overrides of the identity and env are marked reverted, e.g. to save their statuses.
+	OnReverted func(ctx context.Context, identity string, env string)
+}
+
+// Run reverts the overrides as they expire until the context is cancelled.
+func (e *Expirer) Run(ctx context.Context) {
+	ctx.Log.Info("Starting override expirer")
+	for {
+		c := clock.Get()
+		e.Tick(ctx)
+		var (
+			timer  k8sclock.Timer
+			expiry <-chan time.Time
+		)
+		if next := NextExpiry(); !next.IsZero() {
+			// A past expiry is an override whose revert failed
+			if !next.After(c.Now()) {
+				next = c.Now().Add(revertRetryInterval)
+			}
+			timer = c.NewTimer(next.Sub(c.Now()))
+			expiry = timer.C()
+		}
+		select {
+		case <-ctx.Context.Done():
+			ctx.Log.Info("Override expirer stopped")
+			return
+		case <-tracking:
+		case <-expiry:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// Tick reverts the overrides expired now, OnExpiry is called once per identity and env.
+// The overrides of an identity and env are marked reverted only once OnExpiry succeeded for them.
+func (e *Expirer) Tick(ctx context.Context) {
+	type identityEnv struct{ identity, env string }
+	now := clock.Get().Now()
+	reverting := map[identityEnv]bool{}
+	for _, status := range Expired(now) {
+		ctx.Log.Str(logger.WorkloadIdentifierKey, status.Identity).Str(logger.EnvKey, status.Env).Str(logger.NameKey, status.Name).
+			Str("expiresAt", status.ExpiresAt.String()).Info("Override expired, reverting")
+		key := identityEnv{identity: status.Identity, env: status.Env}
+		if reverting[key] {
+			continue
+		}
+		reverting[key] = true
+		if e.OnExpiry != nil {
+			if err := e.OnExpiry(ctx, status.Identity, status.Env); err != nil {
+				ctx.Log.Str(logger.WorkloadIdentifierKey, status.Identity).Str(logger.EnvKey, status.Env).Str(logger.ErrorKey, err.Error()).
+					Info("Override not reverted, retrying on the next tick")
+				continue
+			}
+		}
+		Revert(status.Identity, status.Env, now)
+		if e.OnReverted != nil {
+			e.OnReverted(ctx, status.Identity, status.Env)
+		}
+	}
+}
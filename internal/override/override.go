@@ -0,0 +1,195 @@
+package override
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// OverridesKey is the traffic config annotation holding the YAML temporary overrides.
+const OverridesKey = "trafficOverrides"
+
+// Override temporarily replaces quota limits and target group weights of the traffic config until it expires.
+type Override struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason,omitempty"`
+	// ExpiresAt is the RFC 3339 time the override is reverted at.
+	ExpiresAt time.Time         `json:"expiresAt"`
+	Quotas    []*QuotaOverride  `json:"quotas,omitempty"`
+	Weights   []*WeightOverride `json:"weights,omitempty"`
+}
+
+// QuotaOverride replaces the maxAmount, and the timePeriod if set, of a quota.
+type QuotaOverride struct {
+	// QuotaGroup is the name of the total or app quota group of the quota.
+	QuotaGroup string `json:"quotaGroup"`
+	Quota      string `json:"quota"`
+	MaxAmount  int    `json:"maxAmount"`
+	TimePeriod string `json:"timePeriod,omitempty"`
+}
+
+// WeightOverride replaces the weights of a target group.
+type WeightOverride struct {
+	TargetGroup string              `json:"targetGroup"`
+	Weights     []*admiralv1.Weight `json:"weights"`
+}
+
+// Parse parses and validates the overrides of the traffic config annotation, expired ones included. It returns nil if there are none.
+func Parse(tc *admiralv1.TrafficConfig) ([]*Override, error) {
+	if tc == nil {
+		return nil, nil
+	}
+	content, ok := tc.Annotations[OverridesKey]
+	if !ok || len(strings.TrimSpace(content)) == 0 {
+		return nil, nil
+	}
+	overrides := []*Override{}
+	if err := yaml.UnmarshalStrict([]byte(content), &overrides); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", OverridesKey, err)
+	}
+	names := map[string]bool{}
+	for i, override := range overrides {
+		if err := override.validate(); err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", OverridesKey, i, err)
+		}
+		if names[override.Name] {
+			return nil, fmt.Errorf("%s[%d]: duplicate name %q", OverridesKey, i, override.Name)
+		}
+		names[override.Name] = true
+	}
+	return overrides, nil
+}
+
+func (o *Override) validate() error {
+	if len(o.Name) == 0 {
+		return fmt.Errorf("name is required")
+	}
+	if o.ExpiresAt.IsZero() {
+		return fmt.Errorf("expiresAt is required")
+	}
+	if len(o.Quotas) == 0 && len(o.Weights) == 0 {
+		return fmt.Errorf("at least one quota or weight override is required")
+	}
+	for i, quota := range o.Quotas {
+		if len(quota.QuotaGroup) == 0 || len(quota.Quota) == 0 {
+			return fmt.Errorf("quotas[%d]: quotaGroup and quota are required", i)
+		}
+		if quota.MaxAmount <= 0 {
+			return fmt.Errorf("quotas[%d]: maxAmount must be positive", i)
+		}
+		if len(quota.TimePeriod) > 0 {
+			if period, err := time.ParseDuration(quota.TimePeriod); err != nil || period <= 0 {
+				return fmt.Errorf("quotas[%d]: invalid timePeriod %q", i, quota.TimePeriod)
+			}
+		}
+	}
+	for i, weights := range o.Weights {
+		if len(weights.TargetGroup) == 0 {
+			return fmt.Errorf("weights[%d]: targetGroup is required", i)
+		}
+		sum := 0
+		for _, weight := range weights.Weights {
+			sum += weight.Weight
+		}
+		if sum != 100 {
+			return fmt.Errorf("weights[%d]: weights sum up to %d, expected 100", i, sum)
+		}
+	}
+	return nil
+}
+
+// IsActive returns true if the override is not expired at now.
+func (o *Override) IsActive(now time.Time) bool {
+	return now.Before(o.ExpiresAt)
+}
+
+// Active returns the overrides of the traffic config active at now, nil if the annotation is invalid.
+func Active(tc *admiralv1.TrafficConfig, now time.Time) []*Override {
+	overrides, err := Parse(tc)
+	if err != nil {
+		return nil
+	}
+	active := make([]*Override, 0, len(overrides))
+	for _, override := range overrides {
+		if override.IsActive(now) {
+			active = append(active, override)
+		}
+	}
+	return active
+}
+
+// Apply returns a copy of the traffic config with the overrides active at now applied in order, the last one wins,
+// and the active overrides. The traffic config itself is returned when no override is active.
+func Apply(tc *admiralv1.TrafficConfig, now time.Time) (*admiralv1.TrafficConfig, []*Override) {
+	active := Active(tc, now)
+	if len(active) == 0 {
+		return tc, active
+	}
+	effective := tc.DeepCopy()
+	for _, override := range active {
+		for _, quotaOverride := range override.Quotas {
+			for _, quota := range FindQuotas(effective.Spec.QuotaGroup, quotaOverride.QuotaGroup, quotaOverride.Quota) {
+				quota.MaxAmount = quotaOverride.MaxAmount
+				if len(quotaOverride.TimePeriod) > 0 {
+					quota.TimePeriod = quotaOverride.TimePeriod
+				}
+			}
+		}
+		if effective.Spec.EdgeService == nil {
+			continue
+		}
+		for _, weightOverride := range override.Weights {
+			for _, targetGroup := range effective.Spec.EdgeService.TargetGroups {
+				if targetGroup.Name != weightOverride.TargetGroup {
+					continue
+				}
+				targetGroup.Weights = make([]*admiralv1.Weight, 0, len(weightOverride.Weights))
+				for _, weight := range weightOverride.Weights {
+					targetGroup.Weights = append(targetGroup.Weights, &admiralv1.Weight{Name: weight.Name, Weight: weight.Weight})
+				}
+			}
+		}
+	}
+	return effective, active
+}
+
+// IsQuotaOverridden returns true if one of the overrides replaces the limit of the quota of the quota group.
+func IsQuotaOverridden(overrides []*Override, quotaGroup string, quota string) bool {
+	for _, override := range overrides {
+		for _, quotaOverride := range override.Quotas {
+			if quotaOverride.QuotaGroup == quotaGroup && quotaOverride.Quota == quota {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FindQuotas returns the quotas named quotaName of the total and app quota groups named groupName.
+func FindQuotas(quotaGroup *admiralv1.QuotaGroup, groupName string, quotaName string) []*admiralv1.Quota {
+	if quotaGroup == nil {
+		return nil
+	}
+	quotas := []*admiralv1.Quota{}
+	collect := func(groupQuotas []*admiralv1.Quota) {
+		for _, quota := range groupQuotas {
+			if quota.Name == quotaName {
+				quotas = append(quotas, quota)
+			}
+		}
+	}
+	for _, totalQuotaGroup := range quotaGroup.TotalQuotaGroup {
+		if totalQuotaGroup.Name == groupName {
+			collect(totalQuotaGroup.Quotas)
+		}
+	}
+	for _, appQuotaGroup := range quotaGroup.AppQuotaGroups {
+		if appQuotaGroup.Name == groupName {
+			collect(appQuotaGroup.Quotas)
+		}
+	}
+	return quotas
+}
@@ -0,0 +1,13 @@
+package override
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestOverride(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "override_test")
+}
@@ -0,0 +1,408 @@
+package override
+
+import (
+	gocontext "context"
+	"errors"
+	"time"
+
+	"github.com/intuit/naavik/cmd/options"
+	resourcebuilder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/internal/utils/clock"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+const bumpOverrides = `
+- name: incident-bump
+  reason: INC-123
+  expiresAt: 2026-10-19T14:00:00Z
+  quotas:
+  - quotaGroup: Total Throttling Plan
+    quota: Total
+    maxAmount: 500
+- name: canary-shift
+  expiresAt: 2026-10-19T16:00:00Z
+  quotas:
+  - quotaGroup: Total Throttling Plan
+    quota: Total
+    maxAmount: 300
+    timePeriod: 1m
+  weights:
+  - targetGroup: DefaultGroup
+    weights:
+    - {name: Default, weight: 60}
+    - {name: Canary, weight: 40}
+`
+
+var (
+	noon    = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	onePM   = time.Date(2026, 10, 19, 13, 0, 0, 0, time.UTC)
+	twoPM   = time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC)
+	threePM = time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC)
+	fourPM  = time.Date(2026, 10, 19, 16, 0, 0, 0, time.UTC)
+)
+
+var _ = Describe("Test traffic config overrides", func() {
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+	})
+
+	It("should return nil without the annotation", func() {
+		overrides, err := Parse(resourcebuilder.GetFakeTrafficConfig("app.overridden", "qal", "1", "namespace"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(overrides).To(BeNil())
+	})
+
+	DescribeTable("should reject invalid overrides",
+		func(overrides string) {
+			_, err := Parse(resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.overridden", "qal", map[string]string{OverridesKey: overrides}))
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("unknown field", "- name: o\n  expires: 2026-10-19T14:00:00Z\n"),
+		Entry("missing name", "- expiresAt: 2026-10-19T14:00:00Z\n  quotas: [{quotaGroup: g, quota: q, maxAmount: 10}]\n"),
+		Entry("missing expiry", "- name: o\n  quotas: [{quotaGroup: g, quota: q, maxAmount: 10}]\n"),
+		Entry("invalid expiry", "- name: o\n  expiresAt: in two hours\n  quotas: [{quotaGroup: g, quota: q, maxAmount: 10}]\n"),
+		Entry("nothing overridden", "- name: o\n  expiresAt: 2026-10-19T14:00:00Z\n"),
+		Entry("no max amount", "- name: o\n  expiresAt: 2026-10-19T14:00:00Z\n  quotas: [{quotaGroup: g, quota: q}]\n"),
+		Entry("invalid time period", "- name: o\n  expiresAt: 2026-10-19T14:00:00Z\n  quotas: [{quotaGroup: g, quota: q, maxAmount: 10, timePeriod: 1x}]\n"),
+		Entry("weights not summing up to 100", "- name: o\n  expiresAt: 2026-10-19T14:00:00Z\n  weights: [{targetGroup: g, weights: [{name: a, weight: 90}]}]\n"),
+		Entry("duplicate name", "- name: o\n  expiresAt: 2026-10-19T14:00:00Z\n  quotas: [{quotaGroup: g, quota: q, maxAmount: 10}]\n- name: o\n  expiresAt: 2026-10-19T14:00:00Z\n  quotas: [{quotaGroup: g, quota: q, maxAmount: 10}]\n"),
+	)
+
+	It("should apply the active overrides in order on a copy", func() {
+		tc := resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.overridden", "qal", map[string]string{OverridesKey: bumpOverrides})
+		effective, active := Apply(tc, noon)
+		Expect(active).To(HaveLen(2))
+		Expect(effective).NotTo(BeIdenticalTo(tc))
+		quota := effective.Spec.QuotaGroup.TotalQuotaGroup[0].Quotas[0]
+		Expect(quota.MaxAmount).To(Equal(300))
+		Expect(quota.TimePeriod).To(Equal("1m"))
+		Expect(effective.Spec.EdgeService.TargetGroups[0].Weights).To(HaveLen(2))
+		Expect(effective.Spec.EdgeService.TargetGroups[0].Weights[1].Weight).To(Equal(40))
+		Expect(tc.Spec.QuotaGroup.TotalQuotaGroup[0].Quotas[0].MaxAmount).To(Equal(100))
+		Expect(tc.Spec.EdgeService.TargetGroups[0].Weights).To(HaveLen(1))
+		Expect(IsQuotaOverridden(active, "Total Throttling Plan", "Total")).To(BeTrue())
+		Expect(IsQuotaOverridden(active, "Total Throttling Plan", "Other")).To(BeFalse())
+	})
+
+	It("should revert the overrides at their expiry", func() {
+		tc := resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.overridden", "qal", map[string]string{OverridesKey: bumpOverrides})
+		effective, active := Apply(tc, twoPM)
+		Expect(active).To(HaveLen(1))
+		Expect(active[0].Name).To(Equal("canary-shift"))
+		Expect(effective.Spec.QuotaGroup.TotalQuotaGroup[0].Quotas[0].MaxAmount).To(Equal(300))
+
+		effective, active = Apply(tc, fourPM)
+		Expect(active).To(BeEmpty())
+		Expect(effective).To(BeIdenticalTo(tc))
+	})
+
+	It("should apply no override when the annotation is invalid", func() {
+		tc := resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.overridden", "qal", map[string]string{OverridesKey: "- name: o\n"})
+		effective, active := Apply(tc, noon)
+		Expect(active).To(BeEmpty())
+		Expect(effective).To(BeIdenticalTo(tc))
+	})
+})
+
+var _ = Describe("Test override tracking", func() {
+	var overrides []*Override
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		Reset()
+		var err error
+		overrides, err = Parse(resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.overridden", "qal", map[string]string{OverridesKey: bumpOverrides}))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should track the applied overrides and their time remaining", func() {
+		Track("app.overridden", "qal", overrides, noon)
+		Track("app.overridden", "qal", overrides, onePM)
+		list := List(false)
+		Expect(list).To(HaveLen(2))
+		Expect(list[0].Name).To(Equal("canary-shift"))
+		Expect(list[0].AppliedTime).To(Equal(noon))
+		Expect(list[0].Remaining(noon)).To(Equal(4 * time.Hour))
+		Expect(NextExpiry()).To(Equal(twoPM))
+	})
+
+	It("should revert the overrides removed from the traffic config", func() {
+		Track("app.overridden", "qal", overrides, noon)
+		Track("app.overridden", "qal", overrides[1:], onePM)
+		Expect(List(false)).To(HaveLen(1))
+		statuses := Get("APP.overridden", "qal")
+		Expect(statuses).To(HaveLen(2))
+		Expect(statuses[1].Name).To(Equal("incident-bump"))
+		Expect(*statuses[1].RevertedTime).To(Equal(onePM))
+		Expect(statuses[1].Remaining(noon)).To(BeZero())
+	})
+
+	It("should keep the expired overrides active until reverted", func() {
+		Track("app.overridden", "qal", overrides, noon)
+		Expect(Expired(noon)).To(BeEmpty())
+		expired := Expired(threePM)
+		Expect(expired).To(HaveLen(1))
+		Expect(expired[0].Name).To(Equal("incident-bump"))
+
+		// The reconcile at expiry does not revert the expired override
+		Track("app.overridden", "qal", overrides[1:], threePM)
+		Expect(Expired(threePM)).To(HaveLen(1))
+		Expect(NextExpiry()).To(Equal(twoPM))
+
+		Expect(Revert("app.other", "qal", threePM)).To(BeEmpty())
+		reverted := Revert("app.overridden", "qal", threePM)
+		Expect(reverted).To(HaveLen(1))
+		Expect(*reverted[0].RevertedTime).To(Equal(threePM))
+		Expect(Expired(threePM)).To(BeEmpty())
+		Expect(NextExpiry()).To(Equal(fourPM))
+	})
+
+	It("should track the overrides of an identity and env case insensitively", func() {
+		Track("App.Overridden", "QAL", overrides, noon)
+		Track("app.overridden", "qal", overrides, onePM)
+		list := List(false)
+		Expect(list).To(HaveLen(2))
+		Expect(list[0].AppliedTime).To(Equal(noon))
+		Expect(Revert("app.overridden", "qal", threePM)).To(HaveLen(1))
+	})
+
+	It("should drop the overrides reverted for a day", func() {
+		Track("app.overridden", "qal", overrides, noon)
+		Track("app.overridden", "qal", nil, onePM)
+		Expect(List(true)).To(HaveLen(2))
+		Track("app.overridden", "qal", nil, onePM.Add(revertedRetention+time.Minute))
+		Expect(List(true)).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Test override expirer", func() {
+	var (
+		ctx       context.Context
+		fakeClock *clocktesting.FakeClock
+		overrides []*Override
+	)
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		Reset()
+		ctx = context.NewContextWithLogger()
+		fakeClock = clocktesting.NewFakeClock(noon)
+		clock.Set(fakeClock)
+		var err error
+		overrides, err = Parse(resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.overridden", "qal", map[string]string{OverridesKey: bumpOverrides}))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		clock.Reset()
+		Reset()
+	})
+
+	It("should reconcile the traffic configs of the expired overrides once", func() {
+		Track("app.overridden", "qal", overrides, noon)
+		Track("app.other", "qal", overrides, noon)
+		reverted := []string{}
+		expirer := &Expirer{OnExpiry: func(_ context.Context, identity string, env string) error {
+			reverted = append(reverted, identity+"/"+env)
+			return nil
+		}}
+		expirer.Tick(ctx)
+		Expect(reverted).To(BeEmpty())
+		fakeClock.SetTime(fourPM)
+		expirer.Tick(ctx)
+		Expect(reverted).To(ConsistOf("app.overridden/qal", "app.other/qal"))
+		Expect(List(false)).To(BeEmpty())
+	})
+
+	It("should keep the overrides active until their revert succeeds", func() {
+		Track("app.overridden", "qal", overrides, noon)
+		var revertErr error
+		attempts, saved := 0, 0
+		expirer := &Expirer{
+			OnExpiry: func(context.Context, string, string) error {
+				attempts++
+				return revertErr
+			},
+			OnReverted: func(context.Context, string, string) {
+				saved++
+			},
+		}
+		fakeClock.SetTime(threePM)
+		revertErr = errors.New("reconcile operation failed")
+		expirer.Tick(ctx)
+		expirer.Tick(ctx)
+		Expect(attempts).To(Equal(2))
+		Expect(saved).To(BeZero())
+		Expect(List(false)).To(HaveLen(2))
+		Expect(Get("app.overridden", "qal")[1].RevertedTime).To(BeNil())
+
+		revertErr = nil
+		expirer.Tick(ctx)
+		Expect(attempts).To(Equal(3))
+		Expect(saved).To(Equal(1))
+		statuses := Get("app.overridden", "qal")
+		Expect(statuses[0].IsActive()).To(BeTrue())
+		Expect(*statuses[1].RevertedTime).To(Equal(threePM))
+	})
+
+	It("should revert the overrides at their expiry until the context is cancelled", func() {
+		reverted := make(chan string, 2)
+		expirer := &Expirer{OnExpiry: func(_ context.Context, identity string, _ string) error {
+			reverted <- identity
+			return nil
+		}}
+		cctx, cancel := gocontext.WithCancel(gocontext.Background())
+		ctx.Context = cctx
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			expirer.Run(ctx)
+		}()
+		Track("app.overridden", "qal", overrides, noon)
+		Eventually(fakeClock.HasWaiters).Should(BeTrue())
+		Consistently(reverted).ShouldNot(Receive())
+		fakeClock.SetTime(twoPM)
+		Eventually(reverted).Should(Receive(Equal("app.overridden")))
+		Eventually(fakeClock.HasWaiters).Should(BeTrue())
+		fakeClock.SetTime(fourPM)
+		Eventually(reverted).Should(Receive(Equal("app.overridden")))
+		cancel()
+		Eventually(done).Should(BeClosed())
+	})
+})
+
+var _ = Describe("Test override status persistence", func() {
+	var (
+		client    *dynamicfake.FakeDynamicClient
+		tc        *admiralv1.TrafficConfig
+		overrides []*Override
+	)
+
+	// getSavedTrafficConfig returns the traffic config as saved in the client.
+	getSavedTrafficConfig := func() *unstructured.Unstructured {
+		obj, err := client.Resource(trafficConfigResource).Namespace("admiral").Get(gocontext.Background(), "app.overridden", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return obj
+	}
+
+	// loadStatuses returns the statuses saved in the status of the traffic config.
+	loadStatuses := func() []Status {
+		loaded, err := LoadStatuses(gocontext.Background(), "admiral")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loaded).To(HaveKey(k8stypes.NamespacedName{Namespace: "admiral", Name: "app.overridden"}))
+		return loaded[k8stypes.NamespacedName{Namespace: "admiral", Name: "app.overridden"}]
+	}
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		Reset()
+		tc = resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.overridden", "qal", map[string]string{OverridesKey: bumpOverrides})
+		tc.Name, tc.Namespace = "app.overridden", "admiral"
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(tc)
+		Expect(err).NotTo(HaveOccurred())
+		obj := &unstructured.Unstructured{Object: content}
+		obj.SetAPIVersion(admiralv1.SchemeGroupVersion.String())
+		obj.SetKind("TrafficConfig")
+		client = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{trafficConfigResource: "TrafficConfigList"}, obj)
+		SetStatusClient(client)
+		ResumeSaves()
+		overrides, err = Parse(tc)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		SetStatusClient(nil)
+		SuspendSaves()
+	})
+
+	It("should save the tracked overrides in the traffic config status once", func() {
+		Track("app.overridden", "qal", overrides, noon)
+		Track("app.overridden", "qal", overrides[1:], onePM)
+		Expect(SaveStatuses(gocontext.Background(), tc, "app.overridden", "qal")).To(Succeed())
+		Expect(client.Actions()).To(HaveLen(1))
+		Expect(client.Actions()[0].GetVerb()).To(Equal("patch"))
+		Expect(client.Actions()[0].GetSubresource()).To(Equal("status"))
+		statuses, err := ParseStatuses(getSavedTrafficConfig())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statuses).To(Equal(Get("app.overridden", "qal")))
+
+		client.ClearActions()
+		Expect(SaveStatuses(gocontext.Background(), tc, "app.overridden", "qal")).To(Succeed())
+		Expect(client.Actions()).To(BeEmpty())
+	})
+
+	It("should resume the saved overrides after a restart", func() {
+		Track("app.overridden", "qal", overrides, noon)
+		Expect(Revert("app.overridden", "qal", threePM)).To(HaveLen(1))
+		Expect(SaveStatuses(gocontext.Background(), tc, "app.overridden", "qal")).To(Succeed())
+		saved := Get("app.overridden", "qal")
+
+		Reset()
+		statuses := loadStatuses()
+		Restore("app.overridden", "qal", statuses)
+		Expect(Get("app.overridden", "qal")).To(Equal(saved))
+		Expect(*Get("app.overridden", "qal")[1].RevertedTime).To(Equal(threePM))
+		Expect(NextExpiry()).To(Equal(fourPM))
+
+		// The restored statuses are not saved again
+		client.ClearActions()
+		Expect(SaveStatuses(gocontext.Background(), tc, "app.overridden", "qal")).To(Succeed())
+		Expect(client.Actions()).To(BeEmpty())
+
+		// The overrides reverted since are kept
+		Track("app.overridden", "qal", nil, threePM)
+		Restore("app.overridden", "qal", statuses)
+		Expect(List(false)).To(BeEmpty())
+	})
+
+	It("should resume the saved overrides re-tracked before the restore after a failover", func() {
+		Track("app.overridden", "qal", overrides, noon)
+		Expect(SaveStatuses(gocontext.Background(), tc, "app.overridden", "qal")).To(Succeed())
+
+		// The new leader reconciles the traffic config before restoring the saved overrides
+		Reset()
+		SuspendSaves()
+		Track("app.overridden", "qal", overrides[1:], onePM)
+		client.ClearActions()
+		Expect(SaveStatuses(gocontext.Background(), tc, "app.overridden", "qal")).To(Succeed())
+		Expect(client.Actions()).To(BeEmpty())
+
+		Restore("app.overridden", "qal", loadStatuses())
+		ResumeSaves()
+		statuses := Get("app.overridden", "qal")
+		Expect(statuses).To(HaveLen(2))
+		Expect(statuses[0].Name).To(Equal("canary-shift"))
+		Expect(statuses[0].AppliedTime).To(Equal(noon))
+		Expect(statuses[1].Name).To(Equal("incident-bump"))
+		Expect(statuses[1].IsActive()).To(BeTrue())
+		Expect(NextExpiry()).To(Equal(twoPM))
+	})
+
+	It("should remove the status field once no override is tracked", func() {
+		Track("app.overridden", "qal", overrides, noon)
+		Expect(SaveStatuses(gocontext.Background(), tc, "app.overridden", "qal")).To(Succeed())
+		Track("app.overridden", "qal", nil, onePM)
+		Track("app.overridden", "qal", nil, onePM.Add(revertedRetention+time.Minute))
+		Expect(SaveStatuses(gocontext.Background(), tc, "app.overridden", "qal")).To(Succeed())
+		_, found, err := unstructured.NestedFieldNoCopy(getSavedTrafficConfig().Object, "status", StatusField)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeFalse())
+		Expect(getSavedTrafficConfig().GetAnnotations()).To(HaveKey(OverridesKey))
+	})
+
+	It("should reject an invalid status", func() {
+		_, err := ParseStatuses(&unstructured.Unstructured{Object: map[string]interface{}{"status": map[string]interface{}{StatusField: "{"}}})
+		Expect(err).To(MatchError(ContainSubstring("invalid status." + StatusField)))
+	})
+})
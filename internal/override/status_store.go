@@ -0,0 +1,179 @@
+package override
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// StatusField is the traffic config status field the tracked overrides are saved in, so they survive a restart or a failover.
+const StatusField = "overrides"
+
+// trafficConfigResource is the resource the statuses are saved on, the typed traffic config status has no overrides field.
+var trafficConfigResource = admiralv1.SchemeGroupVersion.WithResource("trafficconfigs")
+
+var (
+	// statusClient is the client of the cluster of the traffic configs the statuses are saved with, nil if not set.
+	statusClient atomic.Pointer[dynamic.Interface]
+	// savesResumed is unset until the saved statuses are restored, so they are not overwritten by the ones tracked in the meantime.
+	savesResumed atomic.Bool
+	// saved is the content last saved or restored per traffic config, the status is only patched when it changes.
+	saved    = map[trackerKey]string{}
+	saveLock = sync.Mutex{}
+)
+
+// SetStatusClient sets the client the tracked overrides are saved in the traffic config statuses with.
+func SetStatusClient(client dynamic.Interface) {
+	if client == nil {
+		statusClient.Store(nil)
+		return
+	}
+	statusClient.Store(&client)
+}
+
+// SuspendSaves stops saving the statuses until ResumeSaves, the content last saved is forgotten as another instance saves them meanwhile.
+func SuspendSaves() {
+	saveLock.Lock()
+	defer saveLock.Unlock()
+	savesResumed.Store(false)
+	saved = map[trackerKey]string{}
+}
+
+// ResumeSaves saves the statuses again, once the saved ones are restored.
+func ResumeSaves() {
+	savesResumed.Store(true)
+}
+
+// ParseStatuses returns the tracked overrides saved in the status of the traffic config, nil if there are none.
+func ParseStatuses(obj *unstructured.Unstructured) ([]Status, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	content, found, err := unstructured.NestedFieldNoCopy(obj.Object, "status", StatusField)
+	if err != nil {
+		return nil, fmt.Errorf("invalid status.%s: %w", StatusField, err)
+	}
+	if !found || content == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid status.%s: %w", StatusField, err)
+	}
+	statuses := []Status{}
+	if err := json.Unmarshal(data, &statuses); err != nil {
+		return nil, fmt.Errorf("invalid status.%s: %w", StatusField, err)
+	}
+	return statuses, nil
+}
+
+// LoadStatuses returns the tracked overrides saved in the status of the traffic configs of the namespace, all of them if empty.
+// The traffic configs with an invalid status are skipped and their errors returned along with the others.
+func LoadStatuses(ctx gocontext.Context, namespace string) (map[k8stypes.NamespacedName][]Status, error) {
+	client := statusClient.Load()
+	if client == nil {
+		return nil, nil
+	}
+	list, err := (*client).Resource(trafficConfigResource).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing the traffic configs: %w", err)
+	}
+	loaded := map[k8stypes.NamespacedName][]Status{}
+	errs := []error{}
+	for i := range list.Items {
+		statuses, err := ParseStatuses(&list.Items[i])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("traffic config %s/%s: %w", list.Items[i].GetNamespace(), list.Items[i].GetName(), err))
+			continue
+		}
+		loaded[k8stypes.NamespacedName{Namespace: list.Items[i].GetNamespace(), Name: list.Items[i].GetName()}] = statuses
+	}
+	return loaded, errors.Join(errs...)
+}
+
+// Restore tracks the overrides saved in the status of the traffic config of the identity and env.
+// The overrides already tracked are kept, the active ones re-tracked since are given back their saved applied time.
+func Restore(identity string, env string, statuses []Status) {
+	key := newTrackerKey(identity, env)
+	trackerLock.Lock()
+	for _, status := range statuses {
+		if len(status.Name) == 0 {
+			continue
+		}
+		if tracked[key] == nil {
+			tracked[key] = map[string]*Status{}
+		}
+		existing, found := tracked[key][status.Name]
+		switch {
+		case !found:
+			restored := status
+			restored.Identity, restored.Env = identity, env
+			tracked[key][status.Name] = &restored
+			notifyTracking()
+		case existing.IsActive() && status.IsActive() && status.AppliedTime.Before(existing.AppliedTime):
+			existing.AppliedTime = status.AppliedTime
+		}
+	}
+	trackerLock.Unlock()
+
+	content := ""
+	if len(statuses) > 0 {
+		if data, err := json.Marshal(statuses); err == nil {
+			content = string(data)
+		}
+	}
+	saveLock.Lock()
+	defer saveLock.Unlock()
+	saved[key] = content
+}
+
+// SaveStatuses saves the tracked overrides of the traffic config in its status subresource,
+// unless they did not change since last saved, the saves are suspended or no client is set.
+func SaveStatuses(ctx gocontext.Context, tc *admiralv1.TrafficConfig, identity string, env string) error {
+	client := statusClient.Load()
+	if client == nil || tc == nil {
+		return nil
+	}
+	saveLock.Lock()
+	defer saveLock.Unlock()
+	if !savesResumed.Load() {
+		return nil
+	}
+	key := newTrackerKey(identity, env)
+	var value interface{}
+	content := ""
+	if statuses := Get(identity, env); len(statuses) > 0 {
+		data, err := json.Marshal(statuses)
+		if err != nil {
+			return fmt.Errorf("error marshalling status.%s: %w", StatusField, err)
+		}
+		value, content = statuses, string(data)
+	}
+	if saved[key] == content {
+		return nil
+	}
+	// A null value removes the field
+	patch, err := json.Marshal(map[string]interface{}{"status": map[string]interface{}{StatusField: value}})
+	if err != nil {
+		return fmt.Errorf("error marshalling status.%s patch: %w", StatusField, err)
+	}
+	_, err = (*client).Resource(trafficConfigResource).Namespace(tc.Namespace).Patch(ctx, tc.Name, k8stypes.MergePatchType, patch, metav1.PatchOptions{}, "status")
+	switch {
+	case k8serrors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return fmt.Errorf("error saving status.%s: %w", StatusField, err)
+	}
+	saved[key] = content
+	return nil
+}
@@ -0,0 +1,201 @@
+package override
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// revertedRetention is how long the reverted overrides are kept in the tracker.
+const revertedRetention = 24 * time.Hour
+
+// Status is the lifecycle of an override applied by this instance.
+type Status struct {
+	Identity    string    `json:"identity"`
+	Env         string    `json:"env"`
+	Name        string    `json:"name"`
+	Reason      string    `json:"reason,omitempty"`
+	AppliedTime time.Time `json:"appliedTime"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	// RevertedTime is set once the override expired or was removed from the traffic config.
+	RevertedTime *time.Time `json:"revertedTime,omitempty"`
+}
+
+// IsActive returns true if the override is not reverted yet.
+func (s *Status) IsActive() bool {
+	return s.RevertedTime == nil
+}
+
+// Remaining returns the time left before the override expires at now, zero if reverted or past its expiry.
+func (s *Status) Remaining(now time.Time) time.Duration {
+	if !s.IsActive() || !now.Before(s.ExpiresAt) {
+		return 0
+	}
+	return s.ExpiresAt.Sub(now)
+}
+
+type trackerKey struct {
+	identity string
+	env      string
+}
+
+// newTrackerKey returns the key of the identity and env, case insensitive like the traffic config cache.
+func newTrackerKey(identity string, env string) trackerKey {
+	return trackerKey{identity: strings.ToLower(identity), env: strings.ToLower(env)}
+}
+
+var (
+	tracked     = map[trackerKey]map[string]*Status{}
+	trackerLock = sync.RWMutex{}
+	// tracking wakes up the expirer when an override is tracked, it may expire before the next known expiry
+	tracking = make(chan struct{}, 1)
+)
+
+// Track records the overrides applied to the traffic config of the identity and env at now.
+// The tracked overrides removed from active are reverted, the reverted ones older than revertedRetention are dropped.
+// The expired ones are left active, the expirer reverts them once their revert reconcile succeeded.
+func Track(identity string, env string, active []*Override, now time.Time) {
+	trackerLock.Lock()
+	defer trackerLock.Unlock()
+	key := newTrackerKey(identity, env)
+	statuses := tracked[key]
+	if statuses == nil {
+		statuses = map[string]*Status{}
+	}
+	names := map[string]bool{}
+	for _, override := range active {
+		names[override.Name] = true
+		status, found := statuses[override.Name]
+		if found && status.IsActive() {
+			if !status.ExpiresAt.Equal(override.ExpiresAt) {
+				status.ExpiresAt = override.ExpiresAt
+				notifyTracking()
+			}
+			status.Reason = override.Reason
+			continue
+		}
+		statuses[override.Name] = &Status{
+			Identity:    identity,
+			Env:         env,
+			Name:        override.Name,
+			Reason:      override.Reason,
+			AppliedTime: now,
+			ExpiresAt:   override.ExpiresAt,
+		}
+		notifyTracking()
+	}
+	for name, status := range statuses {
+		switch {
+		case names[name]:
+		case status.IsActive() && !now.Before(status.ExpiresAt):
+		case status.IsActive():
+			revertedTime := now
+			status.RevertedTime = &revertedTime
+		case now.Sub(*status.RevertedTime) > revertedRetention:
+			delete(statuses, name)
+		}
+	}
+	if len(statuses) == 0 {
+		delete(tracked, key)
+		return
+	}
+	tracked[key] = statuses
+}
+
+// List returns the tracked overrides sorted by identity, env and name. Only the active ones are returned unless all is set.
+func List(all bool) []Status {
+	trackerLock.RLock()
+	defer trackerLock.RUnlock()
+	list := []Status{}
+	for _, statuses := range tracked {
+		for _, status := range statuses {
+			if all || status.IsActive() {
+				list = append(list, *status)
+			}
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Identity != list[j].Identity {
+			return list[i].Identity < list[j].Identity
+		}
+		if list[i].Env != list[j].Env {
+			return list[i].Env < list[j].Env
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Get returns the tracked overrides of the identity and env, the reverted ones included.
+func Get(identity string, env string) []Status {
+	list := []Status{}
+	for _, status := range List(true) {
+		if strings.EqualFold(status.Identity, identity) && strings.EqualFold(status.Env, env) {
+			list = append(list, status)
+		}
+	}
+	return list
+}
+
+// Expired returns the active overrides expired at now, they stay active until reverted.
+func Expired(now time.Time) []Status {
+	trackerLock.RLock()
+	defer trackerLock.RUnlock()
+	expired := []Status{}
+	for _, statuses := range tracked {
+		for _, status := range statuses {
+			if status.IsActive() && !now.Before(status.ExpiresAt) {
+				expired = append(expired, *status)
+			}
+		}
+	}
+	return expired
+}
+
+// Revert marks the active overrides of the identity and env expired at now as reverted and returns them.
+func Revert(identity string, env string, now time.Time) []Status {
+	trackerLock.Lock()
+	defer trackerLock.Unlock()
+	reverted := []Status{}
+	for _, status := range tracked[newTrackerKey(identity, env)] {
+		if status.IsActive() && !now.Before(status.ExpiresAt) {
+			revertedTime := now
+			status.RevertedTime = &revertedTime
+			reverted = append(reverted, *status)
+		}
+	}
+	return reverted
+}
+
+// NextExpiry returns the earliest expiry of the active overrides, the zero time if none.
+func NextExpiry() time.Time {
+	trackerLock.RLock()
+	defer trackerLock.RUnlock()
+	var next time.Time
+	for _, statuses := range tracked {
+		for _, status := range statuses {
+			if status.IsActive() && (next.IsZero() || status.ExpiresAt.Before(next)) {
+				next = status.ExpiresAt
+			}
+		}
+	}
+	return next
+}
+
+// Reset clears the tracked overrides and the content last saved.
+func Reset() {
+	trackerLock.Lock()
+	tracked = map[trackerKey]map[string]*Status{}
+	trackerLock.Unlock()
+	saveLock.Lock()
+	defer saveLock.Unlock()
+	saved = map[trackerKey]string{}
+}
+
+func notifyTracking() {
+	select {
+	case tracking <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,55 @@
+package peeridentity
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+)
+
+// defaultServiceAccount is the service account of the pods not setting one.
+const defaultServiceAccount = "default"
+
+// Principals returns the sorted principals, ns/<namespace>/sa/<service account>, of the deployments and rollouts
+// of the identity in all the clusters. The SPIFFE ID of the mTLS certificate of a workload ends with its principal.
+func Principals(identity string) []string {
+	principals := map[string]bool{}
+	for _, item := range cache.Deployments.GetByIdentity(identity) {
+		deployment := item.Deployment
+		principals[principal(deployment.Namespace, deployment.Spec.Template.Spec.ServiceAccountName)] = true
+	}
+	if options.IsArgoRolloutsEnabled() {
+		for _, item := range cache.Rollouts.GetByIdentity(identity) {
+			rollout := item.Rollout
+			principals[principal(rollout.Namespace, rollout.Spec.Template.Spec.ServiceAccountName)] = true
+		}
+	}
+	list := make([]string, 0, len(principals))
+	for p := range principals {
+		list = append(list, p)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func principal(namespace string, serviceAccount string) string {
+	if len(serviceAccount) == 0 {
+		serviceAccount = defaultServiceAccount
+	}
+	return fmt.Sprintf("ns/%s/sa/%s", namespace, serviceAccount)
+}
+
+// Expression returns the CEL expression evaluating to value when the URI SAN of the mTLS peer certificate of the connection
+// is the SPIFFE ID of one of the principals in the mesh trust domain. The expression fails otherwise, it indexes an empty list,
+// and on the connections without peer certificate, so the descriptor is skipped instead of added with an empty value.
+func Expression(principals []string, value string) string {
+	quoted := make([]string, 0, len(principals))
+	for _, p := range principals {
+		quoted = append(quoted, regexp.QuoteMeta(p))
+	}
+	pattern := fmt.Sprintf("^spiffe://%s/(%s)$", regexp.QuoteMeta(options.GetMeshTrustDomain()), strings.Join(quoted, "|"))
+	return fmt.Sprintf("(connection.uri_san_peer_certificate.matches(r'%s') ? ['%s'] : [])[0]", pattern, value)
+}
@@ -0,0 +1,13 @@
+package peeridentity
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPeerIdentity(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "peeridentity_test")
+}
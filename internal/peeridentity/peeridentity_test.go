@@ -0,0 +1,40 @@
+package peeridentity
+
+import (
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	resourcebuilder "github.com/intuit/naavik/internal/fake/builder/resource"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test peer identity", func() {
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.Deployments.Reset()
+		cache.Rollouts.Reset()
+	})
+
+	It("should map the identity to the service accounts of its deployments and rollouts", func() {
+		deployment := resourcebuilder.BuildFakeDeployment("client", "app.client", "client", "qal", "client-ns")
+		deployment.Spec.Template.Spec.ServiceAccountName = "client-sa"
+		cache.Deployments.Add("cluster1", deployment)
+		cache.Deployments.Add("cluster2", resourcebuilder.BuildFakeDeployment("client", "app.client", "client", "e2e", "client-ns"))
+		cache.Rollouts.Add("cluster1", resourcebuilder.BuildFakeRollout("client", "app.client", "client", "prf", "rollout-ns"))
+		cache.Deployments.Add("cluster1", resourcebuilder.BuildFakeDeployment("other", "app.other", "other", "qal", "other-ns"))
+
+		Expect(Principals("App.Client")).To(Equal([]string{"ns/client-ns/sa/client-sa", "ns/client-ns/sa/default", "ns/rollout-ns/sa/default"}))
+		Expect(Principals("app.unknown")).To(BeEmpty())
+
+		options.Params.ArgoRolloutsEnabled = false
+		Expect(Principals("app.client")).To(Equal([]string{"ns/client-ns/sa/client-sa", "ns/client-ns/sa/default"}))
+	})
+
+	It("should match the SPIFFE IDs of the principals", func() {
+		Expect(Expression([]string{"ns/client.ns/sa/client-sa", "ns/other/sa/default"}, "a2V5")).To(Equal(
+			`(connection.uri_san_peer_certificate.matches(r'^spiffe://cluster\.local/(ns/client\.ns/sa/client-sa|ns/other/sa/default)$') ? ['a2V5'] : [])[0]`))
+		options.Params.MeshTrustDomain = "mesh.example.com"
+		Expect(Expression([]string{"ns/other/sa/default"}, "a2V5")).To(Equal(
+			`(connection.uri_san_peer_certificate.matches(r'^spiffe://mesh\.example\.com/(ns/other/sa/default)$') ? ['a2V5'] : [])[0]`))
+	})
+})
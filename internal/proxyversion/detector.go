@@ -0,0 +1,108 @@
+package proxyversion
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/scope"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/internal/utils/clock"
+	"github.com/intuit/naavik/pkg/logger"
+)
+
+// Detector detects the proxy versions of the clusters in scope every Interval, and calls OnChange with the clusters
+// whose throttle filters versions changed. The injected pods of the clusters are watched, so a detection does not list them.
+type Detector struct {
+	Interval time.Duration
+	// OnChange regenerates the throttle filters of the clusters.
+	OnChange func(ctx context.Context, clusterIDs []string)
+
+	// watchers are the pod watchers of the clusters in scope, by lowercase cluster id
+	watchers map[string]*podWatcher
+}
+
+// Run detects the proxy versions every interval until the context is cancelled.
+func (d *Detector) Run(ctx context.Context) {
+	ctx.Log.Infof("Detecting proxy versions every %s", d.Interval)
+	for {
+		d.Tick(ctx)
+		timer := clock.Get().NewTimer(d.Interval)
+		select {
+		case <-ctx.Context.Done():
+			timer.Stop()
+			d.Stop()
+			ctx.Log.Info("Proxy version detector stopped")
+			return
+		case <-timer.C():
+		}
+	}
+}
+
+// Tick detects the proxy versions of the clusters in scope and returns the clusters whose throttle filters versions changed.
+// A cluster keeps its last versions until its pods are synced, and falls back to the envoy_filter_versions when it has no injected pod.
+func (d *Detector) Tick(ctx context.Context) []string {
+	if d.watchers == nil {
+		d.watchers = map[string]*podWatcher{}
+	}
+	changed := []string{}
+	seen := map[string]bool{}
+	for _, rc := range cache.RemoteCluster.ListClusters() {
+		clusterID := rc.GetClusterID()
+		if !scope.IsClusterInScope(clusterID) {
+			continue
+		}
+		key := strings.ToLower(clusterID)
+		seen[key] = true
+		watcher := d.watchers[key]
+		// The client changes when the cluster is re-added with new credentials
+		if watcher == nil || watcher.client != rc.K8sClient() {
+			if watcher != nil {
+				watcher.Stop()
+			}
+			watcher = newPodWatcher(rc.K8sClient())
+			d.watchers[key] = watcher
+		}
+		if !watcher.HasSynced() {
+			ctx.Log.Str(logger.ClusterKey, clusterID).Debug("Waiting for the injected pods to sync, keeping the last proxy versions")
+			continue
+		}
+		versions := watcher.Versions()
+		previous := ForCluster(clusterID)
+		if len(versions) == 0 {
+			Delete(clusterID)
+		} else {
+			Set(clusterID, versions)
+		}
+		if current := ForCluster(clusterID); !slices.Equal(previous, current) {
+			ctx.Log.Str(logger.ClusterKey, clusterID).Any("previousVersions", previous).Any("versions", current).Info("Proxy versions changed")
+			changed = append(changed, clusterID)
+		}
+	}
+	for key, watcher := range d.watchers {
+		if !seen[key] {
+			watcher.Stop()
+			delete(d.watchers, key)
+		}
+	}
+	detectedLock.Lock()
+	for clusterID := range detected {
+		if !seen[clusterID] {
+			delete(detected, clusterID)
+		}
+	}
+	detectedLock.Unlock()
+	if len(changed) > 0 && d.OnChange != nil {
+		d.OnChange(ctx, changed)
+	}
+	return changed
+}
+
+// Stop stops watching the pods of the clusters.
+func (d *Detector) Stop() {
+	for key, watcher := range d.watchers {
+		watcher.Stop()
+		delete(d.watchers, key)
+	}
+}
@@ -0,0 +1,86 @@
+package proxyversion
+
+import (
+	"slices"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	k8scache "k8s.io/client-go/tools/cache"
+)
+
+// podWatcher caches the injected pods of a cluster, so the versions are read without listing the pods from the API server.
+type podWatcher struct {
+	client   kubernetes.Interface
+	informer k8scache.SharedIndexInformer
+	stop     chan struct{}
+}
+
+// newPodWatcher starts watching the injected pods of the cluster.
+func newPodWatcher(client kubernetes.Interface) *podWatcher {
+	factory := informers.NewSharedInformerFactoryWithOptions(client, 0,
+		informers.WithTweakListOptions(func(listOpts *metav1.ListOptions) {
+			listOpts.LabelSelector = injectedPodsSelector
+		}))
+	informer := factory.Core().V1().Pods().Informer()
+	// Only the fields the versions are read from are cached, the informer has not started so it cannot fail
+	_ = informer.SetTransform(stripPod)
+	w := &podWatcher{client: client, informer: informer, stop: make(chan struct{})}
+	factory.Start(w.stop)
+	return w
+}
+
+// HasSynced returns true once the initial list of the pods is cached.
+func (w *podWatcher) HasSynced() bool {
+	return w.informer.HasSynced()
+}
+
+// Versions returns the sorted major.minor versions of the cached pods.
+func (w *podWatcher) Versions() []string {
+	versions := []string{}
+	for _, obj := range w.informer.GetStore().List() {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			continue
+		}
+		if version, found := podVersion(pod); found && !slices.Contains(versions, version) {
+			versions = append(versions, version)
+		}
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// Stop stops watching the pods.
+func (w *podWatcher) Stop() {
+	close(w.stop)
+}
+
+// stripPod keeps the labels and the proxy container of the pod, the only fields the version is read from.
+func stripPod(obj interface{}) (interface{}, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return obj, nil
+	}
+	stripped := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            pod.Name,
+			Namespace:       pod.Namespace,
+			ResourceVersion: pod.ResourceVersion,
+			Labels:          pod.Labels,
+		},
+	}
+	for _, container := range pod.Spec.Containers {
+		if container.Name == proxyContainerName {
+			stripped.Spec.Containers = append(stripped.Spec.Containers, corev1.Container{Name: container.Name, Image: container.Image})
+		}
+	}
+	for _, container := range pod.Spec.InitContainers {
+		if container.Name == proxyContainerName {
+			stripped.Spec.InitContainers = append(stripped.Spec.InitContainers, corev1.Container{Name: container.Name, Image: container.Image})
+		}
+	}
+	return stripped, nil
+}
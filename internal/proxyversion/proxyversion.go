@@ -0,0 +1,112 @@
+package proxyversion
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/intuit/naavik/cmd/options"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// proxyContainerName is the name of the sidecar container injected by istio, a regular or an init container for native sidecars.
+	proxyContainerName = "istio-proxy"
+	// injectedPodsSelector selects the pods with an istio sidecar.
+	injectedPodsSelector = "security.istio.io/tlsMode=istio"
+	// revisionLabel is the istiod revision of the control plane that injected the pod, usually named after its version, e.g. 1-21.
+	revisionLabel = "istio.io/rev"
+)
+
+// versionRegex matches the major.minor version at the start of a proxy image tag, e.g. 1.21 in 1.21.3-distroless.
+var versionRegex = regexp.MustCompile(`^v?(\d+\.\d+)(\D|$)`)
+
+// revisionRegex matches the major-minor version at the start of an istiod revision, e.g. 1-21 in 1-21-3.
+var revisionRegex = regexp.MustCompile(`^v?(\d+)-(\d+)(\D|$)`)
+
+var (
+	detected     = map[string][]string{}
+	detectedLock = sync.RWMutex{}
+)
+
+// podVersion returns the major.minor version of the sidecar image of the pod.
+// The images without a version tag, e.g. pinned by digest only, fall back to the istiod revision of the pod, else are skipped.
+func podVersion(pod *corev1.Pod) (string, bool) {
+	for _, container := range slices.Concat(pod.Spec.Containers, pod.Spec.InitContainers) {
+		if container.Name != proxyContainerName {
+			continue
+		}
+		if version, found := ParseImageVersion(container.Image); found {
+			return version, true
+		}
+		break
+	}
+	return ParseRevisionVersion(pod.Labels[revisionLabel])
+}
+
+// ParseImageVersion returns the major.minor version of the tag of the proxy image, e.g. 1.21 for docker.io/istio/proxyv2:1.21.3.
+func ParseImageVersion(image string) (string, bool) {
+	image, _, _ = strings.Cut(image, "@")
+	slash := strings.LastIndex(image, "/")
+	colon := strings.LastIndex(image, ":")
+	if colon <= slash {
+		return "", false
+	}
+	match := versionRegex.FindStringSubmatch(image[colon+1:])
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// ParseRevisionVersion returns the major.minor version of an istiod revision named after its version, e.g. 1.21 for 1-21-3.
+func ParseRevisionVersion(revision string) (string, bool) {
+	match := revisionRegex.FindStringSubmatch(revision)
+	if match == nil {
+		return "", false
+	}
+	return match[1] + "." + match[2], true
+}
+
+// Set records the proxy versions detected in the cluster.
+func Set(clusterID string, versions []string) {
+	detectedLock.Lock()
+	defer detectedLock.Unlock()
+	detected[strings.ToLower(clusterID)] = versions
+}
+
+// Get returns the proxy versions detected in the cluster, false if not detected yet.
+func Get(clusterID string) ([]string, bool) {
+	detectedLock.RLock()
+	defer detectedLock.RUnlock()
+	versions, found := detected[strings.ToLower(clusterID)]
+	return versions, found
+}
+
+// Delete forgets the proxy versions of the cluster.
+func Delete(clusterID string) {
+	detectedLock.Lock()
+	defer detectedLock.Unlock()
+	delete(detected, strings.ToLower(clusterID))
+}
+
+// Reset forgets the proxy versions of all the clusters.
+func Reset() {
+	detectedLock.Lock()
+	defer detectedLock.Unlock()
+	detected = map[string][]string{}
+}
+
+// ForCluster returns the versions the throttle filters of the cluster are generated for, without the deprecated ones.
+// They are the proxy versions detected in the cluster when the detection is enabled and succeeded, the envoy_filter_versions otherwise.
+func ForCluster(clusterID string) []string {
+	versions := options.GetEnvoyFilterVersions()
+	if options.IsProxyVersionDetectionEnabled() {
+		if detectedVersions, found := Get(clusterID); found {
+			versions = detectedVersions
+		}
+	}
+	deprecated := options.GetDeprecatedEnvoyFilterVersions()
+	return slices.DeleteFunc(slices.Clone(versions), func(version string) bool { return slices.Contains(deprecated, version) })
+}
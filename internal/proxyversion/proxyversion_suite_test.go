@@ -0,0 +1,13 @@
+package proxyversion
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestProxyVersion(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "proxyversion_test")
+}
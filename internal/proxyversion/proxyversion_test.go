@@ -0,0 +1,184 @@
+package proxyversion
+
+import (
+	gocontext "context"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/fake/builder"
+	"github.com/intuit/naavik/internal/types/context"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func buildPod(name string, labels map[string]string, containers ...corev1.Container) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", Labels: labels},
+		Spec:       corev1.PodSpec{Containers: containers},
+	}
+}
+
+func injectedPod(name string, image string) *corev1.Pod {
+	return buildPod(name, map[string]string{"security.istio.io/tlsMode": "istio"},
+		corev1.Container{Name: "app", Image: "app:2.0.1"},
+		corev1.Container{Name: proxyContainerName, Image: image})
+}
+
+func createPods(client kubernetes.Interface, pods ...*corev1.Pod) {
+	for _, pod := range pods {
+		_, err := client.CoreV1().Pods(pod.Namespace).Create(gocontext.Background(), pod, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+}
+
+var _ = Describe("Test proxy version parsing", func() {
+	DescribeTable("should parse the version of the proxy image",
+		func(image string, expected string, expectedFound bool) {
+			version, found := ParseImageVersion(image)
+			Expect(found).To(Equal(expectedFound))
+			Expect(version).To(Equal(expected))
+		},
+		Entry("release tag", "docker.io/istio/proxyv2:1.21.3", "1.21", true),
+		Entry("distroless tag", "gcr.io/istio-release/proxyv2:1.22.0-distroless", "1.22", true),
+		Entry("prefixed tag", "proxyv2:v1.20", "1.20", true),
+		Entry("registry port and digest", "registry:5000/istio/proxyv2:1.21.1@sha256:abc", "1.21", true),
+		Entry("digest only", "registry:5000/istio/proxyv2@sha256:abc", "", false),
+		Entry("no tag", "istio/proxyv2", "", false),
+		Entry("non version tag", "istio/proxyv2:latest", "", false),
+	)
+
+	DescribeTable("should parse the version of the istiod revision",
+		func(revision string, expected string, expectedFound bool) {
+			version, found := ParseRevisionVersion(revision)
+			Expect(found).To(Equal(expectedFound))
+			Expect(version).To(Equal(expected))
+		},
+		Entry("major minor", "1-21", "1.21", true),
+		Entry("patch", "1-22-3", "1.22", true),
+		Entry("canary", "canary", "", false),
+		Entry("empty", "", "", false),
+	)
+})
+
+var _ = Describe("Test proxy version detection", func() {
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+	})
+
+	It("should detect the sorted versions of the injected pods", func() {
+		client := fake.NewSimpleClientset()
+		createPods(client,
+			injectedPod("a", "istio/proxyv2:1.22.1"),
+			injectedPod("b", "istio/proxyv2:1.21.3"),
+			injectedPod("c", "istio/proxyv2:1.22.0"),
+			injectedPod("d", "istio/proxyv2@sha256:abc"),
+			buildPod("e", map[string]string{"security.istio.io/tlsMode": "istio", revisionLabel: "1-20"},
+				corev1.Container{Name: proxyContainerName, Image: "istio/proxyv2@sha256:abc"}),
+			buildPod("f", nil, corev1.Container{Name: proxyContainerName, Image: "istio/proxyv2:1.19.0"}),
+		)
+		watcher := newPodWatcher(client)
+		defer watcher.Stop()
+		Eventually(watcher.HasSynced).Should(BeTrue())
+		Expect(watcher.Versions()).To(Equal([]string{"1.20", "1.21", "1.22"}))
+
+		createPods(client, injectedPod("g", "istio/proxyv2:1.23.0"))
+		Eventually(watcher.Versions).Should(Equal([]string{"1.20", "1.21", "1.22", "1.23"}))
+	})
+
+	It("should read the native sidecar init container", func() {
+		client := fake.NewSimpleClientset()
+		pod := buildPod("a", map[string]string{"security.istio.io/tlsMode": "istio"}, corev1.Container{Name: "app", Image: "app:1.0"})
+		pod.Spec.InitContainers = []corev1.Container{{Name: proxyContainerName, Image: "istio/proxyv2:1.23.0"}}
+		createPods(client, pod)
+		watcher := newPodWatcher(client)
+		defer watcher.Stop()
+		Eventually(watcher.HasSynced).Should(BeTrue())
+		Expect(watcher.Versions()).To(Equal([]string{"1.23"}))
+	})
+
+	It("should only cache the labels and the proxy container of the pods", func() {
+		pod := injectedPod("a", "istio/proxyv2:1.22.1")
+		pod.Annotations = map[string]string{"annotation": "value"}
+		pod.Spec.InitContainers = []corev1.Container{{Name: "init", Image: "init:1.0"}}
+		stripped, err := stripPod(pod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stripped).To(Equal(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "ns", Labels: pod.Labels},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: proxyContainerName, Image: "istio/proxyv2:1.22.1"}}},
+		}))
+	})
+})
+
+var _ = Describe("Test proxy versions of the clusters", func() {
+	var (
+		ctx      context.Context
+		changed  [][]string
+		detector *Detector
+	)
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(&options.NaavikArgs{
+			EnvoyFilterVersions:           []string{"1.21"},
+			DeprecatedEnvoyFilterVersions: []string{"1.13"},
+			ProxyVersionDetection:         true,
+		})
+		ctx = context.NewContextWithLogger()
+		cache.RemoteCluster.Reset()
+		Reset()
+		changed = nil
+		detector = &Detector{OnChange: func(_ context.Context, clusterIDs []string) {
+			changed = append(changed, clusterIDs)
+		}}
+	})
+
+	AfterEach(func() {
+		detector.Stop()
+		cache.RemoteCluster.Reset()
+		Reset()
+	})
+
+	It("should exclude the deprecated versions", func() {
+		Set("cluster1", []string{"1.13", "1.22"})
+		Expect(ForCluster("Cluster1")).To(Equal([]string{"1.22"}))
+	})
+
+	It("should fall back to the envoy filter versions", func() {
+		Expect(ForCluster("cluster1")).To(Equal([]string{"1.21"}))
+		options.InitializeNaavikArgs(&options.NaavikArgs{EnvoyFilterVersions: []string{"1.13", "1.21"}, DeprecatedEnvoyFilterVersions: []string{"1.13"}})
+		Set("cluster1", []string{"1.22"})
+		Expect(ForCluster("cluster1")).To(Equal([]string{"1.21"}))
+	})
+
+	It("should report the clusters whose versions changed", func() {
+		rc := builder.BuildRemoteCluster("proxyversion-cluster1")
+		cache.RemoteCluster.AddCluster(rc)
+		other := builder.BuildRemoteCluster("proxyversion-cluster2")
+		cache.RemoteCluster.AddCluster(other)
+
+		Expect(detector.Tick(ctx)).To(BeEmpty())
+		Expect(changed).To(BeEmpty())
+
+		createPods(rc.K8sClient(), injectedPod("a", "istio/proxyv2:1.22.1"))
+		Eventually(func() []string { return detector.Tick(ctx) }).Should(Equal([]string{"proxyversion-cluster1"}))
+		Expect(ForCluster("proxyversion-cluster1")).To(Equal([]string{"1.22"}))
+		Expect(ForCluster("proxyversion-cluster2")).To(Equal([]string{"1.21"}))
+		Expect(changed).To(Equal([][]string{{"proxyversion-cluster1"}}))
+
+		Expect(detector.Tick(ctx)).To(BeEmpty())
+
+		Expect(rc.K8sClient().CoreV1().Pods("ns").Delete(gocontext.Background(), "a", metav1.DeleteOptions{})).To(Succeed())
+		Eventually(func() []string { return detector.Tick(ctx) }).Should(Equal([]string{"proxyversion-cluster1"}))
+		Expect(ForCluster("proxyversion-cluster1")).To(Equal([]string{"1.21"}))
+	})
+
+	It("should forget the clusters removed", func() {
+		Set("removed", []string{"1.22"})
+		detector.Tick(ctx)
+		_, found := Get("removed")
+		Expect(found).To(BeFalse())
+	})
+})
@@ -0,0 +1,51 @@
+package quotaenforcement
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/types"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+)
+
+// EnforcementKey is the traffic config annotation setting where its app quota groups are enforced.
+const EnforcementKey = "appQuotaEnforcement"
+
+// Enforcement is where the app quota groups are enforced: on the inbound sidecars of the identity,
+// on the outbound sidecars of the associated apps, or both.
+type Enforcement string
+
+// Parse returns the enforcement of the traffic config annotation, the app_quota_enforcement flag if not set.
+func Parse(tc *admiralv1.TrafficConfig) (Enforcement, error) {
+	if tc == nil {
+		return Enforcement(options.GetAppQuotaEnforcement()), nil
+	}
+	value := strings.TrimSpace(tc.Annotations[EnforcementKey])
+	if len(value) == 0 {
+		return Enforcement(options.GetAppQuotaEnforcement()), nil
+	}
+	if err := options.ValidateAppQuotaEnforcement(value); err != nil {
+		return "", fmt.Errorf("invalid %s annotation: %w", EnforcementKey, err)
+	}
+	return Enforcement(value), nil
+}
+
+// Get returns the enforcement of the traffic config, the app_quota_enforcement flag if the annotation is invalid.
+func Get(tc *admiralv1.TrafficConfig) Enforcement {
+	enforcement, err := Parse(tc)
+	if err != nil {
+		return Enforcement(options.GetAppQuotaEnforcement())
+	}
+	return enforcement
+}
+
+// IsInbound returns true if the app quota groups are enforced on the inbound sidecars of the identity.
+func (e Enforcement) IsInbound() bool {
+	return e == types.EnforceInbound || e == types.EnforceBoth
+}
+
+// IsOutbound returns true if the app quota groups are enforced on the outbound sidecars of the associated apps.
+func (e Enforcement) IsOutbound() bool {
+	return e == types.EnforceOutbound || e == types.EnforceBoth
+}
@@ -0,0 +1,13 @@
+package quotaenforcement
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestQuotaEnforcement(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "quotaenforcement_test")
+}
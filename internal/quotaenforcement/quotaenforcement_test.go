@@ -0,0 +1,43 @@
+package quotaenforcement
+
+import (
+	"github.com/intuit/naavik/cmd/options"
+	resourcebuilder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test app quota enforcement", func() {
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+	})
+
+	It("should default to the flag", func() {
+		enforcement, err := Parse(resourcebuilder.GetFakeTrafficConfig("app.shed", "qal", "1", "namespace"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(enforcement).To(Equal(Enforcement(types.EnforceInbound)))
+
+		options.Params.AppQuotaEnforcement = types.EnforceBoth
+		Expect(Get(nil)).To(Equal(Enforcement(types.EnforceBoth)))
+	})
+
+	DescribeTable("should enforce the app quota groups on the sidecars of the annotation",
+		func(value string, inbound bool, outbound bool) {
+			enforcement, err := Parse(resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.shed", "qal", map[string]string{EnforcementKey: value}))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(enforcement.IsInbound()).To(Equal(inbound))
+			Expect(enforcement.IsOutbound()).To(Equal(outbound))
+		},
+		Entry("inbound", "inbound", true, false),
+		Entry("outbound", "outbound", false, true),
+		Entry("both", " both ", true, true),
+	)
+
+	It("should reject an unknown enforcement and fall back to the flag", func() {
+		tc := resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.shed", "qal", map[string]string{EnforcementKey: "client"})
+		_, err := Parse(tc)
+		Expect(err).To(MatchError(ContainSubstring(EnforcementKey)))
+		Expect(Get(tc)).To(Equal(Enforcement(types.EnforceInbound)))
+	})
+})
@@ -0,0 +1,183 @@
+package quotaschedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// SchedulesKey is the traffic config annotation holding the YAML quota schedules.
+const SchedulesKey = "quotaSchedules"
+
+// maxWindowDuration bounds the duration of a window, so finding the active windows stays cheap.
+const maxWindowDuration = 7 * 24 * time.Hour
+
+// QuotaSchedule overrides the limit of a quota of the traffic config during cron windows.
+type QuotaSchedule struct {
+	// QuotaGroup is the name of the total or app quota group of the quota.
+	QuotaGroup string `json:"quotaGroup"`
+	Quota      string `json:"quota"`
+	// TimeZone is the IANA time zone the windows are evaluated in, defaults to UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+	// Windows are evaluated in order, the first active window wins.
+	Windows []*Window `json:"windows"`
+
+	location *time.Location
+}
+
+// Window is a cron window replacing the maxAmount and timePeriod of the quota.
+type Window struct {
+	Name string `json:"name,omitempty"`
+	// Cron is the start of the window, e.g. "0 22 * * *".
+	Cron string `json:"cron"`
+	// Duration is the length of the window, e.g. 6h.
+	Duration   string `json:"duration"`
+	MaxAmount  int    `json:"maxAmount"`
+	TimePeriod string `json:"timePeriod"`
+
+	cron     *Cron
+	duration time.Duration
+}
+
+// Parse parses and validates the quota schedules of the traffic config annotation, nil if there are none.
+func Parse(tc *admiralv1.TrafficConfig) ([]*QuotaSchedule, error) {
+	if tc == nil {
+		return nil, nil
+	}
+	content, ok := tc.Annotations[SchedulesKey]
+	if !ok || len(strings.TrimSpace(content)) == 0 {
+		return nil, nil
+	}
+	schedules := []*QuotaSchedule{}
+	if err := yaml.UnmarshalStrict([]byte(content), &schedules); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", SchedulesKey, err)
+	}
+	for i, schedule := range schedules {
+		if err := schedule.compile(); err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", SchedulesKey, i, err)
+		}
+	}
+	return schedules, nil
+}
+
+func (s *QuotaSchedule) compile() error {
+	if len(s.QuotaGroup) == 0 || len(s.Quota) == 0 {
+		return fmt.Errorf("quotaGroup and quota are required")
+	}
+	location, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return fmt.Errorf("invalid timeZone %q: %w", s.TimeZone, err)
+	}
+	s.location = location
+	if len(s.Windows) == 0 {
+		return fmt.Errorf("at least one window is required")
+	}
+	for i, window := range s.Windows {
+		if err := window.compile(); err != nil {
+			return fmt.Errorf("windows[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (w *Window) compile() error {
+	var err error
+	if w.cron, err = ParseCron(w.Cron); err != nil {
+		return err
+	}
+	if w.duration, err = time.ParseDuration(w.Duration); err != nil {
+		return fmt.Errorf("invalid duration %q: %w", w.Duration, err)
+	}
+	if w.duration < time.Minute || w.duration > maxWindowDuration {
+		return fmt.Errorf("duration %q must be between 1m and %s", w.Duration, maxWindowDuration)
+	}
+	if w.MaxAmount <= 0 {
+		return fmt.Errorf("maxAmount must be positive")
+	}
+	if period, err := time.ParseDuration(w.TimePeriod); err != nil || period <= 0 {
+		return fmt.Errorf("invalid timePeriod %q", w.TimePeriod)
+	}
+	return nil
+}
+
+// Matches returns true if the schedule applies to the quota of the quota group.
+func (s *QuotaSchedule) Matches(quotaGroup string, quota string) bool {
+	return s.QuotaGroup == quotaGroup && s.Quota == quota
+}
+
+// ActiveWindow returns the index of the first window active at now, -1 if none.
+func (s *QuotaSchedule) ActiveWindow(now time.Time) int {
+	for i, window := range s.Windows {
+		if _, active := window.lastStart(now.In(s.location)); active {
+			return i
+		}
+	}
+	return -1
+}
+
+// NextBoundary returns the first time after now a window of the schedule starts or ends.
+func (s *QuotaSchedule) NextBoundary(now time.Time) time.Time {
+	now = now.In(s.location)
+	var next time.Time
+	for _, window := range s.Windows {
+		candidates := []time.Time{window.cron.Next(now)}
+		if start, active := window.lastStart(now); active {
+			candidates = append(candidates, start.Add(window.duration))
+		}
+		for _, candidate := range candidates {
+			if !candidate.IsZero() && candidate.After(now) && (next.IsZero() || candidate.Before(next)) {
+				next = candidate
+			}
+		}
+	}
+	return next
+}
+
+// lastStart returns the last start of the window at or before now, and whether the window is still active at now.
+func (w *Window) lastStart(now time.Time) (time.Time, bool) {
+	last := w.cron.Prev(now)
+	if last.IsZero() {
+		return last, false
+	}
+	return last, now.Before(last.Add(w.duration))
+}
+
+// EffectiveQuota returns the quota with the maxAmount and timePeriod of the active window of its schedule at now,
+// the quota itself when no window is active.
+func EffectiveQuota(schedules []*QuotaSchedule, quotaGroup string, quota *admiralv1.Quota, now time.Time) *admiralv1.Quota {
+	for _, schedule := range schedules {
+		if !schedule.Matches(quotaGroup, quota.Name) {
+			continue
+		}
+		if i := schedule.ActiveWindow(now); i >= 0 {
+			effective := *quota
+			effective.MaxAmount = schedule.Windows[i].MaxAmount
+			effective.TimePeriod = schedule.Windows[i].TimePeriod
+			return &effective
+		}
+	}
+	return quota
+}
+
+// ActiveWindows returns a key identifying the active window of each schedule, it changes at the window boundaries.
+func ActiveWindows(schedules []*QuotaSchedule, now time.Time) string {
+	keys := make([]string, 0, len(schedules))
+	for _, schedule := range schedules {
+		keys = append(keys, fmt.Sprintf("%s/%s=%d", schedule.QuotaGroup, schedule.Quota, schedule.ActiveWindow(now)))
+	}
+	return strings.Join(keys, ",")
+}
+
+// NextBoundary returns the first time after now a window of the schedules starts or ends, the zero time if none.
+func NextBoundary(schedules []*QuotaSchedule, now time.Time) time.Time {
+	var next time.Time
+	for _, schedule := range schedules {
+		if boundary := schedule.NextBoundary(now); !boundary.IsZero() && (next.IsZero() || boundary.Before(next)) {
+			next = boundary
+		}
+	}
+	return next
+}
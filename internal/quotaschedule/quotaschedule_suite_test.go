@@ -0,0 +1,13 @@
+package quotaschedule
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestQuotaSchedule(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "quotaschedule_test")
+}
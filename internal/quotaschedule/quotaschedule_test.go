@@ -0,0 +1,299 @@
+package quotaschedule
+
+import (
+	gocontext "context"
+	"errors"
+	"time"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	resourcebuilder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/internal/utils/clock"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+const nightlySchedules = `
+- quotaGroup: Total Throttling Plan
+  quota: Total
+  windows:
+  - name: nightly-batch
+    cron: "0 22 * * *"
+    duration: 6h
+    maxAmount: 1000
+    timePeriod: 1m
+`
+
+var _ = Describe("Test cron expressions", func() {
+	It("should parse lists, ranges and steps", func() {
+		cron, err := ParseCron("*/15 9-17 * * 1-5")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cron.Matches(time.Date(2026, 10, 19, 9, 45, 0, 0, time.UTC))).To(BeTrue())
+		Expect(cron.Matches(time.Date(2026, 10, 19, 9, 40, 0, 0, time.UTC))).To(BeFalse())
+		Expect(cron.Matches(time.Date(2026, 10, 18, 9, 45, 0, 0, time.UTC))).To(BeFalse())
+	})
+
+	It("should treat day of week 7 as Sunday", func() {
+		cron, err := ParseCron("0 0 * * 7")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cron.Next(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC))).To(Equal(time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("should match either day when both are restricted", func() {
+		cron, err := ParseCron("0 0 1 * 1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cron.Next(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC))).To(Equal(time.Date(2026, 10, 26, 0, 0, 0, 0, time.UTC)))
+		Expect(cron.Next(time.Date(2026, 10, 26, 0, 0, 0, 0, time.UTC))).To(Equal(time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("should return the next activation strictly after the time", func() {
+		cron, err := ParseCron("30 22 * * *")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cron.Next(time.Date(2026, 10, 19, 22, 30, 0, 0, time.UTC))).To(Equal(time.Date(2026, 10, 20, 22, 30, 0, 0, time.UTC)))
+		Expect(cron.Next(time.Date(2026, 12, 31, 23, 0, 0, 0, time.UTC))).To(Equal(time.Date(2027, 1, 1, 22, 30, 0, 0, time.UTC)))
+	})
+
+	It("should return the previous activation at or before the time", func() {
+		cron, err := ParseCron("30 22 * * *")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cron.Prev(time.Date(2026, 10, 19, 22, 30, 59, 0, time.UTC))).To(Equal(time.Date(2026, 10, 19, 22, 30, 0, 0, time.UTC)))
+		Expect(cron.Prev(time.Date(2026, 10, 19, 22, 29, 0, 0, time.UTC))).To(Equal(time.Date(2026, 10, 18, 22, 30, 0, 0, time.UTC)))
+		Expect(cron.Prev(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC))).To(Equal(time.Date(2026, 12, 31, 22, 30, 0, 0, time.UTC)))
+
+		cron, err = ParseCron("*/15 9-17 * * 1-5")
+		Expect(err).NotTo(HaveOccurred())
+		// Monday morning goes back to Friday evening
+		Expect(cron.Prev(time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC))).To(Equal(time.Date(2026, 10, 16, 17, 45, 0, 0, time.UTC)))
+		Expect(cron.Prev(time.Date(2026, 10, 19, 9, 44, 0, 0, time.UTC))).To(Equal(time.Date(2026, 10, 19, 9, 30, 0, 0, time.UTC)))
+	})
+
+	It("should return the zero time when the cron never fires", func() {
+		cron, err := ParseCron("0 0 30 2 *")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cron.Next(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)).IsZero()).To(BeTrue())
+		Expect(cron.Prev(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)).IsZero()).To(BeTrue())
+	})
+
+	It("should find the active window of a frequent cron in a long window", func() {
+		schedules, err := Parse(resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.scheduled", "qal", map[string]string{SchedulesKey: `
+- quotaGroup: Total Throttling Plan
+  quota: Total
+  windows:
+  - name: every-minute
+    cron: "* * * * *"
+    duration: 168h
+    maxAmount: 1000
+    timePeriod: 1m
+`}))
+		Expect(err).NotTo(HaveOccurred())
+		now := time.Date(2026, 10, 19, 12, 0, 30, 0, time.UTC)
+		Expect(schedules[0].ActiveWindow(now)).To(Equal(0))
+		Expect(NextBoundary(schedules, now)).To(Equal(time.Date(2026, 10, 19, 12, 1, 0, 0, time.UTC)))
+	})
+
+	DescribeTable("should reject invalid expressions",
+		func(expression string) {
+			_, err := ParseCron(expression)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("too few fields", "0 22 * *"),
+		Entry("out of range minute", "60 * * * *"),
+		Entry("reversed range", "0 17-9 * * *"),
+		Entry("invalid step", "*/0 * * * *"),
+		Entry("invalid value", "0 x * * *"),
+	)
+})
+
+var _ = Describe("Test quota schedules", func() {
+	It("should return nil without the annotation", func() {
+		schedules, err := Parse(resourcebuilder.GetFakeTrafficConfig("app.scheduled", "qal", "1", "namespace"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(schedules).To(BeNil())
+	})
+
+	DescribeTable("should reject invalid schedules",
+		func(schedules string) {
+			_, err := Parse(resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.scheduled", "qal", map[string]string{SchedulesKey: schedules}))
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("unknown field", "- quotaGroup: g\n  quota: q\n  window: []\n"),
+		Entry("missing quota", "- quotaGroup: g\n  windows: [{cron: '0 22 * * *', duration: 6h, maxAmount: 10, timePeriod: 1s}]\n"),
+		Entry("no window", "- quotaGroup: g\n  quota: q\n"),
+		Entry("invalid time zone", "- quotaGroup: g\n  quota: q\n  timeZone: Mars/Olympus\n  windows: [{cron: '0 22 * * *', duration: 6h, maxAmount: 10, timePeriod: 1s}]\n"),
+		Entry("invalid cron", "- quotaGroup: g\n  quota: q\n  windows: [{cron: '0 22 *', duration: 6h, maxAmount: 10, timePeriod: 1s}]\n"),
+		Entry("too long duration", "- quotaGroup: g\n  quota: q\n  windows: [{cron: '0 22 * * *', duration: 200h, maxAmount: 10, timePeriod: 1s}]\n"),
+		Entry("no max amount", "- quotaGroup: g\n  quota: q\n  windows: [{cron: '0 22 * * *', duration: 6h, timePeriod: 1s}]\n"),
+		Entry("invalid time period", "- quotaGroup: g\n  quota: q\n  windows: [{cron: '0 22 * * *', duration: 6h, maxAmount: 10, timePeriod: 1x}]\n"),
+	)
+
+	It("should apply the active window to the quota", func() {
+		schedules, err := Parse(resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.scheduled", "qal", map[string]string{SchedulesKey: nightlySchedules}))
+		Expect(err).NotTo(HaveOccurred())
+		quota := &admiralv1.Quota{Name: "Total", MaxAmount: 100, TimePeriod: "1s"}
+
+		effective := EffectiveQuota(schedules, "Total Throttling Plan", quota, time.Date(2026, 10, 20, 3, 0, 0, 0, time.UTC))
+		Expect(effective.MaxAmount).To(Equal(1000))
+		Expect(effective.TimePeriod).To(Equal("1m"))
+		Expect(quota.MaxAmount).To(Equal(100))
+
+		Expect(EffectiveQuota(schedules, "Total Throttling Plan", quota, time.Date(2026, 10, 20, 4, 0, 0, 0, time.UTC))).To(BeIdenticalTo(quota))
+		Expect(EffectiveQuota(schedules, "Other Plan", quota, time.Date(2026, 10, 20, 3, 0, 0, 0, time.UTC))).To(BeIdenticalTo(quota))
+	})
+
+	It("should prefer the first active window", func() {
+		schedules, err := Parse(resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.scheduled", "qal", map[string]string{SchedulesKey: `
+- quotaGroup: Total Throttling Plan
+  quota: Total
+  windows:
+  - {name: weekend, cron: "0 0 * * 6", duration: 48h, maxAmount: 50, timePeriod: 1s}
+  - {name: nightly, cron: "0 22 * * *", duration: 6h, maxAmount: 1000, timePeriod: 1m}
+`}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(schedules[0].ActiveWindow(time.Date(2026, 10, 24, 23, 0, 0, 0, time.UTC))).To(Equal(0))
+		Expect(schedules[0].ActiveWindow(time.Date(2026, 10, 22, 23, 0, 0, 0, time.UTC))).To(Equal(1))
+		Expect(schedules[0].ActiveWindow(time.Date(2026, 10, 22, 12, 0, 0, 0, time.UTC))).To(Equal(-1))
+	})
+
+	It("should evaluate the windows in the time zone of the schedule", func() {
+		schedules, err := Parse(resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.scheduled", "qal", map[string]string{SchedulesKey: `
+- quotaGroup: Total Throttling Plan
+  quota: Total
+  timeZone: America/Los_Angeles
+  windows:
+  - {cron: "0 22 * * *", duration: 6h, maxAmount: 1000, timePeriod: 1m}
+`}))
+		Expect(err).NotTo(HaveOccurred())
+		// 22:00 in Los Angeles is 05:00 UTC during daylight saving time
+		Expect(schedules[0].ActiveWindow(time.Date(2026, 10, 20, 5, 30, 0, 0, time.UTC))).To(Equal(0))
+		Expect(schedules[0].ActiveWindow(time.Date(2026, 10, 19, 22, 30, 0, 0, time.UTC))).To(Equal(-1))
+		Expect(NextBoundary(schedules, time.Date(2026, 10, 20, 5, 30, 0, 0, time.UTC)).Equal(time.Date(2026, 10, 20, 11, 0, 0, 0, time.UTC))).To(BeTrue())
+	})
+
+	It("should return the next window start or end", func() {
+		schedules, err := Parse(resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.scheduled", "qal", map[string]string{SchedulesKey: nightlySchedules}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(NextBoundary(schedules, time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC))).To(Equal(time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)))
+		Expect(NextBoundary(schedules, time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC))).To(Equal(time.Date(2026, 10, 20, 4, 0, 0, 0, time.UTC)))
+		Expect(NextBoundary(nil, time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)).IsZero()).To(BeTrue())
+	})
+})
+
+var _ = Describe("Test quota scheduler", func() {
+	var (
+		ctx        context.Context
+		fakeClock  *clocktesting.FakeClock
+		scheduler  *Scheduler
+		boundaries []*admiralv1.TrafficConfig
+		tc         *admiralv1.TrafficConfig
+	)
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.TrafficConfigCache.Reset()
+		ctx = context.NewContextWithLogger()
+		fakeClock = clocktesting.NewFakeClock(time.Date(2026, 10, 19, 21, 0, 0, 0, time.UTC))
+		clock.Set(fakeClock)
+		boundaries = nil
+		scheduler = &Scheduler{
+			Interval: time.Hour * 24,
+			OnBoundary: func(_ context.Context, tc *admiralv1.TrafficConfig) error {
+				boundaries = append(boundaries, tc)
+				return nil
+			},
+		}
+		tc = resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.scheduled", "qal", map[string]string{SchedulesKey: nightlySchedules})
+		cache.TrafficConfigCache.AddTrafficConfigToCache(tc)
+	})
+
+	AfterEach(func() {
+		clock.Reset()
+		cache.TrafficConfigCache.Reset()
+	})
+
+	It("should regenerate the filters at the window start and end", func() {
+		next := scheduler.Tick(ctx)
+		Expect(boundaries).To(BeEmpty())
+		Expect(next).To(Equal(time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)))
+
+		fakeClock.SetTime(next)
+		next = scheduler.Tick(ctx)
+		Expect(boundaries).To(HaveLen(1))
+		Expect(boundaries[0].Name).To(Equal(tc.Name))
+		Expect(next).To(Equal(time.Date(2026, 10, 20, 4, 0, 0, 0, time.UTC)))
+
+		fakeClock.SetTime(time.Date(2026, 10, 20, 1, 0, 0, 0, time.UTC))
+		scheduler.Tick(ctx)
+		Expect(boundaries).To(HaveLen(1))
+
+		fakeClock.SetTime(next)
+		scheduler.Tick(ctx)
+		Expect(boundaries).To(HaveLen(2))
+	})
+
+	It("should retry the boundaries whose regeneration failed", func() {
+		var boundaryErr error
+		scheduler.OnBoundary = func(_ context.Context, tc *admiralv1.TrafficConfig) error {
+			boundaries = append(boundaries, tc)
+			return boundaryErr
+		}
+		scheduler.Tick(ctx)
+		boundaryErr = errors.New("throttle filter write failed")
+		fakeClock.SetTime(time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC))
+		next := scheduler.Tick(ctx)
+		Expect(boundaries).To(HaveLen(1))
+		Expect(next).To(Equal(time.Date(2026, 10, 19, 22, 0, 30, 0, time.UTC)))
+
+		boundaryErr = nil
+		fakeClock.SetTime(next)
+		next = scheduler.Tick(ctx)
+		Expect(boundaries).To(HaveLen(2))
+		Expect(next).To(Equal(time.Date(2026, 10, 20, 4, 0, 0, 0, time.UTC)))
+
+		fakeClock.SetTime(time.Date(2026, 10, 19, 22, 1, 0, 0, time.UTC))
+		scheduler.Tick(ctx)
+		Expect(boundaries).To(HaveLen(2))
+	})
+
+	It("should regenerate the filters of a traffic config added before a boundary passed", func() {
+		cache.TrafficConfigCache.Reset()
+		scheduler.Tick(ctx)
+		cache.TrafficConfigCache.AddTrafficConfigToCache(tc)
+		fakeClock.SetTime(time.Date(2026, 10, 19, 22, 0, 30, 0, time.UTC))
+		scheduler.Tick(ctx)
+		Expect(boundaries).To(HaveLen(1))
+	})
+
+	It("should not regenerate the filters of a traffic config added after a boundary passed", func() {
+		cache.TrafficConfigCache.Reset()
+		fakeClock.SetTime(time.Date(2026, 10, 19, 22, 0, 30, 0, time.UTC))
+		scheduler.Tick(ctx)
+		cache.TrafficConfigCache.AddTrafficConfigToCache(tc)
+		fakeClock.SetTime(time.Date(2026, 10, 19, 22, 1, 0, 0, time.UTC))
+		scheduler.Tick(ctx)
+		Expect(boundaries).To(BeEmpty())
+	})
+
+	It("should evaluate the schedules at the boundaries until the context is cancelled", func() {
+		regenerated := make(chan *admiralv1.TrafficConfig, 1)
+		scheduler.OnBoundary = func(_ context.Context, tc *admiralv1.TrafficConfig) error {
+			regenerated <- tc
+			return nil
+		}
+		cctx, cancel := gocontext.WithCancel(gocontext.Background())
+		ctx.Context = cctx
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			scheduler.Run(ctx)
+		}()
+		Eventually(fakeClock.HasWaiters).Should(BeTrue())
+		Consistently(regenerated).ShouldNot(Receive())
+		fakeClock.SetTime(time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC))
+		Eventually(regenerated).Should(Receive(HaveField("Name", tc.Name)))
+		cancel()
+		Eventually(done).Should(BeClosed())
+	})
+})
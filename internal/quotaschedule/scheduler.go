@@ -0,0 +1,106 @@
+package quotaschedule
+
+import (
+	"time"
+
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/internal/utils/clock"
+	"github.com/intuit/naavik/pkg/logger"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+)
+
+const (
+	// defaultInterval is the max time between two evaluations when Interval is not set.
+	defaultInterval = time.Minute
+	// boundaryRetryInterval is how long the scheduler waits before retrying the boundaries whose regeneration failed.
+	boundaryRetryInterval = 30 * time.Second
+)
+
+// Scheduler calls OnBoundary for the traffic configs whose active windows changed, at the window boundaries.
+type Scheduler struct {
+	// Interval is the max time between two evaluations, so the schedules of new traffic configs are picked up.
+	Interval time.Duration
+	// OnBoundary regenerates the throttle filters of the traffic config.
+	// The boundary is only handled if it succeeds, otherwise it is retried on the next evaluation.
+	OnBoundary func(ctx context.Context, tc *admiralv1.TrafficConfig) error
+
+	// active is the active windows per identity and env at the last evaluation
+	active   map[string]string
+	lastTick time.Time
+}
+
+// Run evaluates the schedules at the window boundaries until the context is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ctx.Log.Info("Starting quota scheduler")
+	for {
+		c := clock.Get()
+		next := s.Tick(ctx)
+		timer := c.NewTimer(next.Sub(c.Now()))
+		select {
+		case <-ctx.Context.Done():
+			timer.Stop()
+			ctx.Log.Info("Quota scheduler stopped")
+			return
+		case <-timer.C():
+		}
+	}
+}
+
+// Tick evaluates the schedules of the cached traffic configs and returns when to evaluate them next.
+// The traffic configs seen for the first time are compared to the previous evaluation, in case a boundary passed since they were reconciled.
+func (s *Scheduler) Tick(ctx context.Context) time.Time {
+	now := clock.Get().Now()
+	if s.active == nil {
+		s.active = map[string]string{}
+		s.lastTick = now
+	}
+	interval := s.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	next := now.Add(interval)
+	seen := map[string]bool{}
+	for _, identity := range cache.TrafficConfigCache.ListIdentities() {
+		entry := cache.TrafficConfigCache.GetTrafficConfigEntry(identity)
+		if entry == nil {
+			continue
+		}
+		for env, tc := range entry.EnvTrafficConfig {
+			schedules, err := Parse(tc)
+			if err != nil || len(schedules) == 0 {
+				continue
+			}
+			key := identity + "/" + env
+			seen[key] = true
+			active := ActiveWindows(schedules, now)
+			previous, found := s.active[key]
+			if !found {
+				previous = ActiveWindows(schedules, s.lastTick)
+			}
+			s.active[key] = active
+			if active != previous && s.OnBoundary != nil {
+				log := ctx.Log.Str(logger.WorkloadIdentifierKey, identity).Str(logger.EnvKey, env).Str("activeWindows", active)
+				log.Info("Quota schedule window changed")
+				if err := s.OnBoundary(ctx, tc); err != nil {
+					// Keep the previous windows, the change is seen again on the next evaluation
+					log.Str(logger.ErrorKey, err.Error()).Info("Quota schedule boundary not handled, retrying")
+					s.active[key] = previous
+					if retry := now.Add(boundaryRetryInterval); retry.Before(next) {
+						next = retry
+					}
+				}
+			}
+			if boundary := NextBoundary(schedules, now); !boundary.IsZero() && boundary.Before(next) {
+				next = boundary
+			}
+		}
+	}
+	for key := range s.active {
+		if !seen[key] {
+			delete(s.active, key)
+		}
+	}
+	s.lastTick = now
+	return next
+}
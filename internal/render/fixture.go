@@ -0,0 +1,107 @@
+package render
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Fixture is the state of the mesh the traffic config is rendered against, in place of the informers.
+type Fixture struct {
+	// Dependencies lists the identities each source identity calls.
+	Dependencies []FixtureDependency `json:"dependencies"`
+	// IdentityClusters lists the clusters of each identity, the clusters of the deployments are added to it.
+	IdentityClusters map[string][]string `json:"identityClusters"` // map[identity][]cluster
+	Deployments      []FixtureDeployment `json:"deployments"`
+}
+
+type FixtureDependency struct {
+	Source       string   `json:"source"`
+	Destinations []string `json:"destinations"`
+}
+
+// FixtureDeployment is a deployment in a cluster, the labels and the annotations are the ones of the pod template,
+// e.g. the app label and the inbound ports annotation used by the throttle filters.
+type FixtureDeployment struct {
+	Cluster     string            `json:"cluster"`
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	Identity    string            `json:"identity"`
+	Env         string            `json:"env"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// ReadFixture reads a fixture from a YAML or JSON file.
+func ReadFixture(path string) (*Fixture, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fixture := &Fixture{}
+	if err := yaml.UnmarshalStrict(content, fixture); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+	}
+	return fixture, nil
+}
+
+// Load adds the fixture to the caches the same way the dependency and deployment handlers do.
+func (f *Fixture) Load() error {
+	for _, dependency := range f.Dependencies {
+		if len(dependency.Source) == 0 {
+			return fmt.Errorf("dependency without source")
+		}
+		for _, destination := range dependency.Destinations {
+			cache.IdentityDependency.AddDependencyToIdentity(dependency.Source, destination)
+			cache.IdentityDependency.AddDependentToIdentity(destination, dependency.Source)
+		}
+	}
+	for identity, clusters := range f.IdentityClusters {
+		for _, cluster := range clusters {
+			cache.IdentityCluster.AddClusterToIdentity(identity, cluster)
+		}
+	}
+	for i, deployment := range f.Deployments {
+		if len(deployment.Cluster) == 0 || len(deployment.Identity) == 0 || len(deployment.Env) == 0 {
+			return fmt.Errorf("deployment %d requires a cluster, an identity and an env", i)
+		}
+		cache.Deployments.Add(deployment.Cluster, deployment.build())
+		cache.IdentityCluster.AddClusterToIdentity(deployment.Identity, deployment.Cluster)
+	}
+	return nil
+}
+
+func (fd FixtureDeployment) build() *k8sAppsV1.Deployment {
+	name := fd.Name
+	if len(name) == 0 {
+		name = fd.Identity
+	}
+	objectMeta := metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   fd.Namespace,
+		Labels:      make(map[string]string, len(fd.Labels)+2),
+		Annotations: make(map[string]string, len(fd.Annotations)),
+	}
+	for key, value := range fd.Labels {
+		objectMeta.Labels[key] = value
+	}
+	for key, value := range fd.Annotations {
+		objectMeta.Annotations[key] = value
+	}
+	objectMeta.Labels[options.GetWorkloadIdentityKey()] = fd.Identity
+	objectMeta.Labels[options.GetEnvKey()] = fd.Env
+	return &k8sAppsV1.Deployment{
+		ObjectMeta: objectMeta,
+		Spec: k8sAppsV1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: objectMeta,
+			},
+		},
+	}
+}
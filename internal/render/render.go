@@ -0,0 +1,76 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/intuit/naavik/internal/handler/trafficconfig"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// ReadTrafficConfig reads a traffic config from a YAML or JSON file.
+func ReadTrafficConfig(path string) (*admiralv1.TrafficConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	trafficConfig := &admiralv1.TrafficConfig{}
+	if err := yaml.Unmarshal(content, trafficConfig); err != nil {
+		return nil, fmt.Errorf("invalid traffic config %s: %w", path, err)
+	}
+	if len(trafficConfig.Kind) > 0 && trafficConfig.Kind != "TrafficConfig" {
+		return nil, fmt.Errorf("invalid kind %s in %s, expected TrafficConfig", trafficConfig.Kind, path)
+	}
+	return trafficConfig, nil
+}
+
+// WriteYAML writes the rendered resources as YAML documents, clusters sorted by name.
+// Each document starts with a comment naming the cluster it is written to.
+func WriteYAML(w io.Writer, rendered *trafficconfig.RenderedTrafficConfig) error {
+	clusters := make([]string, 0, len(rendered.Clusters))
+	for cluster := range rendered.Clusters {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+	for _, cluster := range clusters {
+		resources := rendered.Clusters[cluster]
+		objects := make([]interface{}, 0, len(resources.VirtualServices)+len(resources.EnvoyFilters))
+		for _, vs := range resources.VirtualServices {
+			objects = append(objects, vs)
+		}
+		for _, envoyFilter := range resources.EnvoyFilters {
+			objects = append(objects, envoyFilter)
+		}
+		for _, object := range objects {
+			content, err := ToManifest(object)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "---\n# cluster: %s\n%s", cluster, content); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ToManifest renders the object as YAML, leaving out the fields only set by the api server.
+func ToManifest(object interface{}) ([]byte, error) {
+	content, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	manifest := map[string]interface{}{}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+	delete(manifest, "status")
+	if metadata, ok := manifest["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "creationTimestamp")
+	}
+	return yaml.Marshal(manifest)
+}
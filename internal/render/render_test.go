@@ -0,0 +1,70 @@
+package render
+
+import (
+	"bytes"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	k8s_builder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/handler/trafficconfig"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test render of a traffic config", func() {
+	var fixture *Fixture
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		fixture = &Fixture{
+			Dependencies:     []FixtureDependency{{Source: "bar", Destinations: []string{"foo"}}},
+			IdentityClusters: map[string][]string{"bar": {"cluster-b"}},
+			Deployments: []FixtureDeployment{{
+				Cluster:     "cluster-a",
+				Namespace:   "foo-qa",
+				Identity:    "foo",
+				Env:         "qa",
+				Labels:      map[string]string{"app": "foo"},
+				Annotations: map[string]string{types.IncludeInboundPortsAnnotation: "8080"},
+			}},
+		}
+	})
+
+	AfterEach(func() {
+		cache.ResetAllCaches()
+	})
+
+	It("should load the fixture into the caches", func() {
+		Expect(fixture.Load()).To(Succeed())
+		Expect(cache.IdentityDependency.GetDependentsForIdentity("foo")).To(ConsistOf("bar"))
+		Expect(cache.IdentityCluster.GetClustersForIdentity("foo")).To(ConsistOf("cluster-a"))
+		Expect(cache.IdentityCluster.GetClustersForIdentity("bar")).To(ConsistOf("cluster-b"))
+		Expect(cache.Deployments.GetByClusterIdentityEnv("cluster-a", "foo", "qa")).NotTo(BeNil())
+	})
+
+	It("should reject a deployment without identity", func() {
+		fixture.Deployments[0].Identity = ""
+		Expect(fixture.Load()).NotTo(Succeed())
+	})
+
+	It("should render the throttle filters in the identity clusters and the virtual service in the dependent clusters", func() {
+		Expect(fixture.Load()).To(Succeed())
+		rendered, err := trafficconfig.RenderTrafficConfig(context.NewContextWithLogger(), k8s_builder.GetFakeTrafficConfig("foo", "qa", "1", "ns"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rendered.Clusters).To(HaveLen(2))
+		Expect(rendered.Clusters["cluster-a"].EnvoyFilters).To(HaveLen(len(options.GetEnvoyFilterVersions())))
+		Expect(rendered.Clusters["cluster-a"].VirtualServices).To(BeEmpty())
+		Expect(rendered.Clusters["cluster-b"].VirtualServices).To(HaveLen(1))
+		Expect(rendered.Clusters["cluster-b"].EnvoyFilters).To(BeEmpty())
+
+		out := &bytes.Buffer{}
+		Expect(WriteYAML(out, rendered)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("# cluster: cluster-a\napiVersion: networking.istio.io/v1alpha3\nkind: EnvoyFilter"))
+		Expect(out.String()).To(ContainSubstring("# cluster: cluster-b\napiVersion: networking.istio.io/v1alpha3\nkind: VirtualService"))
+		Expect(out.String()).To(ContainSubstring("name: inbound|http|8080"))
+		Expect(out.String()).NotTo(ContainSubstring("creationTimestamp"))
+	})
+})
@@ -0,0 +1,55 @@
+package scope
+
+import (
+	"sort"
+	"sync"
+)
+
+var (
+	// pendingClusters and pendingAssets left the scope while this instance could not delete their resources
+	pendingClusters = map[string]bool{}
+	pendingAssets   = map[string]bool{}
+	pendingLock     = sync.Mutex{}
+)
+
+// RecordPendingCleanup records the clusters and assets that left the scope, their resources are deleted once this instance can write,
+// i.e. after cache warm up or failover. The reconcile of all the traffic configs only writes to the clusters and assets in scope.
+func RecordPendingCleanup(change Change) {
+	pendingLock.Lock()
+	defer pendingLock.Unlock()
+	for _, clusterID := range change.LeftClusters {
+		pendingClusters[clusterID] = true
+	}
+	for _, asset := range change.LeftAssets {
+		pendingAssets[asset] = true
+	}
+}
+
+// TakePendingCleanup returns and forgets the deferred clusters and assets that are still out of scope.
+func TakePendingCleanup() Change {
+	pendingLock.Lock()
+	defer pendingLock.Unlock()
+	change := Change{LeftClusters: []string{}, LeftAssets: []string{}}
+	for clusterID := range pendingClusters {
+		if !IsClusterInScope(clusterID) {
+			change.LeftClusters = append(change.LeftClusters, clusterID)
+		}
+	}
+	for asset := range pendingAssets {
+		if !IsAssetInScope(asset) {
+			change.LeftAssets = append(change.LeftAssets, asset)
+		}
+	}
+	sort.Strings(change.LeftClusters)
+	sort.Strings(change.LeftAssets)
+	pendingClusters = map[string]bool{}
+	pendingAssets = map[string]bool{}
+	return change
+}
+
+// HasPendingCleanup returns true if some clusters or assets are waiting for their resources to be deleted.
+func HasPendingCleanup() bool {
+	pendingLock.Lock()
+	defer pendingLock.Unlock()
+	return len(pendingClusters) > 0 || len(pendingAssets) > 0
+}
@@ -0,0 +1,279 @@
+package scope
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	"sigs.k8s.io/yaml"
+)
+
+// ListsKey is the config map key holding the YAML allow and deny lists.
+const ListsKey = "scope"
+
+// Lists are the allow and deny lists of the clusters and assets the traffic configs are applied to.
+// They narrow the --traffic_config_clusters_scope and --ignore_asset_aliases flags, a deny list wins over an allow list.
+type Lists struct {
+	// AllowedClusters are case insensitive cluster regexes, empty allows all the clusters in the flags scope.
+	AllowedClusters []string `json:"allowedClusters,omitempty"`
+	// DeniedClusters are case insensitive cluster regexes.
+	DeniedClusters []string `json:"deniedClusters,omitempty"`
+	// AllowedAssets are case insensitive asset aliases, empty allows all the assets not ignored by the flags.
+	AllowedAssets []string `json:"allowedAssets,omitempty"`
+	// DeniedAssets are case insensitive asset aliases.
+	DeniedAssets []string `json:"deniedAssets,omitempty"`
+}
+
+// State is the last state read from the scope config map.
+type State struct {
+	ConfigMapFound  bool      `json:"configMapFound"`
+	ResourceVersion string    `json:"resourceVersion,omitempty"`
+	LastUpdated     time.Time `json:"lastUpdated"`
+	// Error is set when the config map is invalid, the last valid lists are kept.
+	Error string `json:"error,omitempty"`
+	Lists Lists  `json:"lists"`
+}
+
+// compiledLists are the lists as evaluated, compiled once when the state is set.
+type compiledLists struct {
+	allowedClusters []*regexp.Regexp
+	deniedClusters  []*regexp.Regexp
+	allowedAssets   map[string]bool
+	deniedAssets    map[string]bool
+}
+
+var (
+	currentState = State{}
+	compiled     = &compiledLists{}
+	stateLock    = sync.RWMutex{}
+)
+
+// Parse parses the lists of the config map data.
+func Parse(data map[string]string) (*Lists, error) {
+	lists := &Lists{}
+	content, ok := data[ListsKey]
+	if !ok || len(strings.TrimSpace(content)) == 0 {
+		return lists, nil
+	}
+	if err := yaml.UnmarshalStrict([]byte(content), lists); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ListsKey, err)
+	}
+	if err := lists.Validate(); err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+// Validate returns an error if a cluster pattern is not a valid regex or an asset is empty.
+func (l *Lists) Validate() error {
+	_, err := compile(l)
+	return err
+}
+
+func compile(lists *Lists) (*compiledLists, error) {
+	var err error
+	c := &compiledLists{}
+	if c.allowedClusters, err = compileClusters("allowedClusters", lists.AllowedClusters); err != nil {
+		return nil, err
+	}
+	if c.deniedClusters, err = compileClusters("deniedClusters", lists.DeniedClusters); err != nil {
+		return nil, err
+	}
+	if c.allowedAssets, err = assetSet("allowedAssets", lists.AllowedAssets); err != nil {
+		return nil, err
+	}
+	if c.deniedAssets, err = assetSet("deniedAssets", lists.DeniedAssets); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func compileClusters(field string, patterns []string) ([]*regexp.Regexp, error) {
+	regexes := make([]*regexp.Regexp, 0, len(patterns))
+	for i, pattern := range patterns {
+		regex, err := options.CompileClusterRegex(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: invalid cluster regex %q: %w", field, i, pattern, err)
+		}
+		regexes = append(regexes, regex)
+	}
+	return regexes, nil
+}
+
+func assetSet(field string, assets []string) (map[string]bool, error) {
+	set := make(map[string]bool, len(assets))
+	for i, asset := range assets {
+		asset = strings.ToLower(strings.TrimSpace(asset))
+		if len(asset) == 0 {
+			return nil, fmt.Errorf("%s[%d]: asset alias is required", field, i)
+		}
+		set[asset] = true
+	}
+	return set, nil
+}
+
+// SetState replaces the lists, they are expected to be validated.
+func SetState(state State) error {
+	lists, err := compile(&state.Lists)
+	if err != nil {
+		return err
+	}
+	stateLock.Lock()
+	defer stateLock.Unlock()
+	currentState = state
+	compiled = lists
+	return nil
+}
+
+// GetState returns the last state read from the config map.
+func GetState() State {
+	stateLock.RLock()
+	defer stateLock.RUnlock()
+	return currentState
+}
+
+// Reset removes all the lists and the deferred cleanups.
+func Reset() {
+	_ = SetState(State{})
+	_ = TakePendingCleanup()
+}
+
+// IsClusterInScope returns true if the traffic config resources are written to the cluster.
+func IsClusterInScope(clusterID string) bool {
+	if !options.IsClusterInAllowedScope(clusterID) {
+		return false
+	}
+	stateLock.RLock()
+	defer stateLock.RUnlock()
+	if matchesAny(compiled.deniedClusters, clusterID) {
+		return false
+	}
+	return len(compiled.allowedClusters) == 0 || matchesAny(compiled.allowedClusters, clusterID)
+}
+
+// IsAssetInScope returns true if the traffic configs of the asset, and the asset as a dependent, are processed.
+func IsAssetInScope(asset string) bool {
+	if options.IsAssetIgnored(asset) {
+		return false
+	}
+	asset = strings.ToLower(asset)
+	stateLock.RLock()
+	defer stateLock.RUnlock()
+	if compiled.deniedAssets[asset] {
+		return false
+	}
+	return len(compiled.allowedAssets) == 0 || compiled.allowedAssets[asset]
+}
+
+func matchesAny(regexes []*regexp.Regexp, value string) bool {
+	for _, regex := range regexes {
+		if regex.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshot is the scope of the known clusters and assets at a point in time, keyed by lowercase name.
+type Snapshot struct {
+	Clusters map[string]bool
+	Assets   map[string]bool
+}
+
+// TakeSnapshot evaluates the scope of the remote clusters and of the assets with workloads or traffic configs.
+func TakeSnapshot() Snapshot {
+	snapshot := Snapshot{Clusters: map[string]bool{}, Assets: map[string]bool{}}
+	for _, rc := range cache.RemoteCluster.ListClusters() {
+		clusterID := strings.ToLower(rc.GetClusterID())
+		snapshot.Clusters[clusterID] = IsClusterInScope(clusterID)
+	}
+	for _, asset := range append(cache.IdentityCluster.ListIdentities(), cache.TrafficConfigCache.ListIdentities()...) {
+		asset = strings.ToLower(asset)
+		snapshot.Assets[asset] = IsAssetInScope(asset)
+	}
+	return snapshot
+}
+
+// Change lists the clusters and assets that left or joined the scope.
+type Change struct {
+	LeftClusters   []string `json:"leftClusters,omitempty"`
+	JoinedClusters []string `json:"joinedClusters,omitempty"`
+	LeftAssets     []string `json:"leftAssets,omitempty"`
+	JoinedAssets   []string `json:"joinedAssets,omitempty"`
+}
+
+// IsEmpty returns true if no cluster or asset left or joined the scope.
+func (c Change) IsEmpty() bool {
+	return len(c.LeftClusters) == 0 && len(c.JoinedClusters) == 0 && len(c.LeftAssets) == 0 && len(c.JoinedAssets) == 0
+}
+
+// Diff returns the clusters and assets whose scope changed between the snapshots.
+// Clusters and assets missing from one of the snapshots are not a scope change.
+func Diff(previous Snapshot, current Snapshot) Change {
+	change := Change{}
+	change.LeftClusters, change.JoinedClusters = diff(previous.Clusters, current.Clusters)
+	change.LeftAssets, change.JoinedAssets = diff(previous.Assets, current.Assets)
+	return change
+}
+
+func diff(previous map[string]bool, current map[string]bool) ([]string, []string) {
+	left, joined := []string{}, []string{}
+	for name, inScope := range current {
+		wasInScope, found := previous[name]
+		switch {
+		case !found || wasInScope == inScope:
+		case inScope:
+			joined = append(joined, name)
+		default:
+			left = append(left, name)
+		}
+	}
+	sort.Strings(left)
+	sort.Strings(joined)
+	return left, joined
+}
+
+// AffectedIdentities returns the identities of the cached traffic configs to reconcile for the clusters and assets that joined the scope:
+// the joined assets, the identities the joined assets depend on, and the identities with workloads or dependents in the joined clusters.
+func AffectedIdentities(change Change) []string {
+	joinedClusters := map[string]bool{}
+	for _, clusterID := range change.JoinedClusters {
+		joinedClusters[clusterID] = true
+	}
+	candidates := map[string]bool{}
+	for _, asset := range change.JoinedAssets {
+		candidates[asset] = true
+		for _, dependency := range cache.IdentityDependency.GetDependenciesForIdentity(asset) {
+			candidates[strings.ToLower(dependency)] = true
+		}
+	}
+	identities := []string{}
+	for _, identity := range cache.TrafficConfigCache.ListIdentities() {
+		if !IsAssetInScope(identity) {
+			continue
+		}
+		if candidates[strings.ToLower(identity)] || (len(joinedClusters) > 0 && hasClusterIn(identity, joinedClusters)) {
+			identities = append(identities, identity)
+		}
+	}
+	sort.Strings(identities)
+	return identities
+}
+
+// hasClusterIn returns true if the identity or one of its dependents has workloads in the clusters.
+func hasClusterIn(identity string, clusters map[string]bool) bool {
+	assets := append([]string{identity}, cache.IdentityDependency.GetDependentsForIdentity(identity)...)
+	for _, asset := range assets {
+		for _, clusterID := range cache.IdentityCluster.GetClustersForIdentity(asset) {
+			if clusters[strings.ToLower(clusterID)] {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+package scope
+
+import (
+	gocontext "context"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/configmapwatcher"
+	"github.com/intuit/naavik/internal/fake/builder"
+	k8s_builder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/types/context"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func setLists(content string) {
+	lists, err := Parse(map[string]string{ListsKey: content})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(SetState(State{ConfigMapFound: true, Lists: *lists})).To(Succeed())
+}
+
+var _ = Describe("Test scope lists", func() {
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		Reset()
+	})
+
+	It("should follow the flags without lists", func() {
+		Expect(IsClusterInScope("cluster1")).To(BeTrue())
+		Expect(IsAssetInScope("foo")).To(BeTrue())
+		options.InitializeNaavikArgs(&options.NaavikArgs{AllowedClusterScope: []string{"west-.*"}, IgnoreAssetAliases: []string{"Foo"}})
+		Expect(IsClusterInScope("WEST-1")).To(BeTrue())
+		Expect(IsClusterInScope("east-1")).To(BeFalse())
+		Expect(IsAssetInScope("foo")).To(BeFalse())
+		Expect(IsAssetInScope("bar")).To(BeTrue())
+	})
+
+	It("should narrow the flags with the allow and deny lists", func() {
+		options.InitializeNaavikArgs(&options.NaavikArgs{AllowedClusterScope: []string{"west-.*", "east-.*"}})
+		setLists(`
+allowedClusters: ["west-.*"]
+deniedClusters: ["west-2"]
+allowedAssets: [Foo, bar]
+deniedAssets: [BAR]
+`)
+		Expect(IsClusterInScope("west-1")).To(BeTrue())
+		Expect(IsClusterInScope("west-2")).To(BeFalse())
+		Expect(IsClusterInScope("east-1")).To(BeFalse())
+		Expect(IsAssetInScope("FOO")).To(BeTrue())
+		Expect(IsAssetInScope("bar")).To(BeFalse())
+		Expect(IsAssetInScope("baz")).To(BeFalse())
+	})
+
+	It("should reject invalid regexes, empty assets and unknown fields", func() {
+		_, err := Parse(map[string]string{ListsKey: "deniedClusters: ['[']\n"})
+		Expect(err).To(MatchError(ContainSubstring("deniedClusters[0]: invalid cluster regex")))
+		_, err = Parse(map[string]string{ListsKey: "allowedAssets: [' ']\n"})
+		Expect(err).To(MatchError(ContainSubstring("allowedAssets[0]: asset alias is required")))
+		_, err = Parse(map[string]string{ListsKey: "clusters: [west]\n"})
+		Expect(err).To(HaveOccurred())
+		lists, err := Parse(map[string]string{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*lists).To(Equal(Lists{}))
+	})
+
+	It("should skip invalid cluster scope flags instead of panicking", func() {
+		options.InitializeNaavikArgs(&options.NaavikArgs{AllowedClusterScope: []string{"[", "west-.*"}})
+		Expect(options.GetDynamicArgs().Validate()).To(MatchError(ContainSubstring("invalid traffic_config_clusters_scope")))
+		Expect(IsClusterInScope("west-1")).To(BeTrue())
+		Expect(IsClusterInScope("east-1")).To(BeFalse())
+	})
+
+	It("should only return the deferred cleanups still out of scope", func() {
+		setLists(`
+deniedClusters: [west-1, west-2]
+deniedAssets: [foo]
+`)
+		RecordPendingCleanup(Change{LeftClusters: []string{"west-2", "west-1", "east-1"}, LeftAssets: []string{"foo", "bar"}})
+		Expect(TakePendingCleanup()).To(Equal(Change{LeftClusters: []string{"west-1", "west-2"}, LeftAssets: []string{"foo"}}))
+		Expect(TakePendingCleanup()).To(Equal(Change{LeftClusters: []string{}, LeftAssets: []string{}}))
+	})
+})
+
+var _ = Describe("Test scope config map changes", func() {
+	var (
+		w       *watcher
+		changes []Change
+	)
+	ctx := context.NewContextWithLogger()
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		Reset()
+		changes = nil
+		w = &watcher{opts: WatcherOpts{ConfigMapName: "naavik-scope", OnChange: func(_ context.Context, change Change) {
+			changes = append(changes, change)
+		}}}
+		cache.RemoteCluster.AddCluster(builder.BuildRemoteCluster("west-1"))
+		cache.RemoteCluster.AddCluster(builder.BuildRemoteCluster("east-1"))
+		cache.IdentityCluster.AddClusterToIdentity("foo", "west-1")
+		cache.IdentityCluster.AddClusterToIdentity("bar", "east-1")
+		cache.IdentityDependency.AddDependentToIdentity("foo", "bar")
+		cache.IdentityDependency.AddDependencyToIdentity("bar", "foo")
+		cache.TrafficConfigCache.AddTrafficConfigToCache(k8s_builder.GetFakeTrafficConfig("foo", "qa", "1", "admiral"))
+	})
+
+	buildConfigMap := func(lists string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "naavik-scope", ResourceVersion: "1"},
+			Data:       map[string]string{ListsKey: lists},
+		}
+	}
+
+	It("should report the clusters and assets leaving and joining the scope", func() {
+		w.apply(ctx, configmapwatcher.NewEvent(buildConfigMap("deniedClusters: [east-.*]\ndeniedAssets: [bar]\n"), Parse))
+		Expect(changes).To(Equal([]Change{{LeftClusters: []string{"east-1"}, JoinedClusters: []string{}, LeftAssets: []string{"bar"}, JoinedAssets: []string{}}}))
+
+		changes = nil
+		w.apply(ctx, configmapwatcher.NewEvent(buildConfigMap("deniedClusters: [east-.*]\ndeniedAssets: [bar]\n"), Parse))
+		Expect(changes).To(BeNil())
+
+		w.apply(ctx, configmapwatcher.NewEvent(nil, Parse))
+		Expect(changes).To(HaveLen(1))
+		Expect(changes[0].JoinedClusters).To(ConsistOf("east-1"))
+		Expect(changes[0].JoinedAssets).To(ConsistOf("bar"))
+		Expect(GetState().ConfigMapFound).To(BeFalse())
+		// bar depends on foo and has workloads in east-1, so the traffic config of foo is reconciled
+		Expect(AffectedIdentities(changes[0])).To(ConsistOf("foo"))
+	})
+
+	It("should report an empty change on every config map event while a cleanup is pending", func() {
+		w.apply(ctx, configmapwatcher.NewEvent(buildConfigMap("deniedClusters: [east-.*]\n"), Parse))
+		Expect(changes).To(HaveLen(1))
+
+		changes = nil
+		RecordPendingCleanup(Change{LeftClusters: []string{"east-1"}})
+		w.apply(ctx, configmapwatcher.NewEvent(buildConfigMap("deniedClusters: [east-.*]\n"), Parse))
+		Expect(changes).To(HaveLen(1))
+		Expect(changes[0].IsEmpty()).To(BeTrue())
+
+		changes = nil
+		TakePendingCleanup()
+		w.apply(ctx, configmapwatcher.NewEvent(buildConfigMap("deniedClusters: [east-.*]\n"), Parse))
+		Expect(changes).To(BeNil())
+	})
+
+	It("should keep the last valid lists on an invalid config map", func() {
+		w.apply(ctx, configmapwatcher.NewEvent(buildConfigMap("deniedClusters: [west-1]\n"), Parse))
+		Expect(changes).To(HaveLen(1))
+
+		changes = nil
+		w.apply(ctx, configmapwatcher.NewEvent(buildConfigMap("deniedClusters: ['[']\n"), Parse))
+		Expect(changes).To(BeNil())
+		state := GetState()
+		Expect(state.Error).To(ContainSubstring("invalid cluster regex"))
+		Expect(state.Lists.DeniedClusters).To(ConsistOf("west-1"))
+		Expect(IsClusterInScope("west-1")).To(BeFalse())
+	})
+
+	It("should not reconcile the identities out of scope", func() {
+		setLists("deniedAssets: [foo]\n")
+		Expect(AffectedIdentities(Change{JoinedClusters: []string{"west-1"}})).To(BeEmpty())
+	})
+
+	It("should save the lists to the config map", func() {
+		Expect(Save(gocontext.Background(), Lists{})).To(MatchError(ErrNotWatching))
+
+		client := fake.NewSimpleClientset()
+		w.client = client
+		w.opts.Namespace = "admiral-sync"
+		activeWatcher.Store(w)
+		defer activeWatcher.Store(nil)
+
+		Expect(Save(gocontext.Background(), Lists{DeniedClusters: []string{"["}})).To(MatchError(ContainSubstring("invalid cluster regex")))
+		Expect(Save(gocontext.Background(), Lists{DeniedAssets: []string{"bar"}})).To(Succeed())
+		Expect(Save(gocontext.Background(), Lists{AllowedClusters: []string{"west-.*"}})).To(Succeed())
+		cm, err := client.CoreV1().ConfigMaps("admiral-sync").Get(gocontext.Background(), "naavik-scope", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		lists, err := Parse(cm.Data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*lists).To(Equal(Lists{AllowedClusters: []string{"west-.*"}}))
+	})
+})
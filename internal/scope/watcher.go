@@ -0,0 +1,118 @@
+package scope
+
+import (
+	gocontext "context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/intuit/naavik/internal/configmapwatcher"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/logger"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// ErrNotWatching is returned when the lists are saved while the scope config map is not watched.
+var ErrNotWatching = errors.New("scope config map is not watched")
+
+type WatcherOpts struct {
+	Namespace     string
+	ConfigMapName string
+	ResyncPeriod  time.Duration
+	// OnChange is called with the clusters and assets that left or joined the scope.
+	// It is also called with an empty change on every config map event while a cleanup is pending, e.g. on resync, to retry it.
+	OnChange func(ctx context.Context, change Change)
+}
+
+type watcher struct {
+	opts   WatcherOpts
+	client kubernetes.Interface
+}
+
+// activeWatcher is the watcher the lists are saved with.
+var activeWatcher atomic.Pointer[watcher]
+
+// Watch watches the scope config map until the context is cancelled.
+func Watch(ctx context.Context, client kubernetes.Interface, opts WatcherOpts) {
+	w := &watcher{opts: opts, client: client}
+	activeWatcher.Store(w)
+	defer activeWatcher.CompareAndSwap(w, nil)
+	configmapwatcher.Watch(ctx, client, configmapwatcher.Opts[*Lists]{
+		Name:          "scope",
+		Namespace:     opts.Namespace,
+		ConfigMapName: opts.ConfigMapName,
+		ResyncPeriod:  opts.ResyncPeriod,
+		Parse:         Parse,
+		OnChange:      w.apply,
+	})
+}
+
+// apply applies the lists of the config map and reports the clusters and assets that left or joined the scope.
+func (w *watcher) apply(ctx context.Context, event configmapwatcher.Event[*Lists]) {
+	log := ctx.Log.Str(logger.NameKey, w.opts.ConfigMapName).Str(logger.NamespaceKey, w.opts.Namespace)
+	state := State{LastUpdated: time.Now(), ConfigMapFound: event.Found, ResourceVersion: event.ResourceVersion}
+	if event.Err != nil {
+		// Keep the last valid lists, a typo must not take the whole mesh out of scope
+		log.Str(logger.ErrorKey, event.Err.Error()).Error("Invalid scope config map, keeping the last valid lists")
+		state.Lists = GetState().Lists
+		state.Error = event.Err.Error()
+		_ = SetState(state)
+		return
+	}
+	if event.Value != nil {
+		state.Lists = *event.Value
+	}
+	before := TakeSnapshot()
+	if err := SetState(state); err != nil {
+		log.Str(logger.ErrorKey, err.Error()).Error("error setting the scope lists")
+		return
+	}
+	change := Diff(before, TakeSnapshot())
+	log.Any("change", change).Info("Scope lists updated")
+	if (!change.IsEmpty() || HasPendingCleanup()) && w.opts.OnChange != nil {
+		w.opts.OnChange(ctx, change)
+	}
+}
+
+// Save writes the lists to the scope config map, creating it if missing. The watcher applies them once the update is received.
+func Save(ctx gocontext.Context, lists Lists) error {
+	if err := lists.Validate(); err != nil {
+		return err
+	}
+	w := activeWatcher.Load()
+	if w == nil {
+		return ErrNotWatching
+	}
+	content, err := yaml.Marshal(lists)
+	if err != nil {
+		return err
+	}
+	configMaps := w.client.CoreV1().ConfigMaps(w.opts.Namespace)
+	cm, err := configMaps.Get(ctx, w.opts.ConfigMapName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      w.opts.ConfigMapName,
+				Namespace: w.opts.Namespace,
+				Labels:    map[string]string{types.CreatedByKey: types.NaavikName},
+			},
+			Data: map[string]string{ListsKey: string(content)},
+		}
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[ListsKey] = string(content)
+	_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
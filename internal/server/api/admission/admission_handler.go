@@ -0,0 +1,72 @@
+package admission
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intuit/naavik/internal/server/api"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/internal/webhook"
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+// maxReviewBodyBytes is the max size of an admission review, the API server limits the objects to 3MB.
+const maxReviewBodyBytes = 3 << 20
+
+func AddRoutes(routerGroup *gin.RouterGroup) *gin.RouterGroup {
+	admissionRoutes := routerGroup.Group("/admission")
+	admissionRoutes.POST("/trafficconfig", requireTLS(), reviewTrafficConfig)
+	return routerGroup
+}
+
+// requireTLS rejects the requests not received over TLS, the routes are only registered on the router of the TLS server.
+func requireTLS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.TLS == nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, api.ErrorResponse{Message: "the admission webhook is only served over TLS"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// reviewTrafficConfig godoc
+//
+//	@Summary		Traffic Config Admission Webhook
+//	@Description	Validating admission webhook called by the API server on traffic config creates and updates, over the TLS server.
+//	@Description	Denies a traffic config with validation errors, returns the warnings, e.g. unknown identities or workload envs, to the client.
+//	@Tags			Admission
+//	@Accept			json
+//	@Produce		json
+//	@Param			review	body		object	true	"AdmissionReview admission.k8s.io/v1"
+//	@Success		200		{object}	object
+//	@Failure		400		{object}	api.ErrorResponse
+//	@Failure		403		{object}	api.ErrorResponse
+//	@Failure		413		{object}	api.ErrorResponse
+//	@Router			/admission/trafficconfig [post].
+func reviewTrafficConfig(c *gin.Context) {
+	review := &admissionv1.AdmissionReview{}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxReviewBodyBytes)
+	if err := c.ShouldBindJSON(review); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, api.ErrorResponse{Message: fmt.Sprintf("admission review exceeds %d bytes", maxBytesErr.Limit)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{Message: fmt.Sprintf("invalid admission review: %s", err.Error())})
+		return
+	}
+	if review.APIVersion != admissionv1.SchemeGroupVersion.String() {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{Message: fmt.Sprintf("unsupported api version %q, expected %s", review.APIVersion, admissionv1.SchemeGroupVersion)})
+		return
+	}
+	ctx := context.NewContextWithLogger()
+	reviewResponse, err := webhook.ReviewTrafficConfig(ctx, review)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, reviewResponse)
+}
@@ -0,0 +1,131 @@
+package admission
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	k8s_builder "github.com/intuit/naavik/internal/fake/builder/resource"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var _ = Describe("Test admission handler", func() {
+	var (
+		router *gin.Engine
+		tc     *admiralv1.TrafficConfig
+	)
+
+	// buildBody returns the admission review of the traffic config as JSON.
+	buildBody := func(apiVersion string, tc *admiralv1.TrafficConfig) []byte {
+		raw, err := json.Marshal(tc)
+		Expect(err).NotTo(HaveOccurred())
+		body, err := json.Marshal(&admissionv1.AdmissionReview{
+			TypeMeta: metav1.TypeMeta{APIVersion: apiVersion, Kind: "AdmissionReview"},
+			Request: &admissionv1.AdmissionRequest{
+				UID:       "uid",
+				Operation: admissionv1.Create,
+				Name:      tc.Name,
+				Namespace: tc.Namespace,
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return body
+	}
+
+	// review posts the body to the webhook, over TLS if requested.
+	review := func(body []byte, overTLS bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admission/trafficconfig", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if overTLS {
+			req.TLS = &tls.ConnectionState{}
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// reviewResponse returns the admission response of the webhook response.
+	reviewResponse := func(w *httptest.ResponseRecorder) *admissionv1.AdmissionResponse {
+		Expect(w.Code).To(Equal(http.StatusOK))
+		reviewed := &admissionv1.AdmissionReview{}
+		Expect(json.Unmarshal(w.Body.Bytes(), reviewed)).To(Succeed())
+		Expect(reviewed.APIVersion).To(Equal("admission.k8s.io/v1"))
+		Expect(reviewed.Response).NotTo(BeNil())
+		Expect(reviewed.Response.UID).To(BeEquivalentTo("uid"))
+		return reviewed.Response
+	}
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		cache.InformerSync.SetWarmedUp()
+		tc = k8s_builder.GetFakeTrafficConfig("foo", "qa", "1", "admiral")
+		tc.Spec.EdgeService.Routes[1].FilterSelector = "fake-filter"
+		cache.IdentityCluster.AddClusterToIdentity("foo", "cluster1")
+		cache.Deployments.Add("cluster1", k8s_builder.BuildFakeDeployment("foo", "foo", "foo", "qa", "ns"))
+
+		gin.SetMode(gin.TestMode)
+		router = gin.New()
+		AddRoutes(router.Group("/api/v1"))
+	})
+
+	AfterEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+	})
+
+	It("should reject the requests not received over TLS", func() {
+		w := review(buildBody("admission.k8s.io/v1", tc), false)
+		Expect(w.Code).To(Equal(http.StatusForbidden))
+		Expect(w.Body.String()).To(ContainSubstring("the admission webhook is only served over TLS"))
+	})
+
+	It("should reject an unsupported api version", func() {
+		w := review(buildBody("admission.k8s.io/v1beta1", tc), true)
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+		Expect(w.Body.String()).To(ContainSubstring(`unsupported api version \"admission.k8s.io/v1beta1\"`))
+	})
+
+	It("should reject an invalid or oversized body", func() {
+		w := review([]byte("not json"), true)
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+		Expect(w.Body.String()).To(ContainSubstring("invalid admission review"))
+
+		w = review([]byte(`{"apiVersion": "admission.k8s.io/v1", "kind": "`+strings.Repeat("a", maxReviewBodyBytes)+`"}`), true)
+		Expect(w.Code).To(Equal(http.StatusRequestEntityTooLarge))
+		Expect(w.Body.String()).To(ContainSubstring("admission review exceeds 3145728 bytes"))
+	})
+
+	It("should allow a valid traffic config", func() {
+		response := reviewResponse(review(buildBody("admission.k8s.io/v1", tc), true))
+		Expect(response.Allowed).To(BeTrue())
+		Expect(response.Warnings).To(BeEmpty())
+	})
+
+	It("should deny a traffic config with validation errors", func() {
+		tc.Spec.EdgeService.Routes[1].Config[0].TargetSelector = "unknown"
+		response := reviewResponse(review(buildBody("admission.k8s.io/v1", tc), true))
+		Expect(response.Allowed).To(BeFalse())
+		Expect(response.Result.Code).To(BeEquivalentTo(http.StatusUnprocessableEntity))
+		Expect(response.Result.Message).To(ContainSubstring(`unknown target "unknown"`))
+	})
+
+	It("should return the warnings of an allowed traffic config", func() {
+		cache.IdentityCluster.Reset()
+		response := reviewResponse(review(buildBody("admission.k8s.io/v1", tc), true))
+		Expect(response.Allowed).To(BeTrue())
+		Expect(response.Warnings).To(ConsistOf(ContainSubstring(`no workloads found for identity "foo"`)))
+	})
+})
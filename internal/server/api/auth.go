@@ -0,0 +1,67 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/pkg/logger"
+)
+
+const bearerPrefix = "Bearer "
+
+// RequireToken authenticates the write APIs with a bearer token listed in options.GetAPITokenFile().
+// The token file is read on every request so the tokens can be rotated without a restart.
+// Requests are rejected when no token file is configured or the file does not exist.
+func RequireToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenFile := options.GetAPITokenFile()
+		if len(tokenFile) == 0 {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Message: "write APIs are disabled, no api token file is configured"})
+			return
+		}
+		header := c.GetHeader("Authorization")
+		if !strings.HasPrefix(header, bearerPrefix) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Message: "missing bearer token"})
+			return
+		}
+		tokens, err := readTokens(tokenFile)
+		if errors.Is(err, fs.ErrNotExist) {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Message: "write APIs are disabled, the api token file does not exist"})
+			return
+		}
+		if err != nil {
+			logger.Log.Str(logger.ErrorKey, err.Error()).Error("error reading api token file")
+			c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorResponse{Message: "error reading api tokens"})
+			return
+		}
+		token := []byte(strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix)))
+		for _, allowed := range tokens {
+			if subtle.ConstantTimeCompare(token, []byte(allowed)) == 1 {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Message: "invalid bearer token"})
+	}
+}
+
+// readTokens returns the non empty lines of the token file.
+func readTokens(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tokens := []string{}
+	for _, line := range strings.Split(string(content), "\n") {
+		if token := strings.TrimSpace(line); len(token) > 0 {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intuit/naavik/cmd/options"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test api token authentication", func() {
+	var (
+		router    *gin.Engine
+		tokenFile string
+	)
+
+	// post sends a write request with the authorization header, if set.
+	post := func(authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/write", nil)
+		if len(authorization) > 0 {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+		router = gin.New()
+		router.POST("/write", RequireToken(), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		tokenFile = filepath.Join(GinkgoT().TempDir(), "tokens")
+		Expect(os.WriteFile(tokenFile, []byte("token1\n\n  token2  \n"), 0o600)).To(Succeed())
+		options.InitializeNaavikArgs(&options.NaavikArgs{APITokenFile: tokenFile})
+	})
+
+	AfterEach(func() {
+		options.InitializeNaavikArgs(nil)
+	})
+
+	It("should reject the write requests when no token file is configured", func() {
+		options.InitializeNaavikArgs(nil)
+		w := post("Bearer token1")
+		Expect(w.Code).To(Equal(http.StatusForbidden))
+		Expect(w.Body.String()).To(ContainSubstring("no api token file is configured"))
+	})
+
+	It("should reject a missing bearer token", func() {
+		Expect(post("").Code).To(Equal(http.StatusUnauthorized))
+		w := post("Basic token1")
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		Expect(w.Body.String()).To(ContainSubstring("missing bearer token"))
+	})
+
+	It("should reject a token not in the token file", func() {
+		w := post("Bearer token3")
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		Expect(w.Body.String()).To(ContainSubstring("invalid bearer token"))
+		Expect(post("Bearer ").Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("should accept any token of the token file", func() {
+		Expect(post("Bearer token1").Code).To(Equal(http.StatusOK))
+		Expect(post("Bearer token2").Code).To(Equal(http.StatusOK))
+	})
+
+	It("should read the rotated tokens without a restart", func() {
+		Expect(os.WriteFile(tokenFile, []byte("token3\n"), 0o600)).To(Succeed())
+		Expect(post("Bearer token1").Code).To(Equal(http.StatusUnauthorized))
+		Expect(post("Bearer token3").Code).To(Equal(http.StatusOK))
+	})
+
+	It("should reject the write requests when the token file does not exist", func() {
+		Expect(os.Remove(tokenFile)).To(Succeed())
+		w := post("Bearer token1")
+		Expect(w.Code).To(Equal(http.StatusForbidden))
+		Expect(w.Body.String()).To(ContainSubstring("the api token file does not exist"))
+	})
+
+	It("should fail when the token file cannot be read", func() {
+		Expect(os.Remove(tokenFile)).To(Succeed())
+		Expect(os.Mkdir(tokenFile, 0o700)).To(Succeed())
+		Expect(post("Bearer token1").Code).To(Equal(http.StatusInternalServerError))
+	})
+})
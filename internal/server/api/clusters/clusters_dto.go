@@ -3,4 +3,6 @@ package clusters
 type Cluster struct {
 	Name string `json:"name"`
 	Host string `json:"host"`
+	// ProxyVersions are the versions the throttle filters of the cluster are generated for.
+	ProxyVersions []string `json:"proxyVersions"`
 }
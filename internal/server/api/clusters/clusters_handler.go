@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/intuit/naavik/cmd/options"
 	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/proxyversion"
 	"github.com/intuit/naavik/internal/server/api"
 	"github.com/intuit/naavik/internal/types"
 	"github.com/intuit/naavik/internal/types/context"
@@ -38,8 +39,9 @@ func getRemoteClusters(c *gin.Context) {
 	clusters := cache.RemoteCluster.ListClusters()
 	for _, cluster := range clusters {
 		clusterNames = append(clusterNames, Cluster{
-			Name: cluster.GetClusterID(),
-			Host: cluster.GetHost(),
+			Name:          cluster.GetClusterID(),
+			Host:          cluster.GetHost(),
+			ProxyVersions: proxyversion.ForCluster(cluster.GetClusterID()),
 		})
 	}
 	c.JSON(http.StatusOK, clusterNames)
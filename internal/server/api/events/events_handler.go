@@ -0,0 +1,126 @@
+package events
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intuit/naavik/internal/server/api"
+	"github.com/intuit/naavik/pkg/eventhistory"
+)
+
+// watchHeartbeatInterval is the interval of the heartbeats sent to detect the disconnected watchers.
+const watchHeartbeatInterval = 15 * time.Second
+
+func AddRoutes(routerGroup *gin.RouterGroup) *gin.RouterGroup {
+	eventRoutes := routerGroup.Group("/events")
+	eventRoutes.GET("", getEvents)
+	eventRoutes.GET("/watch", watchEvents)
+
+	return routerGroup
+}
+
+// getEvents godoc
+//
+//	@Summary		Event History
+//	@Description	Get the processed events, oldest first, with the clusters and resources written and the result
+//	@Tags			Events
+//	@Produce		json
+//	@Param			identity	query		string	false	"Asset Alias"
+//	@Param			controller	query		string	false	"Controller Name"
+//	@Param			since		query		string	false	"RFC3339 time or duration ago, e.g. 15m"
+//	@Param			limit		query		int		false	"Max number of most recent events"
+//	@Success		200			{object}	[]eventhistory.Entry
+//	@Failure		400			{object}	api.ErrorResponse
+//	@Router			/events [get].
+func getEvents(c *gin.Context) {
+	filter := eventhistory.Filter{
+		Identity:   c.Query("identity"),
+		Controller: c.Query("controller"),
+	}
+	if since := c.Query("since"); len(since) > 0 {
+		sinceTime, err := parseSince(since, time.Now())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, api.ErrorResponse{Message: err.Error()})
+			return
+		}
+		filter.Since = sinceTime
+	}
+	if limit := c.Query("limit"); len(limit) > 0 {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 0 {
+			c.JSON(http.StatusBadRequest, api.ErrorResponse{Message: fmt.Sprintf("invalid limit %q", limit)})
+			return
+		}
+		filter.Limit = value
+	}
+	c.JSON(http.StatusOK, eventhistory.History.List(filter))
+}
+
+// parseSince parses an RFC3339 time or a duration relative to now.
+func parseSince(since string, now time.Time) (time.Time, error) {
+	if sinceTime, err := time.Parse(time.RFC3339, since); err == nil {
+		return sinceTime, nil
+	}
+	duration, err := time.ParseDuration(since)
+	if err != nil || duration < 0 {
+		return time.Time{}, fmt.Errorf("invalid since %q, expected an RFC3339 time or a duration", since)
+	}
+	return now.Add(-duration), nil
+}
+
+// watchEvents godoc
+//
+//	@Summary		Watch Events
+//	@Description	Stream the event status transitions and the resource write outcomes as Server-Sent Events.
+//	@Description	Events are dropped when the watcher does not keep up, the number of dropped events is sent as a "dropped" event.
+//	@Tags			Events
+//	@Produce		text/event-stream
+//	@Param			identity	query	string	false	"Asset Alias"
+//	@Param			cluster		query	string	false	"Cluster Name"
+//	@Param			controller	query	string	false	"Controller Name"
+//	@Success		200			{object}	eventhistory.StreamEvent
+//	@Router			/events/watch [get].
+func watchEvents(c *gin.Context) {
+	subscription := eventhistory.Stream.Subscribe(eventhistory.StreamFilter{
+		Identity:   c.Query("identity"),
+		Cluster:    c.Query("cluster"),
+		Controller: c.Query("controller"),
+	}, eventhistory.DefaultSubscriptionSize)
+	defer subscription.Close()
+
+	heartbeat := time.NewTicker(watchHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Disable the proxy buffering so the events are delivered as they happen
+	c.Header("X-Accel-Buffering", "no")
+	c.Header("Content-Type", "text/event-stream")
+	// Send the headers right away, the watcher would otherwise wait for the first event
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+	var reportedDropped uint64
+	c.Stream(func(_ io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return false
+			}
+			if dropped := subscription.Dropped(); dropped > reportedDropped {
+				c.SSEvent("dropped", gin.H{"dropped": dropped - reportedDropped})
+				reportedDropped = dropped
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", gin.H{"time": time.Now()})
+			return true
+		}
+	})
+}
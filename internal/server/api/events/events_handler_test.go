@@ -0,0 +1,114 @@
+package events
+
+import (
+	"bufio"
+	gocontext "context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intuit/naavik/pkg/eventhistory"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test events handler", func() {
+	var (
+		router  *gin.Engine
+		history eventhistory.Store
+		stream  *eventhistory.Broker
+	)
+
+	// getEntries returns the event history listed by the api with the query.
+	getEntries := func(query string) []eventhistory.Entry {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/events"+query, nil))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		entries := []eventhistory.Entry{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &entries)).To(Succeed())
+		return entries
+	}
+
+	BeforeEach(func() {
+		history, stream = eventhistory.History, eventhistory.Stream
+		eventhistory.History = eventhistory.NewMemoryStore(eventhistory.DefaultSize, eventhistory.DefaultRetention)
+		eventhistory.Stream = eventhistory.NewBroker()
+		now := time.Now()
+		eventhistory.History.Add(eventhistory.Entry{EventID: "1", Controller: "trafficconfig", Identity: "identity1", StartTime: now.Add(-time.Hour)})
+		eventhistory.History.Add(eventhistory.Entry{EventID: "2", Controller: "deployment", Identity: "identity1", StartTime: now.Add(-time.Minute)})
+		eventhistory.History.Add(eventhistory.Entry{EventID: "3", Controller: "trafficconfig", Identity: "identity2", StartTime: now})
+
+		gin.SetMode(gin.TestMode)
+		router = gin.New()
+		AddRoutes(router.Group("/api/v1"))
+	})
+
+	AfterEach(func() {
+		eventhistory.History, eventhistory.Stream = history, stream
+	})
+
+	It("should list the events matching the filters, oldest first", func() {
+		Expect(getEntries("")).To(HaveLen(3))
+		entries := getEntries("?identity=Identity1")
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].EventID).To(Equal("1"))
+		Expect(getEntries("?controller=trafficconfig&identity=identity1")).To(HaveLen(1))
+		Expect(getEntries("?since=15m")).To(HaveLen(2))
+		Expect(getEntries("?since=" + time.Now().Add(-30*time.Minute).UTC().Format(time.RFC3339))).To(HaveLen(2))
+		entries = getEntries("?limit=1")
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].EventID).To(Equal("3"))
+	})
+
+	DescribeTable("should reject an invalid filter",
+		func(query string, message string) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/events"+query, nil))
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+			Expect(w.Body.String()).To(ContainSubstring(message))
+		},
+		Entry("since neither a time nor a duration", "?since=yesterday", "invalid since"),
+		Entry("negative since", "?since=-5m", "invalid since"),
+		Entry("limit not a number", "?limit=ten", "invalid limit"),
+		Entry("negative limit", "?limit=-1", "invalid limit"),
+	)
+
+	It("should stream the events matching the filters until the watcher disconnects", func() {
+		server := httptest.NewServer(router)
+		defer server.Close()
+		ctx, cancel := gocontext.WithCancel(gocontext.Background())
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/events/watch?identity=identity1", nil)
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(HavePrefix("text/event-stream"))
+
+		Expect(eventhistory.Stream.HasSubscribers()).To(BeTrue())
+		eventhistory.Stream.Publish(eventhistory.StreamEvent{Type: eventhistory.StreamEventStatus, EventID: "other", Identity: "identity2"})
+		eventhistory.Stream.Publish(eventhistory.StreamEvent{Type: eventhistory.StreamEventStatus, EventID: "watched", Identity: "identity1", Status: "processed"})
+
+		reader := bufio.NewReader(resp.Body)
+		lines := []string{}
+		for len(lines) < 2 {
+			line, err := reader.ReadString('\n')
+			Expect(err).NotTo(HaveOccurred())
+			if line = strings.TrimSpace(line); len(line) > 0 {
+				lines = append(lines, line)
+			}
+		}
+		Expect(lines[0]).To(Equal("event:" + eventhistory.StreamEventStatus))
+		event := eventhistory.StreamEvent{}
+		Expect(json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data:")), &event)).To(Succeed())
+		Expect(event.EventID).To(Equal("watched"))
+		Expect(event.Status).To(Equal("processed"))
+
+		cancel()
+		Eventually(eventhistory.Stream.HasSubscribers).Should(BeFalse())
+	})
+})
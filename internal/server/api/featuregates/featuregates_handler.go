@@ -0,0 +1,62 @@
+package featuregates
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/featuregate"
+	"github.com/intuit/naavik/internal/types"
+)
+
+// IdentityFeatures is the evaluation of the feature gates for a traffic config identity and env.
+type IdentityFeatures struct {
+	Identity string                                           `json:"identity"`
+	Env      string                                           `json:"env"`
+	Features map[types.FeatureName]*featuregate.FeatureStatus `json:"features"`
+}
+
+func AddRoutes(routerGroup *gin.RouterGroup) *gin.RouterGroup {
+	featureGatesRoutes := routerGroup.Group("/featuregates")
+	featureGatesRoutes.GET("", getFeatureGates)
+	featureGatesRoutes.GET("/identities/:identity/env/:env", getIdentityFeatures)
+	return routerGroup
+}
+
+// getFeatureGates godoc
+//
+//	@Summary		Feature Gates
+//	@Description	Get the feature gates read from the feature gates config map, with the error of the config map if invalid
+//	@Tags			Feature Gates
+//	@Produce		json
+//	@Success		200	{object}	featuregate.State
+//	@Router			/featuregates [get].
+func getFeatureGates(c *gin.Context) {
+	c.JSON(http.StatusOK, featuregate.GetState())
+}
+
+// getIdentityFeatures godoc
+//
+//	@Summary		Features By Identity and Env
+//	@Description	Get the features enabled for the traffic config of an Identity and Env, in the clusters of the Identity and of its dependents
+//	@Tags			Feature Gates
+//	@Produce		json
+//	@Param			identity	path		string	true	"Asset Alias"
+//	@Param			env			path		string	true	"Environment"
+//	@Success		200			{object}	IdentityFeatures
+//	@Router			/featuregates/identities/{identity}/env/{env} [get].
+func getIdentityFeatures(c *gin.Context) {
+	identity := c.Params.ByName("identity")
+	env := c.Params.ByName("env")
+	clusters := cache.IdentityCluster.GetClustersForIdentity(identity)
+	for _, dependent := range cache.IdentityDependency.GetDependentsForIdentity(identity) {
+		clusters = append(clusters, cache.IdentityCluster.GetClustersForIdentity(dependent)...)
+	}
+	slices.Sort(clusters)
+	c.JSON(http.StatusOK, IdentityFeatures{
+		Identity: identity,
+		Env:      env,
+		Features: featuregate.Evaluate(identity, env, slices.Compact(clusters)),
+	})
+}
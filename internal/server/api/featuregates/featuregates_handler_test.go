@@ -0,0 +1,95 @@
+package featuregates
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/featuregate"
+	"github.com/intuit/naavik/internal/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test feature gates handler", func() {
+	var router *gin.Engine
+
+	// serve sends the request and returns the response.
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		featuregate.Reset()
+		gates, err := featuregate.Parse(map[string]string{featuregate.GatesKey: `
+- feature: throttlefilter
+  enabled: false
+  identity: identity1
+  cluster: cluster2
+`})
+		Expect(err).NotTo(HaveOccurred())
+		featuregate.SetState(featuregate.State{ConfigMapFound: true, ResourceVersion: "1", Gates: gates})
+
+		gin.SetMode(gin.TestMode)
+		router = gin.New()
+		AddRoutes(router.Group("/api/v1"))
+	})
+
+	AfterEach(func() {
+		cache.ResetAllCaches()
+		featuregate.Reset()
+	})
+
+	It("should return the gates of the config map", func() {
+		w := serve("/api/v1/featuregates")
+		Expect(w.Code).To(Equal(http.StatusOK))
+		state := featuregate.State{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &state)).To(Succeed())
+		Expect(state.ConfigMapFound).To(BeTrue())
+		Expect(state.ResourceVersion).To(Equal("1"))
+		Expect(state.Gates).To(HaveLen(1))
+		Expect(state.Gates[0].Feature).To(Equal(types.FeatureThrottleFilter))
+	})
+
+	It("should return the error of an invalid config map", func() {
+		featuregate.SetState(featuregate.State{ConfigMapFound: true, Error: "invalid gates"})
+		w := serve("/api/v1/featuregates")
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(ContainSubstring(`"error":"invalid gates"`))
+	})
+
+	It("should evaluate the features in the clusters of the identity and of its dependents", func() {
+		cache.IdentityCluster.AddClusterToIdentity("identity1", "cluster1")
+		cache.IdentityDependency.AddDependentToIdentity("identity1", "dependent1")
+		cache.IdentityCluster.AddClusterToIdentity("dependent1", "cluster2")
+		cache.IdentityCluster.AddClusterToIdentity("dependent1", "cluster1")
+
+		w := serve("/api/v1/featuregates/identities/identity1/env/qa")
+		Expect(w.Code).To(Equal(http.StatusOK))
+		features := IdentityFeatures{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &features)).To(Succeed())
+		Expect(features.Identity).To(Equal("identity1"))
+		Expect(features.Env).To(Equal("qa"))
+		Expect(features.Features).To(HaveKey(types.FeatureThrottleFilter))
+		throttleFilter := features.Features[types.FeatureThrottleFilter]
+		Expect(throttleFilter.EnabledInAnyCluster).To(BeTrue())
+		Expect(throttleFilter.Clusters).To(Equal(map[string]bool{"cluster1": true, "cluster2": false}))
+		Expect(features.Features[types.FeatureVirtualService].Clusters).To(Equal(map[string]bool{"cluster1": true, "cluster2": true}))
+	})
+
+	It("should evaluate the features of an unknown identity without clusters", func() {
+		w := serve("/api/v1/featuregates/identities/unknown/env/qa")
+		Expect(w.Code).To(Equal(http.StatusOK))
+		features := IdentityFeatures{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &features)).To(Succeed())
+		Expect(features.Features[types.FeatureThrottleFilter].Clusters).To(BeEmpty())
+		Expect(features.Features[types.FeatureThrottleFilter].EnabledInAnyCluster).To(BeTrue())
+	})
+})
@@ -4,10 +4,18 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/intuit/naavik/internal/leasechecker"
 )
 
 const HealthCheckPath = "/health/full"
 
+type HealthStatus struct {
+	Status           string `json:"status"`
+	ReadOnly         bool   `json:"readOnly"`
+	StateInitialized bool   `json:"stateInitialized"`
+	LeaseHolder      string `json:"leaseHolder,omitempty"`
+}
+
 func AddRoutes(routerGroup *gin.RouterGroup) *gin.RouterGroup {
 	return routerGroup
 }
@@ -15,11 +23,16 @@ func AddRoutes(routerGroup *gin.RouterGroup) *gin.RouterGroup {
 // HealthCheck godoc
 //
 //	@Summary		Health Status of Naavik
-//	@Description	Health Status of Naavik
+//	@Description	Health Status of Naavik along with the lease state and the current lease holder
 //	@Tags			Health Check
 //	@Produce		json
-//	@Success		200	string	Health	full	ok
+//	@Success		200	{object}	HealthStatus
 //	@Router			/health/full [get].
 func HealthCheck(c *gin.Context) {
-	c.String(http.StatusOK, "Health full ok")
+	c.JSON(http.StatusOK, HealthStatus{
+		Status:           "ok",
+		ReadOnly:         leasechecker.IsReadOnly(),
+		StateInitialized: leasechecker.IsStateInitialized(),
+		LeaseHolder:      leasechecker.GetLeaseHolder(),
+	})
 }
@@ -0,0 +1,108 @@
+package killswitch
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intuit/naavik/internal/killswitch"
+	"github.com/intuit/naavik/internal/server/api"
+	"github.com/intuit/naavik/pkg/logger"
+)
+
+// ChangeRequest is the audited author and reason of a kill switch change.
+type ChangeRequest struct {
+	Actor  string `json:"actor"`
+	Reason string `json:"reason"`
+}
+
+func AddRoutes(routerGroup *gin.RouterGroup) *gin.RouterGroup {
+	killSwitchRoutes := routerGroup.Group("/killswitch")
+	killSwitchRoutes.GET("", getKillSwitch)
+	killSwitchRoutes.POST("/engage", api.RequireToken(), engageKillSwitch)
+	killSwitchRoutes.POST("/restore", api.RequireToken(), restoreKillSwitch)
+	return routerGroup
+}
+
+// getKillSwitch godoc
+//
+//	@Summary		Rate Limit Kill Switch
+//	@Description	Get the state of the rate limit kill switch and its audit trail
+//	@Tags			Kill Switch
+//	@Produce		json
+//	@Success		200	{object}	killswitch.State
+//	@Router			/killswitch [get].
+func getKillSwitch(c *gin.Context) {
+	c.JSON(http.StatusOK, killswitch.GetState())
+}
+
+// engageKillSwitch godoc
+//
+//	@Summary		Engage Rate Limit Kill Switch
+//	@Description	Stop enforcing all the throttle filters generated by naavik, the filters are updated in place. Requires a bearer token from the api token file.
+//	@Description	The change is persisted and audited in the kill switch config map.
+//	@Tags			Kill Switch
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		ChangeRequest	true	"Actor and reason"
+//	@Success		202		{object}	killswitch.Record
+//	@Failure		400		{object}	api.ErrorResponse
+//	@Failure		401		{object}	api.ErrorResponse
+//	@Failure		500		{object}	api.ErrorResponse
+//	@Failure		503		{object}	api.ErrorResponse
+//	@Router			/killswitch/engage [post].
+func engageKillSwitch(c *gin.Context) {
+	setKillSwitch(c, killswitch.ActionEngage)
+}
+
+// restoreKillSwitch godoc
+//
+//	@Summary		Restore Rate Limit Kill Switch
+//	@Description	Enforce the throttle filters generated by naavik again, the filters are updated in place. Requires a bearer token from the api token file.
+//	@Description	The change is persisted and audited in the kill switch config map. The kill switch engaged by flag cannot be restored.
+//	@Tags			Kill Switch
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		ChangeRequest	true	"Actor and reason"
+//	@Success		202		{object}	killswitch.Record
+//	@Failure		400		{object}	api.ErrorResponse
+//	@Failure		401		{object}	api.ErrorResponse
+//	@Failure		409		{object}	api.ErrorResponse
+//	@Failure		500		{object}	api.ErrorResponse
+//	@Failure		503		{object}	api.ErrorResponse
+//	@Router			/killswitch/restore [post].
+func restoreKillSwitch(c *gin.Context) {
+	if killswitch.GetState().EngagedByFlag {
+		c.JSON(http.StatusConflict, api.ErrorResponse{Message: "kill switch is engaged by the --rate_limit_kill_switch flag, restart without it to restore"})
+		return
+	}
+	setKillSwitch(c, killswitch.ActionRestore)
+}
+
+func setKillSwitch(c *gin.Context, action killswitch.Action) {
+	request := ChangeRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{Message: fmt.Sprintf("invalid request: %s", err.Error())})
+		return
+	}
+	request.Actor = strings.TrimSpace(request.Actor)
+	request.Reason = strings.TrimSpace(request.Reason)
+	if len(request.Actor) == 0 || len(request.Reason) == 0 {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{Message: "actor and reason are required"})
+		return
+	}
+	entry := killswitch.AuditEntry{Action: action, Actor: request.Actor, Reason: request.Reason, ClientIP: c.ClientIP()}
+	record, err := killswitch.Set(c.Request.Context(), entry)
+	if err != nil {
+		if errors.Is(err, killswitch.ErrNotWatching) {
+			c.JSON(http.StatusServiceUnavailable, api.ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, api.ErrorResponse{Message: err.Error()})
+		return
+	}
+	logger.Log.Str("action", string(action)).Str("actor", entry.Actor).Str("reason", entry.Reason).Str("clientIp", entry.ClientIP).Warn("Rate limit kill switch changed")
+	c.JSON(http.StatusAccepted, record)
+}
@@ -0,0 +1,156 @@
+package killswitch
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/killswitch"
+	"github.com/intuit/naavik/internal/types/context"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Test kill switch handler", func() {
+	var (
+		router    *gin.Engine
+		client    *k8sfake.Clientset
+		tokenFile string
+	)
+
+	// serve sends the request with the api token and returns the response.
+	serve := func(method string, path string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token1")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// watch watches the kill switch config map until the test ends.
+	watch := func() {
+		ctx := context.NewContextWithLogger()
+		goCtx, cancel := gocontext.WithCancel(ctx.Context)
+		ctx.Context = goCtx
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			killswitch.Watch(ctx, client, killswitch.WatcherOpts{Namespace: "admiral-sync", ConfigMapName: "naavik-kill-switch"})
+		}()
+		DeferCleanup(func() {
+			cancel()
+			Eventually(done).Should(BeClosed())
+		})
+		Eventually(func() bool { return killswitch.GetState().ConfigMapFound }).Should(BeTrue())
+	}
+
+	BeforeEach(func() {
+		tokenFile = filepath.Join(GinkgoT().TempDir(), "tokens")
+		Expect(os.WriteFile(tokenFile, []byte("token1\n"), 0o600)).To(Succeed())
+		options.InitializeNaavikArgs(&options.NaavikArgs{APITokenFile: tokenFile})
+		killswitch.Reset()
+		client = k8sfake.NewSimpleClientset(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "naavik-kill-switch", Namespace: "admiral-sync"},
+		})
+
+		gin.SetMode(gin.TestMode)
+		router = gin.New()
+		AddRoutes(router.Group("/api/v1"))
+	})
+
+	AfterEach(func() {
+		options.InitializeNaavikArgs(nil)
+		killswitch.Reset()
+	})
+
+	It("should require a bearer token to change the kill switch", func() {
+		watch()
+		for _, path := range []string{"/api/v1/killswitch/engage", "/api/v1/killswitch/restore"} {
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"actor": "oncall", "reason": "incident"}`))
+			req.Header.Set("Authorization", "Bearer token2")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		}
+
+		options.InitializeNaavikArgs(nil)
+		Expect(serve(http.MethodPost, "/api/v1/killswitch/engage", `{"actor": "oncall", "reason": "incident"}`).Code).To(Equal(http.StatusForbidden))
+		Expect(killswitch.IsEngaged()).To(BeFalse())
+	})
+
+	It("should engage and restore the kill switch with an audit trail", func() {
+		watch()
+		w := serve(http.MethodPost, "/api/v1/killswitch/engage", `{"actor": " oncall ", "reason": "incident"}`)
+		Expect(w.Code).To(Equal(http.StatusAccepted))
+		record := killswitch.Record{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &record)).To(Succeed())
+		Expect(record.Engaged).To(BeTrue())
+		Expect(record.Audit).To(HaveLen(1))
+		Expect(record.Audit[0].Actor).To(Equal("oncall"))
+		Expect(record.Audit[0].ClientIP).NotTo(BeEmpty())
+		Eventually(killswitch.IsEngaged).Should(BeTrue())
+
+		Expect(serve(http.MethodPost, "/api/v1/killswitch/restore", `{"actor": "oncall", "reason": "resolved"}`).Code).To(Equal(http.StatusAccepted))
+		Eventually(killswitch.IsEngaged).Should(BeFalse())
+
+		w = serve(http.MethodGet, "/api/v1/killswitch", "")
+		Expect(w.Code).To(Equal(http.StatusOK))
+		state := killswitch.State{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &state)).To(Succeed())
+		Expect(state.Engaged).To(BeFalse())
+		Expect(state.Audit).To(HaveLen(2))
+		Expect(state.Audit[1].Action).To(Equal(killswitch.ActionRestore))
+		Expect(state.Audit[1].Reason).To(Equal("resolved"))
+	})
+
+	DescribeTable("should reject a change without its actor and reason",
+		func(body string, message string) {
+			watch()
+			w := serve(http.MethodPost, "/api/v1/killswitch/engage", body)
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+			Expect(w.Body.String()).To(ContainSubstring(message))
+			Expect(killswitch.GetState().Audit).To(BeEmpty())
+		},
+		Entry("not json", `actor: oncall`, "invalid request"),
+		Entry("no actor", `{"reason": "incident"}`, "actor and reason are required"),
+		Entry("blank reason", `{"actor": "oncall", "reason": "  "}`, "actor and reason are required"),
+	)
+
+	It("should not restore the kill switch engaged by flag", func() {
+		options.InitializeNaavikArgs(&options.NaavikArgs{APITokenFile: tokenFile, RateLimitKillSwitch: true})
+		watch()
+		w := serve(http.MethodPost, "/api/v1/killswitch/restore", `{"actor": "oncall", "reason": "resolved"}`)
+		Expect(w.Code).To(Equal(http.StatusConflict))
+		Expect(killswitch.GetState().EngagedByFlag).To(BeTrue())
+	})
+
+	It("should be unavailable while the config map is not watched", func() {
+		w := serve(http.MethodPost, "/api/v1/killswitch/engage", `{"actor": "oncall", "reason": "incident"}`)
+		Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(w.Body.String()).To(ContainSubstring(killswitch.ErrNotWatching.Error()))
+	})
+
+	It("should return an error when the config map fails to be updated", func() {
+		watch()
+		client.PrependReactor("update", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("update failed")
+		})
+		w := serve(http.MethodPost, "/api/v1/killswitch/engage", `{"actor": "oncall", "reason": "incident"}`)
+		Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		Expect(w.Body.String()).To(ContainSubstring("update failed"))
+		Expect(killswitch.IsEngaged()).To(BeFalse())
+	})
+})
@@ -0,0 +1,65 @@
+package override
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intuit/naavik/internal/override"
+	"github.com/intuit/naavik/internal/utils/clock"
+)
+
+// OverrideStatus is a tracked override with the time remaining before it expires.
+type OverrideStatus struct {
+	override.Status
+	Remaining        string `json:"remaining"`
+	RemainingSeconds int64  `json:"remainingSeconds"`
+}
+
+func AddRoutes(routerGroup *gin.RouterGroup) *gin.RouterGroup {
+	overrideRoutes := routerGroup.Group("/overrides")
+	overrideRoutes.GET("", getOverrides)
+	overrideRoutes.GET("/identities/:identity/env/:env", getIdentityOverrides)
+	return routerGroup
+}
+
+// getOverrides godoc
+//
+//	@Summary		Active Overrides
+//	@Description	Get the traffic config overrides applied by this instance and not expired yet, with their time remaining
+//	@Tags			Overrides
+//	@Produce		json
+//	@Param			all	query		bool	false	"Include the overrides reverted in the last 24 hours"
+//	@Success		200	{object}	[]OverrideStatus
+//	@Router			/overrides [get].
+func getOverrides(c *gin.Context) {
+	c.JSON(http.StatusOK, withRemaining(override.List(c.Query("all") == "true")))
+}
+
+// getIdentityOverrides godoc
+//
+//	@Summary		Overrides By Identity and Env
+//	@Description	Get the overrides of the traffic config of an Identity and Env with their applied and reverted times
+//	@Tags			Overrides
+//	@Produce		json
+//	@Param			identity	path		string	true	"Asset Alias"
+//	@Param			env			path		string	true	"Environment"
+//	@Success		200			{object}	[]OverrideStatus
+//	@Router			/overrides/identities/{identity}/env/{env} [get].
+func getIdentityOverrides(c *gin.Context) {
+	c.JSON(http.StatusOK, withRemaining(override.Get(c.Params.ByName("identity"), c.Params.ByName("env"))))
+}
+
+func withRemaining(statuses []override.Status) []OverrideStatus {
+	now := clock.Get().Now()
+	list := make([]OverrideStatus, 0, len(statuses))
+	for _, status := range statuses {
+		remaining := status.Remaining(now).Truncate(time.Second)
+		list = append(list, OverrideStatus{
+			Status:           status,
+			Remaining:        remaining.String(),
+			RemainingSeconds: int64(remaining.Seconds()),
+		})
+	}
+	return list
+}
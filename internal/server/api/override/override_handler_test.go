@@ -0,0 +1,94 @@
+package override
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intuit/naavik/cmd/options"
+	resourcebuilder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/override"
+	"github.com/intuit/naavik/internal/utils/clock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+const overrides = `
+- name: incident-bump
+  reason: INC-123
+  expiresAt: 2026-10-19T14:00:00Z
+  quotas:
+  - quotaGroup: Total Throttling Plan
+    quota: Total
+    maxAmount: 500
+- name: canary-shift
+  expiresAt: 2026-10-19T16:00:00Z
+  quotas:
+  - quotaGroup: Total Throttling Plan
+    quota: Total
+    maxAmount: 300
+`
+
+var _ = Describe("Test override handler", func() {
+	var (
+		router *gin.Engine
+		noon   = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	)
+
+	// getOverrides returns the overrides listed by the api at the path.
+	getOverrides := func(path string) []OverrideStatus {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		statuses := []OverrideStatus{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &statuses)).To(Succeed())
+		return statuses
+	}
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		override.Reset()
+		clock.Set(clocktesting.NewFakeClock(noon.Add(30 * time.Minute)))
+		tc := resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.overridden", "qal", map[string]string{override.OverridesKey: overrides})
+		active, err := override.Parse(tc)
+		Expect(err).NotTo(HaveOccurred())
+		override.Track("app.overridden", "qal", active, noon)
+		override.Track("app.overridden", "qal", active[1:], noon.Add(15*time.Minute))
+
+		gin.SetMode(gin.TestMode)
+		router = gin.New()
+		AddRoutes(router.Group("/api/v1"))
+	})
+
+	AfterEach(func() {
+		override.Reset()
+		clock.Reset()
+	})
+
+	It("should list the active overrides with their time remaining", func() {
+		statuses := getOverrides("/api/v1/overrides")
+		Expect(statuses).To(HaveLen(1))
+		Expect(statuses[0].Name).To(Equal("canary-shift"))
+		Expect(statuses[0].AppliedTime).To(Equal(noon))
+		Expect(statuses[0].Remaining).To(Equal("3h30m0s"))
+		Expect(statuses[0].RemainingSeconds).To(Equal(int64(3*60*60 + 30*60)))
+	})
+
+	It("should list the reverted overrides with no time remaining", func() {
+		statuses := getOverrides("/api/v1/overrides?all=true")
+		Expect(statuses).To(HaveLen(2))
+		Expect(statuses[1].Name).To(Equal("incident-bump"))
+		Expect(statuses[1].Reason).To(Equal("INC-123"))
+		Expect(*statuses[1].RevertedTime).To(Equal(noon.Add(15 * time.Minute)))
+		Expect(statuses[1].Remaining).To(Equal("0s"))
+		Expect(statuses[1].RemainingSeconds).To(BeZero())
+	})
+
+	It("should list the overrides of an identity and env case insensitively", func() {
+		Expect(getOverrides("/api/v1/overrides/identities/App.Overridden/env/QAL")).To(HaveLen(2))
+		Expect(getOverrides("/api/v1/overrides/identities/app.other/env/qal")).To(BeEmpty())
+	})
+})
@@ -0,0 +1,141 @@
+package reconcile
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/handler/trafficconfig"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/server/api"
+	"github.com/intuit/naavik/internal/types/context"
+)
+
+func AddRoutes(routerGroup *gin.RouterGroup) *gin.RouterGroup {
+	reconcileRoutes := routerGroup.Group("/reconcile")
+	reconcileRoutes.POST("/identities/:identity", api.RequireToken(), reconcileIdentity)
+	reconcileRoutes.POST("/identities/:identity/env/:env", api.RequireToken(), reconcileIdentity)
+	reconcileRoutes.POST("/clusters/:clusterId", api.RequireToken(), reconcileCluster)
+	reconcileRoutes.GET("/operations", getOperations)
+	reconcileRoutes.GET("/operations/:operationId", getOperation)
+
+	return routerGroup
+}
+
+// reconcileIdentity godoc
+//
+//	@Summary		Reconcile Identity
+//	@Description	Re-push the traffic configs of an Identity, and of its dependents for the Identity, optionally only for an Env.
+//	@Description	Requires a bearer token from the api token file. Returns the operation to poll for the progress and the per-cluster results.
+//	@Tags			Reconcile
+//	@Produce		json
+//	@Param			identity	path		string	true	"Asset Alias"
+//	@Param			env			path		string	false	"Environment"
+//	@Success		202			{object}	cache.ReconcileOperation
+//	@Failure		401			{object}	api.ErrorResponse
+//	@Failure		404			{object}	api.ErrorResponse
+//	@Failure		503			{object}	api.ErrorResponse
+//	@Router			/reconcile/identities/{identity} [post]
+//	@Router			/reconcile/identities/{identity}/env/{env} [post].
+func reconcileIdentity(c *gin.Context) {
+	identity := c.Params.ByName("identity")
+	env := c.Params.ByName("env")
+	if !isReady(c) {
+		return
+	}
+	if cache.TrafficConfigCache.GetTrafficConfigEntry(identity) == nil && len(cache.IdentityCluster.GetClustersForIdentity(identity)) == 0 {
+		c.JSON(http.StatusNotFound, api.ErrorResponse{Message: fmt.Sprintf("identity %s not found", identity)})
+		return
+	}
+	startOperation(c, cache.ReconcileScope{Identity: identity, Env: env}, []string{strings.ToLower(identity)}, env)
+}
+
+// reconcileCluster godoc
+//
+//	@Summary		Reconcile Cluster
+//	@Description	Re-push the traffic configs of every Identity with workloads in the cluster.
+//	@Description	Requires a bearer token from the api token file. Returns the operation to poll for the progress and the per-cluster results.
+//	@Tags			Reconcile
+//	@Produce		json
+//	@Param			clusterId	path		string	true	"Cluster ID"
+//	@Success		202			{object}	cache.ReconcileOperation
+//	@Failure		401			{object}	api.ErrorResponse
+//	@Failure		404			{object}	api.ErrorResponse
+//	@Failure		503			{object}	api.ErrorResponse
+//	@Router			/reconcile/clusters/{clusterId} [post].
+func reconcileCluster(c *gin.Context) {
+	clusterID := c.Params.ByName("clusterId")
+	if !isReady(c) {
+		return
+	}
+	if _, found := cache.RemoteCluster.GetCluster(clusterID); !found {
+		c.JSON(http.StatusNotFound, api.ErrorResponse{Message: fmt.Sprintf("cluster %s not found", clusterID)})
+		return
+	}
+	identities := []string{}
+	for _, identity := range cache.IdentityCluster.ListIdentities() {
+		if cache.IdentityCluster.IsClusterPresentInIdentity(identity, clusterID) {
+			identities = append(identities, identity)
+		}
+	}
+	sort.Strings(identities)
+	startOperation(c, cache.ReconcileScope{Cluster: clusterID}, identities, "")
+}
+
+// getOperations godoc
+//
+//	@Summary		Reconcile Operations
+//	@Description	Get the on-demand reconcile operations, most recent first
+//	@Tags			Reconcile
+//	@Produce		json
+//	@Success		200	{object}	[]cache.ReconcileOperation
+//	@Router			/reconcile/operations [get].
+func getOperations(c *gin.Context) {
+	c.JSON(http.StatusOK, cache.ReconcileOperations.List())
+}
+
+// getOperation godoc
+//
+//	@Summary		Reconcile Operation
+//	@Description	Get the progress and the per-cluster results of an on-demand reconcile operation
+//	@Tags			Reconcile
+//	@Produce		json
+//	@Param			operationId	path		string	true	"Operation ID"
+//	@Success		200			{object}	cache.ReconcileOperation
+//	@Failure		404			{object}	api.ErrorResponse
+//	@Router			/reconcile/operations/{operationId} [get].
+func getOperation(c *gin.Context) {
+	operationID := c.Params.ByName("operationId")
+	operation := cache.ReconcileOperations.Get(operationID)
+	if operation == nil {
+		c.JSON(http.StatusNotFound, api.ErrorResponse{Message: fmt.Sprintf("operation %s not found", operationID)})
+		return
+	}
+	c.JSON(http.StatusOK, operation)
+}
+
+// isReady rejects the reconcile when this instance is not allowed to write or the caches are not warmed up yet.
+func isReady(c *gin.Context) bool {
+	if leasechecker.IsReadOnly() {
+		c.JSON(http.StatusServiceUnavailable, api.ErrorResponse{Message: "instance is in read only mode"})
+		return false
+	}
+	if !cache.InformerSync.IsWarmedUp() {
+		c.JSON(http.StatusServiceUnavailable, api.ErrorResponse{Message: "cache is not warmed up yet"})
+		return false
+	}
+	return true
+}
+
+func startOperation(c *gin.Context, scope cache.ReconcileScope, identities []string, env string) {
+	operationID := uuid.New().String()
+	operation := cache.ReconcileOperations.Start(operationID, scope, identities)
+	ctx := context.NewContextWithLogger()
+	ctx.Log = ctx.Log.Str("clientIp", c.ClientIP())
+	go trafficconfig.RunReconcileOperation(ctx, operationID, identities, env)
+	c.JSON(http.StatusAccepted, operation)
+}
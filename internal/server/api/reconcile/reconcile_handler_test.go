@@ -0,0 +1,127 @@
+package reconcile
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/fake/builder"
+	resourcebuilder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/types/context"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test reconcile handler", func() {
+	var router *gin.Engine
+
+	// serve sends the request with the api token and returns the response.
+	serve := func(method string, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer token1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// getOperation returns the operation polled from the api.
+	getOperation := func(operationID string) *cache.ReconcileOperation {
+		w := serve(http.MethodGet, "/api/v1/reconcile/operations/"+operationID)
+		Expect(w.Code).To(Equal(http.StatusOK))
+		operation := &cache.ReconcileOperation{}
+		Expect(json.Unmarshal(w.Body.Bytes(), operation)).To(Succeed())
+		return operation
+	}
+
+	BeforeEach(func() {
+		tokenFile := filepath.Join(GinkgoT().TempDir(), "tokens")
+		Expect(os.WriteFile(tokenFile, []byte("token1\n"), 0o600)).To(Succeed())
+		options.InitializeNaavikArgs(&options.NaavikArgs{APITokenFile: tokenFile, FullReconcileQPS: 100})
+		cache.ResetAllCaches()
+		cache.InformerSync.SetWarmedUp()
+		ctx := context.NewContextWithLogger()
+		leasechecker.RunStateCheck(ctx, leasechecker.GetStateChecker(ctx, types.StateCheckerNone))
+
+		cache.RemoteCluster.AddCluster(builder.BuildRemoteCluster("cluster1"))
+		for _, identity := range []string{"identity1", "identity2"} {
+			cache.Deployments.Add("cluster1", resourcebuilder.BuildFakeDeployment(identity+"-env", identity, identity, "env", "namespace"))
+			cache.IdentityCluster.AddClusterToIdentity(identity, "cluster1")
+			cache.TrafficConfigCache.AddTrafficConfigToCache(resourcebuilder.GetFakeTrafficConfig(identity, "env", "1", "admiral"))
+		}
+
+		gin.SetMode(gin.TestMode)
+		router = gin.New()
+		AddRoutes(router.Group("/api/v1"))
+	})
+
+	AfterEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		leasechecker.ResetState()
+	})
+
+	It("should require a bearer token", func() {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/reconcile/identities/identity1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		Expect(cache.ReconcileOperations.List()).To(BeEmpty())
+	})
+
+	It("should reject the reconcile in read only mode or before cache warm up", func() {
+		leasechecker.ResetState()
+		w := serve(http.MethodPost, "/api/v1/reconcile/identities/identity1")
+		Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(w.Body.String()).To(ContainSubstring("read only mode"))
+
+		ctx := context.NewContextWithLogger()
+		leasechecker.RunStateCheck(ctx, leasechecker.GetStateChecker(ctx, types.StateCheckerNone))
+		cache.InformerSync.Reset()
+		w = serve(http.MethodPost, "/api/v1/reconcile/clusters/cluster1")
+		Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(w.Body.String()).To(ContainSubstring("cache is not warmed up yet"))
+	})
+
+	It("should return not found for an unknown identity, cluster or operation", func() {
+		Expect(serve(http.MethodPost, "/api/v1/reconcile/identities/unknown").Code).To(Equal(http.StatusNotFound))
+		Expect(serve(http.MethodPost, "/api/v1/reconcile/clusters/unknown").Code).To(Equal(http.StatusNotFound))
+		Expect(serve(http.MethodGet, "/api/v1/reconcile/operations/unknown").Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should return the operation of an identity and its progress", func() {
+		w := serve(http.MethodPost, "/api/v1/reconcile/identities/Identity1/env/env")
+		Expect(w.Code).To(Equal(http.StatusAccepted))
+		operation := &cache.ReconcileOperation{}
+		Expect(json.Unmarshal(w.Body.Bytes(), operation)).To(Succeed())
+		Expect(operation.Scope).To(Equal(cache.ReconcileScope{Identity: "Identity1", Env: "env"}))
+		Expect(operation.Identities).To(Equal([]string{"identity1"}))
+
+		Eventually(func() string { return getOperation(operation.ID).Status }).Should(Equal(cache.ReconcileOperationCompleted))
+		Expect(getOperation(operation.ID).IdentitiesDone).To(Equal(1))
+	})
+
+	It("should reconcile the identities of a cluster", func() {
+		w := serve(http.MethodPost, "/api/v1/reconcile/clusters/cluster1")
+		Expect(w.Code).To(Equal(http.StatusAccepted))
+		operation := &cache.ReconcileOperation{}
+		Expect(json.Unmarshal(w.Body.Bytes(), operation)).To(Succeed())
+		Expect(operation.Identities).To(Equal([]string{"identity1", "identity2"}))
+
+		Eventually(func() int { return getOperation(operation.ID).IdentitiesDone }).Should(Equal(2))
+		Eventually(func() string { return getOperation(operation.ID).Status }).Should(Equal(cache.ReconcileOperationCompleted))
+		Expect(getOperation(operation.ID).Clusters).To(HaveKey("cluster1"))
+
+		w = serve(http.MethodGet, "/api/v1/reconcile/operations")
+		Expect(w.Code).To(Equal(http.StatusOK))
+		operations := []*cache.ReconcileOperation{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &operations)).To(Succeed())
+		Expect(operations).To(HaveLen(1))
+	})
+})
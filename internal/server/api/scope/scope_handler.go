@@ -0,0 +1,107 @@
+package scope
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/scope"
+	"github.com/intuit/naavik/internal/server/api"
+)
+
+// Scope is the scope of the traffic configs, the lists of the scope config map narrow the flags.
+type Scope struct {
+	ClustersScope       []string    `json:"clustersScope"`
+	IgnoredAssetAliases []string    `json:"ignoredAssetAliases"`
+	ConfigMap           scope.State `json:"configMap"`
+}
+
+func AddRoutes(routerGroup *gin.RouterGroup) *gin.RouterGroup {
+	scopeRoutes := routerGroup.Group("/scope")
+	scopeRoutes.GET("", getScope)
+	scopeRoutes.PUT("", api.RequireToken(), putScope)
+	scopeRoutes.GET("/clusters/:clusterId", getClusterScope)
+	scopeRoutes.GET("/assets/:asset", getAssetScope)
+	return routerGroup
+}
+
+// getScope godoc
+//
+//	@Summary		Scope
+//	@Description	Get the clusters scope and ignored asset aliases flags, and the allow and deny lists of the scope config map with its error if invalid
+//	@Tags			Scope
+//	@Produce		json
+//	@Success		200	{object}	Scope
+//	@Router			/scope [get].
+func getScope(c *gin.Context) {
+	args := options.GetDynamicArgs()
+	c.JSON(http.StatusOK, Scope{
+		ClustersScope:       args.AllowedClusterScope,
+		IgnoredAssetAliases: args.IgnoreAssetAliases,
+		ConfigMap:           scope.GetState(),
+	})
+}
+
+// putScope godoc
+//
+//	@Summary		Update Scope
+//	@Description	Replace the allow and deny lists of the scope config map. Requires a bearer token from the api token file.
+//	@Description	The lists are applied once the config map update is received: the resources of the clusters and assets leaving the scope are deleted, the ones joining are reconciled.
+//	@Tags			Scope
+//	@Accept			json
+//	@Produce		json
+//	@Param			lists	body		scope.Lists	true	"Allow and deny lists"
+//	@Success		202		{object}	scope.Lists
+//	@Failure		400		{object}	api.ErrorResponse
+//	@Failure		401		{object}	api.ErrorResponse
+//	@Failure		500		{object}	api.ErrorResponse
+//	@Failure		503		{object}	api.ErrorResponse
+//	@Router			/scope [put].
+func putScope(c *gin.Context) {
+	lists := scope.Lists{}
+	if err := c.ShouldBindJSON(&lists); err != nil {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{Message: fmt.Sprintf("invalid scope lists: %s", err.Error())})
+		return
+	}
+	if err := lists.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{Message: err.Error()})
+		return
+	}
+	if err := scope.Save(c.Request.Context(), lists); err != nil {
+		if errors.Is(err, scope.ErrNotWatching) {
+			c.JSON(http.StatusServiceUnavailable, api.ErrorResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, api.ErrorResponse{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, lists)
+}
+
+// getClusterScope godoc
+//
+//	@Summary		Cluster Scope
+//	@Description	Get whether the traffic config resources are written to the cluster
+//	@Tags			Scope
+//	@Produce		json
+//	@Param			clusterId	path		string	true	"Cluster ID"
+//	@Success		200			{object}	map[string]bool
+//	@Router			/scope/clusters/{clusterId} [get].
+func getClusterScope(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"inScope": scope.IsClusterInScope(c.Params.ByName("clusterId"))})
+}
+
+// getAssetScope godoc
+//
+//	@Summary		Asset Scope
+//	@Description	Get whether the traffic configs of the asset, and the asset as a dependent, are processed
+//	@Tags			Scope
+//	@Produce		json
+//	@Param			asset	path		string	true	"Asset Alias"
+//	@Success		200		{object}	map[string]bool
+//	@Router			/scope/assets/{asset} [get].
+func getAssetScope(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"inScope": scope.IsAssetInScope(c.Params.ByName("asset"))})
+}
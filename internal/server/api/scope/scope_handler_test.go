@@ -0,0 +1,149 @@
+package scope
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/scope"
+	"github.com/intuit/naavik/internal/types/context"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Test scope handler", func() {
+	var (
+		router *gin.Engine
+		client *k8sfake.Clientset
+	)
+
+	// serve sends the request with the api token and returns the response.
+	serve := func(method string, path string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token1")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// watch watches the scope config map until the test ends.
+	watch := func() {
+		ctx := context.NewContextWithLogger()
+		goCtx, cancel := gocontext.WithCancel(ctx.Context)
+		ctx.Context = goCtx
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			scope.Watch(ctx, client, scope.WatcherOpts{Namespace: "admiral-sync", ConfigMapName: "naavik-scope"})
+		}()
+		DeferCleanup(func() {
+			cancel()
+			Eventually(done).Should(BeClosed())
+		})
+		Eventually(func() bool { return scope.GetState().ConfigMapFound }).Should(BeTrue())
+	}
+
+	BeforeEach(func() {
+		tokenFile := filepath.Join(GinkgoT().TempDir(), "tokens")
+		Expect(os.WriteFile(tokenFile, []byte("token1\n"), 0o600)).To(Succeed())
+		options.InitializeNaavikArgs(&options.NaavikArgs{APITokenFile: tokenFile})
+		scope.Reset()
+		client = k8sfake.NewSimpleClientset(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "naavik-scope", Namespace: "admiral-sync"},
+			Data:       map[string]string{scope.ListsKey: "deniedAssets: [asset1]\n"},
+		})
+
+		gin.SetMode(gin.TestMode)
+		router = gin.New()
+		AddRoutes(router.Group("/api/v1"))
+	})
+
+	AfterEach(func() {
+		options.InitializeNaavikArgs(nil)
+		scope.Reset()
+	})
+
+	It("should return the lists of the config map and the scope of the clusters and assets", func() {
+		watch()
+		w := serve(http.MethodGet, "/api/v1/scope", "")
+		Expect(w.Code).To(Equal(http.StatusOK))
+		response := Scope{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &response)).To(Succeed())
+		Expect(response.ConfigMap.ConfigMapFound).To(BeTrue())
+		Expect(response.ConfigMap.Lists.DeniedAssets).To(Equal([]string{"asset1"}))
+
+		Expect(serve(http.MethodGet, "/api/v1/scope/assets/Asset1", "").Body.String()).To(MatchJSON(`{"inScope": false}`))
+		Expect(serve(http.MethodGet, "/api/v1/scope/assets/asset2", "").Body.String()).To(MatchJSON(`{"inScope": true}`))
+		Expect(serve(http.MethodGet, "/api/v1/scope/clusters/cluster1", "").Body.String()).To(MatchJSON(`{"inScope": true}`))
+	})
+
+	It("should require a bearer token to update the lists", func() {
+		watch()
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/scope", strings.NewReader(`{"deniedClusters": ["cluster1"]}`))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+
+		w = serve(http.MethodGet, "/api/v1/scope/clusters/cluster1", "")
+		Expect(w.Body.String()).To(MatchJSON(`{"inScope": true}`))
+	})
+
+	It("should save the lists in the config map and apply them once received", func() {
+		watch()
+		w := serve(http.MethodPut, "/api/v1/scope", `{"deniedClusters": ["cluster1"]}`)
+		Expect(w.Code).To(Equal(http.StatusAccepted))
+		Expect(w.Body.String()).To(MatchJSON(`{"deniedClusters": ["cluster1"]}`))
+
+		cm, err := client.CoreV1().ConfigMaps("admiral-sync").Get(gocontext.Background(), "naavik-scope", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		lists, err := scope.Parse(cm.Data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lists.DeniedClusters).To(Equal([]string{"cluster1"}))
+		Expect(lists.DeniedAssets).To(BeEmpty())
+		Eventually(func() string {
+			return serve(http.MethodGet, "/api/v1/scope/clusters/cluster1", "").Body.String()
+		}).Should(MatchJSON(`{"inScope": false}`))
+	})
+
+	DescribeTable("should reject invalid lists",
+		func(body string, message string) {
+			watch()
+			w := serve(http.MethodPut, "/api/v1/scope", body)
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+			Expect(w.Body.String()).To(ContainSubstring(message))
+			Expect(scope.GetState().Lists.DeniedAssets).To(Equal([]string{"asset1"}))
+		},
+		Entry("not json", `deniedClusters: [cluster1]`, "invalid scope lists"),
+		Entry("unknown list type", `{"deniedClusters": "cluster1"}`, "invalid scope lists"),
+		Entry("invalid cluster regex", `{"deniedClusters": ["["]}`, "invalid cluster regex"),
+	)
+
+	It("should be unavailable while the config map is not watched", func() {
+		w := serve(http.MethodPut, "/api/v1/scope", `{"deniedClusters": ["cluster1"]}`)
+		Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(w.Body.String()).To(ContainSubstring(scope.ErrNotWatching.Error()))
+	})
+
+	It("should return an error when the config map fails to be updated", func() {
+		watch()
+		client.PrependReactor("update", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("update failed")
+		})
+		w := serve(http.MethodPut, "/api/v1/scope", `{"deniedClusters": ["cluster1"]}`)
+		Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		Expect(w.Body.String()).To(ContainSubstring("update failed"))
+	})
+})
@@ -0,0 +1,12 @@
+package state
+
+import "github.com/intuit/naavik/internal/leasechecker"
+
+type LeaseState struct {
+	StateChecker     string                         `json:"stateChecker"`
+	ReadOnly         bool                           `json:"readOnly"`
+	StateInitialized bool                           `json:"stateInitialized"`
+	LeaseHolder      string                         `json:"leaseHolder,omitempty"`
+	DR               *leasechecker.DRState          `json:"dr,omitempty"`
+	Transitions      []leasechecker.StateTransition `json:"transitions"`
+}
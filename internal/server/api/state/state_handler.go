@@ -0,0 +1,35 @@
+package state
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/leasechecker"
+)
+
+func AddRoutes(routerGroup *gin.RouterGroup) *gin.RouterGroup {
+	stateRoutes := routerGroup.Group("/state")
+	stateRoutes.GET("", getLeaseState)
+
+	return routerGroup
+}
+
+// getLeaseState godoc
+//
+//	@Summary		Lease State
+//	@Description	Get the read only/read write state of this instance along with the DR state and the recent state transitions
+//	@Tags			State
+//	@Produce		json
+//	@Success		200	{object}	LeaseState
+//	@Router			/state [get].
+func getLeaseState(c *gin.Context) {
+	c.JSON(http.StatusOK, LeaseState{
+		StateChecker:     options.GetStateChecker(),
+		ReadOnly:         leasechecker.IsReadOnly(),
+		StateInitialized: leasechecker.IsStateInitialized(),
+		LeaseHolder:      leasechecker.GetLeaseHolder(),
+		DR:               leasechecker.GetDRState(),
+		Transitions:      leasechecker.GetStateTransitions(),
+	})
+}
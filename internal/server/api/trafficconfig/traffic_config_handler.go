@@ -1,12 +1,22 @@
 package trafficconfig
 
 import (
+	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/intuit/naavik/internal/cache"
+	tchandler "github.com/intuit/naavik/internal/handler/trafficconfig"
+	"github.com/intuit/naavik/internal/server/api"
+	"github.com/intuit/naavik/internal/types/context"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	"sigs.k8s.io/yaml"
 )
 
+// maxPreviewBodyBytes is the max size of a traffic config submitted for a preview.
+const maxPreviewBodyBytes = 1 << 20
+
 func AddRoutes(routerGroup *gin.RouterGroup) *gin.RouterGroup {
 	workloadRoutes := routerGroup.Group("/trafficonfig")
 	workloadRoutes.GET("/resources/identities/:identity", getResourcesRelatedToIdentity)
@@ -14,6 +24,9 @@ func AddRoutes(routerGroup *gin.RouterGroup) *gin.RouterGroup {
 	workloadRoutes.GET("/resources/identities/:identity/dependents/:dependent/env/:env", getResourcesRelatedToIdentityAndDependentAndEnv)
 	workloadRoutes.GET("/identities/:identity", getByIdentity)
 	workloadRoutes.GET("/identities/:identity/env/:env", getByIdentityEnv)
+	workloadRoutes.GET("/propagation/unconverged", getUnconvergedPropagations)
+	workloadRoutes.GET("/propagation/identities/:identity/env/:env", getPropagationByIdentityEnv)
+	workloadRoutes.POST("/preview", api.RequireToken(), previewTrafficConfig)
 	return routerGroup
 }
 
@@ -51,6 +64,86 @@ func getByIdentityEnv(c *gin.Context) {
 	c.JSON(http.StatusOK, trafficConfig)
 }
 
+// getUnconvergedPropagations godoc
+//
+//	@Summary		Unconverged Traffic Config Revisions
+//	@Description	List the traffic config revisions not yet applied to all the clusters, oldest first
+//	@Tags			Traffic Config
+//	@Produce		json
+//	@Success		200	{object}	[]cache.PropagationStatus
+//	@Router			/trafficonfig/propagation/unconverged [get].
+func getUnconvergedPropagations(c *gin.Context) {
+	c.JSON(http.StatusOK, cache.Propagation.ListUnconverged())
+}
+
+// getPropagationByIdentityEnv godoc
+//
+//	@Summary		Traffic Config Propagation By Identity and Env
+//	@Description	Get the propagation state of the latest traffic config revision of an Identity and Env
+//	@Tags			Traffic Config
+//	@Produce		json
+//	@Param			identity	path		string	true	"Asset Alias"
+//	@Param			env			path		string	true	"Environment"
+//	@Success		200			{object}	cache.PropagationStatus
+//	@Failure		404			{object}	api.ErrorResponse
+//	@Router			/trafficonfig/propagation/identities/{identity}/env/{env} [get].
+func getPropagationByIdentityEnv(c *gin.Context) {
+	identity := c.Params.ByName("identity")
+	env := c.Params.ByName("env")
+	status := cache.Propagation.Get(identity, env)
+	if status == nil {
+		c.JSON(http.StatusNotFound, api.ErrorResponse{Message: fmt.Sprintf("no propagation tracked for identity %s and env %s", identity, env)})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// previewTrafficConfig godoc
+//
+//	@Summary		Preview Traffic Config
+//	@Description	Render the throttle filters and the virtual service of a traffic config against the current caches, without writing anything.
+//	@Description	Returns the rendered resources per target cluster, with the action and the diff against the deployed resources.
+//	@Description	Requires a bearer token from the api token file, as a preview lists and renders the resources of all the target clusters.
+//	@Tags			Traffic Config
+//	@Accept			json
+//	@Accept			application/yaml
+//	@Produce		json
+//	@Param			trafficConfig	body		object	true	"Traffic Config as YAML or JSON"
+//	@Success		200				{object}	trafficconfig.Preview
+//	@Failure		400				{object}	api.ErrorResponse
+//	@Failure		401				{object}	api.ErrorResponse
+//	@Failure		503				{object}	api.ErrorResponse
+//	@Router			/trafficonfig/preview [post].
+func previewTrafficConfig(c *gin.Context) {
+	if !cache.InformerSync.IsWarmedUp() {
+		c.JSON(http.StatusServiceUnavailable, api.ErrorResponse{Message: "cache is not warmed up yet"})
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxPreviewBodyBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{Message: fmt.Sprintf("error reading traffic config: %s", err.Error())})
+		return
+	}
+	// YAML is a superset of JSON, both are accepted
+	trafficConfig := &admiralv1.TrafficConfig{}
+	if err := yaml.Unmarshal(body, trafficConfig); err != nil {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{Message: fmt.Sprintf("invalid traffic config: %s", err.Error())})
+		return
+	}
+	if len(trafficConfig.Kind) > 0 && trafficConfig.Kind != "TrafficConfig" {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{Message: fmt.Sprintf("invalid kind %s, expected TrafficConfig", trafficConfig.Kind)})
+		return
+	}
+	ctx := context.NewContextWithLogger()
+	ctx.Log = ctx.Log.Str("clientIp", c.ClientIP())
+	preview, err := tchandler.PreviewTrafficConfig(ctx, trafficConfig)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, api.ErrorResponse{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, preview)
+}
+
 // getResourcesRelatedToIdentity godoc
 //
 //	@Summary		Resources Related to Traffic Config Identity
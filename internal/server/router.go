@@ -6,23 +6,35 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/intuit/naavik/cmd/options"
 	"github.com/intuit/naavik/internal/server/api"
+	"github.com/intuit/naavik/internal/server/api/admission"
 	"github.com/intuit/naavik/internal/server/api/clusters"
 	"github.com/intuit/naavik/internal/server/api/dependency"
+	"github.com/intuit/naavik/internal/server/api/events"
+	"github.com/intuit/naavik/internal/server/api/featuregates"
+	"github.com/intuit/naavik/internal/server/api/killswitch"
+	"github.com/intuit/naavik/internal/server/api/override"
+	"github.com/intuit/naavik/internal/server/api/reconcile"
+	"github.com/intuit/naavik/internal/server/api/scope"
+	"github.com/intuit/naavik/internal/server/api/state"
 	trafficconfig "github.com/intuit/naavik/internal/server/api/trafficconfig"
 	"github.com/intuit/naavik/internal/server/api/workload"
 	"github.com/intuit/naavik/internal/server/swagger"
 	"github.com/intuit/naavik/pkg/logger"
+	"github.com/intuit/naavik/pkg/metrics"
 )
 
-const HealthCheckPath = "/health/full"
+const (
+	HealthCheckPath = "/health/full"
+	MetricsPath     = "/metrics"
+)
 
+// SetupRouter returns the router of the HTTP server.
 func SetupRouter() *gin.Engine {
-	gin.DefaultWriter = io.Discard
-	r := gin.Default()
-
-	r.Use(Logger(), gin.Recovery())
+	r := newEngine()
 	r.GET(HealthCheckPath, api.HealthCheck)
+	r.GET(MetricsPath, gin.WrapH(metrics.Handler()))
 
 	// Base Path
 	group := r.Group("api/v1")
@@ -34,7 +46,33 @@ func SetupRouter() *gin.Engine {
 	clusters.AddRoutes(group)
 	dependency.AddRoutes(group)
 	trafficconfig.AddRoutes(group)
+	state.AddRoutes(group)
+	events.AddRoutes(group)
+	reconcile.AddRoutes(group)
+	featuregates.AddRoutes(group)
+	scope.AddRoutes(group)
+	killswitch.AddRoutes(group)
+	override.AddRoutes(group)
+
+	return r
+}
 
+// SetupTLSRouter returns the router of the TLS server. It only serves the admission webhook the API server calls,
+// the other APIs are only served by the HTTP server.
+func SetupTLSRouter() *gin.Engine {
+	r := newEngine()
+	if options.IsAdmissionWebhookEnabled() {
+		admission.AddRoutes(r.Group("api/v1"))
+	}
+	return r
+}
+
+// newEngine returns a router with the middlewares shared by the HTTP and TLS servers.
+func newEngine() *gin.Engine {
+	gin.DefaultWriter = io.Discard
+	r := gin.Default()
+
+	r.Use(Logger(), gin.Recovery())
 	return r
 }
 
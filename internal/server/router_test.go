@@ -0,0 +1,91 @@
+package server
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/leasechecker"
+	"github.com/intuit/naavik/internal/server/api"
+	"github.com/intuit/naavik/internal/webhook"
+	"github.com/intuit/naavik/pkg/metrics"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test routers", func() {
+	// postReview posts an invalid admission review to the router over TLS.
+	postReview := func(router *gin.Engine) int {
+		req := httptest.NewRequest(http.MethodPost, webhook.TrafficConfigPath, strings.NewReader("{}"))
+		req.TLS = &tls.ConnectionState{}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	BeforeEach(func() {
+		gin.SetMode(gin.TestMode)
+	})
+
+	AfterEach(func() {
+		options.InitializeNaavikArgs(nil)
+	})
+
+	It("should only serve the admission webhook on the TLS router", func() {
+		options.InitializeNaavikArgs(&options.NaavikArgs{AdmissionWebhookEnabled: true})
+		Expect(postReview(SetupRouter())).To(Equal(http.StatusNotFound))
+		Expect(postReview(SetupTLSRouter())).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should not serve the other APIs on the TLS router", func() {
+		options.InitializeNaavikArgs(&options.NaavikArgs{AdmissionWebhookEnabled: true})
+		router := SetupTLSRouter()
+		for _, path := range []string{HealthCheckPath, MetricsPath, "/api/v1/state", "/api/v1/reconcile/operations", "/api/v1/killswitch", "/api/v1/scope"} {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			Expect(w.Code).To(Equal(http.StatusNotFound), path)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/reconcile/identities/foo", nil))
+		Expect(w.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should not serve the admission webhook when disabled", func() {
+		Expect(postReview(SetupTLSRouter())).To(Equal(http.StatusNotFound))
+	})
+
+	It("should serve the lease state on the state group path like the other groups", func() {
+		router := SetupRouter()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/state", nil))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(ContainSubstring(`"readOnly"`))
+	})
+
+	It("should serve the lease state and the lease holder on the health check", func() {
+		leasechecker.ResetState()
+		router := SetupRouter()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, HealthCheckPath, nil))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		status := api.HealthStatus{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &status)).To(Succeed())
+		Expect(status).To(Equal(api.HealthStatus{Status: "ok", ReadOnly: true}))
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/lease", nil))
+		Expect(w.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should serve the naavik metrics", func() {
+		metrics.RegisterCacheSizeFunc("router", func() int { return 4 })
+		w := httptest.NewRecorder()
+		SetupRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(ContainSubstring(`naavik_cache_size{cache="router"} 4`))
+	})
+})
@@ -30,17 +30,16 @@ func ListenAndServe() (httpServer *http.Server, tlsServer *http.Server) {
 	// Initialize swagger
 	swagger.Initialize()
 
-	router := SetupRouter()
 	httpportstr := os.Getenv("MESH_TRAFFIC_PORT")
 	httpport, err := strconv.Atoi(httpportstr)
 	if len(httpportstr) == 0 || err != nil {
 		httpport = 8090
 	}
-	httpServer = startHTTPServer("", httpport, router)
+	httpServer = startHTTPServer("", httpport, SetupRouter())
 
 	env := options.GetEnvironment()
 	if env != types.EnvDev {
-		tlsServer = startTLSServer("", 8443, router, "./ssl/certificate.crt", "./ssl/certificate.key")
+		tlsServer = startTLSServer("", 8443, SetupTLSRouter(), "./ssl/certificate.crt", "./ssl/certificate.key")
 	}
 	return httpServer, tlsServer
 }
@@ -0,0 +1,147 @@
+package tokenbucket
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/intuit/naavik/cmd/options"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// BucketsKey is the traffic config annotation holding the YAML token buckets of the throttle filter.
+const BucketsKey = "quotaTokenBuckets"
+
+// Buckets separates the burst capacity from the sustained rate of the quotas and of the vhost.
+type Buckets struct {
+	// Vhost is the bucket of the requests matching no quota, defaults to the vhost flags.
+	Vhost  *Bucket        `json:"vhost,omitempty"`
+	Quotas []*QuotaBucket `json:"quotas,omitempty"`
+}
+
+// Bucket is a token bucket holding up to MaxTokens, refilled with TokensPerFill every FillInterval.
+type Bucket struct {
+	// MaxTokens is the burst capacity of the bucket.
+	MaxTokens int `json:"maxTokens"`
+	// TokensPerFill and FillInterval are the sustained rate, they default to the maxAmount and timePeriod of a quota.
+	TokensPerFill int    `json:"tokensPerFill,omitempty"`
+	FillInterval  string `json:"fillInterval,omitempty"`
+
+	fillInterval time.Duration
+}
+
+// QuotaBucket is the bucket of a quota.
+type QuotaBucket struct {
+	// QuotaGroup is the name of the total or app quota group of the quota.
+	QuotaGroup string `json:"quotaGroup"`
+	Quota      string `json:"quota"`
+	Bucket
+}
+
+// Settings are the values of a token bucket of the throttle filter.
+type Settings struct {
+	MaxTokens     int
+	TokensPerFill int
+	FillInterval  time.Duration
+}
+
+// Parse parses and validates the token buckets of the traffic config annotation, nil if there are none.
+func Parse(tc *admiralv1.TrafficConfig) (*Buckets, error) {
+	if tc == nil {
+		return nil, nil
+	}
+	content, ok := tc.Annotations[BucketsKey]
+	if !ok || len(strings.TrimSpace(content)) == 0 {
+		return nil, nil
+	}
+	buckets := &Buckets{}
+	if err := yaml.UnmarshalStrict([]byte(content), buckets); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", BucketsKey, err)
+	}
+	if buckets.Vhost != nil {
+		if err := buckets.Vhost.compile(); err != nil {
+			return nil, fmt.Errorf("%s vhost: %w", BucketsKey, err)
+		}
+		if err := options.ValidateTokenBucket(buckets.Vhost.MaxTokens, buckets.Vhost.TokensPerFill, buckets.Vhost.fillInterval); err != nil {
+			return nil, fmt.Errorf("%s vhost: %w", BucketsKey, err)
+		}
+	}
+	for i, quota := range buckets.Quotas {
+		if len(quota.QuotaGroup) == 0 || len(quota.Quota) == 0 {
+			return nil, fmt.Errorf("%s quotas[%d]: quotaGroup and quota are required", BucketsKey, i)
+		}
+		if err := quota.compile(); err != nil {
+			return nil, fmt.Errorf("%s quotas[%d]: %w", BucketsKey, i, err)
+		}
+	}
+	return buckets, nil
+}
+
+// compile parses the fill interval and checks the values set, the vhost bucket requires all of them.
+func (b *Bucket) compile() error {
+	if len(b.FillInterval) > 0 {
+		interval, err := time.ParseDuration(b.FillInterval)
+		if err != nil {
+			return fmt.Errorf("invalid fillInterval %q: %w", b.FillInterval, err)
+		}
+		if interval < options.MinFillInterval {
+			return fmt.Errorf("fillInterval %q must be at least %s", b.FillInterval, options.MinFillInterval)
+		}
+		b.fillInterval = interval
+	}
+	if b.MaxTokens <= 0 {
+		return fmt.Errorf("maxTokens must be positive")
+	}
+	if b.TokensPerFill < 0 {
+		return fmt.Errorf("tokensPerFill must be positive")
+	}
+	if b.TokensPerFill > b.MaxTokens {
+		return fmt.Errorf("tokensPerFill %d exceed maxTokens %d", b.TokensPerFill, b.MaxTokens)
+	}
+	return nil
+}
+
+// ForQuota returns the bucket of the quota of the quota group refilled every timePeriod. Without a bucket, the quota
+// refills its maxAmount and cannot burst above it. The max tokens are raised to the tokens per fill if lower,
+// e.g. when a schedule window or an override raises the maxAmount of a quota above its burst capacity.
+func (b *Buckets) ForQuota(quotaGroup string, quota *admiralv1.Quota, timePeriod time.Duration) Settings {
+	settings := Settings{MaxTokens: quota.MaxAmount, TokensPerFill: quota.MaxAmount, FillInterval: timePeriod}
+	if bucket := b.Find(quotaGroup, quota.Name); bucket != nil {
+		settings.MaxTokens = bucket.MaxTokens
+		if bucket.TokensPerFill > 0 {
+			settings.TokensPerFill = bucket.TokensPerFill
+		}
+		if bucket.fillInterval > 0 {
+			settings.FillInterval = bucket.fillInterval
+		}
+	}
+	settings.MaxTokens = max(settings.MaxTokens, settings.TokensPerFill)
+	return settings
+}
+
+// ForVhost returns the vhost bucket of the traffic config, the vhost flags if not set.
+func (b *Buckets) ForVhost() Settings {
+	if b == nil || b.Vhost == nil {
+		return DefaultVhost()
+	}
+	return Settings{MaxTokens: b.Vhost.MaxTokens, TokensPerFill: b.Vhost.TokensPerFill, FillInterval: b.Vhost.fillInterval}
+}
+
+// DefaultVhost returns the vhost bucket of the vhost flags.
+func DefaultVhost() Settings {
+	return Settings{MaxTokens: options.GetVhostMaxTokens(), TokensPerFill: options.GetVhostTokensPerFill(), FillInterval: options.GetVhostFillInterval()}
+}
+
+// Find returns the bucket of the quota of the quota group, nil if none.
+func (b *Buckets) Find(quotaGroup string, quota string) *QuotaBucket {
+	if b == nil {
+		return nil
+	}
+	for _, bucket := range b.Quotas {
+		if bucket.QuotaGroup == quotaGroup && bucket.Quota == quota {
+			return bucket
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package tokenbucket
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTokenBucket(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "tokenbucket_test")
+}
@@ -0,0 +1,64 @@
+package tokenbucket
+
+import (
+	"time"
+
+	"github.com/intuit/naavik/cmd/options"
+	resourcebuilder "github.com/intuit/naavik/internal/fake/builder/resource"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test token buckets", func() {
+	quota := &admiralv1.Quota{Name: "Total", MaxAmount: 100, TimePeriod: "1s"}
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+	})
+
+	It("should refill the quota max amount without bucket", func() {
+		buckets, err := Parse(resourcebuilder.GetFakeTrafficConfig("app.bursty", "qal", "1", "namespace"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(buckets).To(BeNil())
+		Expect(buckets.ForQuota("Total Throttling Plan", quota, time.Second)).To(Equal(Settings{MaxTokens: 100, TokensPerFill: 100, FillInterval: time.Second}))
+		Expect(buckets.ForVhost()).To(Equal(Settings{
+			MaxTokens:     options.DefaultVhostMaxTokens,
+			TokensPerFill: options.DefaultVhostTokensPerFill,
+			FillInterval:  options.DefaultVhostFillInterval,
+		}))
+	})
+
+	It("should separate the burst capacity from the sustained rate", func() {
+		buckets, err := Parse(resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.bursty", "qal", map[string]string{BucketsKey: `
+vhost: {maxTokens: 5000, tokensPerFill: 1000, fillInterval: 1s}
+quotas:
+- {quotaGroup: Total Throttling Plan, quota: Total, maxTokens: 300}
+- {quotaGroup: App Plan, quota: Total, maxTokens: 50, tokensPerFill: 10, fillInterval: 100ms}
+`}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(buckets.ForQuota("Total Throttling Plan", quota, time.Second)).To(Equal(Settings{MaxTokens: 300, TokensPerFill: 100, FillInterval: time.Second}))
+		Expect(buckets.ForQuota("App Plan", quota, time.Second)).To(Equal(Settings{MaxTokens: 50, TokensPerFill: 10, FillInterval: 100 * time.Millisecond}))
+		Expect(buckets.ForVhost()).To(Equal(Settings{MaxTokens: 5000, TokensPerFill: 1000, FillInterval: time.Second}))
+	})
+
+	It("should raise the max tokens to the tokens per fill", func() {
+		buckets, err := Parse(resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.bursty", "qal", map[string]string{BucketsKey: "quotas: [{quotaGroup: Total Throttling Plan, quota: Total, maxTokens: 50}]\n"}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(buckets.ForQuota("Total Throttling Plan", quota, time.Second).MaxTokens).To(Equal(100))
+	})
+
+	DescribeTable("should reject inconsistent buckets",
+		func(buckets string) {
+			_, err := Parse(resourcebuilder.GetFakeTrafficConfigWithAnnotations("app.bursty", "qal", map[string]string{BucketsKey: buckets}))
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("unknown field", "quotas: [{quotaGroup: g, quota: q, burst: 10}]\n"),
+		Entry("missing quota", "quotas: [{quotaGroup: g, maxTokens: 10}]\n"),
+		Entry("no max tokens", "quotas: [{quotaGroup: g, quota: q, tokensPerFill: 10}]\n"),
+		Entry("fill above max tokens", "quotas: [{quotaGroup: g, quota: q, maxTokens: 10, tokensPerFill: 20}]\n"),
+		Entry("too short fill interval", "quotas: [{quotaGroup: g, quota: q, maxTokens: 10, fillInterval: 10ms}]\n"),
+		Entry("invalid fill interval", "quotas: [{quotaGroup: g, quota: q, maxTokens: 10, fillInterval: 1x}]\n"),
+		Entry("incomplete vhost", "vhost: {maxTokens: 10}\n"),
+	)
+})
@@ -13,12 +13,21 @@ const (
 	AssetAliasMetaDataKey  = "assetAlias"
 	SourceIdentityKey      = "sourceIdentity"
 	DestinationIdentityKey = "destinationIdentity"
+	EventReceivedTimeKey   = "eventReceivedTime"
+	TargetEnvKey           = "targetEnv"
 
 	StateCheckerNone     = "none"
+	StateCheckerLease    = "lease"
+	StateCheckerDR       = "dr"
 	ConfigResolverSecret = "secret"
 
 	IncludeInboundPortsAnnotation = "admiral.io/inboundPorts"
 
+	// App quota group enforcement, on the sidecars of the identity, of its associated apps or both.
+	EnforceInbound  = "inbound"
+	EnforceOutbound = "outbound"
+	EnforceBoth     = "both"
+
 	// TrafficConfig related constants.
 	TransactionIDKey        = "transactionID"
 	RevisionNumberKey       = "revisionNumber"
@@ -27,6 +36,7 @@ const (
 	CreatedForKey           = "createdFor"
 	CreatedForEnvKey        = "createdForEnv"
 	CreatedForTrafficEnvKey = "createdForTrafficEnv"
+	CreatedForAppKey        = "createdForApp"
 	EnvKey                  = "env"
 	IsDisabledKey           = "isDisabled"
 	IsTrue                  = "true"
@@ -46,6 +56,14 @@ const (
 	EnvLabelKey = "env"
 )
 
+// VirtualServiceEnvPrefixes maps the traffic config envs virtual services are created for to the env prefix of the virtual service name.
+var VirtualServiceEnvPrefixes = map[string]string{
+	"qa":  "qal",
+	"e2e": "e2e",
+	"prd": "prd",
+	"prf": "prf",
+}
+
 type EventType string
 
 const (
@@ -0,0 +1,31 @@
+package clock
+
+import (
+	"sync"
+
+	k8sclock "k8s.io/utils/clock"
+)
+
+var (
+	current k8sclock.WithTicker = k8sclock.RealClock{}
+	lock                        = sync.RWMutex{}
+)
+
+// Set replaces the clock the time based features are evaluated with, e.g. by a fake clock in tests.
+func Set(c k8sclock.WithTicker) {
+	lock.Lock()
+	defer lock.Unlock()
+	current = c
+}
+
+// Get returns the clock the time based features are evaluated with, e.g. the quota schedules and the override expiries.
+func Get() k8sclock.WithTicker {
+	lock.RLock()
+	defer lock.RUnlock()
+	return current
+}
+
+// Reset restores the real clock.
+func Reset() {
+	Set(k8sclock.RealClock{})
+}
@@ -0,0 +1,35 @@
+package validation
+
+import (
+	"strings"
+
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+)
+
+// ValidateDependency validates the dependency the way the dependency handler uses it.
+func ValidateDependency(dependency *admiralv1.Dependency) *Result {
+	result := newResult()
+	source := dependency.Spec.Source
+	if len(source) == 0 {
+		result.errorf("spec.source", "source is required")
+	}
+	if len(dependency.Spec.Destinations) == 0 {
+		result.warnf("spec.destinations", "no destinations")
+	}
+	destinations := map[string]bool{}
+	for i, destination := range dependency.Spec.Destinations {
+		field := index("spec.destinations", i)
+		// Identities are case insensitive in the caches
+		destination = strings.ToLower(destination)
+		switch {
+		case len(destination) == 0:
+			result.errorf(field, "destination is required")
+		case destinations[destination]:
+			result.warnf(field, "duplicate destination %q", dependency.Spec.Destinations[i])
+		case strings.EqualFold(destination, source):
+			result.warnf(field, "source %q depends on itself", source)
+		}
+		destinations[destination] = true
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+package validation
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	KindTrafficConfig = "TrafficConfig"
+	KindDependency    = "Dependency"
+)
+
+// ManifestResult is the validation of a manifest of a YAML or JSON stream.
+type ManifestResult struct {
+	// Document is the position of the manifest in the stream, starting at 1.
+	Document  int    `json:"document"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	*Result
+}
+
+// ValidateManifests validates the TrafficConfig and Dependency manifests of a YAML stream, documents separated by ---, or of a JSON document.
+// Documents of other kinds are reported as errors.
+func ValidateManifests(reader io.Reader) ([]*ManifestResult, error) {
+	yamlReader := k8syaml.NewYAMLReader(bufio.NewReader(reader))
+	results := make([]*ManifestResult, 0)
+	for document := 1; ; document++ {
+		content, err := yamlReader.Read()
+		if errors.Is(err, io.EOF) {
+			return results, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading document %d: %w", document, err)
+		}
+		if len(bytes.TrimSpace(content)) == 0 {
+			document--
+			continue
+		}
+		result, err := ValidateManifest(content)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing document %d: %w", document, err)
+		}
+		result.Document = document
+		results = append(results, result)
+	}
+}
+
+// ValidateManifest validates a TrafficConfig or a Dependency manifest, as YAML or JSON.
+func ValidateManifest(content []byte) (*ManifestResult, error) {
+	metadata := metav1.PartialObjectMetadata{}
+	if err := yaml.Unmarshal(content, &metadata); err != nil {
+		return nil, err
+	}
+	manifestResult := &ManifestResult{Kind: metadata.Kind, Name: metadata.Name, Namespace: metadata.Namespace}
+	switch metadata.Kind {
+	case KindTrafficConfig:
+		trafficConfig := &admiralv1.TrafficConfig{}
+		if err := yaml.Unmarshal(content, trafficConfig); err != nil {
+			return nil, err
+		}
+		manifestResult.Result = ValidateTrafficConfig(trafficConfig)
+	case KindDependency:
+		dependency := &admiralv1.Dependency{}
+		if err := yaml.Unmarshal(content, dependency); err != nil {
+			return nil, err
+		}
+		manifestResult.Result = ValidateDependency(dependency)
+	default:
+		manifestResult.Result = newResult()
+		manifestResult.errorf("kind", "unsupported kind %q, expected %s or %s", metadata.Kind, KindTrafficConfig, KindDependency)
+	}
+	return manifestResult, nil
+}
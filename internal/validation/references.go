@@ -0,0 +1,55 @@
+package validation
+
+import (
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/pkg/utils"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+)
+
+// ValidateTrafficConfigReferences checks the identities and the workload envs referenced by the traffic config against the caches.
+// The caches are eventually consistent, so a missing reference is a warning, the workloads may not be discovered yet.
+func ValidateTrafficConfigReferences(trafficConfig *admiralv1.TrafficConfig) *Result {
+	result := newResult()
+	tcUtil := utils.TrafficConfigUtil(trafficConfig)
+	identity := tcUtil.GetIdentity()
+	if len(identity) == 0 {
+		return result
+	}
+	clusters := cache.IdentityCluster.GetClustersForIdentity(identity)
+	if len(clusters) == 0 {
+		result.warnf(key("metadata.labels", options.GetTrafficConfigIdentityKey()), "no workloads found for identity %q", identity)
+	} else {
+		for i, env := range trafficConfig.Spec.WorkloadEnv {
+			if !hasWorkload(clusters, identity, env) {
+				result.warnf(index("spec.workloadEnvs", i), "no workload found for identity %q in env %q", identity, env)
+			}
+		}
+	}
+
+	if trafficConfig.Spec.EdgeService == nil {
+		return result
+	}
+	for i, targetGroup := range trafficConfig.Spec.EdgeService.TargetGroups {
+		for j, appOverride := range targetGroup.AppOverrides {
+			if len(appOverride.AssetAlias) == 0 {
+				continue
+			}
+			if len(cache.IdentityCluster.GetClustersForIdentity(appOverride.AssetAlias)) == 0 {
+				field := index(index("spec.edgeService.targetGroups", i)+".appOverrides", j) + ".assetAlias"
+				result.warnf(field, "unknown asset alias %q, no workloads found", appOverride.AssetAlias)
+			}
+		}
+	}
+	return result
+}
+
+func hasWorkload(clusters []string, identity string, env string) bool {
+	for _, clusterID := range clusters {
+		if cache.Deployments.GetByClusterIdentityEnv(clusterID, identity, env) != nil ||
+			cache.Rollouts.GetByClusterIdentityEnv(clusterID, identity, env) != nil {
+			return true
+		}
+	}
+	return false
+}
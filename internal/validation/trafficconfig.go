@@ -0,0 +1,305 @@
+package validation
+
+import (
+	"slices"
+	"time"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/override"
+	"github.com/intuit/naavik/internal/quotaenforcement"
+	"github.com/intuit/naavik/internal/quotaschedule"
+	"github.com/intuit/naavik/internal/tokenbucket"
+	"github.com/intuit/naavik/internal/types"
+	"github.com/intuit/naavik/internal/utils/clock"
+	"github.com/intuit/naavik/pkg/utils"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+)
+
+// totalWeight is the sum of the weights of a target group.
+const totalWeight = 100
+
+// ValidateTrafficConfig validates the traffic config the way the throttle filter and the virtual service builders use it.
+func ValidateTrafficConfig(trafficConfig *admiralv1.TrafficConfig) *Result {
+	result := newResult()
+	tcUtil := utils.TrafficConfigUtil(trafficConfig)
+	if len(tcUtil.GetIdentity()) == 0 {
+		result.errorf(key("metadata.labels", options.GetTrafficConfigIdentityKey()), "identity is required")
+	}
+	env := tcUtil.GetEnv()
+	if len(env) == 0 {
+		result.errorf(key("metadata.labels", types.EnvKey), "env is required")
+	}
+	workloadEnvs := trafficConfig.Spec.WorkloadEnv
+	if len(workloadEnvs) == 0 {
+		result.warnf("spec.workloadEnvs", "no workload envs, no throttle filters are created")
+	}
+
+	if trafficConfig.Spec.EdgeService != nil {
+		if _, found := types.VirtualServiceEnvPrefixes[env]; len(env) > 0 && !found {
+			result.errorf(key("metadata.labels", types.EnvKey), "no virtual service is created for env %q, expected one of %v", env, virtualServiceEnvs())
+		}
+		validateEdgeService(result, "spec.edgeService", trafficConfig.Spec.EdgeService, workloadEnvs)
+	}
+	if trafficConfig.Spec.QuotaGroup != nil {
+		validateQuotaGroup(result, "spec.quotaGroup", trafficConfig.Spec.QuotaGroup, workloadEnvs)
+	}
+	validateQuotaSchedules(result, key("metadata.annotations", quotaschedule.SchedulesKey), trafficConfig)
+	validateOverrides(result, key("metadata.annotations", override.OverridesKey), trafficConfig)
+	validateTokenBuckets(result, key("metadata.annotations", tokenbucket.BucketsKey), trafficConfig)
+	validateQuotaEnforcement(result, key("metadata.annotations", quotaenforcement.EnforcementKey), trafficConfig)
+	return result
+}
+
+func virtualServiceEnvs() []string {
+	envs := make([]string, 0, len(types.VirtualServiceEnvPrefixes))
+	for env := range types.VirtualServiceEnvPrefixes {
+		envs = append(envs, env)
+	}
+	slices.Sort(envs)
+	return envs
+}
+
+func validateEdgeService(result *Result, field string, edgeService *admiralv1.EdgeService, workloadEnvs []string) {
+	if len(edgeService.Routes) == 0 {
+		result.errorf(field+".routes", "at least one route is required")
+	}
+
+	targets := map[string]bool{}
+	for i, target := range edgeService.Targets {
+		targetField := index(field+".targets", i)
+		if len(target.Name) == 0 {
+			result.errorf(targetField+".name", "name is required")
+			continue
+		}
+		if targets[target.Name] {
+			result.errorf(targetField+".name", "duplicate target %q", target.Name)
+		}
+		targets[target.Name] = true
+	}
+
+	filters := map[string]bool{}
+	for _, filter := range edgeService.Filters {
+		filters[filter.Name] = true
+	}
+
+	// Routes select the target group weights, or the app override weights, by name
+	weightNames := map[string]bool{}
+	targetGroups := map[string]bool{}
+	for i, targetGroup := range edgeService.TargetGroups {
+		targetGroupField := index(field+".targetGroups", i)
+		if len(targetGroup.Name) == 0 {
+			result.errorf(targetGroupField+".name", "name is required")
+		} else if targetGroups[targetGroup.Name] {
+			result.errorf(targetGroupField+".name", "duplicate target group %q", targetGroup.Name)
+		}
+		targetGroups[targetGroup.Name] = true
+		validateWeights(result, targetGroupField+".weights", targetGroup.Weights, weightNames)
+		for j, appOverride := range targetGroup.AppOverrides {
+			appOverrideField := index(targetGroupField+".appOverrides", j)
+			if len(appOverride.AssetAlias) == 0 {
+				result.errorf(appOverrideField+".assetAlias", "asset alias is required")
+			}
+			validateWeights(result, appOverrideField+".weights", appOverride.Weights, weightNames)
+		}
+	}
+
+	routes := map[string]bool{}
+	for i, route := range edgeService.Routes {
+		routeField := index(field+".routes", i)
+		if len(route.Name) == 0 {
+			result.errorf(routeField+".name", "name is required")
+		} else if routes[route.Name] {
+			result.errorf(routeField+".name", "duplicate route %q", route.Name)
+		}
+		routes[route.Name] = true
+		if len(route.Inbound) == 0 {
+			result.errorf(routeField+".inbound", "inbound is required")
+		}
+		if route.Timeout < 0 {
+			result.errorf(routeField+".timeout", "timeout must not be negative")
+		}
+		if len(route.FilterSelector) > 0 && !filters[route.FilterSelector] {
+			result.warnf(routeField+".filterSelector", "unknown filter %q", route.FilterSelector)
+		}
+		validateWorkloadEnvSelectors(result, routeField+".workloadEnvSelectors", route.WorkloadEnvSelectors, workloadEnvs)
+		for j, config := range route.Config {
+			configField := index(routeField+".config", j)
+			if len(edgeService.Targets) > 0 && !targets[config.TargetSelector] {
+				result.errorf(configField+".targetSelector", "unknown target %q", config.TargetSelector)
+			}
+			if len(edgeService.TargetGroups) > 0 && !weightNames[config.TargetGroupSelector] {
+				result.errorf(configField+".targetGroupSelector", "unknown target group weight %q", config.TargetGroupSelector)
+			}
+		}
+	}
+}
+
+// validateWeights checks that the weights sum up to 100 and adds their names to weightNames.
+func validateWeights(result *Result, field string, weights []*admiralv1.Weight, weightNames map[string]bool) {
+	if len(weights) == 0 {
+		return
+	}
+	sum := 0
+	for i, weight := range weights {
+		if weight.Weight < 0 || weight.Weight > totalWeight {
+			result.errorf(index(field, i)+".weight", "weight %d must be between 0 and %d", weight.Weight, totalWeight)
+		}
+		if len(weight.Name) == 0 {
+			result.errorf(index(field, i)+".name", "name is required")
+		}
+		weightNames[weight.Name] = true
+		sum += weight.Weight
+	}
+	if sum != totalWeight {
+		result.errorf(field, "weights sum up to %d, expected %d", sum, totalWeight)
+	}
+}
+
+func validateWorkloadEnvSelectors(result *Result, field string, selectors []string, workloadEnvs []string) {
+	if len(selectors) == 0 {
+		result.warnf(field, "no workload env selected")
+		return
+	}
+	for i, selector := range selectors {
+		if !slices.Contains(workloadEnvs, selector) {
+			result.warnf(index(field, i), "workload env %q is not in spec.workloadEnvs", selector)
+		}
+	}
+}
+
+func validateQuotaGroup(result *Result, field string, quotaGroup *admiralv1.QuotaGroup, workloadEnvs []string) {
+	for i, totalQuotaGroup := range quotaGroup.TotalQuotaGroup {
+		groupField := index(field+".totalQuotaGroups", i)
+		if len(totalQuotaGroup.Name) == 0 {
+			result.errorf(groupField+".name", "name is required")
+		}
+		validateWorkloadEnvSelectors(result, groupField+".workloadEnvSelectors", totalQuotaGroup.WorkloadEnvSelectors, workloadEnvs)
+		validateQuotas(result, groupField+".quotas", totalQuotaGroup.Quotas)
+	}
+	for i, appQuotaGroup := range quotaGroup.AppQuotaGroups {
+		groupField := index(field+".appQuotaGroups", i)
+		if len(appQuotaGroup.Name) == 0 {
+			result.errorf(groupField+".name", "name is required")
+		}
+		if len(appQuotaGroup.AssociatedApps) == 0 {
+			result.warnf(groupField+".associatedApps", "no associated apps, the quotas apply to no app")
+		}
+		validateWorkloadEnvSelectors(result, groupField+".workloadEnvSelectors", appQuotaGroup.WorkloadEnvSelectors, workloadEnvs)
+		validateQuotas(result, groupField+".quotas", appQuotaGroup.Quotas)
+	}
+}
+
+func validateQuotas(result *Result, field string, quotas []*admiralv1.Quota) {
+	for i, quota := range quotas {
+		quotaField := index(field, i)
+		if len(quota.Name) == 0 {
+			result.errorf(quotaField+".name", "name is required")
+		}
+		timePeriod, err := time.ParseDuration(quota.TimePeriod)
+		switch {
+		case err != nil:
+			result.errorf(quotaField+".timePeriod", "invalid time period %q, the quota is skipped: %s", quota.TimePeriod, err.Error())
+		case timePeriod <= 0:
+			result.errorf(quotaField+".timePeriod", "time period %q must be positive", quota.TimePeriod)
+		}
+		if quota.MaxAmount < 0 {
+			result.errorf(quotaField+".maxAmount", "max amount %d must not be negative", quota.MaxAmount)
+		}
+	}
+}
+
+func validateQuotaSchedules(result *Result, field string, trafficConfig *admiralv1.TrafficConfig) {
+	schedules, err := quotaschedule.Parse(trafficConfig)
+	if err != nil {
+		result.errorf(field, "%s, the quotas are used as is", err.Error())
+		return
+	}
+	for i, schedule := range schedules {
+		if !hasQuota(trafficConfig.Spec.QuotaGroup, schedule.QuotaGroup, schedule.Quota) {
+			result.warnf(index(field, i), "no quota %q in quota group %q, the schedule applies to no quota", schedule.Quota, schedule.QuotaGroup)
+		}
+	}
+}
+
+func validateOverrides(result *Result, field string, trafficConfig *admiralv1.TrafficConfig) {
+	overrides, err := override.Parse(trafficConfig)
+	if err != nil {
+		result.errorf(field, "%s, no override is applied", err.Error())
+		return
+	}
+	now := clock.Get().Now()
+	for i, trafficOverride := range overrides {
+		if !trafficOverride.IsActive(now) {
+			result.warnf(index(field, i), "override %s expired at %s and can be removed", trafficOverride.Name, trafficOverride.ExpiresAt.Format(time.RFC3339))
+			continue
+		}
+		for j, quotaOverride := range trafficOverride.Quotas {
+			if !hasQuota(trafficConfig.Spec.QuotaGroup, quotaOverride.QuotaGroup, quotaOverride.Quota) {
+				result.warnf(index(index(field, i)+".quotas", j), "no quota %q in quota group %q, the override applies to no quota", quotaOverride.Quota, quotaOverride.QuotaGroup)
+			}
+		}
+		for j, weightOverride := range trafficOverride.Weights {
+			if !hasTargetGroup(trafficConfig.Spec.EdgeService, weightOverride.TargetGroup) {
+				result.warnf(index(index(field, i)+".weights", j), "no target group %q, the override applies to no target group", weightOverride.TargetGroup)
+			}
+		}
+	}
+}
+
+func validateTokenBuckets(result *Result, field string, trafficConfig *admiralv1.TrafficConfig) {
+	buckets, err := tokenbucket.Parse(trafficConfig)
+	if err != nil {
+		result.errorf(field, "%s, the quotas cannot burst", err.Error())
+		return
+	}
+	if buckets == nil {
+		return
+	}
+	for i, bucket := range buckets.Quotas {
+		quotas := override.FindQuotas(trafficConfig.Spec.QuotaGroup, bucket.QuotaGroup, bucket.Quota)
+		if len(quotas) == 0 {
+			result.warnf(index(field+".quotas", i), "no quota %q in quota group %q, the bucket applies to no quota", bucket.Quota, bucket.QuotaGroup)
+			continue
+		}
+		for _, quota := range quotas {
+			if bucket.TokensPerFill == 0 && bucket.MaxTokens < quota.MaxAmount {
+				result.errorf(index(field+".quotas", i)+".maxTokens", "maxTokens %d is lower than the maxAmount %d refilled every time period", bucket.MaxTokens, quota.MaxAmount)
+			}
+		}
+	}
+}
+
+func validateQuotaEnforcement(result *Result, field string, trafficConfig *admiralv1.TrafficConfig) {
+	enforcement, err := quotaenforcement.Parse(trafficConfig)
+	if err != nil {
+		result.errorf(field, "%s, the app_quota_enforcement flag is used", err.Error())
+		return
+	}
+	if enforcement.IsOutbound() && (trafficConfig.Spec.QuotaGroup == nil || !slices.ContainsFunc(trafficConfig.Spec.QuotaGroup.AppQuotaGroups, func(aqg *admiralv1.AppQuotaGroup) bool { return len(aqg.AssociatedApps) > 0 })) {
+		result.warnf(field, "no app quota group with associated apps, no outbound throttle filters are created")
+	}
+}
+
+func hasTargetGroup(edgeService *admiralv1.EdgeService, name string) bool {
+	return edgeService != nil && slices.ContainsFunc(edgeService.TargetGroups, func(targetGroup *admiralv1.TargetGroup) bool { return targetGroup.Name == name })
+}
+
+func hasQuota(quotaGroup *admiralv1.QuotaGroup, groupName string, quotaName string) bool {
+	if quotaGroup == nil {
+		return false
+	}
+	containsQuota := func(quotas []*admiralv1.Quota) bool {
+		return slices.ContainsFunc(quotas, func(quota *admiralv1.Quota) bool { return quota.Name == quotaName })
+	}
+	for _, totalQuotaGroup := range quotaGroup.TotalQuotaGroup {
+		if totalQuotaGroup.Name == groupName && containsQuota(totalQuotaGroup.Quotas) {
+			return true
+		}
+	}
+	for _, appQuotaGroup := range quotaGroup.AppQuotaGroups {
+		if appQuotaGroup.Name == groupName && containsQuota(appQuotaGroup.Quotas) {
+			return true
+		}
+	}
+	return false
+}
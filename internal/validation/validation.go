@@ -0,0 +1,63 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Issue is a problem found in a field of a manifest.
+type Issue struct {
+	// Field is the path of the field, e.g. spec.edgeService.targetGroups[0].weights.
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s", i.Field, i.Message)
+}
+
+// Result is the outcome of a validation.
+// Errors make the manifest unusable or its resources wrong, warnings are likely mistakes.
+type Result struct {
+	Errors   []Issue `json:"errors"`
+	Warnings []Issue `json:"warnings"`
+}
+
+func newResult() *Result {
+	return &Result{
+		Errors:   make([]Issue, 0),
+		Warnings: make([]Issue, 0),
+	}
+}
+
+func (r *Result) HasErrors() bool {
+	return len(r.Errors) > 0
+}
+
+// Err returns an error listing the errors, nil if there is none.
+func (r *Result) Err() error {
+	if !r.HasErrors() {
+		return nil
+	}
+	messages := make([]string, 0, len(r.Errors))
+	for _, issue := range r.Errors {
+		messages = append(messages, issue.String())
+	}
+	return fmt.Errorf("invalid manifest: %s", strings.Join(messages, "; "))
+}
+
+func (r *Result) errorf(field string, format string, args ...interface{}) {
+	r.Errors = append(r.Errors, Issue{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *Result) warnf(field string, format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, Issue{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func index(field string, i int) string {
+	return fmt.Sprintf("%s[%d]", field, i)
+}
+
+func key(field string, key string) string {
+	return fmt.Sprintf("%s[%s]", field, key)
+}
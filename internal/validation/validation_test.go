@@ -0,0 +1,202 @@
+package validation
+
+import (
+	"strings"
+
+	"github.com/intuit/naavik/cmd/options"
+	k8s_builder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/override"
+	"github.com/intuit/naavik/internal/quotaenforcement"
+	"github.com/intuit/naavik/internal/quotaschedule"
+	"github.com/intuit/naavik/internal/tokenbucket"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func fields(issues []Issue) []string {
+	list := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		list = append(list, issue.Field)
+	}
+	return list
+}
+
+var _ = Describe("Test traffic config validation", func() {
+	var tc *admiralv1.TrafficConfig
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		tc = k8s_builder.GetFakeTrafficConfig("foo", "qa", "1", "ns")
+		tc.Spec.EdgeService.Routes[1].FilterSelector = "fake-filter"
+	})
+
+	It("should accept a valid traffic config", func() {
+		result := ValidateTrafficConfig(tc)
+		Expect(result.Errors).To(BeEmpty())
+		Expect(result.Warnings).To(BeEmpty())
+		Expect(result.Err()).NotTo(HaveOccurred())
+	})
+
+	It("should report a missing identity and env", func() {
+		tc.Labels = map[string]string{}
+		result := ValidateTrafficConfig(tc)
+		Expect(fields(result.Errors)).To(ConsistOf("metadata.labels["+options.GetTrafficConfigIdentityKey()+"]", "metadata.labels[env]"))
+	})
+
+	It("should report an env without virtual service name", func() {
+		tc.Labels["env"] = "stage"
+		result := ValidateTrafficConfig(tc)
+		Expect(fields(result.Errors)).To(ConsistOf("metadata.labels[env]"))
+	})
+
+	It("should report target group weights not summing up to 100", func() {
+		tc.Spec.EdgeService.TargetGroups[0].Weights = append(tc.Spec.EdgeService.TargetGroups[0].Weights, &admiralv1.Weight{Name: "Canary", Weight: 10})
+		result := ValidateTrafficConfig(tc)
+		Expect(fields(result.Errors)).To(ConsistOf("spec.edgeService.targetGroups[0].weights"))
+		Expect(result.Errors[0].Message).To(Equal("weights sum up to 110, expected 100"))
+	})
+
+	It("should report unknown target and target group selectors", func() {
+		tc.Spec.EdgeService.Routes[1].Config[0].TargetSelector = "unknown"
+		tc.Spec.EdgeService.Routes[1].Config[0].TargetGroupSelector = "unknown"
+		result := ValidateTrafficConfig(tc)
+		Expect(fields(result.Errors)).To(ConsistOf(
+			"spec.edgeService.routes[1].config[0].targetSelector",
+			"spec.edgeService.routes[1].config[0].targetGroupSelector"))
+	})
+
+	It("should report an invalid quota time period", func() {
+		tc.Spec.QuotaGroup.TotalQuotaGroup[0].Quotas[0].TimePeriod = "1 sec"
+		result := ValidateTrafficConfig(tc)
+		Expect(fields(result.Errors)).To(ConsistOf("spec.quotaGroup.totalQuotaGroups[0].quotas[0].timePeriod"))
+		Expect(result.Err()).To(MatchError(ContainSubstring(`invalid time period "1 sec"`)))
+	})
+
+	It("should report invalid quota schedules and warn about unknown quotas", func() {
+		tc.Annotations[quotaschedule.SchedulesKey] = "- quotaGroup: Total Throttling Plan\n  quota: Total\n  windows: []\n"
+		result := ValidateTrafficConfig(tc)
+		Expect(fields(result.Errors)).To(ConsistOf("metadata.annotations[quotaSchedules]"))
+
+		tc.Annotations[quotaschedule.SchedulesKey] = `
+- quotaGroup: Total Throttling Plan
+  quota: Total
+  windows: [{cron: "0 22 * * *", duration: 6h, maxAmount: 1000, timePeriod: 1m}]
+- quotaGroup: Total Throttling Plan
+  quota: Unknown
+  windows: [{cron: "0 22 * * *", duration: 6h, maxAmount: 1000, timePeriod: 1m}]
+`
+		result = ValidateTrafficConfig(tc)
+		Expect(result.Errors).To(BeEmpty())
+		Expect(fields(result.Warnings)).To(ConsistOf("metadata.annotations[quotaSchedules][1]"))
+	})
+
+	It("should report invalid overrides and warn about expired ones", func() {
+		tc.Annotations[override.OverridesKey] = "- name: bump\n  quotas: [{quotaGroup: Total Throttling Plan, quota: Total, maxAmount: 500}]\n"
+		result := ValidateTrafficConfig(tc)
+		Expect(fields(result.Errors)).To(ConsistOf("metadata.annotations[trafficOverrides]"))
+
+		tc.Annotations[override.OverridesKey] = `
+- name: expired
+  expiresAt: 2000-01-01T00:00:00Z
+  quotas: [{quotaGroup: Total Throttling Plan, quota: Total, maxAmount: 500}]
+- name: unknown
+  expiresAt: 2999-01-01T00:00:00Z
+  quotas: [{quotaGroup: Total Throttling Plan, quota: Unknown, maxAmount: 500}]
+  weights: [{targetGroup: Unknown, weights: [{name: Default, weight: 100}]}]
+`
+		result = ValidateTrafficConfig(tc)
+		Expect(result.Errors).To(BeEmpty())
+		Expect(fields(result.Warnings)).To(ConsistOf(
+			"metadata.annotations[trafficOverrides][0]",
+			"metadata.annotations[trafficOverrides][1].quotas[0]",
+			"metadata.annotations[trafficOverrides][1].weights[0]"))
+	})
+
+	It("should report token buckets that cannot hold a fill", func() {
+		tc.Annotations[tokenbucket.BucketsKey] = `
+quotas:
+- {quotaGroup: Total Throttling Plan, quota: Total, maxTokens: 50}
+- {quotaGroup: Total Throttling Plan, quota: Unknown, maxTokens: 500}
+`
+		result := ValidateTrafficConfig(tc)
+		Expect(fields(result.Errors)).To(ConsistOf("metadata.annotations[quotaTokenBuckets].quotas[0].maxTokens"))
+		Expect(fields(result.Warnings)).To(ConsistOf("metadata.annotations[quotaTokenBuckets].quotas[1]"))
+
+		tc.Annotations[tokenbucket.BucketsKey] = "quotas: [{quotaGroup: Total Throttling Plan, quota: Total, maxTokens: 50, tokensPerFill: 60}]\n"
+		result = ValidateTrafficConfig(tc)
+		Expect(fields(result.Errors)).To(ConsistOf("metadata.annotations[quotaTokenBuckets]"))
+	})
+
+	It("should report an unknown app quota enforcement and warn about outbound enforcement without associated apps", func() {
+		tc.Annotations[quotaenforcement.EnforcementKey] = "client"
+		result := ValidateTrafficConfig(tc)
+		Expect(fields(result.Errors)).To(ConsistOf("metadata.annotations[appQuotaEnforcement]"))
+
+		tc.Annotations[quotaenforcement.EnforcementKey] = "outbound"
+		result = ValidateTrafficConfig(tc)
+		Expect(result.Errors).To(BeEmpty())
+		Expect(fields(result.Warnings)).To(ConsistOf("metadata.annotations[appQuotaEnforcement]"))
+
+		tc.Spec.QuotaGroup.AppQuotaGroups = []*admiralv1.AppQuotaGroup{{Name: "App Plan", AssociatedApps: []string{"app.client"}, WorkloadEnvSelectors: tc.Spec.WorkloadEnv}}
+		result = ValidateTrafficConfig(tc)
+		Expect(result.Warnings).To(BeEmpty())
+	})
+
+	It("should warn about unknown filters and workload envs", func() {
+		tc.Spec.EdgeService.Routes[0].FilterSelector = "unknown"
+		tc.Spec.EdgeService.Routes[0].WorkloadEnvSelectors = []string{"e2e"}
+		result := ValidateTrafficConfig(tc)
+		Expect(result.Errors).To(BeEmpty())
+		Expect(fields(result.Warnings)).To(ConsistOf(
+			"spec.edgeService.routes[0].filterSelector",
+			"spec.edgeService.routes[0].workloadEnvSelectors[0]"))
+	})
+})
+
+var _ = Describe("Test dependency validation", func() {
+	It("should report a missing source and warn about duplicate destinations", func() {
+		dependency := k8s_builder.BuildFakeDependency("ns", "", []string{"foo", "Foo", ""})
+		result := ValidateDependency(dependency)
+		Expect(fields(result.Errors)).To(ConsistOf("spec.source", "spec.destinations[2]"))
+		Expect(fields(result.Warnings)).To(ConsistOf("spec.destinations[1]"))
+	})
+
+	It("should warn about a source depending on itself", func() {
+		result := ValidateDependency(k8s_builder.BuildFakeDependency("ns", "foo", []string{"foo"}))
+		Expect(result.Errors).To(BeEmpty())
+		Expect(fields(result.Warnings)).To(ConsistOf("spec.destinations[0]"))
+	})
+})
+
+var _ = Describe("Test manifests validation", func() {
+	It("should validate each document of a YAML stream", func() {
+		manifests := `apiVersion: admiral.io/v1
+kind: Dependency
+metadata:
+  name: bar
+spec:
+  source: bar
+  destinations: [foo]
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`
+		results, err := ValidateManifests(strings.NewReader(manifests))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].Document).To(Equal(1))
+		Expect(results[0].Name).To(Equal("bar"))
+		Expect(results[0].HasErrors()).To(BeFalse())
+		Expect(results[1].Document).To(Equal(2))
+		Expect(results[1].Name).To(Equal("config"))
+		Expect(fields(results[1].Errors)).To(ConsistOf("kind"))
+	})
+
+	It("should fail on a document that is not a manifest", func() {
+		_, err := ValidateManifests(strings.NewReader("kind: [TrafficConfig"))
+		Expect(err).To(HaveOccurred())
+	})
+})
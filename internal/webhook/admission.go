@@ -0,0 +1,70 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/intuit/naavik/internal/cache"
+	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/internal/validation"
+	"github.com/intuit/naavik/pkg/logger"
+	"github.com/intuit/naavik/pkg/utils"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TrafficConfigPath is the path the API server calls to validate a traffic config.
+const TrafficConfigPath = "/api/v1/admission/trafficconfig"
+
+// ReviewTrafficConfig answers the admission review of a traffic config.
+// A traffic config with validation errors is denied, the warnings are returned to the client, e.g. kubectl.
+// Deletes and ignored traffic configs are always allowed.
+func ReviewTrafficConfig(ctx context.Context, review *admissionv1.AdmissionReview) (*admissionv1.AdmissionReview, error) {
+	if review.Request == nil {
+		return nil, fmt.Errorf("admission review has no request")
+	}
+	request := review.Request
+	response := &admissionv1.AdmissionResponse{UID: request.UID, Allowed: true}
+	reviewResponse := &admissionv1.AdmissionReview{TypeMeta: review.TypeMeta, Response: response}
+	if request.Operation == admissionv1.Delete {
+		return reviewResponse, nil
+	}
+
+	trafficConfig := &admiralv1.TrafficConfig{}
+	if err := json.Unmarshal(request.Object.Raw, trafficConfig); err != nil {
+		return nil, fmt.Errorf("invalid traffic config: %w", err)
+	}
+	tcUtil := utils.TrafficConfigUtil(trafficConfig)
+	if tcUtil.IsIgnored() {
+		return reviewResponse, nil
+	}
+
+	result := validation.ValidateTrafficConfig(trafficConfig)
+	warnings := result.Warnings
+	if cache.InformerSync.IsWarmedUp() {
+		warnings = append(warnings, validation.ValidateTrafficConfigReferences(trafficConfig).Warnings...)
+	} else {
+		response.Warnings = append(response.Warnings, "naavik caches are not warmed up yet, the references are not checked")
+	}
+	for _, issue := range warnings {
+		response.Warnings = append(response.Warnings, issue.String())
+	}
+
+	log := ctx.Log.Str(logger.NameKey, request.Name).Str(logger.NamespaceKey, request.Namespace).Str(logger.OperationKey, string(request.Operation)).
+		Str(logger.WorkloadIdentifierKey, tcUtil.GetIdentity()).Int("warnings", len(warnings))
+	if err := result.Err(); err != nil {
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusUnprocessableEntity,
+			Reason:  metav1.StatusReasonInvalid,
+			Message: err.Error(),
+		}
+		log.Infof("Traffic config denied, %s", err.Error())
+		return reviewResponse, nil
+	}
+	log.Info("Traffic config allowed.")
+	return reviewResponse, nil
+}
@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/intuit/naavik/internal/types"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConfigurationName is the name of the ValidatingWebhookConfiguration, and of its webhook.
+	ConfigurationName = "trafficconfig.naavik.admiral.io"
+	// DefaultServicePort is the port of the TLS server serving the webhook.
+	DefaultServicePort = 8443
+	// namespaceNameLabel is set by kubernetes on every namespace.
+	namespaceNameLabel = "kubernetes.io/metadata.name"
+)
+
+// ConfigurationOptions describe where the webhook is served.
+type ConfigurationOptions struct {
+	ServiceName      string
+	ServiceNamespace string
+	ServicePort      int32
+	// CABundle is the PEM encoded CA the API server uses to verify the TLS server certificate.
+	CABundle []byte
+	// FailurePolicy is Ignore or Fail, what the API server does when naavik is not reachable.
+	FailurePolicy admissionregistrationv1.FailurePolicyType
+	// TrafficConfigNamespace is the namespace the traffic configs are watched in, the only namespace validated.
+	TrafficConfigNamespace string
+	TimeoutSeconds         int32
+}
+
+// BuildValidatingWebhookConfiguration builds the ValidatingWebhookConfiguration calling the traffic config webhook on creates and updates.
+func BuildValidatingWebhookConfiguration(opts ConfigurationOptions) (*admissionregistrationv1.ValidatingWebhookConfiguration, error) {
+	if len(opts.ServiceName) == 0 || len(opts.ServiceNamespace) == 0 {
+		return nil, fmt.Errorf("service name and namespace are required")
+	}
+	if opts.FailurePolicy != admissionregistrationv1.Ignore && opts.FailurePolicy != admissionregistrationv1.Fail {
+		return nil, fmt.Errorf("invalid failure policy %q, expected %q or %q", opts.FailurePolicy, admissionregistrationv1.Ignore, admissionregistrationv1.Fail)
+	}
+	port := opts.ServicePort
+	if port == 0 {
+		port = DefaultServicePort
+	}
+	path := TrafficConfigPath
+	sideEffects := admissionregistrationv1.SideEffectClassNone
+	scope := admissionregistrationv1.NamespacedScope
+	failurePolicy := opts.FailurePolicy
+
+	webhook := admissionregistrationv1.ValidatingWebhook{
+		Name: ConfigurationName,
+		ClientConfig: admissionregistrationv1.WebhookClientConfig{
+			Service: &admissionregistrationv1.ServiceReference{
+				Name:      opts.ServiceName,
+				Namespace: opts.ServiceNamespace,
+				Path:      &path,
+				Port:      &port,
+			},
+			CABundle: opts.CABundle,
+		},
+		Rules: []admissionregistrationv1.RuleWithOperations{
+			{
+				Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update},
+				Rule: admissionregistrationv1.Rule{
+					APIGroups:   []string{"admiral.io"},
+					APIVersions: []string{"v1"},
+					Resources:   []string{"trafficconfigs"},
+					Scope:       &scope,
+				},
+			},
+		},
+		FailurePolicy:           &failurePolicy,
+		SideEffects:             &sideEffects,
+		AdmissionReviewVersions: []string{"v1"},
+	}
+	if len(opts.TrafficConfigNamespace) > 0 {
+		webhook.NamespaceSelector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{namespaceNameLabel: opts.TrafficConfigNamespace},
+		}
+	}
+	if opts.TimeoutSeconds > 0 {
+		timeoutSeconds := opts.TimeoutSeconds
+		webhook.TimeoutSeconds = &timeoutSeconds
+	}
+
+	return &admissionregistrationv1.ValidatingWebhookConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "admissionregistration.k8s.io/v1",
+			Kind:       "ValidatingWebhookConfiguration",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   ConfigurationName,
+			Labels: map[string]string{types.CreatedByKey: types.NaavikName},
+		},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{webhook},
+	}, nil
+}
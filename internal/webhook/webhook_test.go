@@ -0,0 +1,138 @@
+package webhook
+
+import (
+	"encoding/json"
+
+	"github.com/intuit/naavik/cmd/options"
+	"github.com/intuit/naavik/internal/cache"
+	k8s_builder "github.com/intuit/naavik/internal/fake/builder/resource"
+	"github.com/intuit/naavik/internal/types/context"
+	admiralv1 "github.com/istio-ecosystem/admiral-api/pkg/apis/admiral/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func buildReview(operation admissionv1.Operation, tc *admiralv1.TrafficConfig) *admissionv1.AdmissionReview {
+	raw, err := json.Marshal(tc)
+	Expect(err).NotTo(HaveOccurred())
+	return &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "uid",
+			Operation: operation,
+			Name:      tc.Name,
+			Namespace: tc.Namespace,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+}
+
+var _ = Describe("Test traffic config admission review", func() {
+	var tc *admiralv1.TrafficConfig
+	ctx := context.NewContextWithLogger()
+
+	BeforeEach(func() {
+		options.InitializeNaavikArgs(nil)
+		cache.ResetAllCaches()
+		cache.InformerSync.SetWarmedUp()
+		tc = k8s_builder.GetFakeTrafficConfig("foo", "qa", "1", "admiral")
+		tc.Spec.EdgeService.Routes[1].FilterSelector = "fake-filter"
+		cache.IdentityCluster.AddClusterToIdentity("foo", "cluster1")
+		cache.Deployments.Add("cluster1", k8s_builder.BuildFakeDeployment("foo", "foo", "foo", "qa", "ns"))
+	})
+
+	It("should allow a valid traffic config", func() {
+		review, err := ReviewTrafficConfig(ctx, buildReview(admissionv1.Create, tc))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(review.APIVersion).To(Equal("admission.k8s.io/v1"))
+		Expect(review.Response.UID).To(BeEquivalentTo("uid"))
+		Expect(review.Response.Allowed).To(BeTrue())
+		Expect(review.Response.Warnings).To(BeEmpty())
+	})
+
+	It("should deny a traffic config with validation errors", func() {
+		tc.Spec.EdgeService.Routes[1].Config[0].TargetSelector = "unknown"
+		review, err := ReviewTrafficConfig(ctx, buildReview(admissionv1.Update, tc))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(review.Response.Allowed).To(BeFalse())
+		Expect(review.Response.Result.Message).To(ContainSubstring(`spec.edgeService.routes[1].config[0].targetSelector: unknown target "unknown"`))
+	})
+
+	It("should warn about an identity without workloads", func() {
+		cache.IdentityCluster.Reset()
+		review, err := ReviewTrafficConfig(ctx, buildReview(admissionv1.Create, tc))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(review.Response.Allowed).To(BeTrue())
+		Expect(review.Response.Warnings).To(ConsistOf(`metadata.labels[` + options.GetTrafficConfigIdentityKey() + `]: no workloads found for identity "foo"`))
+	})
+
+	It("should warn about workload envs and asset aliases without workloads", func() {
+		tc.Spec.WorkloadEnv = append(tc.Spec.WorkloadEnv, "e2e")
+		tc.Spec.EdgeService.TargetGroups[0].AppOverrides = []*admiralv1.AppOverride{
+			{AssetAlias: "bar", Weights: tc.Spec.EdgeService.TargetGroups[0].Weights},
+		}
+		review, err := ReviewTrafficConfig(ctx, buildReview(admissionv1.Create, tc))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(review.Response.Allowed).To(BeTrue())
+		Expect(review.Response.Warnings).To(ConsistOf(
+			`spec.workloadEnvs[1]: no workload found for identity "foo" in env "e2e"`,
+			`spec.edgeService.targetGroups[0].appOverrides[0].assetAlias: unknown asset alias "bar", no workloads found`))
+	})
+
+	It("should skip the references until the caches are warmed up", func() {
+		cache.InformerSync.Reset()
+		cache.IdentityCluster.Reset()
+		review, err := ReviewTrafficConfig(ctx, buildReview(admissionv1.Create, tc))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(review.Response.Allowed).To(BeTrue())
+		Expect(review.Response.Warnings).To(ConsistOf(ContainSubstring("not warmed up")))
+	})
+
+	It("should allow deletes and ignored traffic configs", func() {
+		tc.Labels = map[string]string{}
+		review, err := ReviewTrafficConfig(ctx, buildReview(admissionv1.Delete, tc))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(review.Response.Allowed).To(BeTrue())
+
+		tc.Labels[options.GetResourceIgnoreLabel()] = "true"
+		review, err = ReviewTrafficConfig(ctx, buildReview(admissionv1.Create, tc))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(review.Response.Allowed).To(BeTrue())
+	})
+
+	It("should fail on a review without request", func() {
+		_, err := ReviewTrafficConfig(ctx, &admissionv1.AdmissionReview{})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Test validating webhook configuration", func() {
+	It("should build the configuration for the traffic config namespace", func() {
+		configuration, err := BuildValidatingWebhookConfiguration(ConfigurationOptions{
+			ServiceName:            "naavik",
+			ServiceNamespace:       "admiral",
+			CABundle:               []byte("ca"),
+			FailurePolicy:          admissionregistrationv1.Fail,
+			TrafficConfigNamespace: "admiral",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(configuration.Webhooks).To(HaveLen(1))
+		webhook := configuration.Webhooks[0]
+		Expect(*webhook.ClientConfig.Service.Path).To(Equal(TrafficConfigPath))
+		Expect(*webhook.ClientConfig.Service.Port).To(BeEquivalentTo(DefaultServicePort))
+		Expect(webhook.ClientConfig.CABundle).To(Equal([]byte("ca")))
+		Expect(*webhook.FailurePolicy).To(Equal(admissionregistrationv1.Fail))
+		Expect(webhook.NamespaceSelector.MatchLabels).To(HaveKeyWithValue("kubernetes.io/metadata.name", "admiral"))
+		Expect(webhook.Rules[0].Resources).To(ConsistOf("trafficconfigs"))
+		Expect(webhook.Rules[0].Operations).To(ConsistOf(admissionregistrationv1.Create, admissionregistrationv1.Update))
+	})
+
+	It("should reject an invalid failure policy", func() {
+		_, err := BuildValidatingWebhookConfiguration(ConfigurationOptions{ServiceName: "naavik", ServiceNamespace: "admiral", FailurePolicy: "Retry"})
+		Expect(err).To(MatchError(ContainSubstring(`invalid failure policy "Retry"`)))
+	})
+})
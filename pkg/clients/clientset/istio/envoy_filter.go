@@ -7,6 +7,7 @@ import (
 
 	"github.com/intuit/naavik/internal/types/context"
 	"github.com/intuit/naavik/pkg/logger"
+	"github.com/intuit/naavik/pkg/metrics"
 	"github.com/intuit/naavik/pkg/types"
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -18,7 +19,9 @@ func (i *istioClientData) GetEnvoyFilter(_ context.Context, name string, namespa
 
 func (i *istioClientData) CreateEnvoyFilter(ctx context.Context, envoyFilter *v1alpha3.EnvoyFilter, options metav1.CreateOptions) (*v1alpha3.EnvoyFilter, error) {
 	envoyFilter.Annotations[types.LastUpdatedTimestampKey] = time.Now().UTC().Format(time.RFC3339)
+	span := i.startSpan(ctx, metrics.KindEnvoyFilter, metrics.OperationCreate, envoyFilter.Name, envoyFilter.Namespace)
 	ef, err := i.istioClient.NetworkingV1alpha3().EnvoyFilters(envoyFilter.Namespace).Create(context.Background(), envoyFilter, options)
+	i.observe(ctx, span, metrics.KindEnvoyFilter, metrics.OperationCreate, envoyFilter.Name, envoyFilter.Namespace, err)
 	if err != nil {
 		ctx.Log.Str(logger.ClusterKey, i.clusterID).Str(logger.OperationKey, "Create").Str(logger.NameKey, envoyFilter.Name).Str(logger.NamespaceKey, envoyFilter.Namespace).Str(logger.ErrorKey, err.Error()).Error("error creating envoy filter")
 		return nil, err
@@ -29,7 +32,9 @@ func (i *istioClientData) CreateEnvoyFilter(ctx context.Context, envoyFilter *v1
 
 func (i *istioClientData) UpdateEnvoyFilter(ctx context.Context, envoyFilter *v1alpha3.EnvoyFilter, options metav1.UpdateOptions) (*v1alpha3.EnvoyFilter, error) {
 	envoyFilter.Annotations[types.LastUpdatedTimestampKey] = time.Now().UTC().Format(time.RFC3339)
+	span := i.startSpan(ctx, metrics.KindEnvoyFilter, metrics.OperationUpdate, envoyFilter.Name, envoyFilter.Namespace)
 	ef, err := i.istioClient.NetworkingV1alpha3().EnvoyFilters(envoyFilter.Namespace).Update(context.Background(), envoyFilter, options)
+	i.observe(ctx, span, metrics.KindEnvoyFilter, metrics.OperationUpdate, envoyFilter.Name, envoyFilter.Namespace, err)
 	if err != nil {
 		ctx.Log.Str(logger.ClusterKey, i.clusterID).Str(logger.OperationKey, "Update").Str(logger.NameKey, envoyFilter.Name).Str(logger.NamespaceKey, envoyFilter.Namespace).Str(logger.ErrorKey, err.Error()).Error("error updating envoy filter")
 		return nil, err
@@ -39,7 +44,9 @@ func (i *istioClientData) UpdateEnvoyFilter(ctx context.Context, envoyFilter *v1
 }
 
 func (i *istioClientData) DeleteEnvoyFilter(ctx context.Context, name string, namespace string, options metav1.DeleteOptions) error {
+	span := i.startSpan(ctx, metrics.KindEnvoyFilter, metrics.OperationDelete, name, namespace)
 	err := i.istioClient.NetworkingV1alpha3().EnvoyFilters(namespace).Delete(context.Background(), name, options)
+	i.observe(ctx, span, metrics.KindEnvoyFilter, metrics.OperationDelete, name, namespace, err)
 	if err != nil {
 		ctx.Log.Str(logger.ClusterKey, i.clusterID).Str(logger.OperationKey, "Delete").Str(logger.NameKey, name).Str(logger.NamespaceKey, namespace).Str(logger.ErrorKey, err.Error()).Error("error deleting envoy filter")
 		return err
@@ -66,7 +73,7 @@ func (i *istioClientData) CreateEnvoyFilters(ctx context.Context, filterList []*
 	for _, f := range filterList {
 		_, err := i.CreateEnvoyFilter(ctx, f, metav1.CreateOptions{})
 		if err != nil {
-			errors.Join(errorList, err)
+			errorList = errors.Join(errorList, err)
 		}
 	}
 	return errorList
@@ -77,7 +84,7 @@ func (i *istioClientData) DeleteEnvoyFilters(ctx context.Context, filterList []*
 	for _, f := range filterList {
 		err := i.DeleteEnvoyFilter(ctx, f.Name, f.Namespace, metav1.DeleteOptions{})
 		if err != nil {
-			errors.Join(errorList, err)
+			errorList = errors.Join(errorList, err)
 		}
 	}
 	return errorList
@@ -88,7 +95,7 @@ func (i *istioClientData) UpdateEnvoyFilters(ctx context.Context, filterList []*
 	for _, f := range filterList {
 		_, err := i.UpdateEnvoyFilter(ctx, f, metav1.UpdateOptions{})
 		if err != nil {
-			errors.Join(errorList, err)
+			errorList = errors.Join(errorList, err)
 		}
 	}
 	return errorList
@@ -121,20 +128,16 @@ func (i *istioClientData) ApplyEnvoyFilters(ctx context.Context,
 
 	if len(filtersToBeCreated) > 0 {
 		createerr := i.CreateEnvoyFilters(ctx, filtersToBeCreated)
-		errors.Join(err, createerr)
+		err = errors.Join(err, createerr)
 	}
 	if len(filtersToBeUpdated) > 0 {
 		updateerr := i.UpdateEnvoyFilters(ctx, filtersToBeUpdated)
-		if err != nil {
-			errors.Join(err, updateerr)
-		}
+		err = errors.Join(err, updateerr)
 	}
 
 	if len(filtersToBeDeleted) > 0 {
 		deleteerr := i.DeleteEnvoyFilters(ctx, filtersToBeDeleted)
-		if err != nil {
-			errors.Join(err, deleteerr)
-		}
+		err = errors.Join(err, deleteerr)
 	}
 	return err
 }
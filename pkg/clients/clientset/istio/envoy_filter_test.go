@@ -0,0 +1,77 @@
+package istio
+
+import (
+	"errors"
+
+	"github.com/intuit/naavik/internal/types/context"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	fakeistioclientset "istio.io/client-go/pkg/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func getEnvoyFilter(name string) *v1alpha3.EnvoyFilter {
+	return &v1alpha3.EnvoyFilter{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "istio-system", Annotations: map[string]string{}}}
+}
+
+var _ = Describe("Test envoy filter batch writes", func() {
+	var (
+		ctx         context.Context
+		istioClient *fakeistioclientset.Clientset
+		client      ClientInterface
+	)
+
+	BeforeEach(func() {
+		ctx = context.NewContextWithLogger()
+		istioClient = fakeistioclientset.NewSimpleClientset(getEnvoyFilter("updated"), getEnvoyFilter("deleted"))
+		client = NewIstioClient("cluster1", istioClient)
+	})
+
+	failVerb := func(verb string) error {
+		writeErr := errors.New(verb + " failed")
+		istioClient.PrependReactor(verb, "envoyfilters", func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, writeErr
+		})
+		return writeErr
+	}
+
+	applyFilters := func() error {
+		existing, err := client.ListEnvoyFilters(ctx, "istio-system", metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return client.ApplyEnvoyFilters(ctx, []*v1alpha3.EnvoyFilter{getEnvoyFilter("created"), getEnvoyFilter("updated")}, existing)
+	}
+
+	It("should apply the filters", func() {
+		Expect(applyFilters()).To(Succeed())
+		filters, err := client.ListEnvoyFilters(ctx, "istio-system", metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filters.Items).To(HaveLen(2))
+	})
+
+	DescribeTable("should return the error of a failed batch write",
+		func(verb string) {
+			writeErr := failVerb(verb)
+			Expect(applyFilters()).To(MatchError(writeErr))
+		},
+		Entry("create", "create"),
+		Entry("update", "update"),
+		Entry("delete", "delete"),
+	)
+
+	It("should return the errors of every failed write", func() {
+		createErr := failVerb("create")
+		deleteErr := failVerb("delete")
+		err := applyFilters()
+		Expect(err).To(MatchError(createErr))
+		Expect(err).To(MatchError(deleteErr))
+	})
+
+	It("should return the errors of the failed filters of a batch", func() {
+		createErr := failVerb("create")
+		err := client.CreateEnvoyFilters(ctx, []*v1alpha3.EnvoyFilter{getEnvoyFilter("first"), getEnvoyFilter("second")})
+		Expect(err).To(MatchError(createErr))
+	})
+})
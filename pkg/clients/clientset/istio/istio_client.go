@@ -1,7 +1,15 @@
 package istio
 
 import (
+	"fmt"
+
 	"github.com/intuit/naavik/internal/types/context"
+	"github.com/intuit/naavik/pkg/eventhistory"
+	"github.com/intuit/naavik/pkg/logger"
+	"github.com/intuit/naavik/pkg/metrics"
+	"github.com/intuit/naavik/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
 	istioclientset "istio.io/client-go/pkg/clientset/versioned"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -44,6 +52,24 @@ type istioClientData struct {
 	istioClient istioclientset.Interface
 }
 
+// startSpan starts the span of an istio write call as a child of the span in the context.
+func (i *istioClientData) startSpan(ctx context.Context, kind string, operation string, name string, namespace string) trace.Span {
+	_, span := tracing.StartSpan(ctx.Context, fmt.Sprintf("istio %s %s", operation, kind),
+		attribute.String(logger.ClusterKey, i.clusterID),
+		attribute.String(logger.TypeKey, kind),
+		attribute.String(logger.OperationKey, operation),
+		attribute.String(logger.NameKey, name),
+		attribute.String(logger.NamespaceKey, namespace))
+	return span
+}
+
+// observe records the metrics and the event history of an istio write call and ends its span.
+func (i *istioClientData) observe(ctx context.Context, span trace.Span, kind string, operation string, name string, namespace string, err error) {
+	metrics.ObserveIstioRequest(i.clusterID, kind, operation, err)
+	eventhistory.RecorderFromContext(ctx.Context).RecordWrite(i.clusterID, kind, operation, name, namespace, err)
+	tracing.EndSpan(span, err)
+}
+
 func NewIstioClient(clusterID string, istioClient istioclientset.Interface) ClientInterface {
 	return &istioClientData{
 		clusterID:   clusterID,
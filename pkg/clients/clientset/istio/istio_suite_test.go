@@ -0,0 +1,13 @@
+package istio
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestIstio(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "istio_test")
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/intuit/naavik/internal/types/context"
 	"github.com/intuit/naavik/pkg/logger"
+	"github.com/intuit/naavik/pkg/metrics"
 	"github.com/intuit/naavik/pkg/types"
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -13,7 +14,9 @@ import (
 // Create Virtual Service.
 func (i *istioClientData) CreateVirtualService(ctx context.Context, virtualService *v1alpha3.VirtualService, options metav1.CreateOptions) (*v1alpha3.VirtualService, error) {
 	virtualService.Annotations[types.LastUpdatedTimestampKey] = time.Now().UTC().Format(time.RFC3339)
+	span := i.startSpan(ctx, metrics.KindVirtualService, metrics.OperationCreate, virtualService.Name, virtualService.Namespace)
 	vs, err := i.istioClient.NetworkingV1alpha3().VirtualServices(virtualService.Namespace).Create(context.Background(), virtualService, options)
+	i.observe(ctx, span, metrics.KindVirtualService, metrics.OperationCreate, virtualService.Name, virtualService.Namespace, err)
 	if err != nil {
 		ctx.Log.Str(logger.ClusterKey, i.clusterID).Str(logger.OperationKey, "Create").Str(logger.NameKey, virtualService.Name).Str(logger.NamespaceKey, virtualService.Namespace).Str(logger.ErrorKey, err.Error()).Error("error creating virtual service")
 		return nil, err
@@ -25,7 +28,9 @@ func (i *istioClientData) CreateVirtualService(ctx context.Context, virtualServi
 // Update Virtual Service.
 func (i *istioClientData) UpdateVirtualService(ctx context.Context, virtualService *v1alpha3.VirtualService, options metav1.UpdateOptions) (*v1alpha3.VirtualService, error) {
 	virtualService.Annotations[types.LastUpdatedTimestampKey] = time.Now().UTC().Format(time.RFC3339)
+	span := i.startSpan(ctx, metrics.KindVirtualService, metrics.OperationUpdate, virtualService.Name, virtualService.Namespace)
 	vs, err := i.istioClient.NetworkingV1alpha3().VirtualServices(virtualService.Namespace).Update(context.Background(), virtualService, options)
+	i.observe(ctx, span, metrics.KindVirtualService, metrics.OperationUpdate, virtualService.Name, virtualService.Namespace, err)
 	if err != nil {
 		ctx.Log.Str(logger.ClusterKey, i.clusterID).Str(logger.OperationKey, "Update").Str(logger.NameKey, virtualService.Name).Str(logger.NamespaceKey, virtualService.Namespace).Str(logger.ErrorKey, err.Error()).Error("error updating virtual service")
 		return nil, err
@@ -36,7 +41,9 @@ func (i *istioClientData) UpdateVirtualService(ctx context.Context, virtualServi
 
 // Delete Virtual Service.
 func (i *istioClientData) DeleteVirtualService(ctx context.Context, name string, namespace string, options metav1.DeleteOptions) error {
+	span := i.startSpan(ctx, metrics.KindVirtualService, metrics.OperationDelete, name, namespace)
 	err := i.istioClient.NetworkingV1alpha3().VirtualServices(namespace).Delete(context.Background(), name, options)
+	i.observe(ctx, span, metrics.KindVirtualService, metrics.OperationDelete, name, namespace, err)
 	if err != nil {
 		ctx.Log.Str(logger.ClusterKey, i.clusterID).Str(logger.OperationKey, "Delete").Str(logger.NameKey, name).Str(logger.NamespaceKey, namespace).Str(logger.ErrorKey, err.Error()).Error("error deleting virtual service")
 		return err
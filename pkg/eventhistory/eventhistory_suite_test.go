@@ -0,0 +1,13 @@
+package eventhistory_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestEventHistory(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "eventhistory_test")
+}
@@ -0,0 +1,211 @@
+package eventhistory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type recorderKey struct{}
+
+// ResourceWrite is a resource written to a cluster while processing an event.
+type ResourceWrite struct {
+	Cluster   string `json:"cluster"`
+	Kind      string `json:"kind"`
+	Operation string `json:"operation"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Entry is the history of a processed event.
+type Entry struct {
+	EventID       string          `json:"eventId"`
+	ChildEventID  string          `json:"childEventId,omitempty"`
+	Controller    string          `json:"controller"`
+	Key           string          `json:"key"`
+	EventType     string          `json:"eventType"`
+	RetryCount    int             `json:"retryCount"`
+	Identity      string          `json:"identity,omitempty"`
+	Env           string          `json:"env,omitempty"`
+	Revision      string          `json:"revision,omitempty"`
+	TransactionID string          `json:"transactionId,omitempty"`
+	Clusters      []string        `json:"clusters"`
+	Resources     []ResourceWrite `json:"resources"`
+	Result        string          `json:"result"`
+	Error         string          `json:"error,omitempty"`
+	StartTime     time.Time       `json:"startTime"`
+	DurationMS    int64           `json:"durationMs"`
+}
+
+// Recorder collects the history of an event while it is processed, it is carried in the event context.
+// All the methods are safe to call on a nil recorder.
+type Recorder struct {
+	mutex sync.Mutex
+	entry Entry
+}
+
+func NewRecorder(eventID string, controller string, key string, eventType string, retryCount int) *Recorder {
+	return &Recorder{
+		entry: Entry{
+			EventID:    eventID,
+			Controller: controller,
+			Key:        key,
+			EventType:  eventType,
+			RetryCount: retryCount,
+			StartTime:  time.Now(),
+		},
+	}
+}
+
+// NewChildRecorder returns the recorder of a child event, the child event is recorded as a separate entry.
+func (r *Recorder) NewChildRecorder(childEventID string) *Recorder {
+	if r == nil {
+		return nil
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return &Recorder{
+		entry: Entry{
+			EventID:       r.entry.EventID,
+			ChildEventID:  childEventID,
+			Controller:    r.entry.Controller,
+			Key:           r.entry.Key,
+			EventType:     r.entry.EventType,
+			RetryCount:    r.entry.RetryCount,
+			Identity:      r.entry.Identity,
+			Env:           r.entry.Env,
+			Revision:      r.entry.Revision,
+			TransactionID: r.entry.TransactionID,
+			StartTime:     time.Now(),
+		},
+	}
+}
+
+// SetTrafficConfig records the traffic config revision handled by the event.
+func (r *Recorder) SetTrafficConfig(identity string, env string, revision string, transactionID string) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.entry.Identity = identity
+	r.entry.Env = env
+	r.entry.Revision = revision
+	r.entry.TransactionID = transactionID
+}
+
+// RecordWrite records a resource written to a cluster.
+func (r *Recorder) RecordWrite(cluster string, kind string, operation string, name string, namespace string, err error) {
+	if r == nil {
+		return
+	}
+	write := ResourceWrite{
+		Cluster:   cluster,
+		Kind:      kind,
+		Operation: operation,
+		Name:      name,
+		Namespace: namespace,
+	}
+	if err != nil {
+		write.Error = err.Error()
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.entry.Resources = append(r.entry.Resources, write)
+	if Stream.HasSubscribers() {
+		event := r.streamEventLocked(StreamEventWrite)
+		event.Write = &write
+		event.Error = write.Error
+		Stream.Publish(event)
+	}
+}
+
+// PublishStatus streams a status transition of the event to the subscribers.
+func (r *Recorder) PublishStatus(status string, err error) {
+	if r == nil || !Stream.HasSubscribers() {
+		return
+	}
+	r.mutex.Lock()
+	event := r.streamEventLocked(StreamEventStatus)
+	event.Clusters = clustersOf(r.entry.Resources)
+	r.mutex.Unlock()
+	event.Status = status
+	if err != nil {
+		event.Error = err.Error()
+	}
+	Stream.Publish(event)
+}
+
+// streamEventLocked returns a stream event of the recorded event, the caller must hold the lock.
+func (r *Recorder) streamEventLocked(eventType string) StreamEvent {
+	return StreamEvent{
+		Type:          eventType,
+		Time:          time.Now(),
+		EventID:       r.entry.EventID,
+		ChildEventID:  r.entry.ChildEventID,
+		Controller:    r.entry.Controller,
+		Key:           r.entry.Key,
+		EventType:     r.entry.EventType,
+		RetryCount:    r.entry.RetryCount,
+		Identity:      r.entry.Identity,
+		Env:           r.entry.Env,
+		Revision:      r.entry.Revision,
+		TransactionID: r.entry.TransactionID,
+	}
+}
+
+// Finish records the result of the event in the history.
+func (r *Recorder) Finish(result string, err error) {
+	if r == nil {
+		return
+	}
+	History.Add(r.Entry(result, err))
+}
+
+// Entry returns the history entry of the event with the result.
+func (r *Recorder) Entry(result string, err error) Entry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	entry := r.entry
+	entry.Result = result
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	entry.DurationMS = time.Since(entry.StartTime).Milliseconds()
+	entry.Resources = append([]ResourceWrite{}, r.entry.Resources...)
+	entry.Clusters = clustersOf(entry.Resources)
+	return entry
+}
+
+// clustersOf returns the sorted clusters the resources were written to.
+func clustersOf(writes []ResourceWrite) []string {
+	clusters := map[string]bool{}
+	for _, write := range writes {
+		clusters[write.Cluster] = true
+	}
+	list := make([]string, 0, len(clusters))
+	for cluster := range clusters {
+		list = append(list, cluster)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// WithRecorder returns a copy of the context carrying the recorder.
+func WithRecorder(ctx context.Context, recorder *Recorder) context.Context {
+	if recorder == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, recorderKey{}, recorder)
+}
+
+// RecorderFromContext returns the recorder of the context, nil if there is none.
+func RecorderFromContext(ctx context.Context) *Recorder {
+	if ctx == nil {
+		return nil
+	}
+	recorder, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return recorder
+}
@@ -0,0 +1,273 @@
+package eventhistory
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultSize      = 1000
+	DefaultRetention = 24 * time.Hour
+)
+
+// History is the store of the processed events.
+var History Store = NewMemoryStore(DefaultSize, DefaultRetention)
+
+type Store interface {
+	// Add adds the entry to the history, the oldest entry is dropped once the store is full.
+	Add(entry Entry)
+	// List returns the entries matching the filter, oldest first.
+	List(filter Filter) []Entry
+	// Close releases the resources of the store.
+	Close() error
+}
+
+// Filter selects the history entries, empty fields match all the entries.
+type Filter struct {
+	Identity   string
+	Controller string
+	Since      time.Time
+	Limit      int
+}
+
+func (f Filter) matches(entry Entry) bool {
+	if len(f.Identity) > 0 && !strings.EqualFold(f.Identity, entry.Identity) {
+		return false
+	}
+	if len(f.Controller) > 0 && f.Controller != entry.Controller {
+		return false
+	}
+	return f.Since.IsZero() || !entry.StartTime.Before(f.Since)
+}
+
+type Opts struct {
+	// Size is the max number of entries kept in memory
+	Size int
+	// Retention is the max age of the entries, older entries are dropped
+	Retention time.Duration
+	// FilePath is the file the entries are persisted to, entries are only kept in memory when empty
+	FilePath string
+	// OnError is called with the errors persisting the entries to the file, the entries are still kept in memory
+	OnError func(err error)
+}
+
+// Init replaces the history store with one configured with the options.
+func Init(opts Opts) error {
+	if opts.Size <= 0 {
+		opts.Size = DefaultSize
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = DefaultRetention
+	}
+	if len(opts.FilePath) == 0 {
+		History = NewMemoryStore(opts.Size, opts.Retention)
+		return nil
+	}
+	store, err := NewFileStore(opts.FilePath, opts.Size, opts.Retention, opts.OnError)
+	if err != nil {
+		return err
+	}
+	History = store
+	return nil
+}
+
+// memoryStore is a ring buffer of the last entries.
+type memoryStore struct {
+	mutex     sync.RWMutex
+	entries   []Entry
+	next      int
+	full      bool
+	retention time.Duration
+}
+
+func NewMemoryStore(size int, retention time.Duration) Store {
+	return &memoryStore{
+		entries:   make([]Entry, size),
+		retention: retention,
+	}
+}
+
+func (m *memoryStore) Add(entry Entry) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.entries[m.next] = entry
+	m.next = (m.next + 1) % len(m.entries)
+	if m.next == 0 {
+		m.full = true
+	}
+}
+
+func (m *memoryStore) List(filter Filter) []Entry {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	if m.retention > 0 {
+		minStart := time.Now().Add(-m.retention)
+		if filter.Since.Before(minStart) {
+			filter.Since = minStart
+		}
+	}
+	list := []Entry{}
+	for _, entry := range m.ordered() {
+		if filter.matches(entry) {
+			list = append(list, entry)
+		}
+	}
+	// Keep the most recent entries
+	if filter.Limit > 0 && len(list) > filter.Limit {
+		list = list[len(list)-filter.Limit:]
+	}
+	return list
+}
+
+// ordered returns the entries oldest first, the caller must hold the lock.
+func (m *memoryStore) ordered() []Entry {
+	if !m.full {
+		return m.entries[:m.next]
+	}
+	return append(append([]Entry{}, m.entries[m.next:]...), m.entries[:m.next]...)
+}
+
+func (m *memoryStore) Close() error {
+	return nil
+}
+
+// fileStore persists the entries as JSON lines so the history survives restarts.
+// The file is compacted to the in-memory entries once it holds twice the max number of entries.
+type fileStore struct {
+	*memoryStore
+	fileMutex sync.Mutex
+	path      string
+	file      *os.File
+	lines     int
+	onError   func(err error)
+}
+
+// NewFileStore loads the entries persisted to the file and compacts it, onError is called with the errors persisting the next entries.
+func NewFileStore(path string, size int, retention time.Duration, onError func(err error)) (Store, error) {
+	store := &fileStore{
+		memoryStore: NewMemoryStore(size, retention).(*memoryStore),
+		path:        path,
+		onError:     onError,
+	}
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+	if err := store.compact(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// load reads the entries persisted by a previous run, invalid lines are skipped.
+func (f *fileStore) load() error {
+	file, err := os.Open(f.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error opening event history file %s: %w", f.path, err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		f.memoryStore.Add(entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading event history file %s: %w", f.path, err)
+	}
+	return nil
+}
+
+func (f *fileStore) Add(entry Entry) {
+	f.memoryStore.Add(entry)
+	f.fileMutex.Lock()
+	defer f.fileMutex.Unlock()
+	if f.file == nil {
+		return
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		f.reportError(fmt.Errorf("error marshalling event history entry %s: %w", entry.EventID, err))
+		return
+	}
+	if _, err := f.file.Write(append(line, '\n')); err != nil {
+		f.reportError(fmt.Errorf("error writing event history file %s: %w", f.path, err))
+		return
+	}
+	f.lines++
+	if f.lines >= 2*len(f.entries) {
+		if err := f.compactLocked(); err != nil {
+			f.reportError(err)
+		}
+	}
+}
+
+// reportError calls onError, if set.
+func (f *fileStore) reportError(err error) {
+	if f.onError != nil {
+		f.onError(err)
+	}
+}
+
+func (f *fileStore) compact() error {
+	f.fileMutex.Lock()
+	defer f.fileMutex.Unlock()
+	return f.compactLocked()
+}
+
+// compactLocked rewrites the file with the entries within the retention, the caller must hold the file lock.
+func (f *fileStore) compactLocked() error {
+	entries := f.memoryStore.List(Filter{})
+	tmpPath := f.path + ".tmp"
+	tmpFile, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("error creating event history file %s: %w", tmpPath, err)
+	}
+	writer := bufio.NewWriter(tmpFile)
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		_, _ = writer.Write(append(line, '\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("error writing event history file %s: %w", tmpPath, err)
+	}
+	tmpFile.Close()
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+	if err := os.Rename(tmpPath, f.path); err != nil {
+		return fmt.Errorf("error replacing event history file %s: %w", f.path, err)
+	}
+	f.file, err = os.OpenFile(f.path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("error opening event history file %s: %w", f.path, err)
+	}
+	f.lines = len(entries)
+	return nil
+}
+
+func (f *fileStore) Close() error {
+	f.fileMutex.Lock()
+	defer f.fileMutex.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
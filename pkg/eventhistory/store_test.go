@@ -0,0 +1,163 @@
+package eventhistory_test
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/intuit/naavik/pkg/eventhistory"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// newEntry returns an entry of the identity started at the time.
+func newEntry(eventID string, identity string, controller string, startTime time.Time) eventhistory.Entry {
+	return eventhistory.Entry{EventID: eventID, Identity: identity, Controller: controller, StartTime: startTime}
+}
+
+// eventIDs returns the event ids of the entries.
+func eventIDs(entries []eventhistory.Entry) []string {
+	ids := []string{}
+	for _, entry := range entries {
+		ids = append(ids, entry.EventID)
+	}
+	return ids
+}
+
+// countLines returns the number of lines of the file.
+func countLines(path string) int {
+	file, err := os.Open(path)
+	Expect(err).NotTo(HaveOccurred())
+	defer file.Close()
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines++
+	}
+	return lines
+}
+
+var _ = Describe("Test memory store", func() {
+	now := time.Now()
+
+	It("should filter the entries by identity, controller and start time", func() {
+		store := eventhistory.NewMemoryStore(10, time.Hour)
+		store.Add(newEntry("1", "foo", "tc", now.Add(-3*time.Minute)))
+		store.Add(newEntry("2", "bar", "tc", now.Add(-2*time.Minute)))
+		store.Add(newEntry("3", "Foo", "deployment", now.Add(-time.Minute)))
+
+		Expect(eventIDs(store.List(eventhistory.Filter{}))).To(Equal([]string{"1", "2", "3"}))
+		Expect(eventIDs(store.List(eventhistory.Filter{Identity: "FOO"}))).To(Equal([]string{"1", "3"}))
+		Expect(eventIDs(store.List(eventhistory.Filter{Controller: "tc"}))).To(Equal([]string{"1", "2"}))
+		Expect(eventIDs(store.List(eventhistory.Filter{Since: now.Add(-2 * time.Minute)}))).To(Equal([]string{"2", "3"}))
+		Expect(eventIDs(store.List(eventhistory.Filter{Since: now}))).To(BeEmpty())
+	})
+
+	It("should keep the most recent entries within the limit", func() {
+		store := eventhistory.NewMemoryStore(10, time.Hour)
+		for i := 1; i <= 5; i++ {
+			store.Add(newEntry(fmt.Sprint(i), "foo", "tc", now))
+		}
+		Expect(eventIDs(store.List(eventhistory.Filter{Limit: 2}))).To(Equal([]string{"4", "5"}))
+		Expect(eventIDs(store.List(eventhistory.Filter{Identity: "foo", Limit: 10}))).To(HaveLen(5))
+	})
+
+	It("should drop the oldest entries once full and the entries out of retention", func() {
+		store := eventhistory.NewMemoryStore(3, time.Hour)
+		store.Add(newEntry("1", "foo", "tc", now.Add(-2*time.Hour)))
+		for i := 2; i <= 4; i++ {
+			store.Add(newEntry(fmt.Sprint(i), "foo", "tc", now))
+		}
+		Expect(eventIDs(store.List(eventhistory.Filter{}))).To(Equal([]string{"2", "3", "4"}))
+
+		store = eventhistory.NewMemoryStore(3, time.Hour)
+		store.Add(newEntry("1", "foo", "tc", now.Add(-2*time.Hour)))
+		store.Add(newEntry("2", "foo", "tc", now))
+		Expect(eventIDs(store.List(eventhistory.Filter{Since: now.Add(-3 * time.Hour)}))).To(Equal([]string{"2"}))
+	})
+})
+
+var _ = Describe("Test file store", func() {
+	var path string
+	now := time.Now()
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "events.jsonl")
+	})
+
+	It("should reload the entries persisted by a previous run", func() {
+		store, err := eventhistory.NewFileStore(path, 10, time.Hour, nil)
+		Expect(err).NotTo(HaveOccurred())
+		store.Add(newEntry("1", "foo", "tc", now))
+		store.Add(newEntry("2", "bar", "tc", now))
+		Expect(store.Close()).To(Succeed())
+
+		// Invalid lines are skipped
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = file.WriteString("not json\n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(file.Close()).To(Succeed())
+
+		store, err = eventhistory.NewFileStore(path, 10, time.Hour, nil)
+		Expect(err).NotTo(HaveOccurred())
+		defer store.Close()
+		Expect(eventIDs(store.List(eventhistory.Filter{}))).To(Equal([]string{"1", "2"}))
+		Expect(eventIDs(store.List(eventhistory.Filter{Identity: "bar"}))).To(Equal([]string{"2"}))
+		// The file is compacted on load
+		Expect(countLines(path)).To(Equal(2))
+	})
+
+	It("should not reload the entries out of retention", func() {
+		store, err := eventhistory.NewFileStore(path, 10, time.Hour, nil)
+		Expect(err).NotTo(HaveOccurred())
+		store.Add(newEntry("1", "foo", "tc", now.Add(-2*time.Hour)))
+		store.Add(newEntry("2", "foo", "tc", now))
+		Expect(store.Close()).To(Succeed())
+
+		store, err = eventhistory.NewFileStore(path, 10, time.Hour, nil)
+		Expect(err).NotTo(HaveOccurred())
+		defer store.Close()
+		Expect(eventIDs(store.List(eventhistory.Filter{}))).To(Equal([]string{"2"}))
+		Expect(countLines(path)).To(Equal(1))
+	})
+
+	It("should compact the file once it holds twice the max number of entries", func() {
+		store, err := eventhistory.NewFileStore(path, 3, time.Hour, nil)
+		Expect(err).NotTo(HaveOccurred())
+		defer store.Close()
+		for i := 1; i <= 5; i++ {
+			store.Add(newEntry(fmt.Sprint(i), "foo", "tc", now))
+		}
+		Expect(countLines(path)).To(Equal(5))
+		store.Add(newEntry("6", "foo", "tc", now))
+		Expect(countLines(path)).To(Equal(3))
+		store.Add(newEntry("7", "foo", "tc", now))
+		Expect(countLines(path)).To(Equal(4))
+		Expect(eventIDs(store.List(eventhistory.Filter{}))).To(Equal([]string{"5", "6", "7"}))
+	})
+
+	It("should report the compaction errors and keep the entries in memory", func() {
+		errs := []error{}
+		store, err := eventhistory.NewFileStore(path, 1, time.Hour, func(err error) {
+			errs = append(errs, err)
+		})
+		Expect(err).NotTo(HaveOccurred())
+		defer store.Close()
+		// The temporary file of the compaction cannot be created
+		Expect(os.Mkdir(path+".tmp", 0o755)).To(Succeed())
+		store.Add(newEntry("1", "foo", "tc", now))
+		store.Add(newEntry("2", "foo", "tc", now))
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0]).To(MatchError(ContainSubstring("error creating event history file")))
+		Expect(eventIDs(store.List(eventhistory.Filter{}))).To(Equal([]string{"2"}))
+	})
+
+	It("should fail to load an unreadable file", func() {
+		Expect(os.Mkdir(path, 0o755)).To(Succeed())
+		_, err := eventhistory.NewFileStore(path, 10, time.Hour, nil)
+		Expect(err).To(MatchError(ContainSubstring("error reading event history file")))
+	})
+})
@@ -0,0 +1,150 @@
+package eventhistory
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StreamEventStatus = "status"
+	StreamEventWrite  = "write"
+
+	// DefaultSubscriptionSize is the number of events buffered for a subscriber before the events are dropped.
+	DefaultSubscriptionSize = 256
+)
+
+// Stream publishes the live events to the subscribers.
+var Stream = NewBroker()
+
+// StreamEvent is an event status transition or a resource write outcome, published as it happens.
+type StreamEvent struct {
+	Type          string    `json:"type"`
+	Time          time.Time `json:"time"`
+	EventID       string    `json:"eventId"`
+	ChildEventID  string    `json:"childEventId,omitempty"`
+	Controller    string    `json:"controller"`
+	Key           string    `json:"key"`
+	EventType     string    `json:"eventType"`
+	RetryCount    int       `json:"retryCount"`
+	Identity      string    `json:"identity,omitempty"`
+	Env           string    `json:"env,omitempty"`
+	Revision      string    `json:"revision,omitempty"`
+	TransactionID string    `json:"transactionId,omitempty"`
+	// Status is set for the status events
+	Status string `json:"status,omitempty"`
+	// Clusters are the clusters written so far by the event, set for the status events
+	Clusters []string `json:"clusters,omitempty"`
+	// Write is set for the write events
+	Write *ResourceWrite `json:"write,omitempty"`
+	Error string         `json:"error,omitempty"`
+}
+
+// StreamFilter selects the streamed events, empty fields match all the events.
+type StreamFilter struct {
+	Identity   string
+	Cluster    string
+	Controller string
+}
+
+func (f StreamFilter) matches(event StreamEvent) bool {
+	if len(f.Identity) > 0 && !strings.EqualFold(f.Identity, event.Identity) {
+		return false
+	}
+	if len(f.Controller) > 0 && f.Controller != event.Controller {
+		return false
+	}
+	if len(f.Cluster) == 0 {
+		return true
+	}
+	if event.Write != nil {
+		return event.Write.Cluster == f.Cluster
+	}
+	for _, cluster := range event.Clusters {
+		if cluster == f.Cluster {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscription receives the events matching its filter.
+// Publishing never blocks, events are dropped when the subscriber does not keep up.
+type Subscription struct {
+	filter  StreamFilter
+	events  chan StreamEvent
+	dropped atomic.Uint64
+	broker  *Broker
+	once    sync.Once
+}
+
+// Events returns the channel of the events, it is closed once the subscription is closed.
+func (s *Subscription) Events() <-chan StreamEvent {
+	return s.events
+}
+
+// Dropped returns the number of events dropped because the subscriber was too slow.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close unsubscribes, it is safe to call multiple times.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.broker.mutex.Lock()
+		defer s.broker.mutex.Unlock()
+		delete(s.broker.subscriptions, s)
+		close(s.events)
+	})
+}
+
+type Broker struct {
+	mutex         sync.RWMutex
+	subscriptions map[*Subscription]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe returns a subscription buffering up to size events.
+func (b *Broker) Subscribe(filter StreamFilter, size int) *Subscription {
+	if size <= 0 {
+		size = DefaultSubscriptionSize
+	}
+	subscription := &Subscription{
+		filter: filter,
+		events: make(chan StreamEvent, size),
+		broker: b,
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.subscriptions[subscription] = struct{}{}
+	return subscription
+}
+
+// HasSubscribers returns true if there is at least one subscriber, to avoid building events nobody listens to.
+func (b *Broker) HasSubscribers() bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return len(b.subscriptions) > 0
+}
+
+// Publish sends the event to the matching subscribers without blocking.
+func (b *Broker) Publish(event StreamEvent) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	for subscription := range b.subscriptions {
+		if !subscription.filter.matches(event) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			subscription.dropped.Add(1)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package eventhistory_test
+
+import (
+	"github.com/intuit/naavik/pkg/eventhistory"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test stream broker", func() {
+	var broker *eventhistory.Broker
+
+	BeforeEach(func() {
+		broker = eventhistory.NewBroker()
+	})
+
+	It("should count the events dropped for a slow subscriber without blocking", func() {
+		slow := broker.Subscribe(eventhistory.StreamFilter{}, 2)
+		defer slow.Close()
+		fast := broker.Subscribe(eventhistory.StreamFilter{}, 10)
+		defer fast.Close()
+
+		for i := 0; i < 5; i++ {
+			broker.Publish(eventhistory.StreamEvent{Type: eventhistory.StreamEventStatus, Identity: "foo"})
+		}
+		Expect(slow.Dropped()).To(Equal(uint64(3)))
+		Expect(slow.Events()).To(HaveLen(2))
+		Expect(fast.Dropped()).To(BeZero())
+		Expect(fast.Events()).To(HaveLen(5))
+
+		// The subscriber catches up
+		<-slow.Events()
+		broker.Publish(eventhistory.StreamEvent{Type: eventhistory.StreamEventStatus, Identity: "foo"})
+		Expect(slow.Dropped()).To(Equal(uint64(3)))
+		Expect(slow.Events()).To(HaveLen(2))
+	})
+
+	It("should only send the events matching the filter", func() {
+		subscription := broker.Subscribe(eventhistory.StreamFilter{Identity: "FOO", Cluster: "cluster1"}, 10)
+		defer subscription.Close()
+
+		broker.Publish(eventhistory.StreamEvent{Type: eventhistory.StreamEventWrite, EventID: "1", Identity: "foo", Write: &eventhistory.ResourceWrite{Cluster: "cluster1"}})
+		broker.Publish(eventhistory.StreamEvent{Type: eventhistory.StreamEventWrite, EventID: "2", Identity: "foo", Write: &eventhistory.ResourceWrite{Cluster: "cluster2"}})
+		broker.Publish(eventhistory.StreamEvent{Type: eventhistory.StreamEventStatus, EventID: "3", Identity: "foo", Clusters: []string{"cluster2", "cluster1"}})
+		broker.Publish(eventhistory.StreamEvent{Type: eventhistory.StreamEventStatus, EventID: "4", Identity: "bar", Clusters: []string{"cluster1"}})
+		Expect((<-subscription.Events()).EventID).To(Equal("1"))
+		Expect((<-subscription.Events()).EventID).To(Equal("3"))
+		Expect(subscription.Events()).To(BeEmpty())
+		Expect(subscription.Dropped()).To(BeZero())
+	})
+
+	It("should close the events of a closed subscription", func() {
+		subscription := broker.Subscribe(eventhistory.StreamFilter{}, 0)
+		Expect(broker.HasSubscribers()).To(BeTrue())
+		subscription.Close()
+		subscription.Close()
+		Expect(broker.HasSubscribers()).To(BeFalse())
+		Eventually(subscription.Events()).Should(BeClosed())
+		broker.Publish(eventhistory.StreamEvent{Type: eventhistory.StreamEventStatus})
+	})
+})
@@ -14,6 +14,7 @@ const (
 	WaitTimeMSKey   = "waitTimeMS"
 	QueueTimeMSKey  = "queueTimeMS"
 	QueueLengthKey  = "queueLen"
+	RetryCountKey   = "retryCount"
 	NameKey         = "name"
 	OperationKey    = "op"
 	TypeKey         = "type"
@@ -44,4 +45,5 @@ const (
 	RolloutNameKey = "rolloutName"
 
 	RouteNameKey = "routeName"
+	FieldKey     = "field"
 )
@@ -0,0 +1,213 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "naavik"
+
+	ControllerLabel = "controller"
+	ClusterLabel    = "cluster"
+	KindLabel       = "kind"
+	OperationLabel  = "operation"
+	CacheLabel      = "cache"
+
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+
+	KindEnvoyFilter    = "EnvoyFilter"
+	KindVirtualService = "VirtualService"
+)
+
+var (
+	// Registry is the registry of the naavik metrics served on the metrics path.
+	Registry = prometheus.NewRegistry()
+
+	// QueueDepth is the number of events waiting in the controller queue, read from the queues at scrape time.
+	QueueDepth = &queueDepthCollector{
+		desc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "controller", "queue_depth"),
+			"Number of events waiting in the controller queue.", []string{ControllerLabel}, nil),
+		queueLens: map[string]func() int{},
+	}
+
+	// QueueLatency is the time an event waits in the controller queue before it is processed.
+	QueueLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "controller",
+		Name:      "queue_latency_seconds",
+		Help:      "Time an event waits in the controller queue before it is processed.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{ControllerLabel})
+
+	// ProcessingLatency is the time taken to process an event, including the status callbacks and child events.
+	ProcessingLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "controller",
+		Name:      "processing_latency_seconds",
+		Help:      "Time taken to process an event, including the status callbacks and child events.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{ControllerLabel})
+
+	// Retries is the number of events requeued for retry.
+	Retries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "controller",
+		Name:      "retries_total",
+		Help:      "Number of events requeued for retry.",
+	}, []string{ControllerLabel})
+
+	// MaxRetries is the number of events dropped after reaching the max retry count.
+	MaxRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "controller",
+		Name:      "max_retries_reached_total",
+		Help:      "Number of events dropped after reaching the max retry count.",
+	}, []string{ControllerLabel})
+
+	// IstioRequests is the number of write calls made to the istio API server.
+	IstioRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "istio",
+		Name:      "requests_total",
+		Help:      "Number of create, update and delete calls made to the istio API server.",
+	}, []string{ClusterLabel, KindLabel, OperationLabel})
+
+	// IstioRequestErrors is the number of failed write calls made to the istio API server.
+	IstioRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "istio",
+		Name:      "request_errors_total",
+		Help:      "Number of failed create, update and delete calls made to the istio API server.",
+	}, []string{ClusterLabel, KindLabel, OperationLabel})
+
+	// PropagationClusterLatency is the time from receiving a traffic config revision to writing a resource of it to a cluster.
+	PropagationClusterLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "propagation",
+		Name:      "cluster_latency_seconds",
+		Help:      "Time from receiving a traffic config revision to writing a resource of it to a cluster.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 14),
+	}, []string{ClusterLabel, KindLabel})
+
+	// PropagationConvergenceLatency is the time from receiving a traffic config revision to all the clusters converging to it.
+	PropagationConvergenceLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "propagation",
+		Name:      "convergence_latency_seconds",
+		Help:      "Time from receiving a traffic config revision to all the clusters converging to it.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 14),
+	})
+)
+
+func init() {
+	Registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	Registry.MustRegister(QueueDepth, QueueLatency, ProcessingLatency, Retries, MaxRetries, IstioRequests, IstioRequestErrors,
+		PropagationClusterLatency, PropagationConvergenceLatency)
+}
+
+// Handler returns the HTTP handler serving the metrics of the registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// register registers the collector, replacing a collector already registered with the same descriptor,
+// so the gauges read at scrape time can be registered again, e.g. on a re-init.
+func register(collector prometheus.Collector) {
+	err := Registry.Register(collector)
+	alreadyRegisteredErr := prometheus.AlreadyRegisteredError{}
+	if errors.As(err, &alreadyRegisteredErr) {
+		Registry.Unregister(alreadyRegisteredErr.ExistingCollector)
+		err = Registry.Register(collector)
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ObserveIstioRequest counts an istio write call and its error if any.
+func ObserveIstioRequest(cluster string, kind string, operation string, err error) {
+	IstioRequests.WithLabelValues(cluster, kind, operation).Inc()
+	if err != nil {
+		IstioRequestErrors.WithLabelValues(cluster, kind, operation).Inc()
+	}
+}
+
+// ObserveDuration records the time elapsed since start in seconds.
+func ObserveDuration(observer prometheus.Observer, start time.Time) {
+	observer.Observe(time.Since(start).Seconds())
+}
+
+// DeleteControllerMetrics removes the series of a stopped controller, e.g. when a remote cluster is removed.
+func DeleteControllerMetrics(controller string) {
+	labels := prometheus.Labels{ControllerLabel: controller}
+	QueueDepth.delete(controller)
+	QueueLatency.Delete(labels)
+	ProcessingLatency.Delete(labels)
+	Retries.Delete(labels)
+	MaxRetries.Delete(labels)
+}
+
+// queueDepthCollector reports the length of the controller queues at scrape time, so the depth is current even when no event is added or processed.
+type queueDepthCollector struct {
+	desc      *prometheus.Desc
+	mutex     sync.RWMutex
+	queueLens map[string]func() int
+}
+
+// RegisterQueue reports the length of the queue of the controller, replacing the queue of a previous controller with the same name.
+func (c *queueDepthCollector) RegisterQueue(controller string, queueLen func() int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.queueLens[controller] = queueLen
+}
+
+func (c *queueDepthCollector) delete(controller string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.queueLens, controller)
+}
+
+// Describe implements prometheus.Collector.
+func (c *queueDepthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *queueDepthCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	for controller, queueLen := range c.queueLens {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(queueLen()), controller)
+	}
+}
+
+// RegisterGaugeFunc registers a gauge whose value is read at scrape time.
+func RegisterGaugeFunc(name string, help string, function func() float64) {
+	register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, function))
+}
+
+// RegisterCacheSizeFunc registers a gauge reporting the number of entries of a cache at scrape time.
+func RegisterCacheSizeFunc(cache string, function func() int) {
+	register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Subsystem:   "cache",
+		Name:        "size",
+		Help:        "Number of entries in the cache.",
+		ConstLabels: prometheus.Labels{CacheLabel: cache},
+	}, func() float64 {
+		return float64(function())
+	}))
+}
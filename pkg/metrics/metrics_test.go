@@ -0,0 +1,63 @@
+package metrics_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/intuit/naavik/pkg/metrics"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test metrics", func() {
+	// scrape returns the body of a metrics request.
+	scrape := func() string {
+		w := httptest.NewRecorder()
+		metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		return w.Body.String()
+	}
+
+	AfterEach(func() {
+		metrics.DeleteControllerMetrics("test-controller")
+	})
+
+	It("should read the queue depth of the controllers at scrape time", func() {
+		queueLen := 3
+		metrics.QueueDepth.RegisterQueue("test-controller", func() int { return queueLen })
+		Expect(scrape()).To(ContainSubstring(`naavik_controller_queue_depth{controller="test-controller"} 3`))
+
+		queueLen = 5
+		Expect(scrape()).To(ContainSubstring(`naavik_controller_queue_depth{controller="test-controller"} 5`))
+
+		metrics.DeleteControllerMetrics("test-controller")
+		Expect(scrape()).NotTo(ContainSubstring(`controller="test-controller"`))
+	})
+
+	It("should read the cache size at scrape time and replace it when registered again", func() {
+		metrics.RegisterCacheSizeFunc("test", func() int { return 2 })
+		Expect(scrape()).To(ContainSubstring(`naavik_cache_size{cache="test"} 2`))
+
+		Expect(func() {
+			metrics.RegisterCacheSizeFunc("test", func() int { return 7 })
+		}).NotTo(Panic())
+		Expect(scrape()).To(ContainSubstring(`naavik_cache_size{cache="test"} 7`))
+	})
+
+	It("should register a gauge again without panicking", func() {
+		metrics.RegisterGaugeFunc("test_gauge", "Test gauge.", func() float64 { return 1 })
+		Expect(func() {
+			metrics.RegisterGaugeFunc("test_gauge", "Test gauge.", func() float64 { return 0 })
+		}).NotTo(Panic())
+		Expect(scrape()).To(ContainSubstring("naavik_test_gauge 0"))
+	})
+
+	It("should count the istio writes and their errors", func() {
+		metrics.ObserveIstioRequest("test-cluster", metrics.KindEnvoyFilter, metrics.OperationCreate, nil)
+		metrics.ObserveIstioRequest("test-cluster", metrics.KindEnvoyFilter, metrics.OperationCreate, errors.New("conflict"))
+		body := scrape()
+		Expect(body).To(ContainSubstring(`naavik_istio_requests_total{cluster="test-cluster",kind="EnvoyFilter",operation="create"} 2`))
+		Expect(body).To(ContainSubstring(`naavik_istio_request_errors_total{cluster="test-cluster",kind="EnvoyFilter",operation="create"} 1`))
+	})
+})
@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const (
+	tracerName = "github.com/intuit/naavik"
+
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+var tracerProvider *sdktrace.TracerProvider
+
+type Opts struct {
+	ServiceName string
+	// Exporter is one of ExporterNone, ExporterOTLP or ExporterStdout
+	Exporter string
+	// Endpoint is the OTLP gRPC endpoint, the OTEL_EXPORTER_OTLP_* environment variables are used when empty
+	Endpoint string
+	// SampleRatio is the ratio of the root spans sampled, child spans follow the parent decision
+	SampleRatio float64
+	// Writer is where the ExporterStdout exporter prints the spans, os.Stdout when nil
+	Writer io.Writer
+}
+
+// Init configures the global tracer provider with the requested exporter.
+// Spans are no-op when the exporter is ExporterNone.
+func Init(ctx context.Context, opts Opts) error {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch opts.Exporter {
+	case ExporterNone, "":
+		tracerProvider = nil
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		return nil
+	case ExporterOTLP:
+		exporterOpts := []otlptracegrpc.Option{}
+		if len(opts.Endpoint) > 0 {
+			exporterOpts = append(exporterOpts, otlptracegrpc.WithEndpoint(opts.Endpoint), otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, exporterOpts...)
+	case ExporterStdout:
+		writer := opts.Writer
+		if writer == nil {
+			writer = os.Stdout
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(writer), stdouttrace.WithPrettyPrint())
+	default:
+		return fmt.Errorf("invalid tracing exporter %q", opts.Exporter)
+	}
+	if err != nil {
+		return fmt.Errorf("error creating %s tracing exporter: %w", opts.Exporter, err)
+	}
+
+	tracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", opts.ServiceName))),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return nil
+}
+
+// Shutdown flushes the pending spans and stops the exporter.
+// The context bounds the flush, e.g. when the OTLP collector is unreachable.
+func Shutdown(ctx context.Context) error {
+	if tracerProvider == nil {
+		return nil
+	}
+	return tracerProvider.Shutdown(ctx)
+}
+
+// StartSpan starts a span as a child of the span in the parent context, if any.
+func StartSpan(parent context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	return otel.Tracer(tracerName).Start(parent, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records the error on the span, if any, and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// SpanFromContext returns the current span of the context, a no-op span is returned if there is none.
+func SpanFromContext(ctx context.Context) trace.Span {
+	if ctx == nil {
+		return trace.SpanFromContext(context.Background())
+	}
+	return trace.SpanFromContext(ctx)
+}
+
+// ContextWithSpan returns a copy of the context carrying the span.
+func ContextWithSpan(ctx context.Context, span trace.Span) context.Context {
+	return trace.ContextWithSpan(ctx, span)
+}
@@ -0,0 +1,89 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/intuit/naavik/pkg/tracing"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test tracing", func() {
+	AfterEach(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = tracing.Shutdown(ctx)
+		Expect(tracing.Init(context.Background(), tracing.Opts{Exporter: tracing.ExporterNone})).To(Succeed())
+	})
+
+	It("should create no-op spans without exporter", func() {
+		Expect(tracing.Init(context.Background(), tracing.Opts{Exporter: tracing.ExporterNone})).To(Succeed())
+		_, span := tracing.StartSpan(context.Background(), "event")
+		Expect(span.IsRecording()).To(BeFalse())
+		Expect(span.SpanContext().IsValid()).To(BeFalse())
+		Expect(tracing.Shutdown(context.Background())).To(Succeed())
+	})
+
+	It("should reject an unknown exporter", func() {
+		Expect(tracing.Init(context.Background(), tracing.Opts{Exporter: "jaeger"})).To(MatchError(ContainSubstring("jaeger")))
+	})
+
+	It("should print the spans with their children and errors to the stdout exporter", func() {
+		var out bytes.Buffer
+		Expect(tracing.Init(context.Background(), tracing.Opts{
+			ServiceName: "naavik-test",
+			Exporter:    tracing.ExporterStdout,
+			SampleRatio: 1,
+			Writer:      &out,
+		})).To(Succeed())
+
+		parentCtx, parent := tracing.StartSpan(context.Background(), "event")
+		_, child := tracing.StartSpan(parentCtx, "child event")
+		tracing.EndSpan(child, errors.New("child failed"))
+		tracing.EndSpan(parent, nil)
+		Expect(child.SpanContext().TraceID()).To(Equal(parent.SpanContext().TraceID()))
+		Expect(tracing.SpanFromContext(parentCtx).SpanContext()).To(Equal(parent.SpanContext()))
+
+		Expect(tracing.Shutdown(context.Background())).To(Succeed())
+		Expect(out.String()).To(ContainSubstring(`"Name": "event"`))
+		Expect(out.String()).To(ContainSubstring(`"Name": "child event"`))
+		Expect(out.String()).To(ContainSubstring("child failed"))
+		Expect(out.String()).To(ContainSubstring("naavik-test"))
+	})
+
+	DescribeTable("should sample the root spans with the ratio and the child spans with their parent",
+		func(ratio float64, sampled bool) {
+			Expect(tracing.Init(context.Background(), tracing.Opts{Exporter: tracing.ExporterStdout, SampleRatio: ratio, Writer: &bytes.Buffer{}})).To(Succeed())
+			parentCtx, parent := tracing.StartSpan(context.Background(), "event")
+			_, child := tracing.StartSpan(parentCtx, "child event")
+			Expect(parent.SpanContext().IsSampled()).To(Equal(sampled))
+			Expect(child.SpanContext().IsSampled()).To(Equal(sampled))
+			tracing.EndSpan(child, nil)
+			tracing.EndSpan(parent, nil)
+		},
+		Entry("all", 1.0, true),
+		Entry("none", 0.0, false),
+	)
+
+	It("should not block the shutdown when the otlp collector is unreachable", func() {
+		Expect(tracing.Init(context.Background(), tracing.Opts{
+			Exporter:    tracing.ExporterOTLP,
+			Endpoint:    "127.0.0.1:1",
+			SampleRatio: 1,
+		})).To(Succeed())
+		_, span := tracing.StartSpan(context.Background(), "event")
+		Expect(span.IsRecording()).To(BeTrue())
+		tracing.EndSpan(span, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		done := make(chan error, 1)
+		go func() {
+			done <- tracing.Shutdown(ctx)
+		}()
+		Eventually(done, 5*time.Second).Should(Receive())
+	})
+})